@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goupter/goupter/pkg/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "配置工具",
+	Long: `查看与检查服务配置。
+
+配置按优先级从低到高合并：
+  默认值 → config.yaml → config.<env>.yaml → config.local.yaml → Consul KV → 环境变量 → 命令行参数`,
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "打印合并后的生效配置",
+	Long: `按服务启动时的规则合并各层配置并打印，敏感值已脱敏。
+
+示例:
+  # 打印生效配置（YAML）
+  goupter config print --config-path ./cmd/user/etc
+
+  # 显示每个配置键的来源
+  goupter config print --config-path ./cmd/user/etc --env production --explain
+
+  # 包含 Consul KV（按本地配置中的 consul.config_path）
+  goupter config print --consul --explain`,
+	Run: runConfigPrint,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate <file>",
+	Short: "校验配置文件",
+	Long: `按服务启动时的规则（类型与 ConfigValidator 校验）检查单个配置文件，逐字段输出错误及行号。
+文件合并到内置默认值之上校验，不读取环境变量与 Consul，不解析密钥引用。有错误时退出码为 1。
+
+示例:
+  goupter config validate ./cmd/user/etc/config.yaml
+  goupter config validate config.prod.yaml --output json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runConfigValidate,
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "导出配置文件的 JSON Schema",
+	Long: `导出包含字段类型、默认值与校验规则的 JSON Schema（draft-07），可用于编辑器补全与 CI 检查。
+
+示例:
+  goupter config schema -o config.schema.json
+
+  # VS Code YAML 插件：在配置文件首行添加
+  # yaml-language-server: $schema=./config.schema.json`,
+	Run: runConfigSchema,
+}
+
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt [value]",
+	Short: "加密配置值",
+	Long: `加密单个配置值，输出可直接写入配置文件的 ENC[...]。未指定 value 时从标准输入读取（避免明文进入 shell 历史）。
+
+密钥取自 --key-file、GOUPTER_CONFIG_KEY 或 GOUPTER_CONFIG_KEY_FILE，可以是 age 私钥或 32 字节 AES 密钥的 base64/hex 编码；
+只有 age 公钥时使用 --age-recipient。
+
+示例:
+  # 生成密钥
+  openssl rand -base64 32 > config.key
+  age-keygen -o config.age.key
+
+  goupter config encrypt --key-file config.key 's3cr3t'
+  echo -n 's3cr3t' | goupter config encrypt --age-recipient age1...`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runConfigEncrypt,
+}
+
+var configDecryptCmd = &cobra.Command{
+	Use:   "decrypt [value]",
+	Short: "解密配置值",
+	Long: `解密 ENC[...] 配置值并输出明文。未指定 value 时从标准输入读取。
+
+示例:
+  goupter config decrypt --key-file config.key 'ENC[aes256gcm:...]'`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runConfigDecrypt,
+}
+
+var (
+	configName      string
+	configPaths     []string
+	configProfile   string
+	configEnvPrefix string
+	configConsul    bool
+	configExplain   bool
+	configOutput    string
+	configSchemaOut string
+	configKeyFile   string
+	configAgeKeys   []string
+)
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configPrintCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configEncryptCmd)
+	configCmd.AddCommand(configDecryptCmd)
+
+	configCmd.PersistentFlags().StringVar(&configName, "config", "config", "配置文件名（不含扩展名）")
+	configCmd.PersistentFlags().StringSliceVar(&configPaths, "config-path", []string{".", "./config"}, "配置文件搜索路径")
+	configCmd.PersistentFlags().StringVar(&configProfile, "env", "", "环境名，加载 config.<env>.yaml（默认取 <前缀>_APP_ENV 或 app.env）")
+	configCmd.PersistentFlags().StringVar(&configEnvPrefix, "env-prefix", "FLIT", "环境变量前缀")
+
+	configPrintCmd.Flags().BoolVar(&configConsul, "consul", false, "合并 Consul KV 中的配置")
+	configPrintCmd.Flags().BoolVar(&configExplain, "explain", false, "显示每个配置键的来源")
+
+	configValidateCmd.Flags().StringVar(&configOutput, "output", "text", "输出格式：text、json")
+
+	configSchemaCmd.Flags().StringVarP(&configSchemaOut, "out", "o", "", "输出文件（默认标准输出）")
+
+	for _, c := range []*cobra.Command{configEncryptCmd, configDecryptCmd} {
+		c.Flags().StringVar(&configKeyFile, "key-file", "", "密钥文件（默认取 GOUPTER_CONFIG_KEY 或 GOUPTER_CONFIG_KEY_FILE）")
+	}
+	configEncryptCmd.Flags().StringSliceVar(&configAgeKeys, "age-recipient", nil, "age 公钥，只加密不需要私钥")
+}
+
+// configOptions 根据命令行参数构造加载选项
+func configOptions() []config.Option {
+	opts := []config.Option{
+		config.WithConfigFile(configName),
+		config.WithConfigPaths(configPaths...),
+		config.WithEnvPrefix(configEnvPrefix),
+	}
+	if configProfile != "" {
+		opts = append(opts, config.WithProfile(configProfile))
+	}
+	return opts
+}
+
+func runConfigPrint(cmd *cobra.Command, args []string) {
+	opts := configOptions()
+	if configConsul {
+		opts = append(opts, config.WithRemoteConsul())
+	}
+
+	values, err := config.Explain(opts...)
+	if err != nil {
+		exitWithError("加载配置失败", err)
+	}
+
+	if configExplain {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+		for _, v := range values {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, formatConfigValue(v.Value), v.Source)
+		}
+		_ = w.Flush()
+		return
+	}
+
+	tree := make(map[string]any)
+	for _, v := range values {
+		setConfigPath(tree, v.Key, v.Value)
+	}
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		exitWithError("输出配置失败", err)
+	}
+	fmt.Print(string(data))
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) {
+	file := args[0]
+	errs, err := config.ValidateFile(file)
+	if err != nil {
+		exitWithError("校验配置失败", err)
+	}
+
+	if configOutput == "json" {
+		if errs == nil {
+			errs = config.ValidationErrors{}
+		}
+		data, err := json.MarshalIndent(errs, "", "  ")
+		if err != nil {
+			exitWithError("输出校验结果失败", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, e := range errs {
+			pos := file
+			if e.Line > 0 {
+				pos = fmt.Sprintf("%s:%d:%d", file, e.Line, e.Column)
+			}
+			msg := fmt.Sprintf("%s: %s: %s", pos, e.Field, e.Message)
+			if e.Value != nil {
+				msg += fmt.Sprintf(" (当前值: %s)", formatConfigValue(e.Value))
+			}
+			fmt.Println(msg)
+		}
+		if len(errs) == 0 {
+			fmt.Printf("%s: 校验通过\n", file)
+		}
+	}
+
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) {
+	data, err := config.JSONSchema()
+	if err != nil {
+		exitWithError("生成 JSON Schema 失败", err)
+	}
+	data = append(data, '\n')
+
+	if configSchemaOut == "" {
+		fmt.Print(string(data))
+		return
+	}
+	if err := os.WriteFile(configSchemaOut, data, 0o644); err != nil {
+		exitWithError("写入 JSON Schema 失败", err)
+	}
+	fmt.Printf("已生成 %s\n", configSchemaOut)
+}
+
+func runConfigEncrypt(cmd *cobra.Command, args []string) {
+	var c config.Cipher
+	var err error
+	if len(configAgeKeys) > 0 {
+		c, err = config.NewAgeEncrypter(configAgeKeys...)
+	} else {
+		c, err = configCipher()
+	}
+	if err != nil {
+		exitWithError("读取密钥失败", err)
+	}
+
+	value, err := configValueArg(args)
+	if err != nil {
+		exitWithError("读取配置值失败", err)
+	}
+	encrypted, err := config.EncryptValue(c, value)
+	if err != nil {
+		exitWithError("加密失败", err)
+	}
+	fmt.Println(encrypted)
+}
+
+func runConfigDecrypt(cmd *cobra.Command, args []string) {
+	c, err := configCipher()
+	if err != nil {
+		exitWithError("读取密钥失败", err)
+	}
+
+	value, err := configValueArg(args)
+	if err != nil {
+		exitWithError("读取配置值失败", err)
+	}
+	value = strings.TrimSpace(value)
+	if !config.IsEncrypted(value) {
+		exitWithError("不是 ENC[...] 格式的加密值", nil)
+	}
+	plaintext, err := config.DecryptValue(c, value)
+	if err != nil {
+		exitWithError("解密失败", err)
+	}
+	fmt.Println(plaintext)
+}
+
+// configCipher 按 --key-file 或环境变量读取密钥
+func configCipher() (config.Cipher, error) {
+	if configKeyFile != "" {
+		data, err := os.ReadFile(configKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return config.ParseCipherKey(string(data))
+	}
+	c, err := config.CipherFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, config.ErrNoConfigKey
+	}
+	return c, nil
+}
+
+// configValueArg 取命令行参数中的值，未指定时从标准输入读取并去掉末尾换行
+func configValueArg(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// formatConfigValue 格式化单个配置值
+func formatConfigValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		if v == "" {
+			return `""`
+		}
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// setConfigPath 按 . 分隔的键写入嵌套 map
+func setConfigPath(tree map[string]any, key string, value any) {
+	parts := strings.Split(key, ".")
+	m := tree
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[part] = next
+		}
+		m = next
+	}
+	if s, ok := value.(fmt.Stringer); ok {
+		value = s.String()
+	}
+	m[parts[len(parts)-1]] = value
+}
@@ -27,12 +27,20 @@ var rootCmd = &cobra.Command{
     model     从数据库表生成 GORM 模型
     crud      生成完整 CRUD 代码（Model + Handler + Routes）
     httpbook  生成 HTTP API 调试文件（.http 格式）
+  config    配置工具
+    print     打印合并后的生效配置（--explain 显示来源）
+    validate  校验配置文件，逐字段输出错误及行号
+    schema    导出配置文件的 JSON Schema
+    encrypt   加密配置值为 ENC[...]
+    decrypt   解密 ENC[...] 配置值
 
 示例:
   goupter new user                                    # 创建 user 服务
   goupter gen model --dsn "user:pass@tcp(...)/db"    # 生成模型
   goupter gen crud --dsn "..." --service user        # 生成 CRUD 到服务目录
-  goupter gen httpbook --service user                # 生成 HTTP 调试文件`,
+  goupter gen httpbook --service user                # 生成 HTTP 调试文件
+  goupter config print --env production --explain    # 查看生效配置及来源
+  goupter config validate config.yaml                # 部署前校验配置文件`,
 	Version: Version,
 }
 
@@ -1,23 +1,31 @@
 package handler
 
 import (
+	"context"
+
 	"github.com/gin-gonic/gin"
 	"github.com/goupter/goupter/examples/http-api/model"
 	"github.com/goupter/goupter/examples/http-api/pkg/token"
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/auth/password"
+	"github.com/goupter/goupter/pkg/cache"
 	"github.com/goupter/goupter/pkg/errors"
 	"github.com/goupter/goupter/pkg/response"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
 	userModel    *model.UsersModel
 	tokenManager *token.Manager
+	hasher       *password.Hasher
+	guard        *password.Guard
 }
 
-func NewAuthHandler(userModel *model.UsersModel, tokenManager *token.Manager) *AuthHandler {
+func NewAuthHandler(userModel *model.UsersModel, tokenManager *token.Manager, c cache.Cache) *AuthHandler {
 	return &AuthHandler{
 		userModel:    userModel,
 		tokenManager: tokenManager,
+		hasher:       password.NewHasher(),
+		guard:        password.NewGuard(c),
 	}
 }
 
@@ -27,10 +35,13 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token,omitempty"`
-	Username     string `json:"username"`
-	Role         string `json:"role"`
+	*auth.TokenPair
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 // Login handles user login
@@ -41,27 +52,32 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userModel.FindByUsername(c.Request.Context(), req.Username)
-	if err != nil {
-		response.Error(c, errors.New(errors.CodeUnauthorized, "invalid credentials"))
-		return
-	}
-
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		response.Error(c, errors.New(errors.CodeUnauthorized, "invalid credentials"))
-		return
-	}
-
-	// Create tokens
-	accessToken, err := h.tokenManager.CreateAccessToken(user.ID, user.Username)
-	if err != nil {
-		response.Error(c, errors.Wrap(err, errors.CodeInternalError, "failed to generate token"))
-		return
-	}
-
-	refreshToken, err := h.tokenManager.CreateRefreshToken(user.ID)
+	// Failed attempts are counted per username and client IP; repeated failures lock the account
+	var user *model.Users
+	err := h.guard.Login(c.Request.Context(), req.Username, c.ClientIP(), func(ctx context.Context) error {
+		var err error
+		user, err = h.userModel.FindByUsername(ctx, req.Username)
+		if err != nil {
+			// Still hash once so unknown usernames cannot be detected by response time
+			_, err = h.hasher.Compare(req.Password, "")
+			return err
+		}
+
+		// Seeded bcrypt hashes are upgraded to argon2id on the first successful login
+		rehash, err := h.hasher.Compare(req.Password, user.Password)
+		if err != nil {
+			return err
+		}
+		if user.Status != 1 {
+			return errors.ErrUserDisabled
+		}
+		if rehash != "" {
+			_ = h.userModel.UpdateColumns(ctx, nil, map[string]any{"id": user.ID}, map[string]any{"password": rehash})
+		}
+		return nil
+	})
 	if err != nil {
-		response.Error(c, errors.Wrap(err, errors.CodeInternalError, "failed to generate refresh token"))
+		response.Error(c, err)
 		return
 	}
 
@@ -72,21 +88,34 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		Role:     user.Role,
 		Status:   user.Status,
 	}
+
+	// Create access/refresh token pair
+	pair, err := h.tokenManager.IssueTokens(userInfo)
+	if err != nil {
+		response.Error(c, errors.Wrap(err, errors.CodeInternalError, "failed to generate token"))
+		return
+	}
+
 	if err := h.tokenManager.RedisSaveUserInfo(userInfo); err != nil {
 		response.Error(c, errors.Wrap(err, errors.CodeInternalError, "failed to save user session"))
 		return
 	}
 
 	response.Success(c, LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		Username:     user.Username,
-		Role:         user.Role,
+		TokenPair: pair,
+		Username:  user.Username,
+		Role:      user.Role,
 	})
 }
 
-// Logout handles user logout
+// Logout handles user logout, revoking all tokens of the current login session
 func (h *AuthHandler) Logout(c *gin.Context, user *token.UserInfo) {
+	if claims := token.GetClaims(c); claims != nil {
+		if err := h.tokenManager.Revoke(c.Request.Context(), claims); err != nil {
+			response.Error(c, errors.Wrap(err, errors.CodeInternalError, "failed to logout"))
+			return
+		}
+	}
 	if err := h.tokenManager.RedisDeleteUserInfo(user.UserId); err != nil {
 		response.Error(c, errors.Wrap(err, errors.CodeInternalError, "failed to logout"))
 		return
@@ -103,30 +132,35 @@ func (h *AuthHandler) Me(c *gin.Context, user *token.UserInfo) {
 	})
 }
 
-// RefreshToken refreshes access token using refresh token
+// RefreshToken rotates the refresh token and issues a new token pair.
+// The refresh token is read from the JSON body or the Authorization header.
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	tokenString := c.GetHeader("Authorization")
-	if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-		tokenString = tokenString[7:]
+	var req RefreshRequest
+	_ = c.ShouldBindJSON(&req)
+	tokenString := req.RefreshToken
+	if tokenString == "" {
+		tokenString = c.GetHeader("Authorization")
+		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
+			tokenString = tokenString[7:]
+		}
 	}
 
-	claims, err := h.tokenManager.ParseToken(tokenString)
+	pair, claims, err := h.tokenManager.Refresh(c.Request.Context(), tokenString)
 	if err != nil {
 		response.Error(c, errors.New(errors.CodeUnauthorized, "invalid refresh token"))
 		return
 	}
-
-	// Get user from database to ensure user still exists
-	user, err := h.userModel.FindOne(c.Request.Context(), map[string]any{"id": claims.UserId})
+	userId, err := token.UserID(claims)
 	if err != nil {
-		response.Error(c, errors.New(errors.CodeUnauthorized, "user not found"))
+		response.Error(c, errors.New(errors.CodeUnauthorized, "invalid refresh token"))
 		return
 	}
 
-	// Create new access token
-	accessToken, err := h.tokenManager.CreateAccessToken(user.ID, user.Username)
+	// Get user from database to ensure user still exists
+	user, err := h.userModel.FindOne(c.Request.Context(), map[string]any{"id": userId})
 	if err != nil {
-		response.Error(c, errors.Wrap(err, errors.CodeInternalError, "failed to generate token"))
+		_ = h.tokenManager.Revoke(c.Request.Context(), claims)
+		response.Error(c, errors.New(errors.CodeUnauthorized, "user not found"))
 		return
 	}
 
@@ -142,7 +176,5 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, gin.H{
-		"access_token": accessToken,
-	})
+	response.Success(c, pair)
 }
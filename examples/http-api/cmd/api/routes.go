@@ -26,7 +26,7 @@ func registerRoutes(a *app.App, userModel *model.UsersModel, articleModel *model
 	})
 
 	// Create handlers
-	authHandler := handler.NewAuthHandler(userModel, tokenManager)
+	authHandler := handler.NewAuthHandler(userModel, tokenManager, c)
 	articleHandler := handler.NewArticleHandler(articleModel, c)
 
 	// API v1 group
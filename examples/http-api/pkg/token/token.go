@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/auth/jwt"
 	"github.com/goupter/goupter/pkg/cache"
 	"github.com/goupter/goupter/pkg/errors"
 	"github.com/goupter/goupter/pkg/response"
@@ -18,27 +20,12 @@ const (
 	RefreshTokenTTL = 7 * 24 * time.Hour
 )
 
-// Config token configuration
-type Config struct {
-	SecretKey       string
-	Issuer          string
-	AccessTokenTTL  time.Duration
-	RefreshTokenTTL time.Duration
-}
-
-// DefaultConfig returns default token configuration
-func DefaultConfig() *Config {
-	return &Config{
-		SecretKey:       "secretkey",
-		Issuer:          "goupter",
-		AccessTokenTTL:  AccessTokenTTL,
-		RefreshTokenTTL: RefreshTokenTTL,
-	}
-}
-
-// NewConfigFromMap creates Config from a map (supports both secret_key and secretkey)
-func NewConfigFromMap(m map[string]any) *Config {
-	cfg := DefaultConfig()
+// NewConfigFromMap creates a JWT config from a map (supports both secret_key and secretkey)
+func NewConfigFromMap(m map[string]any) *jwt.Config {
+	cfg := jwt.DefaultConfig()
+	cfg.SecretKey = "secretkey"
+	cfg.AccessTokenTTL = AccessTokenTTL
+	cfg.RefreshTokenTTL = RefreshTokenTTL
 
 	// Support both secret_key and secretkey
 	if v, ok := m["secret_key"].(string); ok && v != "" {
@@ -77,12 +64,6 @@ func NewConfigFromMap(m map[string]any) *Config {
 	return cfg
 }
 
-// Claims custom JWT claims (compatible with ag-go MyClaims)
-type Claims struct {
-	UserId int64 `json:"user"` // Same as ag-go: json tag is "user"
-	jwt.RegisteredClaims
-}
-
 // UserInfo cached user information (compatible with ag-go User struct)
 type UserInfo struct {
 	UserId   int64  `json:"userId"`
@@ -116,65 +97,58 @@ type UserInfo struct {
 
 // Manager token manager
 type Manager struct {
-	config *Config
-	cache  cache.Cache
+	auth  *jwt.Authenticator
+	cache cache.Cache
 }
 
-// NewManager creates a new token manager
-func NewManager(cfg *Config, c cache.Cache) *Manager {
+// NewManager creates a new token manager.
+// Tokens are issued by pkg/auth/jwt; the cache tracks used refresh tokens and revoked token families.
+func NewManager(cfg *jwt.Config, c cache.Cache) *Manager {
 	if cfg == nil {
-		cfg = DefaultConfig()
+		cfg = NewConfigFromMap(nil)
 	}
 	return &Manager{
-		config: cfg,
-		cache:  c,
+		auth:  jwt.NewAuthenticator(cfg, jwt.WithBlacklist(c)),
+		cache: c,
 	}
 }
 
-// CreateAccessToken creates an access token (compatible with ag-go)
-func (m *Manager) CreateAccessToken(userId int64, subject string) (string, error) {
-	claims := &Claims{
-		UserId: userId,
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    m.config.Issuer,
-			Subject:   subject,
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.config.AccessTokenTTL)),
-		},
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.config.SecretKey))
-}
-
-// CreateRefreshToken creates a refresh token (compatible with ag-go)
-func (m *Manager) CreateRefreshToken(userId int64) (string, error) {
-	claims := &Claims{
-		UserId: userId,
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    m.config.Issuer,
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.config.RefreshTokenTTL)),
-		},
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.config.SecretKey))
+// IssueTokens issues an access/refresh token pair for the user
+func (m *Manager) IssueTokens(user *UserInfo) (*auth.TokenPair, error) {
+	return m.auth.GenerateToken(&auth.Claims{
+		UserID:   strconv.FormatInt(user.UserId, 10),
+		Username: user.Username,
+		Roles:    []string{user.Role},
+	})
 }
 
-// ParseToken parses and validates a token
-func (m *Manager) ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(m.config.SecretKey), nil
-	})
+// Refresh rotates the refresh token and returns the new pair with its claims.
+// Replaying an already used refresh token revokes the whole token family.
+func (m *Manager) Refresh(ctx context.Context, refreshToken string) (*auth.TokenPair, *auth.Claims, error) {
+	pair, err := m.auth.RefreshToken(ctx, refreshToken)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, err := m.auth.Authenticate(ctx, pair.AccessToken)
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil, fmt.Errorf("invalid token")
+	return pair, claims, nil
+}
+
+// ParseToken validates an access token
+func (m *Manager) ParseToken(ctx context.Context, tokenString string) (*auth.Claims, error) {
+	return m.auth.Authenticate(ctx, tokenString)
+}
+
+// Revoke revokes every token issued to the login session of the given claims
+func (m *Manager) Revoke(ctx context.Context, claims *auth.Claims) error {
+	return m.auth.RevokeFamily(ctx, claims.FamilyID)
+}
+
+// UserID parses the numeric user id from token claims
+func UserID(claims *auth.Claims) (int64, error) {
+	return strconv.ParseInt(claims.UserID, 10, 64)
 }
 
 // RedisSaveUserInfo saves user info to Redis (compatible with ag-go)
@@ -211,8 +185,11 @@ func (m *Manager) RedisDeleteUserInfo(userId int64) error {
 	return m.cache.Delete(context.Background(), key)
 }
 
-// Context key for user info
-const ContextKeyUser = "user" // Same as ag-go: c.Set("user", user)
+// Context keys for user info and token claims
+const (
+	ContextKeyUser   = "user" // Same as ag-go: c.Set("user", user)
+	ContextKeyClaims = "claims"
+)
 
 // JWTAuth middleware for JWT authentication (compatible with ag-go JWTAuth)
 func (m *Manager) JWTAuth() gin.HandlerFunc {
@@ -230,7 +207,13 @@ func (m *Manager) JWTAuth() gin.HandlerFunc {
 		}
 
 		// Parse JWT token
-		claims, err := m.ParseToken(tokenString)
+		claims, err := m.ParseToken(c.Request.Context(), tokenString)
+		if err != nil {
+			response.Error(c, errors.New(errors.CodeUnauthorized, "invalid token"))
+			c.Abort()
+			return
+		}
+		userId, err := UserID(claims)
 		if err != nil {
 			response.Error(c, errors.New(errors.CodeUnauthorized, "invalid token"))
 			c.Abort()
@@ -238,7 +221,7 @@ func (m *Manager) JWTAuth() gin.HandlerFunc {
 		}
 
 		// Get user info from Redis (same as ag-go: redis_get_user_info)
-		user := m.RedisGetUserInfo(claims.UserId)
+		user := m.RedisGetUserInfo(userId)
 		if user == nil {
 			response.Error(c, errors.New(errors.CodeUnauthorized, "user session not found"))
 			c.Abort()
@@ -247,10 +230,21 @@ func (m *Manager) JWTAuth() gin.HandlerFunc {
 
 		// Set user to context (same as ag-go: c.Set("user", user))
 		c.Set(ContextKeyUser, user)
+		c.Set(ContextKeyClaims, claims)
 		c.Next()
 	}
 }
 
+// GetClaims gets the access token claims from gin context
+func GetClaims(c *gin.Context) *auth.Claims {
+	v, exists := c.Get(ContextKeyClaims)
+	if !exists {
+		return nil
+	}
+	claims, _ := v.(*auth.Claims)
+	return claims
+}
+
 // GetUser gets user info from gin context (compatible with ag-go get_user)
 func GetUser(c *gin.Context) *UserInfo {
 	u, exists := c.Get(ContextKeyUser)
@@ -33,6 +33,14 @@ type App struct {
 	mq         mq.MessageQueue
 	auth       auth.Authenticator
 
+	// 配置中心（热更新）
+	configCenter *config.ConfigCenter
+	consulCenter *config.ConsulConfigCenter
+	fileCenter   *config.ConfigCenter
+	watchFile    bool
+	reloaders    []configReloader
+	restartKeys  map[string]struct{}
+
 	// 健康检查
 	health *HealthManager
 
@@ -47,6 +55,9 @@ type App struct {
 	beforeStop  []func() error
 	afterStop   []func() error
 
+	// 服务注册ID（未配置时自动生成）
+	serviceID string
+
 	// 状态
 	running bool
 	mu      sync.RWMutex
@@ -125,6 +136,29 @@ func WithAuthenticator(auth auth.Authenticator) Option {
 	}
 }
 
+// WithConfigCenter 设置配置中心
+func WithConfigCenter(c *config.ConsulConfigCenter) Option {
+	return func(a *App) {
+		a.consulCenter = c
+		a.configCenter = c.ConfigCenter
+	}
+}
+
+// WithConfigSource 设置基于任意配置源的配置中心，如 config.NewFileSource 指向的 ConfigMap 文件
+func WithConfigSource(c *config.ConfigCenter) Option {
+	return func(a *App) {
+		a.consulCenter = nil
+		a.configCenter = c
+	}
+}
+
+// WithConfigFileWatch 监听配置文件并热更新
+func WithConfigFileWatch() Option {
+	return func(a *App) {
+		a.watchFile = true
+	}
+}
+
 // WithHealthManager 设置健康检查管理器
 func WithHealthManager(h *HealthManager) Option {
 	return func(a *App) {
@@ -249,6 +283,18 @@ func (a *App) Auth() auth.Authenticator {
 	return a.auth
 }
 
+// ConfigCenter 获取 Consul 配置中心，使用其他配置源时返回 nil
+func (a *App) ConfigCenter() *config.ConsulConfigCenter {
+	return a.consulCenter
+}
+
+// Bind 将自定义配置段解析到 target，参见 config.Bind
+//
+// 需要随配置热更新时使用 config.Section。
+func (a *App) Bind(key string, target any) error {
+	return config.Bind(key, target)
+}
+
 // Health 获取健康检查管理器
 func (a *App) Health() *HealthManager {
 	return a.health
@@ -271,6 +317,9 @@ func (a *App) Run() error {
 		}
 	}
 
+	// 监听配置变更
+	a.startConfigWatch()
+
 	// 启动HTTP服务器
 	if a.httpServer != nil {
 		a.registerBuiltinHTTPEndpoints()
@@ -296,25 +345,7 @@ func (a *App) Run() error {
 	}
 
 	// 注册服务
-	if a.discovery != nil && a.config != nil {
-		address := a.config.Server.HTTP.Host
-		if a.config.Server.HTTP.AdvertiseHost != "" {
-			address = a.config.Server.HTTP.AdvertiseHost
-		}
-
-		serviceInfo := &discovery.ServiceInfo{
-			ID:      a.config.Consul.Service.ID,
-			Name:    a.config.Consul.Service.Name,
-			Address: address,
-			Port:    a.config.Server.HTTP.Port,
-			Tags:    a.config.Consul.Service.Tags,
-		}
-		if err := a.discovery.Register(context.Background(), serviceInfo); err != nil {
-			if a.logger != nil {
-				a.logger.Warn("register service failed", log.Error(err))
-			}
-		}
-	}
+	a.registerService(context.Background())
 
 	// 执行启动后钩子
 	for _, fn := range a.afterStart {
@@ -395,8 +426,14 @@ func (a *App) Shutdown(ctx context.Context) error {
 	}
 
 	// 注销服务
-	if a.discovery != nil && a.config != nil {
-		_ = a.discovery.Deregister(shutdownCtx, a.config.Consul.Service.ID)
+	a.deregisterService(shutdownCtx)
+
+	// 停止监听配置变更
+	if a.configCenter != nil {
+		a.configCenter.Stop()
+	}
+	if a.fileCenter != nil {
+		a.fileCenter.Stop()
 	}
 
 	// 关闭HTTP服务器
@@ -15,6 +15,8 @@ type HealthStatus string
 const (
 	StatusUp   HealthStatus = "up"
 	StatusDown HealthStatus = "down"
+	// StatusDegraded 可继续提供服务但存在异常，不影响存活与就绪判定
+	StatusDegraded HealthStatus = "degraded"
 )
 
 // CheckResult 检查结果
@@ -196,9 +198,7 @@ func (m *HealthManager) CheckLiveness(ctx context.Context) *HealthResponse {
 	for _, checker := range checkers {
 		result := checker.Check(checkCtx)
 		response.Checks[checker.Name()] = &result
-		if result.Status == StatusDown {
-			response.Status = StatusDown
-		}
+		response.Status = worseStatus(response.Status, result.Status)
 	}
 
 	response.Duration = time.Since(start).String()
@@ -240,8 +240,8 @@ func (m *HealthManager) CheckReadiness(ctx context.Context) *ReadyResponse {
 	for _, checker := range checkers {
 		result := checker.Check(checkCtx)
 		response.Checks[checker.Name()] = &result
+		response.Status = worseStatus(response.Status, result.Status)
 		if result.Status == StatusDown {
-			response.Status = StatusDown
 			response.Ready = false
 		}
 	}
@@ -251,6 +251,17 @@ func (m *HealthManager) CheckReadiness(ctx context.Context) *ReadyResponse {
 	return response
 }
 
+// worseStatus 汇总检查结果，down 优先于 degraded
+func worseStatus(current, result HealthStatus) HealthStatus {
+	switch {
+	case current == StatusDown || result == StatusDown:
+		return StatusDown
+	case result == StatusDegraded:
+		return StatusDegraded
+	}
+	return current
+}
+
 func (m *HealthManager) cacheResponse(liveness *HealthResponse, readiness *ReadyResponse) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
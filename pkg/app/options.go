@@ -3,8 +3,12 @@ package app
 import (
 	"fmt"
 
+	"github.com/gin-gonic/gin"
 	"github.com/goupter/goupter/pkg/auth"
+	_ "github.com/goupter/goupter/pkg/auth/apikey"
 	_ "github.com/goupter/goupter/pkg/auth/jwt"
+	_ "github.com/goupter/goupter/pkg/auth/oidc"
+	_ "github.com/goupter/goupter/pkg/auth/session"
 	"github.com/goupter/goupter/pkg/cache"
 	"github.com/goupter/goupter/pkg/config"
 	"github.com/goupter/goupter/pkg/database"
@@ -17,8 +21,9 @@ import (
 
 // Builder 应用构建器
 type Builder struct {
-	opts []Option
-	err  error
+	opts  []Option
+	err   error
+	cache cache.Cache
 }
 
 // NewBuilder 创建应用构建器
@@ -50,6 +55,59 @@ func (b *Builder) LoadConfig(opts ...config.Option) *Builder {
 	return b
 }
 
+// WatchConfigFile 监听配置文件，文件变化时热更新配置
+func (b *Builder) WatchConfigFile() *Builder {
+	b.opts = append(b.opts, WithConfigFileWatch())
+	return b
+}
+
+// InitConfigCenter 初始化配置中心，从 consul.config_path 热更新配置
+func (b *Builder) InitConfigCenter(opts ...config.ConsulConfigCenterOption) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	cfg := config.Get()
+	if cfg == nil {
+		b.err = fmt.Errorf("config not loaded")
+		return b
+	}
+	if cfg.Consul.ConfigPath == "" {
+		b.err = fmt.Errorf("init config center failed: consul.config_path is empty")
+		return b
+	}
+
+	source, err := config.NewConsulSourceWithConfig(&cfg.Consul)
+	if err != nil {
+		b.err = fmt.Errorf("init config center failed: %w", err)
+		return b
+	}
+
+	opts = append([]config.ConsulConfigCenterOption{config.WithReloadLogger(log.Default())}, opts...)
+	b.opts = append(b.opts, WithConfigCenter(config.NewConsulConfigCenter(source, opts...)))
+	return b
+}
+
+// InitFileConfigSource 加载并监听配置文件，适用于挂载 Kubernetes ConfigMap 等场景
+//
+// 需在 LoadConfig 之后、其他 Init 之前调用。文件内容作为一层配置，与 LoadConfig 加载的本地文件、环境变量按优先级合并，变更时经过与 Consul 相同的校验流程。
+func (b *Builder) InitFileConfigSource(path string, opts ...config.ConfigCenterOption) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	source := config.NewFileSource(path, config.WithFileLogger(log.Default()))
+	opts = append([]config.ConfigCenterOption{config.WithReloadLogger(log.Default())}, opts...)
+	center := config.NewConfigCenter(source, opts...)
+	if _, err := center.Load(); err != nil {
+		b.err = fmt.Errorf("init file config source failed: %w", err)
+		return b
+	}
+
+	b.opts = append(b.opts, WithConfigSource(center))
+	return b
+}
+
 // InitLogger 初始化日志
 func (b *Builder) InitLogger() *Builder {
 	if b.err != nil {
@@ -78,7 +136,13 @@ func (b *Builder) InitLogger() *Builder {
 	}
 
 	log.SetDefault(logger)
-	b.opts = append(b.opts, WithLogger(logger))
+	b.opts = append(b.opts,
+		WithLogger(logger),
+		WithConfigReloader(func(change *config.ConfigChange) error {
+			logger.SetLevel(log.ParseLevel(change.New.Log.Level))
+			return nil
+		}, "log.level"),
+	)
 	return b
 }
 
@@ -103,7 +167,10 @@ func (b *Builder) InitDatabase() *Builder {
 		return b
 	}
 
-	b.opts = append(b.opts, WithDatabase(mysql.DB()))
+	b.opts = append(b.opts,
+		WithDatabase(mysql.DB()),
+		WithConfigReloader(databaseReloader(mysql.DB()), databaseReloadKeys(mysql.DB())...),
+	)
 	return b
 }
 
@@ -127,6 +194,7 @@ func (b *Builder) InitCache() *Builder {
 		return b
 	}
 
+	b.cache = redisCache
 	b.opts = append(b.opts, WithCache(redisCache))
 	return b
 }
@@ -143,19 +211,44 @@ func (b *Builder) InitDiscovery() *Builder {
 		return b
 	}
 
-	consulDiscovery, err := discovery.NewConsulDiscovery(
-		discovery.WithConsulConfig(&cfg.Consul),
-		discovery.WithConsulLogger(log.Default()),
-	)
+	d, err := newDiscovery(cfg)
 	if err != nil {
 		b.err = fmt.Errorf("init discovery failed: %w", err)
 		return b
 	}
 
-	b.opts = append(b.opts, WithDiscovery(consulDiscovery))
+	b.opts = append(b.opts, WithDiscovery(d))
 	return b
 }
 
+// newDiscovery 按 discovery.type 创建服务发现
+func newDiscovery(cfg *config.Config) (discovery.Discovery, error) {
+	switch cfg.Discovery.Type {
+	case "", "consul":
+		return discovery.NewConsulDiscovery(
+			discovery.WithConsulConfig(&cfg.Consul),
+			discovery.WithConsulLogger(log.Default()),
+		)
+	case "etcd":
+		return discovery.NewEtcdDiscovery(
+			discovery.WithEtcdConfig(&cfg.Discovery.Etcd),
+			discovery.WithEtcdLogger(log.Default()),
+		)
+	case "dns":
+		return discovery.NewDNSDiscovery(
+			discovery.WithDNSConfig(&cfg.Discovery.DNS),
+			discovery.WithDNSLogger(log.Default()),
+		), nil
+	case "static":
+		return discovery.NewStaticDiscovery(
+			discovery.WithStaticConfig(&cfg.Discovery.Static),
+			discovery.WithStaticLogger(log.Default()),
+		)
+	default:
+		return nil, fmt.Errorf("unsupported discovery type: %s", cfg.Discovery.Type)
+	}
+}
+
 // InitMessageQueue 初始化消息队列
 func (b *Builder) InitMessageQueue() *Builder {
 	if b.err != nil {
@@ -181,7 +274,7 @@ func (b *Builder) InitMessageQueue() *Builder {
 	return b
 }
 
-// InitAuth 初始化鉴权
+// InitAuth 初始化鉴权，需在 InitCache 之后调用
 func (b *Builder) InitAuth() *Builder {
 	if b.err != nil {
 		return b
@@ -193,7 +286,19 @@ func (b *Builder) InitAuth() *Builder {
 		return b
 	}
 
-	authenticator, err := auth.NewAuthenticator(cfg.Auth.Type, cfg.Auth.Config)
+	// 注入 InitCache 创建的缓存，供 JWT 刷新令牌复用检测、黑名单、服务端会话与 OIDC 内省缓存使用
+	authConfig := cfg.Auth.Config
+	if b.cache != nil {
+		authConfig = make(map[string]interface{}, len(cfg.Auth.Config)+1)
+		for k, v := range cfg.Auth.Config {
+			authConfig[k] = v
+		}
+		if _, ok := authConfig["cache"]; !ok {
+			authConfig["cache"] = b.cache
+		}
+	}
+
+	authenticator, err := auth.NewAuthenticator(cfg.Auth.Type, authConfig)
 	if err != nil {
 		b.err = fmt.Errorf("init auth failed: %w", err)
 		return b
@@ -215,17 +320,38 @@ func (b *Builder) InitHTTPServer() *Builder {
 		return b
 	}
 
+	cors := middleware.NewCORSHandler(corsConfig(&cfg.Server.HTTP.CORS))
+	middlewares := []gin.HandlerFunc{
+		middleware.Recovery(log.Default()),
+		middleware.Logger(log.Default()),
+		cors.Handler(),
+	}
+	reloadKeys := []string{"server.http.cors"}
+
+	var limiter *middleware.IPRateLimiter
+	if rl := cfg.Server.HTTP.RateLimit; rl.Enabled {
+		limiter = middleware.NewIPRateLimiter(rl.Rate, rl.Burst)
+		middlewares = append(middlewares, middleware.RateLimit(limiter))
+		reloadKeys = append(reloadKeys, "server.http.rate_limit.rate", "server.http.rate_limit.burst")
+	}
+
 	httpServer := server.NewHTTPServer(
 		server.WithHTTPConfig(&cfg.Server.HTTP),
 		server.WithHTTPLogger(log.Default()),
-		server.WithMiddleware(
-			middleware.Recovery(log.Default()),
-			middleware.Logger(log.Default()),
-			middleware.CORS(),
-		),
+		server.WithMiddleware(middlewares...),
 	)
 
-	b.opts = append(b.opts, WithHTTPServer(httpServer))
+	b.opts = append(b.opts,
+		WithHTTPServer(httpServer),
+		WithConfigReloader(func(change *config.ConfigChange) error {
+			cors.Update(corsConfig(&change.New.Server.HTTP.CORS))
+			if limiter != nil {
+				rl := change.New.Server.HTTP.RateLimit
+				limiter.SetRate(rl.Rate, rl.Burst)
+			}
+			return nil
+		}, reloadKeys...),
+	)
 	return b
 }
 
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goupter/goupter/pkg/discovery"
+	"github.com/goupter/goupter/pkg/log"
+)
+
+// 服务注册元数据键
+const (
+	MetadataHTTPPort = "http_port"
+	MetadataGRPCPort = "grpc_port"
+)
+
+// buildServiceInfo 构建服务注册信息
+//
+// 同一服务实例只注册一条记录，各协议端口写入元数据（http_port / grpc_port），
+// 并为每个正在运行的协议附带对应的健康检查：HTTP 使用 HealthManager 的存活路径，
+// gRPC 使用 GRPCServer 内置的 grpc.health.v1 服务。
+func (a *App) buildServiceInfo() *discovery.ServiceInfo {
+	cfg := a.config
+
+	address := cfg.Server.HTTP.Host
+	if cfg.Server.HTTP.AdvertiseHost != "" {
+		address = cfg.Server.HTTP.AdvertiseHost
+	} else if a.httpServer == nil && a.grpcServer != nil {
+		address = cfg.Server.GRPC.Host
+	}
+
+	name := cfg.Consul.Service.Name
+	if name == "" {
+		name = cfg.App.Name
+	}
+	if name == "" {
+		name = a.name
+	}
+
+	info := &discovery.ServiceInfo{
+		Name:     name,
+		Address:  address,
+		Tags:     cfg.Consul.Service.Tags,
+		Metadata: make(map[string]string),
+	}
+
+	if a.httpServer != nil {
+		port := cfg.Server.HTTP.Port
+		info.Port = port
+		info.Metadata[MetadataHTTPPort] = strconv.Itoa(port)
+
+		path := DefaultHealthConfig().LivenessPath
+		if a.health != nil {
+			path = a.health.Config().LivenessPath
+		}
+		info.Checks = append(info.Checks, &discovery.HealthCheck{
+			Type:     discovery.CheckTypeHTTP,
+			Endpoint: fmt.Sprintf("http://%s%s", info.Endpoint("http"), path),
+		})
+	}
+
+	if a.grpcServer != nil {
+		port := cfg.Server.GRPC.Port
+		if info.Port == 0 {
+			info.Port = port
+		}
+		info.Metadata[MetadataGRPCPort] = strconv.Itoa(port)
+		info.Checks = append(info.Checks, &discovery.HealthCheck{
+			Type:     discovery.CheckTypeGRPC,
+			Endpoint: info.Endpoint("grpc"),
+		})
+	}
+
+	info.ID = cfg.Consul.Service.ID
+	if info.ID == "" {
+		info.ID = generateServiceID(name, address, info.Port)
+	}
+
+	return info
+}
+
+// generateServiceID 生成服务实例ID：<name>-<host>-<port>
+//
+// 监听地址为空或通配地址时使用主机名，保证同一主机重启后 ID 不变。
+func generateServiceID(name, address string, port int) string {
+	host := address
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		if hostname, err := os.Hostname(); err == nil {
+			host = hostname
+		}
+	}
+	host = strings.NewReplacer(":", "-", "/", "-").Replace(host)
+	return fmt.Sprintf("%s-%s-%d", name, host, port)
+}
+
+// registerService 注册服务到服务发现
+func (a *App) registerService(ctx context.Context) {
+	if a.discovery == nil || a.config == nil {
+		return
+	}
+
+	// TTL 心跳以就绪检查结果作为服务健康状态
+	if hd, ok := a.discovery.(discovery.HeartbeatDiscovery); ok && a.health != nil {
+		hd.SetHealthReporter(a.healthReporter())
+	}
+
+	info := a.buildServiceInfo()
+	if err := a.discovery.Register(ctx, info); err != nil {
+		if a.logger != nil {
+			a.logger.Warn("register service failed", log.Error(err))
+		}
+		return
+	}
+
+	a.mu.Lock()
+	a.serviceID = info.ID
+	a.mu.Unlock()
+}
+
+// deregisterService 从服务发现注销服务
+func (a *App) deregisterService(ctx context.Context) {
+	if a.discovery == nil {
+		return
+	}
+
+	a.mu.RLock()
+	serviceID := a.serviceID
+	a.mu.RUnlock()
+	if serviceID == "" {
+		return
+	}
+
+	if err := a.discovery.Deregister(ctx, serviceID); err != nil && a.logger != nil {
+		a.logger.Warn("deregister service failed", log.Error(err))
+	}
+}
+
+// healthReporter 将 HealthManager 的就绪检查转换为服务发现心跳状态
+func (a *App) healthReporter() discovery.HealthReporter {
+	return func(ctx context.Context) (discovery.HealthStatus, string) {
+		ready := a.health.CheckReadiness(ctx)
+		if ready.Ready {
+			return discovery.HealthStatusPassing, "ready"
+		}
+
+		failed := make([]string, 0, len(ready.Checks))
+		for name, result := range ready.Checks {
+			if result.Status != StatusDown {
+				continue
+			}
+			if result.Message != "" {
+				name += ": " + result.Message
+			}
+			failed = append(failed, name)
+		}
+		sort.Strings(failed)
+		return discovery.HealthStatusCritical, "not ready: " + strings.Join(failed, "; ")
+	}
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/goupter/goupter/pkg/config"
+	"github.com/goupter/goupter/pkg/discovery"
+	"github.com/goupter/goupter/pkg/server"
+)
+
+// recordDiscovery 记录注册/注销调用的服务发现
+type recordDiscovery struct {
+	registered   *discovery.ServiceInfo
+	deregistered string
+}
+
+func (d *recordDiscovery) Register(_ context.Context, s *discovery.ServiceInfo) error {
+	d.registered = s
+	return nil
+}
+func (d *recordDiscovery) Deregister(_ context.Context, id string) error {
+	d.deregistered = id
+	return nil
+}
+func (d *recordDiscovery) GetService(context.Context, string) ([]*discovery.ServiceInfo, error) {
+	return nil, nil
+}
+func (d *recordDiscovery) Watch(context.Context, string, func([]*discovery.ServiceInfo)) error {
+	return nil
+}
+func (d *recordDiscovery) Close() error { return nil }
+
+func TestApp_RegisterService_HTTPAndGRPC(t *testing.T) {
+	cfg := &config.Config{
+		App: config.AppConfig{Name: "user-service"},
+		Server: config.ServerConfig{
+			HTTP: config.HTTPConfig{Host: "0.0.0.0", AdvertiseHost: "10.0.0.5", Port: 8080},
+			GRPC: config.GRPCConfig{Host: "0.0.0.0", Port: 9090},
+		},
+	}
+	d := &recordDiscovery{}
+	a := New(
+		WithConfig(cfg),
+		WithDiscovery(d),
+		WithHTTPServer(server.NewHTTPServer()),
+		WithGRPCServer(server.NewGRPCServer()),
+	)
+
+	a.registerService(context.Background())
+
+	s := d.registered
+	if s == nil {
+		t.Fatal("服务未注册")
+	}
+	if s.Name != "user-service" || s.Address != "10.0.0.5" || s.Port != 8080 {
+		t.Errorf("service = %s %s:%d", s.Name, s.Address, s.Port)
+	}
+	if s.ID != "user-service-10.0.0.5-8080" {
+		t.Errorf("ID = %s", s.ID)
+	}
+	if s.Metadata[MetadataHTTPPort] != "8080" || s.Metadata[MetadataGRPCPort] != "9090" {
+		t.Errorf("Metadata = %v", s.Metadata)
+	}
+	if got := s.Endpoint("grpc"); got != "10.0.0.5:9090" {
+		t.Errorf("grpc endpoint = %s", got)
+	}
+
+	if len(s.Checks) != 2 {
+		t.Fatalf("Checks = %d, want 2", len(s.Checks))
+	}
+	if s.Checks[0].Type != discovery.CheckTypeHTTP || s.Checks[0].Endpoint != "http://10.0.0.5:8080/health" {
+		t.Errorf("http check = %+v", s.Checks[0])
+	}
+	if s.Checks[1].Type != discovery.CheckTypeGRPC || s.Checks[1].Endpoint != "10.0.0.5:9090" {
+		t.Errorf("grpc check = %+v", s.Checks[1])
+	}
+
+	a.deregisterService(context.Background())
+	if d.deregistered != s.ID {
+		t.Errorf("deregistered = %s, want %s", d.deregistered, s.ID)
+	}
+}
+
+func TestApp_RegisterService_GRPCOnly(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{GRPC: config.GRPCConfig{Host: "10.0.0.6", Port: 9090}},
+		Consul: config.ConsulConfig{Service: config.ConsulServiceConfig{ID: "fixed-id", Name: "order"}},
+	}
+	d := &recordDiscovery{}
+	a := New(WithConfig(cfg), WithDiscovery(d), WithGRPCServer(server.NewGRPCServer()))
+
+	a.registerService(context.Background())
+
+	s := d.registered
+	if s.ID != "fixed-id" || s.Name != "order" || s.Address != "10.0.0.6" || s.Port != 9090 {
+		t.Errorf("service = %+v", s)
+	}
+	if _, ok := s.Metadata[MetadataHTTPPort]; ok {
+		t.Error("未启用 HTTP 时不应写入 http_port")
+	}
+	if len(s.Checks) != 1 || s.Checks[0].Type != discovery.CheckTypeGRPC {
+		t.Errorf("Checks = %+v", s.Checks)
+	}
+}
+
+func TestApp_HealthReporter(t *testing.T) {
+	a := New()
+	report := a.healthReporter()
+
+	if status, _ := report(context.Background()); status != discovery.HealthStatusPassing {
+		t.Errorf("status = %s, want passing", status)
+	}
+
+	a.health.RegisterReadinessFunc("db", func(context.Context) CheckResult {
+		return CheckResult{Status: StatusDown, Message: "connection refused"}
+	})
+	a.health.ClearCache()
+
+	status, output := report(context.Background())
+	if status != discovery.HealthStatusCritical || output != "not ready: db: connection refused" {
+		t.Errorf("report = %s %q", status, output)
+	}
+}
+
+func TestNewDiscovery(t *testing.T) {
+	cfg := &config.Config{Discovery: config.DiscoveryConfig{Type: "static"}}
+	if d, err := newDiscovery(cfg); err != nil {
+		t.Errorf("static: %v", err)
+	} else if _, ok := d.(*discovery.StaticDiscovery); !ok {
+		t.Errorf("static: got %T", d)
+	}
+
+	cfg.Discovery.Type = "dns"
+	if d, err := newDiscovery(cfg); err != nil {
+		t.Errorf("dns: %v", err)
+	} else if _, ok := d.(*discovery.DNSDiscovery); !ok {
+		t.Errorf("dns: got %T", d)
+	}
+
+	cfg.Discovery.Type = "zookeeper"
+	if _, err := newDiscovery(cfg); err == nil {
+		t.Error("不支持的类型应返回错误")
+	}
+}
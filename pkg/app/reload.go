@@ -0,0 +1,236 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goupter/goupter/pkg/config"
+	"github.com/goupter/goupter/pkg/database"
+	"github.com/goupter/goupter/pkg/log"
+	"github.com/goupter/goupter/pkg/server/middleware"
+	"gorm.io/gorm"
+)
+
+// ConfigReloadFunc 配置热更新处理函数，返回错误表示变更未能生效
+type ConfigReloadFunc func(change *config.ConfigChange) error
+
+// configReloader 负责一组配置键的热更新处理器
+type configReloader struct {
+	keys []string
+	fn   ConfigReloadFunc
+}
+
+// WithConfigReloader 注册配置热更新处理器
+//
+// keys 为处理器负责的配置键或前缀（如 "log.level"、"server.http.cors"），
+// 其中任一键变更时调用 fn。未被任何处理器或 config.Section 负责的键变更时报告为“需要重启”。
+func WithConfigReloader(fn ConfigReloadFunc, keys ...string) Option {
+	return func(a *App) {
+		a.OnConfigChange(fn, keys...)
+	}
+}
+
+// OnConfigChange 注册配置热更新处理器，参见 WithConfigReloader
+func (a *App) OnConfigChange(fn ConfigReloadFunc, keys ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reloaders = append(a.reloaders, configReloader{keys: keys, fn: fn})
+}
+
+// RestartRequired 返回已变更但无法热更新、需要重启才能生效的配置键
+func (a *App) RestartRequired() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	keys := make([]string, 0, len(a.restartKeys))
+	for k := range a.restartKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// applyConfigChange 将配置变更分发给负责的处理器
+func (a *App) applyConfigChange(change *config.ConfigChange) {
+	a.mu.Lock()
+	reloaders := make([]configReloader, len(a.reloaders))
+	copy(reloaders, a.reloaders)
+	a.mu.Unlock()
+
+	handled := make(map[string]bool, len(change.Changes))
+	sectionKeys := config.SectionKeys()
+	for _, key := range change.Keys() {
+		if matchKey(key, sectionKeys) {
+			handled[key] = true
+		}
+	}
+	for _, r := range reloaders {
+		if !change.Changed(r.keys...) {
+			continue
+		}
+		for _, key := range change.Keys() {
+			if matchKey(key, r.keys) {
+				handled[key] = true
+			}
+		}
+		if err := r.fn(change); err != nil && a.logger != nil {
+			a.logger.Error("apply config change failed",
+				log.Strings("keys", r.keys), log.Error(err))
+		}
+	}
+
+	var restart []string
+	for _, key := range change.Keys() {
+		if !handled[key] {
+			restart = append(restart, key)
+		}
+	}
+	if len(restart) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	if a.restartKeys == nil {
+		a.restartKeys = make(map[string]struct{})
+	}
+	for _, key := range restart {
+		a.restartKeys[key] = struct{}{}
+	}
+	a.mu.Unlock()
+
+	if a.logger != nil {
+		a.logger.Warn("config changed, restart required to take effect", log.Strings("keys", restart))
+	}
+}
+
+func matchKey(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkConfigReload 报告配置热更新状态
+//
+// 最近一次重载被拒绝时返回 degraded：服务继续使用当前生效的配置，仍视为就绪，
+// 但运行配置与配置源不一致；之后成功应用新配置时恢复 up。需要重启的变更只在 Message 中说明。
+func (a *App) checkConfigReload(ctx context.Context) CheckResult {
+	result := CheckResult{Status: StatusUp, Timestamp: time.Now()}
+
+	var messages []string
+	for _, center := range a.configCenters() {
+		if status := center.ReloadStatus(); status.LastError != nil {
+			result.Status = StatusDegraded
+			messages = append(messages, fmt.Sprintf("reload rejected at %s, using last known good config: %v",
+				status.LastRejected.Format(time.RFC3339), status.LastError))
+		}
+	}
+	if keys := a.RestartRequired(); len(keys) > 0 {
+		messages = append(messages, "restart required: "+strings.Join(keys, ", "))
+	}
+	result.Message = strings.Join(messages, "; ")
+	return result
+}
+
+// configCenters 返回已启用的配置中心，包括监听本地配置文件的配置中心
+func (a *App) configCenters() []*config.ConfigCenter {
+	var centers []*config.ConfigCenter
+	if a.fileCenter != nil {
+		centers = append(centers, a.fileCenter)
+	}
+	if a.configCenter != nil {
+		centers = append(centers, a.configCenter)
+	}
+	return centers
+}
+
+// startConfigWatch 开始监听配置文件与配置中心
+//
+// 配置文件与配置中心的变更都经过 config.ConfigCenter 的 解析 -> 校验 -> 替换 -> 通知 流程。
+func (a *App) startConfigWatch() {
+	if a.watchFile {
+		center, err := config.NewFileConfigCenter(config.WithReloadLogger(a.logger))
+		if err != nil {
+			if a.logger != nil {
+				a.logger.Warn("watch config file failed", log.Error(err))
+			}
+		} else {
+			a.fileCenter = center
+		}
+	}
+
+	centers := a.configCenters()
+	if len(centers) == 0 {
+		return
+	}
+
+	if a.health != nil {
+		a.health.RegisterReadinessFunc("config", a.checkConfigReload)
+	}
+
+	for _, center := range centers {
+		center.OnChangeDiff(a.applyConfigChange)
+		if err := center.Watch(); err != nil && a.logger != nil {
+			a.logger.Warn("watch config center failed", log.Error(err))
+		}
+	}
+}
+
+// databaseReloadKeys 返回可热更新的数据库配置键
+func databaseReloadKeys(db *gorm.DB) []string {
+	keys := []string{
+		"database.max_open_conns",
+		"database.max_idle_conns",
+		"database.conn_max_lifetime",
+		"database.conn_max_idle_time",
+	}
+	if _, ok := db.Config.Logger.(*database.SlowQueryLogger); ok {
+		keys = append(keys, "database.slow_query_threshold")
+	}
+	return keys
+}
+
+// databaseReloader 热更新连接池参数与慢查询阈值
+func databaseReloader(db *gorm.DB) ConfigReloadFunc {
+	return func(change *config.ConfigChange) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+
+		cfg := change.New.Database
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+		if sl, ok := db.Config.Logger.(*database.SlowQueryLogger); ok {
+			sl.SetThreshold(cfg.SlowQueryThreshold)
+		}
+		return nil
+	}
+}
+
+// corsConfig 将配置转换为跨域中间件配置，未设置的列表使用默认值
+func corsConfig(cfg *config.CORSConfig) middleware.CORSConfig {
+	result := middleware.DefaultCORSConfig()
+	if len(cfg.AllowOrigins) > 0 {
+		result.AllowOrigins = cfg.AllowOrigins
+	}
+	if len(cfg.AllowMethods) > 0 {
+		result.AllowMethods = cfg.AllowMethods
+	}
+	if len(cfg.AllowHeaders) > 0 {
+		result.AllowHeaders = cfg.AllowHeaders
+	}
+	if len(cfg.ExposeHeaders) > 0 {
+		result.ExposeHeaders = cfg.ExposeHeaders
+	}
+	result.AllowCredentials = cfg.AllowCredentials
+	result.MaxAge = cfg.MaxAge
+	return result
+}
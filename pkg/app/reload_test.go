@@ -0,0 +1,171 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/config"
+	"github.com/goupter/goupter/pkg/database"
+	"github.com/goupter/goupter/pkg/log"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func newChange(old, new *config.Config) *config.ConfigChange {
+	return &config.ConfigChange{Old: old, New: new, Changes: config.Diff(old, new)}
+}
+
+func TestApp_ApplyConfigChange(t *testing.T) {
+	var levels []string
+	a := New(
+		WithConfigReloader(func(change *config.ConfigChange) error {
+			levels = append(levels, change.New.Log.Level)
+			return nil
+		}, "log.level"),
+		WithConfigReloader(func(*config.ConfigChange) error {
+			return errors.New("boom")
+		}, "server.http.cors"),
+	)
+
+	old := &config.Config{Log: config.LogConfig{Level: "info"}}
+	new := &config.Config{
+		Log:    config.LogConfig{Level: "debug"},
+		Server: config.ServerConfig{HTTP: config.HTTPConfig{Port: 9000, CORS: config.CORSConfig{AllowOrigins: []string{"https://a.com"}}}},
+	}
+	a.applyConfigChange(newChange(old, new))
+
+	if !reflect.DeepEqual(levels, []string{"debug"}) {
+		t.Errorf("levels = %v", levels)
+	}
+	// 处理失败不影响其他键；未被负责的键需要重启
+	if got := a.RestartRequired(); !reflect.DeepEqual(got, []string{"server.http.port"}) {
+		t.Errorf("RestartRequired() = %v", got)
+	}
+
+	// 无关变更不调用处理器
+	a.applyConfigChange(newChange(new, &config.Config{Log: new.Log, Server: new.Server, App: config.AppConfig{Name: "x"}}))
+	if len(levels) != 1 {
+		t.Errorf("无关变更不应调用处理器, levels = %v", levels)
+	}
+	if got := a.RestartRequired(); len(got) != 2 || got[0] != "app.name" {
+		t.Errorf("RestartRequired() = %v", got)
+	}
+}
+
+func TestDatabaseReloader(t *testing.T) {
+	sl := database.NewSlowQueryLogger(log.Default(), &config.DatabaseConfig{})
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "u:p@tcp(127.0.0.1:1)/db", SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: sl, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	keys := databaseReloadKeys(db)
+	if keys[len(keys)-1] != "database.slow_query_threshold" {
+		t.Errorf("keys = %v", keys)
+	}
+
+	cfg := &config.Config{Database: config.DatabaseConfig{MaxOpenConns: 7, SlowQueryThreshold: time.Second}}
+	if err := databaseReloader(db)(newChange(&config.Config{}, cfg)); err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	sqlDB, _ := db.DB()
+	if sqlDB.Stats().MaxOpenConnections != 7 {
+		t.Errorf("MaxOpenConnections = %d, want 7", sqlDB.Stats().MaxOpenConnections)
+	}
+	if sl.Threshold() != time.Second {
+		t.Errorf("Threshold() = %v, want 1s", sl.Threshold())
+	}
+}
+
+func TestApp_CheckConfigReload(t *testing.T) {
+	source, _ := config.NewConsulSource("127.0.0.1:1", "config/test")
+	a := New(WithConfigCenter(config.NewConsulConfigCenter(source)))
+	a.restartKeys = map[string]struct{}{"server.http.port": {}}
+
+	result := a.checkConfigReload(nil)
+	if result.Status != StatusUp || !strings.Contains(result.Message, "restart required: server.http.port") {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestApp_ConfigFileWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("log:\n  level: info\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Load(config.WithConfigFile("config"), config.WithConfigPaths(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	levels := make(chan string, 10)
+	a := New(WithConfigFileWatch(), WithHealthManager(NewHealthManager(nil)), WithConfigReloader(func(change *config.ConfigChange) error {
+		levels <- change.New.Log.Level
+		return nil
+	}, "log.level"))
+	a.startConfigWatch()
+	if a.fileCenter == nil {
+		t.Fatal("配置文件应通过配置中心监听")
+	}
+	defer a.fileCenter.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	// 校验失败的修改出现在就绪检查中
+	if err := os.WriteFile(path, []byte("server:\n  http:\n    port: 70000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for a.fileCenter.ReloadStatus().Rejected == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if result := a.checkConfigReload(nil); result.Status != StatusDegraded || !strings.Contains(result.Message, "reload rejected") {
+		t.Errorf("result = %+v", result)
+	}
+	if ready := a.health.CheckReadiness(context.Background()); !ready.Ready || ready.Status != StatusDegraded {
+		t.Errorf("拒绝重载后应仍就绪但降级, ready = %+v", ready)
+	}
+
+	if err := os.WriteFile(path, []byte("log:\n  level: debug\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case level := <-levels:
+		if level != "debug" {
+			t.Errorf("log.level = %s", level)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("等待配置变更超时")
+	}
+	if status := a.fileCenter.ReloadStatus(); status.Applied != 1 || status.LastError != nil {
+		t.Errorf("status = %+v", status)
+	}
+	if result := a.checkConfigReload(nil); result.Status != StatusUp {
+		t.Errorf("成功应用后应恢复 up, result = %+v", result)
+	}
+}
+
+func TestApp_ApplyConfigChange_Section(t *testing.T) {
+	type serviceConfig struct {
+		Endpoint string `mapstructure:"endpoint"`
+	}
+	if _, err := config.Section[serviceConfig]("appsection"); err != nil {
+		t.Fatalf("Section() error = %v", err)
+	}
+
+	a := New()
+	old := &config.Config{}
+	new := &config.Config{Extra: map[string]any{"appsection": map[string]any{"endpoint": "x"}}}
+	a.applyConfigChange(newChange(old, new))
+
+	// 配置段负责的键可热更新，不需要重启
+	if got := a.RestartRequired(); len(got) != 0 {
+		t.Errorf("RestartRequired() = %v", got)
+	}
+}
@@ -0,0 +1,332 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/server/middleware"
+)
+
+const PluginName = "apikey"
+
+// TokenType API Key 令牌类型
+const TokenType = "ApiKey"
+
+func init() {
+	auth.Register(&Plugin{})
+}
+
+// Plugin API Key 鉴权插件，Key 来自配置文件
+type Plugin struct {
+	config        *Config
+	authenticator *Authenticator
+}
+
+// Config API Key 配置
+type Config struct {
+	// Prefix 明文 Key 前缀
+	Prefix string `json:"prefix"`
+	// Header 请求头名称，供 middleware.HeaderTokenExtractor 使用
+	Header string `json:"header"`
+	// TTL 新建 Key 的有效期，0 表示不过期
+	TTL time.Duration `json:"ttl"`
+	// LastUsedInterval 最后使用时间的最小更新间隔，避免每个请求都写存储
+	LastUsedInterval time.Duration `json:"last_used_interval"`
+	// Keys 配置文件中声明的 Key，只包含哈希（apikey.Hash）
+	Keys []*Key `json:"keys"`
+}
+
+// DefaultConfig 默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		Prefix:           DefaultPrefix,
+		Header:           middleware.DefaultAPIKeyHeader,
+		LastUsedInterval: time.Minute,
+	}
+}
+
+func (p *Plugin) Name() string { return PluginName }
+
+func (p *Plugin) Init(config map[string]interface{}) error {
+	p.config = DefaultConfig()
+
+	if v, ok := config["prefix"].(string); ok && v != "" {
+		p.config.Prefix = v
+	}
+	if v, ok := config["header"].(string); ok && v != "" {
+		p.config.Header = v
+	}
+	if v, ok := config["ttl"].(string); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			p.config.TTL = d
+		}
+	}
+	if v, ok := config["last_used_interval"].(string); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			p.config.LastUsedInterval = d
+		}
+	}
+	if v, ok := config["keys"]; ok {
+		// 配置来自 YAML/JSON，经 JSON 转换为 Key
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("apikey: invalid keys: %w", err)
+		}
+		if err := json.Unmarshal(data, &p.config.Keys); err != nil {
+			return fmt.Errorf("apikey: invalid keys: %w", err)
+		}
+	}
+	for i, k := range p.config.Keys {
+		// 格式不符的 ID 永远无法被 Parse 解析出，配置错误应尽早暴露
+		if !validID(k.ID) {
+			return fmt.Errorf("apikey: keys[%d] %q: id must be %d hex characters", i, k.ID, idBytes*2)
+		}
+		if len(k.Hash) != 64 {
+			return fmt.Errorf("apikey: keys[%d] %q requires a sha256 hash", i, k.ID)
+		}
+	}
+
+	p.authenticator = NewAuthenticator(p.config, WithStore(NewMemoryStore(p.config.Keys...)))
+	return nil
+}
+
+func (p *Plugin) Authenticator() auth.Authenticator {
+	return p.authenticator
+}
+
+// Authenticator API Key 鉴权器
+type Authenticator struct {
+	config *Config
+	store  Store
+	now    func() time.Time
+}
+
+type Option func(*Authenticator)
+
+// WithStore 设置 Key 存储，默认内存存储
+func WithStore(s Store) Option {
+	return func(a *Authenticator) { a.store = s }
+}
+
+// NewAuthenticator 创建 API Key 鉴权器
+func NewAuthenticator(config *Config, opts ...Option) *Authenticator {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	a := &Authenticator{config: config, now: time.Now}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.store == nil {
+		a.store = NewMemoryStore()
+	}
+	return a
+}
+
+// Store Key 存储
+func (a *Authenticator) Store() Store {
+	return a.store
+}
+
+// lookup 按明文查找 Key 并校验哈希
+func (a *Authenticator) lookup(ctx context.Context, plaintext string) (*Key, error) {
+	id, err := Parse(a.config.Prefix, plaintext)
+	if err != nil {
+		return nil, auth.ErrTokenInvalid
+	}
+	key, err := a.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil, auth.ErrTokenInvalid
+		}
+		return nil, err
+	}
+	if !verify(plaintext, key.Hash) {
+		return nil, auth.ErrTokenInvalid
+	}
+	return key, nil
+}
+
+// Authenticate 验证 API Key，成功时按间隔更新最后使用时间
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (*auth.Claims, error) {
+	key, err := a.lookup(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	now := a.now()
+	if key.IsRevoked() {
+		return nil, auth.ErrTokenRevoked
+	}
+	if key.IsExpired(now) {
+		return nil, auth.ErrTokenExpired
+	}
+
+	if now.Sub(key.LastUsedAt) >= a.config.LastUsedInterval {
+		// 最后使用时间仅用于审计，写入失败不影响认证
+		_ = a.store.TouchLastUsed(ctx, key.ID, now)
+	}
+	return key.claims(), nil
+}
+
+// claims 转换为认证信息
+func (k *Key) claims() *auth.Claims {
+	return &auth.Claims{
+		UserID:    k.UserID,
+		Username:  k.Name,
+		Roles:     k.Roles,
+		Scopes:    k.Scopes,
+		Extra:     map[string]interface{}{"key_id": k.ID},
+		IssuedAt:  k.CreatedAt,
+		ExpiresAt: k.ExpiresAt,
+		TokenID:   k.ID,
+	}
+}
+
+// CreateOptions 新建 Key 的参数
+type CreateOptions struct {
+	Name   string
+	UserID string
+	Scopes []string
+	Roles  []string
+	// TTL 有效期，0 时使用配置的 TTL
+	TTL time.Duration
+}
+
+// Create 新建 Key，明文只在此时返回一次
+func (a *Authenticator) Create(ctx context.Context, opts CreateOptions) (string, *Key, error) {
+	if opts.UserID == "" {
+		return "", nil, errors.New("apikey: user id is required")
+	}
+	plaintext, id, hash, err := Generate(a.config.Prefix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := a.now()
+	key := &Key{
+		ID:        id,
+		Name:      opts.Name,
+		Hash:      hash,
+		UserID:    opts.UserID,
+		Scopes:    opts.Scopes,
+		Roles:     opts.Roles,
+		CreatedAt: now,
+	}
+	ttl := opts.TTL
+	if ttl == 0 {
+		ttl = a.config.TTL
+	}
+	if ttl > 0 {
+		key.ExpiresAt = now.Add(ttl)
+	}
+	if err := a.store.Create(ctx, key); err != nil {
+		return "", nil, err
+	}
+	return plaintext, key, nil
+}
+
+// Rotate 为已有 Key 签发替代 Key，权限与有效期时长不变
+//
+// grace 为旧 Key 的剩余可用时间，便于调用方逐步切换；为 0 时旧 Key 立即吊销。
+func (a *Authenticator) Rotate(ctx context.Context, id string, grace time.Duration) (string, *Key, error) {
+	old, err := a.store.Get(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+	if old.IsRevoked() {
+		return "", nil, auth.ErrTokenRevoked
+	}
+
+	opts := CreateOptions{Name: old.Name, UserID: old.UserID, Scopes: old.Scopes, Roles: old.Roles}
+	if !old.ExpiresAt.IsZero() {
+		opts.TTL = old.ExpiresAt.Sub(old.CreatedAt)
+	}
+	plaintext, key, err := a.Create(ctx, opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := a.now()
+	if grace > 0 {
+		if deadline := now.Add(grace); old.ExpiresAt.IsZero() || deadline.Before(old.ExpiresAt) {
+			old.ExpiresAt = deadline
+		}
+	} else {
+		old.RevokedAt = now
+	}
+	if err := a.store.Update(ctx, old); err != nil {
+		return "", nil, err
+	}
+	return plaintext, key, nil
+}
+
+// Revoke 吊销 Key
+func (a *Authenticator) Revoke(ctx context.Context, id string) error {
+	key, err := a.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if key.IsRevoked() {
+		return nil
+	}
+	key.RevokedAt = a.now()
+	return a.store.Update(ctx, key)
+}
+
+// List 列出用户的 Key，userID 为空时列出全部
+func (a *Authenticator) List(ctx context.Context, userID string) ([]*Key, error) {
+	return a.store.List(ctx, userID)
+}
+
+// GenerateToken 为 claims 对应的用户新建 Key，API Key 没有刷新令牌
+func (a *Authenticator) GenerateToken(claims *auth.Claims) (*auth.TokenPair, error) {
+	plaintext, key, err := a.Create(context.Background(), CreateOptions{
+		Name:   claims.Username,
+		UserID: claims.UserID,
+		Scopes: claims.Scopes,
+		Roles:  claims.Roles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &auth.TokenPair{AccessToken: plaintext, TokenType: TokenType, ExpiresAt: key.ExpiresAt}, nil
+}
+
+// RefreshToken 轮换 Key，旧 Key 立即吊销
+func (a *Authenticator) RefreshToken(ctx context.Context, token string) (*auth.TokenPair, error) {
+	key, err := a.lookup(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, newKey, err := a.Rotate(ctx, key.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.TokenPair{AccessToken: plaintext, TokenType: TokenType, ExpiresAt: newKey.ExpiresAt}, nil
+}
+
+// RevokeToken 吊销明文对应的 Key
+func (a *Authenticator) RevokeToken(ctx context.Context, token string) error {
+	key, err := a.lookup(ctx, token)
+	if err != nil {
+		return err
+	}
+	return a.Revoke(ctx, key.ID)
+}
+
+// Validator 转换为 HTTP 中间件的令牌验证器，配合 middleware.APIKeyAuth 或 BearerAuth 使用
+func (a *Authenticator) Validator() middleware.TokenValidator {
+	return middleware.AuthenticatorValidator(a)
+}
+
+// Extractor 按配置的请求头提取 Key，同时接受 Authorization: Bearer
+func (a *Authenticator) Extractor() middleware.TokenExtractor {
+	return middleware.ChainTokenExtractors(
+		middleware.HeaderTokenExtractor(a.config.Header),
+		middleware.BearerTokenExtractor(),
+	)
+}
@@ -0,0 +1,282 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/server/middleware"
+)
+
+// testClock 可手动推进的时钟
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time { return c.t }
+
+func newTestAuthenticator(cfg *Config) (*Authenticator, *testClock) {
+	clock := &testClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	a := NewAuthenticator(cfg)
+	a.now = clock.now
+	return a, clock
+}
+
+func TestAuthenticator_CreateAndAuthenticate(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	ctx := context.Background()
+
+	plaintext, key, err := a.Create(ctx, CreateOptions{
+		Name:   "ci",
+		UserID: "u1",
+		Scopes: []string{"orders:read"},
+		Roles:  []string{"service"},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if key.Hash == plaintext || key.Hash != Hash(plaintext) {
+		t.Error("store should only keep the hash")
+	}
+
+	claims, err := a.Authenticate(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if claims.UserID != "u1" || claims.Username != "ci" || claims.TokenID != key.ID {
+		t.Errorf("claims = %+v", claims)
+	}
+	if !claims.HasScope("orders:read") || !claims.HasRole("service") {
+		t.Errorf("claims scopes/roles = %v/%v", claims.Scopes, claims.Roles)
+	}
+	if claims.Extra["key_id"] != key.ID {
+		t.Errorf("claims extra = %v", claims.Extra)
+	}
+}
+
+func TestAuthenticator_Authenticate_Invalid(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	ctx := context.Background()
+	plaintext, _, _ := a.Create(ctx, CreateOptions{UserID: "u1"})
+
+	// 同一 id 但 secret 不同
+	forged := plaintext[:len(plaintext)-4] + "0000"
+	if forged == plaintext {
+		forged = plaintext[:len(plaintext)-4] + "1111"
+	}
+	unknown, _, _, _ := Generate(DefaultPrefix)
+
+	for name, token := range map[string]string{
+		"wrong secret": forged,
+		"unknown id":   unknown,
+		"malformed":    "not-a-key",
+	} {
+		if _, err := a.Authenticate(ctx, token); err != auth.ErrTokenInvalid {
+			t.Errorf("Authenticate(%s) error = %v, want ErrTokenInvalid", name, err)
+		}
+	}
+}
+
+func TestAuthenticator_Expired(t *testing.T) {
+	a, clock := newTestAuthenticator(nil)
+	ctx := context.Background()
+	plaintext, _, _ := a.Create(ctx, CreateOptions{UserID: "u1", TTL: time.Hour})
+
+	if _, err := a.Authenticate(ctx, plaintext); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	clock.t = clock.t.Add(time.Hour)
+	if _, err := a.Authenticate(ctx, plaintext); err != auth.ErrTokenExpired {
+		t.Errorf("Authenticate(expired) error = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestAuthenticator_LastUsed(t *testing.T) {
+	a, clock := newTestAuthenticator(&Config{Prefix: DefaultPrefix, LastUsedInterval: time.Minute})
+	ctx := context.Background()
+	plaintext, key, _ := a.Create(ctx, CreateOptions{UserID: "u1"})
+
+	first := clock.t
+	_, _ = a.Authenticate(ctx, plaintext)
+	clock.t = first.Add(30 * time.Second)
+	_, _ = a.Authenticate(ctx, plaintext)
+
+	got, _ := a.Store().Get(ctx, key.ID)
+	if !got.LastUsedAt.Equal(first) {
+		t.Errorf("LastUsedAt = %v, want %v (throttled)", got.LastUsedAt, first)
+	}
+
+	clock.t = first.Add(2 * time.Minute)
+	_, _ = a.Authenticate(ctx, plaintext)
+	got, _ = a.Store().Get(ctx, key.ID)
+	if !got.LastUsedAt.Equal(clock.t) {
+		t.Errorf("LastUsedAt = %v, want %v", got.LastUsedAt, clock.t)
+	}
+}
+
+func TestAuthenticator_Rotate(t *testing.T) {
+	a, clock := newTestAuthenticator(nil)
+	ctx := context.Background()
+	oldPlain, oldKey, _ := a.Create(ctx, CreateOptions{Name: "ci", UserID: "u1", Scopes: []string{"read"}, TTL: 24 * time.Hour})
+
+	newPlain, newKey, err := a.Rotate(ctx, oldKey.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if newKey.ID == oldKey.ID || newKey.Name != "ci" || newKey.Scopes[0] != "read" {
+		t.Errorf("new key = %+v", newKey)
+	}
+	if !newKey.ExpiresAt.Equal(clock.t.Add(24 * time.Hour)) {
+		t.Errorf("new key ExpiresAt = %v, want TTL preserved", newKey.ExpiresAt)
+	}
+
+	// 宽限期内新旧 Key 均可用
+	for name, token := range map[string]string{"old": oldPlain, "new": newPlain} {
+		if _, err := a.Authenticate(ctx, token); err != nil {
+			t.Errorf("Authenticate(%s) error = %v", name, err)
+		}
+	}
+	clock.t = clock.t.Add(time.Hour)
+	if _, err := a.Authenticate(ctx, oldPlain); err != auth.ErrTokenExpired {
+		t.Errorf("Authenticate(old after grace) error = %v, want ErrTokenExpired", err)
+	}
+	if _, err := a.Authenticate(ctx, newPlain); err != nil {
+		t.Errorf("Authenticate(new) error = %v", err)
+	}
+}
+
+func TestAuthenticator_RevokeAndRefresh(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	ctx := context.Background()
+
+	pair, err := a.GenerateToken(&auth.Claims{UserID: "u1", Username: "bot", Roles: []string{"service"}})
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if pair.TokenType != TokenType || pair.RefreshToken != "" {
+		t.Errorf("pair = %+v", pair)
+	}
+
+	// 刷新即轮换，旧 Key 立即失效
+	rotated, err := a.RefreshToken(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if _, err := a.Authenticate(ctx, pair.AccessToken); err != auth.ErrTokenRevoked {
+		t.Errorf("Authenticate(rotated) error = %v, want ErrTokenRevoked", err)
+	}
+
+	if err := a.RevokeToken(ctx, rotated.AccessToken); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if _, err := a.Authenticate(ctx, rotated.AccessToken); err != auth.ErrTokenRevoked {
+		t.Errorf("Authenticate(revoked) error = %v, want ErrTokenRevoked", err)
+	}
+
+	keys, _ := a.List(ctx, "u1")
+	if len(keys) != 2 {
+		t.Fatalf("List() = %d keys, want 2", len(keys))
+	}
+	for _, k := range keys {
+		if !k.IsRevoked() {
+			t.Errorf("key %s should be revoked", k.ID)
+		}
+	}
+}
+
+func TestPlugin_Init_ConfigKeys(t *testing.T) {
+	plaintext, id, hash, _ := Generate("svc")
+
+	p := &Plugin{}
+	err := p.Init(map[string]interface{}{
+		"prefix": "svc",
+		"keys": []interface{}{
+			map[string]interface{}{
+				"id":      id,
+				"hash":    hash,
+				"name":    "billing",
+				"user_id": "service:billing",
+				"scopes":  []interface{}{"invoices:write"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	claims, err := p.Authenticator().Authenticate(context.Background(), plaintext)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if claims.UserID != "service:billing" || !claims.HasScope("invoices:write") {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestPlugin_Init_InvalidKeys(t *testing.T) {
+	_, id, hash, _ := Generate("")
+	tests := []struct {
+		name string
+		key  map[string]interface{}
+		want string
+	}{
+		{"plaintext hash", map[string]interface{}{"id": id, "hash": "plaintext"}, id},
+		{"missing id", map[string]interface{}{"hash": hash}, `""`},
+		{"short id", map[string]interface{}{"id": "abc", "hash": hash}, `"abc"`},
+		{"non-hex id", map[string]interface{}{"id": "billing-service1", "hash": hash}, `"billing-service1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{}
+			err := p.Init(map[string]interface{}{"keys": []interface{}{tt.key}})
+			if err == nil {
+				t.Fatal("Init() should reject the key")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Init() error = %v, want it to name %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a, _ := newTestAuthenticator(nil)
+	plaintext, _, _ := a.Create(context.Background(), CreateOptions{UserID: "u1", Scopes: []string{"read"}})
+
+	router := gin.New()
+	router.GET("/header", middleware.APIKeyAuth(a.Validator()), middleware.RequireScopes("read"), func(c *gin.Context) {
+		info, _ := middleware.GetAuthInfo(c)
+		c.String(http.StatusOK, info.UserID)
+	})
+	router.GET("/bearer", middleware.BearerAuth(a.Validator()), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"X-API-Key", "/header", middleware.DefaultAPIKeyHeader, plaintext, http.StatusOK},
+		{"Bearer fallback", "/header", "Authorization", "Bearer " + plaintext, http.StatusOK},
+		{"BearerAuth", "/bearer", "Authorization", "Bearer " + plaintext, http.StatusOK},
+		{"invalid", "/header", middleware.DefaultAPIKeyHeader, "gk_invalid", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			req.Header.Set(tt.header, tt.value)
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
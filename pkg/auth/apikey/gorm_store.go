@@ -0,0 +1,159 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/goupter/goupter/pkg/model"
+	"gorm.io/gorm"
+)
+
+// Record API Key 数据库模型
+//
+//	CREATE TABLE `api_keys` (
+//	  `id` varchar(32) NOT NULL,
+//	  `name` varchar(128) NOT NULL DEFAULT '',
+//	  `hash` char(64) NOT NULL,
+//	  `user_id` varchar(64) NOT NULL,
+//	  `scopes` varchar(1024) NOT NULL DEFAULT '',
+//	  `roles` varchar(1024) NOT NULL DEFAULT '',
+//	  `expires_at` datetime DEFAULT NULL,
+//	  `revoked_at` datetime DEFAULT NULL,
+//	  `last_used_at` datetime DEFAULT NULL,
+//	  `created_at` datetime NOT NULL,
+//	  PRIMARY KEY (`id`),
+//	  KEY `idx_api_keys_user_id` (`user_id`)
+//	);
+type Record struct {
+	ID         string     `gorm:"column:id;primaryKey;size:32" json:"id"`
+	Name       string     `gorm:"column:name;size:128" json:"name"`
+	Hash       string     `gorm:"column:hash;size:64" json:"-"`
+	UserID     string     `gorm:"column:user_id;size:64;index" json:"user_id"`
+	Scopes     string     `gorm:"column:scopes;size:1024" json:"scopes"`
+	Roles      string     `gorm:"column:roles;size:1024" json:"roles"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName 表名
+func (Record) TableName() string {
+	return "api_keys"
+}
+
+// GormStore 数据库存储
+type GormStore struct {
+	*model.BaseModel[Record]
+}
+
+// NewGormStore 创建数据库存储
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{BaseModel: model.NewBaseModel[Record](db)}
+}
+
+// Get 实现 Store
+func (s *GormStore) Get(ctx context.Context, id string) (*Key, error) {
+	r, err := s.FindOne(ctx, map[string]any{"id": id})
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return r.toKey(), nil
+}
+
+// Create 实现 Store
+func (s *GormStore) Create(ctx context.Context, key *Key) error {
+	return s.Insert(ctx, nil, toRecord(key))
+}
+
+// Update 实现 Store
+func (s *GormStore) Update(ctx context.Context, key *Key) error {
+	r := toRecord(key)
+	return s.UpdateColumns(ctx, nil, map[string]any{"id": key.ID}, map[string]any{
+		"name":       r.Name,
+		"scopes":     r.Scopes,
+		"roles":      r.Roles,
+		"expires_at": r.ExpiresAt,
+		"revoked_at": r.RevokedAt,
+	})
+}
+
+// TouchLastUsed 实现 Store
+func (s *GormStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	return s.UpdateColumns(ctx, nil, map[string]any{"id": id}, map[string]any{"last_used_at": at})
+}
+
+// List 实现 Store
+func (s *GormStore) List(ctx context.Context, userID string) ([]*Key, error) {
+	var records []*Record
+	var err error
+	if userID == "" {
+		records, err = s.FindAll(ctx, "created_at", "")
+	} else {
+		records, err = s.FindAll(ctx, "created_at", "user_id = ?", userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*Key, 0, len(records))
+	for _, r := range records {
+		keys = append(keys, r.toKey())
+	}
+	return keys, nil
+}
+
+func (r *Record) toKey() *Key {
+	return &Key{
+		ID:         r.ID,
+		Name:       r.Name,
+		Hash:       r.Hash,
+		UserID:     r.UserID,
+		Scopes:     splitList(r.Scopes),
+		Roles:      splitList(r.Roles),
+		ExpiresAt:  derefTime(r.ExpiresAt),
+		RevokedAt:  derefTime(r.RevokedAt),
+		LastUsedAt: derefTime(r.LastUsedAt),
+		CreatedAt:  r.CreatedAt,
+	}
+}
+
+func toRecord(k *Key) *Record {
+	return &Record{
+		ID:         k.ID,
+		Name:       k.Name,
+		Hash:       k.Hash,
+		UserID:     k.UserID,
+		Scopes:     strings.Join(k.Scopes, ","),
+		Roles:      strings.Join(k.Roles, ","),
+		ExpiresAt:  timePtr(k.ExpiresAt),
+		RevokedAt:  timePtr(k.RevokedAt),
+		LastUsedAt: timePtr(k.LastUsedAt),
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
@@ -0,0 +1,104 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// DefaultPrefix 默认 Key 前缀
+const DefaultPrefix = "gk"
+
+const (
+	idBytes     = 8
+	secretBytes = 32
+)
+
+// 错误定义
+var (
+	ErrKeyNotFound   = errors.New("apikey: key not found")
+	ErrInvalidFormat = errors.New("apikey: invalid key format")
+)
+
+// Key API Key 元数据，只保存明文的哈希
+//
+// 明文格式为 <prefix>_<id>_<secret>，id 为公开标识，用于日志、管理与查找，
+// 泄露后可按前缀扫描代码仓库定位。
+type Key struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Hash       string    `json:"hash"`
+	UserID     string    `json:"user_id"`
+	Scopes     []string  `json:"scopes,omitempty"`
+	Roles      []string  `json:"roles,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
+	RevokedAt  time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// IsRevoked 是否已吊销
+func (k *Key) IsRevoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// IsExpired 在 now 时是否已过期
+func (k *Key) IsExpired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// Generate 生成新的明文 Key 及其 id 与哈希
+func Generate(prefix string) (plaintext, id, hash string, err error) {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	buf := make([]byte, idBytes+secretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	id = hex.EncodeToString(buf[:idBytes])
+	plaintext = prefix + "_" + id + "_" + hex.EncodeToString(buf[idBytes:])
+	return plaintext, id, Hash(plaintext), nil
+}
+
+// Parse 解析明文 Key，返回其 id
+func Parse(prefix, plaintext string) (string, error) {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	rest, ok := strings.CutPrefix(plaintext, prefix+"_")
+	if !ok {
+		return "", ErrInvalidFormat
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || !validID(id) || len(secret) != secretBytes*2 {
+		return "", ErrInvalidFormat
+	}
+	return id, nil
+}
+
+// validID Key ID 是否为 Generate 生成的格式（定长 hex）
+func validID(id string) bool {
+	if len(id) != idBytes*2 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// Hash 明文 Key 的哈希（SHA-256，hex）
+//
+// Key 为 256 位随机数，无需加盐或慢哈希。
+func Hash(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// verify 常量时间比较明文与哈希
+func verify(plaintext, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(plaintext)), []byte(strings.ToLower(hash))) == 1
+}
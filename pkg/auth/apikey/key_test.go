@@ -0,0 +1,79 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateAndParse(t *testing.T) {
+	plaintext, id, hash, err := Generate("svc")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !strings.HasPrefix(plaintext, "svc_"+id+"_") {
+		t.Errorf("plaintext = %s, want prefix svc_%s_", plaintext, id)
+	}
+	if hash != Hash(plaintext) || !verify(plaintext, hash) {
+		t.Error("hash should match plaintext")
+	}
+
+	got, err := Parse("svc", plaintext)
+	if err != nil || got != id {
+		t.Errorf("Parse() = %s, %v, want %s", got, err, id)
+	}
+}
+
+func TestParse_InvalidFormat(t *testing.T) {
+	plaintext, _, _, _ := Generate("")
+	tests := map[string]string{
+		"wrong prefix":  "xx" + strings.TrimPrefix(plaintext, DefaultPrefix),
+		"missing part":  plaintext[:len(DefaultPrefix)+1+16],
+		"short secret":  plaintext[:len(plaintext)-1],
+		"non hex id":    DefaultPrefix + "_zzzzzzzzzzzzzzzz_" + strings.Repeat("0", 64),
+		"jwt like":      "eyJhbGciOiJIUzI1NiJ9.e30.sig",
+		"empty string":  "",
+		"prefix only":   DefaultPrefix + "_",
+		"extra segment": plaintext + "_x",
+	}
+	for name, s := range tests {
+		if _, err := Parse(DefaultPrefix, s); err != ErrInvalidFormat {
+			t.Errorf("Parse(%s) error = %v, want ErrInvalidFormat", name, err)
+		}
+	}
+}
+
+func TestKey_IsExpired(t *testing.T) {
+	now := time.Now()
+	k := &Key{}
+	if k.IsExpired(now) {
+		t.Error("key without ExpiresAt should not expire")
+	}
+	k.ExpiresAt = now
+	if !k.IsExpired(now) {
+		t.Error("key should be expired at ExpiresAt")
+	}
+}
+
+func TestRecord_RoundTrip(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	key := &Key{
+		ID:        "0123456789abcdef",
+		Name:      "ci",
+		Hash:      Hash("x"),
+		UserID:    "u1",
+		Scopes:    []string{"read", "write"},
+		Roles:     []string{"service"},
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+	}
+	r := toRecord(key)
+	if r.Scopes != "read,write" || r.RevokedAt != nil || r.LastUsedAt != nil {
+		t.Errorf("record = %+v", r)
+	}
+	got := r.toKey()
+	if got.ID != key.ID || len(got.Scopes) != 2 || got.Roles[0] != "service" ||
+		!got.ExpiresAt.Equal(key.ExpiresAt) || !got.RevokedAt.IsZero() {
+		t.Errorf("key = %+v", got)
+	}
+}
@@ -0,0 +1,101 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store API Key 存储
+type Store interface {
+	// Get 按 id 获取，不存在时返回 ErrKeyNotFound
+	Get(ctx context.Context, id string) (*Key, error)
+	// Create 保存新 Key
+	Create(ctx context.Context, key *Key) error
+	// Update 更新 Key（吊销、轮换时调整过期时间）
+	Update(ctx context.Context, key *Key) error
+	// TouchLastUsed 更新最后使用时间
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+	// List 列出用户的 Key，userID 为空时列出全部
+	List(ctx context.Context, userID string) ([]*Key, error)
+}
+
+// MemoryStore 内存存储，用于配置文件中声明的 Key 与测试
+type MemoryStore struct {
+	mu   sync.RWMutex
+	keys map[string]*Key
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore(keys ...*Key) *MemoryStore {
+	s := &MemoryStore{keys: make(map[string]*Key, len(keys))}
+	for _, k := range keys {
+		s.keys[k.ID] = k
+	}
+	return s
+}
+
+// Get 实现 Store
+func (s *MemoryStore) Get(_ context.Context, id string) (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	cp := *k
+	return &cp, nil
+}
+
+// Create 实现 Store
+func (s *MemoryStore) Create(_ context.Context, key *Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[key.ID]; ok {
+		return fmt.Errorf("apikey: duplicate key id %s", key.ID)
+	}
+	cp := *key
+	s.keys[key.ID] = &cp
+	return nil
+}
+
+// Update 实现 Store
+func (s *MemoryStore) Update(_ context.Context, key *Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[key.ID]; !ok {
+		return ErrKeyNotFound
+	}
+	cp := *key
+	s.keys[key.ID] = &cp
+	return nil
+}
+
+// TouchLastUsed 实现 Store
+func (s *MemoryStore) TouchLastUsed(_ context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	k.LastUsedAt = at
+	return nil
+}
+
+// List 实现 Store，按创建时间排序
+func (s *MemoryStore) List(_ context.Context, userID string) ([]*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []*Key
+	for _, k := range s.keys {
+		if userID == "" || k.UserID == userID {
+			cp := *k
+			keys = append(keys, &cp)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
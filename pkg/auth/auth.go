@@ -9,10 +9,10 @@ import (
 type Authenticator interface {
 	// Authenticate 验证令牌
 	Authenticate(ctx context.Context, token string) (*Claims, error)
-	// GenerateToken 生成令牌
-	GenerateToken(claims *Claims) (string, error)
-	// RefreshToken 刷新令牌
-	RefreshToken(token string) (string, error)
+	// GenerateToken 生成访问令牌与刷新令牌
+	GenerateToken(claims *Claims) (*TokenPair, error)
+	// RefreshToken 使用刷新令牌换取新的令牌对
+	RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error)
 	// RevokeToken 撤销令牌
 	RevokeToken(ctx context.Context, token string) error
 }
@@ -22,9 +22,39 @@ type Claims struct {
 	UserID    string                 `json:"user_id"`
 	Username  string                 `json:"username"`
 	Roles     []string               `json:"roles"`
+	Scopes    []string               `json:"scopes,omitempty"`
 	Extra     map[string]interface{} `json:"extra"`
 	IssuedAt  time.Time              `json:"issued_at"`
 	ExpiresAt time.Time              `json:"expires_at"`
+	// TokenID 令牌 ID（jti）
+	TokenID string `json:"token_id,omitempty"`
+	// FamilyID 令牌族 ID，同一次登录及其后续刷新签发的令牌属于同一族
+	FamilyID string `json:"family_id,omitempty"`
+	// AMR 本次登录使用的认证方式（RFC 8176），如 pwd、otp、hwk
+	AMR []string `json:"amr,omitempty"`
+	// ACR 认证上下文等级，取值由部署约定
+	ACR string `json:"acr,omitempty"`
+	// AuthTime 最近一次完成认证（含二次验证）的时间
+	AuthTime time.Time `json:"auth_time,omitempty"`
+}
+
+// TokenPair 访问令牌与刷新令牌
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token,omitempty"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at,omitempty"`
+}
+
+// HasScope 检查是否拥有指定权限范围
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 // IsExpired 检查令牌是否过期
@@ -42,6 +72,36 @@ func (c *Claims) HasRole(role string) bool {
 	return false
 }
 
+// tokenContextKey 原始令牌的上下文键
+type tokenContextKey struct{}
+
+// ContextWithToken 将原始令牌写入上下文，供下游调用透传
+func ContextWithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenContextKey{}, token)
+}
+
+// TokenFromContext 从上下文获取原始令牌
+func TokenFromContext(ctx context.Context) string {
+	if token, ok := ctx.Value(tokenContextKey{}).(string); ok {
+		return token
+	}
+	return ""
+}
+
+// claimsContextKey 认证信息的上下文键
+type claimsContextKey struct{}
+
+// ContextWithClaims 将认证信息写入上下文
+func ContextWithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext 从上下文获取认证信息
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
+
 // AuthError 鉴权错误
 type AuthError struct {
 	Code    int    `json:"code"`
@@ -57,4 +117,7 @@ var (
 	ErrTokenInvalid = &AuthError{Code: 10001, Message: "token invalid"}
 	ErrTokenExpired = &AuthError{Code: 10002, Message: "token expired"}
 	ErrTokenRevoked = &AuthError{Code: 10003, Message: "token revoked"}
+	ErrTokenReused  = &AuthError{Code: 10004, Message: "refresh token reused"}
+	// ErrInsufficientAuth 认证强度不足，需要完成二次验证（step-up）
+	ErrInsufficientAuth = &AuthError{Code: 10005, Message: "insufficient user authentication"}
 )
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -36,6 +37,28 @@ func TestClaims_HasRole(t *testing.T) {
 	}
 }
 
+func TestClaims_HasScope(t *testing.T) {
+	claims := &Claims{Scopes: []string{"orders:read"}}
+
+	if !claims.HasScope("orders:read") {
+		t.Error("HasScope(orders:read) should return true")
+	}
+	if claims.HasScope("orders:write") {
+		t.Error("HasScope(orders:write) should return false")
+	}
+}
+
+func TestClaimsFromContext(t *testing.T) {
+	if _, ok := ClaimsFromContext(context.Background()); ok {
+		t.Error("ClaimsFromContext(empty) should return false")
+	}
+	ctx := ContextWithClaims(context.Background(), &Claims{UserID: "u1"})
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok || claims.UserID != "u1" {
+		t.Errorf("ClaimsFromContext() = %+v, %v", claims, ok)
+	}
+}
+
 func TestAuthError(t *testing.T) {
 	err := &AuthError{Code: 10001, Message: "test error"}
 	if err.Error() != "test error" {
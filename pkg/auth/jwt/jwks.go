@@ -0,0 +1,193 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWKSPath JWKS 的标准发布路径
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWKSHandler 发布密钥集合的公钥，挂载到 JWKSPath
+//
+// Gin 中使用 r.GET(jwt.JWKSPath, gin.WrapH(jwt.JWKSHandler(ks, 5*time.Minute)))。
+// maxAge 为响应的缓存时间，轮换时新密钥需提前至少 maxAge 添加到集合中。
+func JWKSHandler(ks *KeySet, maxAge time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/jwk-set+json")
+		if maxAge > 0 {
+			w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+		}
+		_ = json.NewEncoder(w).Encode(ks.JWKS())
+	})
+}
+
+// RemoteKeySet 从远程 JWKS 获取验签密钥并缓存
+//
+// 缓存过期后在下次验签时刷新；遇到未知 kid 时立即刷新（两次刷新间隔不小于 minRefreshInterval），
+// 以便签发方轮换密钥后无需等待缓存过期。刷新失败时继续使用已缓存的密钥。
+type RemoteKeySet struct {
+	url                string
+	client             *http.Client
+	cacheTTL           time.Duration
+	minRefreshInterval time.Duration
+	now                func() time.Time
+
+	mu          sync.RWMutex
+	keys        map[string]*Key
+	expiresAt   time.Time
+	lastRefresh time.Time
+
+	// refreshMu 保证同一时间只有一个刷新请求
+	refreshMu sync.Mutex
+}
+
+// RemoteOption RemoteKeySet 选项
+type RemoteOption func(*RemoteKeySet)
+
+// WithHTTPClient 设置 HTTP 客户端
+func WithHTTPClient(c *http.Client) RemoteOption {
+	return func(r *RemoteKeySet) { r.client = c }
+}
+
+// WithCacheTTL 设置缓存时间，响应带 Cache-Control max-age 时以响应为准
+func WithCacheTTL(d time.Duration) RemoteOption {
+	return func(r *RemoteKeySet) { r.cacheTTL = d }
+}
+
+// WithMinRefreshInterval 设置未知 kid 触发刷新的最小间隔，防止伪造 kid 造成请求放大
+func WithMinRefreshInterval(d time.Duration) RemoteOption {
+	return func(r *RemoteKeySet) { r.minRefreshInterval = d }
+}
+
+// NewRemoteKeySet 创建远程密钥集合，首次验签时获取
+func NewRemoteKeySet(url string, opts ...RemoteOption) *RemoteKeySet {
+	r := &RemoteKeySet{
+		url:                url,
+		client:             &http.Client{Timeout: 10 * time.Second},
+		cacheTTL:           10 * time.Minute,
+		minRefreshInterval: 30 * time.Second,
+		now:                time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// ResolveKey 实现 KeyResolver
+func (r *RemoteKeySet) ResolveKey(ctx context.Context, kid string) (*Key, error) {
+	k, fresh, ok := r.lookup(kid)
+	if ok && fresh {
+		return k, nil
+	}
+
+	// 缓存过期或 kid 未知时刷新
+	if err := r.refresh(ctx, !ok); err != nil && !ok {
+		return nil, err
+	}
+	if k, _, ok := r.lookup(kid); ok {
+		return k, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+// Refresh 立即从远程获取密钥
+func (r *RemoteKeySet) Refresh(ctx context.Context) error {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	return r.fetch(ctx)
+}
+
+// lookup 查找缓存的密钥，fresh 表示缓存未过期
+func (r *RemoteKeySet) lookup(kid string) (k *Key, fresh, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	k, ok = r.keys[kid]
+	return k, r.now().Before(r.expiresAt), ok
+}
+
+// refresh 按需刷新，unknownKID 时受最小刷新间隔限制
+func (r *RemoteKeySet) refresh(ctx context.Context, unknownKID bool) error {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	// 等待锁期间其他请求可能已经刷新
+	r.mu.RLock()
+	fresh := r.now().Before(r.expiresAt)
+	recent := r.now().Sub(r.lastRefresh) < r.minRefreshInterval
+	r.mu.RUnlock()
+	// 最近刚刷新过（包括失败）时不再请求，避免签发方不可用或伪造 kid 时反复请求
+	if recent || (fresh && !unknownKID) {
+		return nil
+	}
+	return r.fetch(ctx)
+}
+
+// fetch 获取并替换缓存的密钥，调用方持有 refreshMu
+func (r *RemoteKeySet) fetch(ctx context.Context) error {
+	r.mu.Lock()
+	r.lastRefresh = r.now()
+	r.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("jwt: fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwt: fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("jwt: fetch jwks: %w", err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	ttl := r.cacheTTL
+	if maxAge, ok := cacheMaxAge(resp.Header.Get("Cache-Control")); ok {
+		ttl = maxAge
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.expiresAt = r.now().Add(ttl)
+	r.mu.Unlock()
+	return nil
+}
+
+// cacheMaxAge 解析 Cache-Control 中的 max-age
+func cacheMaxAge(header string) (time.Duration, bool) {
+	for _, directive := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
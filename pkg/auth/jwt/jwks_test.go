@@ -0,0 +1,249 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/auth"
+)
+
+// jwksServer 发布密钥集合的本地 JWKS 服务，记录请求次数
+func jwksServer(t *testing.T, ks *KeySet, maxAge time.Duration) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	handler := JWKSHandler(ks, maxAge)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestJWKSHandler(t *testing.T) {
+	k1, _ := GenerateKey(AlgRS256)
+	k2, _ := GenerateKey(AlgEdDSA)
+	ks, _ := NewKeySet(k1)
+	_ = ks.Add(k2)
+
+	rec := httptest.NewRecorder()
+	JWKSHandler(ks, 5*time.Minute).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, JWKSPath, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=300" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+	var set JWKS
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 2 || set.Keys[0].Kid != k1.ID {
+		t.Errorf("keys = %+v, want signing key %s first", set.Keys, k1.ID)
+	}
+
+	rec = httptest.NewRecorder()
+	JWKSHandler(ks, 0).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, JWKSPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+}
+
+func TestRemoteKeySet_VerifyAndCache(t *testing.T) {
+	key, _ := GenerateKey(AlgES256)
+	ks, _ := NewKeySet(key)
+	issuer := NewAuthenticator(DefaultConfig(), WithKeySet(ks))
+	srv, hits := jwksServer(t, ks, 0)
+
+	verifier := NewAuthenticator(DefaultConfig(), WithKeyResolver(NewRemoteKeySet(srv.URL, WithCacheTTL(time.Hour))))
+	ctx := context.Background()
+	token := accessToken(t, issuer, &auth.Claims{UserID: "u1"})
+
+	for i := 0; i < 3; i++ {
+		claims, err := verifier.Authenticate(ctx, token)
+		if err != nil {
+			t.Fatalf("Authenticate() error = %v", err)
+		}
+		if claims.UserID != "u1" {
+			t.Errorf("UserID = %s", claims.UserID)
+		}
+	}
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Errorf("jwks fetched %d times, want 1", n)
+	}
+
+	if _, err := verifier.GenerateToken(&auth.Claims{UserID: "u1"}); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("verifier GenerateToken() error = %v, want ErrNoSigningKey", err)
+	}
+}
+
+func TestRemoteKeySet_RefreshOnUnknownKID(t *testing.T) {
+	oldKey, _ := GenerateKey(AlgRS256)
+	ks, _ := NewKeySet(oldKey)
+	issuer := NewAuthenticator(DefaultConfig(), WithKeySet(ks))
+	srv, hits := jwksServer(t, ks, 0)
+
+	remote := NewRemoteKeySet(srv.URL, WithCacheTTL(time.Hour), WithMinRefreshInterval(time.Minute))
+	now := time.Now()
+	remote.now = func() time.Time { return now }
+	verifier := NewAuthenticator(DefaultConfig(), WithKeyResolver(remote))
+	ctx := context.Background()
+
+	oldToken := accessToken(t, issuer, &auth.Claims{UserID: "u1"})
+	if _, err := verifier.Authenticate(ctx, oldToken); err != nil {
+		t.Fatalf("Authenticate(old) error = %v", err)
+	}
+
+	newKey, _ := GenerateKey(AlgEdDSA)
+	_ = ks.Rotate(newKey)
+	newToken := accessToken(t, issuer, &auth.Claims{UserID: "u1"})
+
+	// 刷新间隔内不因未知 kid 重新获取
+	if _, err := verifier.Authenticate(ctx, newToken); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(new, within interval) error = %v, want ErrTokenInvalid", err)
+	}
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Errorf("jwks fetched %d times, want 1", n)
+	}
+
+	now = now.Add(2 * time.Minute)
+	for name, token := range map[string]string{"new": newToken, "old": oldToken} {
+		if _, err := verifier.Authenticate(ctx, token); err != nil {
+			t.Errorf("Authenticate(%s) error = %v", name, err)
+		}
+	}
+	if n := atomic.LoadInt32(hits); n != 2 {
+		t.Errorf("jwks fetched %d times, want 2", n)
+	}
+}
+
+func TestRemoteKeySet_ExpiryUsesMaxAge(t *testing.T) {
+	key, _ := GenerateKey(AlgEdDSA)
+	ks, _ := NewKeySet(key)
+	srv, hits := jwksServer(t, ks, time.Minute)
+
+	remote := NewRemoteKeySet(srv.URL, WithCacheTTL(time.Hour))
+	now := time.Now()
+	remote.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := remote.ResolveKey(ctx, key.ID); err != nil {
+		t.Fatalf("ResolveKey() error = %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	_, _ = remote.ResolveKey(ctx, key.ID)
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Errorf("jwks fetched %d times before max-age, want 1", n)
+	}
+	now = now.Add(time.Minute)
+	_, _ = remote.ResolveKey(ctx, key.ID)
+	if n := atomic.LoadInt32(hits); n != 2 {
+		t.Errorf("jwks fetched %d times after max-age, want 2", n)
+	}
+}
+
+func TestRemoteKeySet_StaleOnError(t *testing.T) {
+	key, _ := GenerateKey(AlgES256)
+	ks, _ := NewKeySet(key)
+	var fail atomic.Bool
+	handler := JWKSHandler(ks, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	remote := NewRemoteKeySet(srv.URL, WithCacheTTL(time.Minute))
+	now := time.Now()
+	remote.now = func() time.Time { return now }
+	ctx := context.Background()
+	if _, err := remote.ResolveKey(ctx, key.ID); err != nil {
+		t.Fatalf("ResolveKey() error = %v", err)
+	}
+
+	fail.Store(true)
+	now = now.Add(2 * time.Minute)
+	if _, err := remote.ResolveKey(ctx, key.ID); err != nil {
+		t.Errorf("ResolveKey(stale) error = %v, want cached key", err)
+	}
+	if err := remote.Refresh(ctx); err == nil {
+		t.Error("Refresh() should report fetch error")
+	}
+	if _, err := remote.ResolveKey(ctx, "unknown"); err == nil {
+		t.Error("ResolveKey(unknown) should fail")
+	}
+}
+
+func TestAuthenticator_JWKSUnavailable(t *testing.T) {
+	key, _ := GenerateKey(AlgES256)
+	ks, _ := NewKeySet(key)
+	issuer := NewAuthenticator(DefaultConfig(), WithKeySet(ks))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	verifier := NewAuthenticator(DefaultConfig(), WithKeyResolver(NewRemoteKeySet(srv.URL)))
+	token := accessToken(t, issuer, &auth.Claims{UserID: "u1"})
+
+	// 获取 JWKS 失败不是令牌无效，原样返回以便映射为 503
+	_, err := verifier.Authenticate(context.Background(), token)
+	if err == nil || err == auth.ErrTokenInvalid {
+		t.Fatalf("Authenticate() error = %v, want fetch error", err)
+	}
+	var authErr *auth.AuthError
+	if errors.As(err, &authErr) {
+		t.Errorf("Authenticate() error = %v, should not be AuthError", err)
+	}
+}
+
+func TestRemoteKeySet_ConcurrentFetchOnce(t *testing.T) {
+	key, _ := GenerateKey(AlgEdDSA)
+	ks, _ := NewKeySet(key)
+	srv, hits := jwksServer(t, ks, 0)
+	remote := NewRemoteKeySet(srv.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := remote.ResolveKey(context.Background(), key.ID); err != nil {
+				t.Errorf("ResolveKey() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Errorf("jwks fetched %d times, want 1", n)
+	}
+}
+
+func TestPlugin_Init_JWKSURL(t *testing.T) {
+	key, _ := GenerateKey(AlgRS256)
+	ks, _ := NewKeySet(key)
+	srv, _ := jwksServer(t, ks, 0)
+	token := accessToken(t, NewAuthenticator(DefaultConfig(), WithKeySet(ks)), &auth.Claims{UserID: "u1"})
+
+	p := &Plugin{}
+	if err := p.Init(map[string]interface{}{"algorithm": "RS256", "jwks_url": srv.URL}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if p.KeySet() != nil {
+		t.Error("verify-only plugin should have no key set")
+	}
+	if _, err := p.Authenticator().Authenticate(context.Background(), token); err != nil {
+		t.Errorf("Authenticate() error = %v", err)
+	}
+}
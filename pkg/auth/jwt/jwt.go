@@ -2,16 +2,22 @@ package jwt
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/goupter/goupter/pkg/auth"
 	"github.com/goupter/goupter/pkg/cache"
 )
 
 const PluginName = "jwt"
 
+// ErrNoTokenStore 开启了刷新令牌复用检测或黑名单，但未设置令牌状态缓存
+var ErrNoTokenStore = errors.New("jwt: token state cache required for refresh reuse detection and blacklist")
+
 // defaultSecretKey is the insecure default key that must be changed in production
 const defaultSecretKey = "change-me-in-production"
 
@@ -26,27 +32,48 @@ type Plugin struct {
 }
 
 // Config JWT配置
+//
+// Algorithm 为 HS256 时使用 SecretKey 签名与验签；为 RS256、ES256 或 EdDSA 时使用
+// PrivateKey/PrivateKeyFile 中的 PEM 私钥签名，或配置 JWKSURL 只从远程 JWKS 获取公钥验签。
+// Issuer 非空时写入签发的令牌，并要求验证的令牌 iss 与之一致。
+//
+// RefreshReuseDetection 开启时刷新令牌只能使用一次，复用时撤销整个令牌族，需要令牌状态缓存；
+// 未设置缓存时 RefreshToken 返回 ErrNoTokenStore。
 type Config struct {
-	SecretKey        string        `json:"secret_key"`
-	Issuer           string        `json:"issuer"`
-	AccessTokenTTL   time.Duration `json:"access_token_ttl"`
-	RefreshTokenTTL  time.Duration `json:"refresh_token_ttl"`
-	BlacklistEnabled bool          `json:"blacklist_enabled"`
+	SecretKey             string        `json:"secret_key"`
+	Issuer                string        `json:"issuer"`
+	AccessTokenTTL        time.Duration `json:"access_token_ttl"`
+	RefreshTokenTTL       time.Duration `json:"refresh_token_ttl"`
+	BlacklistEnabled      bool          `json:"blacklist_enabled"`
+	RefreshReuseDetection bool          `json:"refresh_reuse_detection"`
+	Algorithm             string        `json:"algorithm"`
+	KeyID                 string        `json:"key_id"`
+	PrivateKey            string        `json:"private_key"`
+	PrivateKeyFile        string        `json:"private_key_file"`
+	JWKSURL               string        `json:"jwks_url"`
+	JWKSCacheTTL          time.Duration `json:"jwks_cache_ttl"`
 }
 
 // DefaultConfig 默认配置
 func DefaultConfig() *Config {
 	return &Config{
-		SecretKey:        defaultSecretKey,
-		Issuer:           "goupter",
-		AccessTokenTTL:   2 * time.Hour,
-		RefreshTokenTTL:  7 * 24 * time.Hour,
-		BlacklistEnabled: false,
+		SecretKey:             defaultSecretKey,
+		Issuer:                "goupter",
+		AccessTokenTTL:        2 * time.Hour,
+		RefreshTokenTTL:       7 * 24 * time.Hour,
+		BlacklistEnabled:      false,
+		RefreshReuseDetection: true,
+		Algorithm:             AlgHS256,
+		JWKSCacheTTL:          10 * time.Minute,
 	}
 }
 
 func (p *Plugin) Name() string { return PluginName }
 
+// Init 初始化插件
+//
+// 令牌状态缓存通过 config["cache"] 注入 cache.Cache（app.Builder 会注入 InitCache 创建的缓存），
+// 多实例部署时应使用 Redis 等共享缓存。
 func (p *Plugin) Init(config map[string]interface{}) error {
 	p.config = DefaultConfig()
 
@@ -69,62 +96,222 @@ func (p *Plugin) Init(config map[string]interface{}) error {
 	if v, ok := config["blacklist_enabled"].(bool); ok {
 		p.config.BlacklistEnabled = v
 	}
+	if v, ok := config["refresh_reuse_detection"].(bool); ok {
+		p.config.RefreshReuseDetection = v
+	}
+	for key, dst := range map[string]*string{
+		"algorithm":        &p.config.Algorithm,
+		"key_id":           &p.config.KeyID,
+		"private_key":      &p.config.PrivateKey,
+		"private_key_file": &p.config.PrivateKeyFile,
+		"jwks_url":         &p.config.JWKSURL,
+	} {
+		if v, ok := config[key].(string); ok {
+			*dst = v
+		}
+	}
+	if v, ok := config["jwks_cache_ttl"].(string); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			p.config.JWKSCacheTTL = d
+		}
+	}
 
-	// Validate secret key - reject default insecure key
-	if p.config.SecretKey == defaultSecretKey {
-		return fmt.Errorf("jwt: secret_key must be configured, using default key is not allowed")
+	opts, err := keyOptions(p.config)
+	if err != nil {
+		return err
 	}
-	if len(p.config.SecretKey) < 32 {
-		return fmt.Errorf("jwt: secret_key must be at least 32 characters")
+
+	store, _ := config["cache"].(cache.Cache)
+	if store != nil {
+		opts = append(opts, WithBlacklist(store))
+	} else if p.config.BlacklistEnabled || (p.config.RefreshReuseDetection && canSign(p.config)) {
+		return fmt.Errorf("%w: inject cache.Cache as config[\"cache\"] or set refresh_reuse_detection: false", ErrNoTokenStore)
 	}
 
-	p.authenticator = NewAuthenticator(p.config)
+	p.authenticator = NewAuthenticator(p.config, opts...)
 	return nil
 }
 
+// canSign 配置是否可以签发令牌，仅验签的服务不处理刷新令牌
+func canSign(cfg *Config) bool {
+	return cfg.Algorithm == "" || cfg.Algorithm == AlgHS256 || cfg.PrivateKey != "" || cfg.PrivateKeyFile != ""
+}
+
+// keyOptions 按配置加载签名与验签密钥
+func keyOptions(cfg *Config) ([]Option, error) {
+	if cfg.Algorithm == "" || cfg.Algorithm == AlgHS256 {
+		// Validate secret key - reject default insecure key
+		if cfg.SecretKey == defaultSecretKey {
+			return nil, fmt.Errorf("jwt: secret_key must be configured, using default key is not allowed")
+		}
+		if len(cfg.SecretKey) < 32 {
+			return nil, fmt.Errorf("jwt: secret_key must be at least 32 characters")
+		}
+		return nil, nil
+	}
+
+	var opts []Option
+	pemData := []byte(cfg.PrivateKey)
+	if len(pemData) == 0 && cfg.PrivateKeyFile != "" {
+		data, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: read private_key_file: %w", err)
+		}
+		pemData = data
+	}
+	if len(pemData) > 0 {
+		key, err := ParsePrivateKeyPEM(cfg.KeyID, cfg.Algorithm, pemData)
+		if err != nil {
+			return nil, err
+		}
+		ks, err := NewKeySet(key)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithKeySet(ks))
+	}
+	if cfg.JWKSURL != "" {
+		opts = append(opts, WithKeyResolver(NewRemoteKeySet(cfg.JWKSURL, WithCacheTTL(cfg.JWKSCacheTTL))))
+	}
+	if len(opts) == 0 {
+		return nil, fmt.Errorf("jwt: %s requires private_key, private_key_file or jwks_url", cfg.Algorithm)
+	}
+	return opts, nil
+}
+
 func (p *Plugin) Authenticator() auth.Authenticator {
 	return p.authenticator
 }
 
+// KeySet 签名密钥集合，用于发布 JWKS 与轮换，HS256 或仅验签时为 nil
+func (p *Plugin) KeySet() *KeySet {
+	if p.authenticator == nil {
+		return nil
+	}
+	return p.authenticator.KeySet()
+}
+
 // Authenticator JWT鉴权器
 type Authenticator struct {
 	config    *Config
 	blacklist cache.Cache
+	keys      *KeySet
+	resolver  KeyResolver
+	now       func() time.Time
 }
 
 type Option func(*Authenticator)
 
-// WithBlacklist 设置黑名单缓存
+// WithBlacklist 设置令牌状态缓存
+//
+// 用于令牌黑名单（需开启 BlacklistEnabled）、刷新令牌的一次性使用与复用检测，
+// 以及令牌族撤销。开启 RefreshReuseDetection 时必须设置。
 func WithBlacklist(c cache.Cache) Option {
 	return func(a *Authenticator) { a.blacklist = c }
 }
 
+// WithKeySet 使用非对称密钥集合签名，未设置 WithKeyResolver 时同时用于验签
+func WithKeySet(ks *KeySet) Option {
+	return func(a *Authenticator) { a.keys = ks }
+}
+
+// WithKeyResolver 设置验签密钥来源，如 RemoteKeySet；未设置 WithKeySet 时只能验签
+func WithKeyResolver(r KeyResolver) Option {
+	return func(a *Authenticator) { a.resolver = r }
+}
+
 // NewAuthenticator 创建JWT鉴权器
 func NewAuthenticator(config *Config, opts ...Option) *Authenticator {
-	a := &Authenticator{config: config}
+	a := &Authenticator{config: config, now: time.Now}
 	for _, opt := range opts {
 		opt(a)
 	}
+	if a.resolver == nil && a.keys != nil {
+		a.resolver = a.keys
+	}
 	return a
 }
 
+// KeySet 签名密钥集合
+func (a *Authenticator) KeySet() *KeySet {
+	return a.keys
+}
+
+// 令牌类型，写入 typ 声明
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// 令牌状态缓存键前缀
+const (
+	blacklistPrefix   = "jwt:blacklist:"
+	refreshUsedPrefix = "jwt:refresh:used:"
+	familyPrefix      = "jwt:family:revoked:"
+)
+
 type jwtClaims struct {
 	jwt.RegisteredClaims
-	UserID   string                 `json:"user_id"`
-	Username string                 `json:"username"`
-	Roles    []string               `json:"roles"`
-	Extra    map[string]interface{} `json:"extra"`
+	UserID    string                 `json:"user_id"`
+	Username  string                 `json:"username"`
+	Roles     []string               `json:"roles"`
+	Scopes    []string               `json:"scopes,omitempty"`
+	Extra     map[string]interface{} `json:"extra"`
+	TokenType string                 `json:"typ,omitempty"`
+	FamilyID  string                 `json:"fid,omitempty"`
+	AMR       []string               `json:"amr,omitempty"`
+	ACR       string                 `json:"acr,omitempty"`
+	AuthTime  *jwt.NumericDate       `json:"auth_time,omitempty"`
 }
 
-func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (*auth.Claims, error) {
+// resolveError 密钥解析失败（如获取 JWKS 出错）
+type resolveError struct{ err error }
+
+func (e *resolveError) Error() string { return e.err.Error() }
+func (e *resolveError) Unwrap() error { return e.err }
+
+// parse 解析并验签令牌
+//
+// 非对称模式下按头部 kid 查找公钥，且令牌的 alg 必须与密钥的算法一致，
+// 不接受 HS256，避免以公钥作为 HMAC 密钥的算法混淆攻击。
+// 验签与声明校验失败返回 ErrTokenInvalid，密钥解析失败原样返回。
+func (a *Authenticator) parse(ctx context.Context, tokenString string) (*jwtClaims, error) {
+	methods := []string{AlgHS256}
+	if a.resolver != nil {
+		methods = []string{AlgRS256, AlgES256, AlgEdDSA}
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithTimeFunc(a.now)}
+	if a.config.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.config.Issuer))
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &jwtClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		if a.resolver == nil {
+			return []byte(a.config.SecretKey), nil
 		}
-		return []byte(a.config.SecretKey), nil
-	})
-
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, ErrKeyNotFound
+		}
+		key, err := a.resolver.ResolveKey(ctx, kid)
+		if err != nil {
+			if errors.Is(err, ErrKeyNotFound) {
+				return nil, err
+			}
+			return nil, &resolveError{err: err}
+		}
+		if key.Algorithm != token.Method.Alg() {
+			return nil, ErrAlgorithmMismatch
+		}
+		return key.PublicKey, nil
+	}, opts...)
 	if err != nil {
+		// 密钥解析失败与令牌无关，原样返回以便上层按服务不可用处理
+		var re *resolveError
+		if errors.As(err, &re) {
+			return nil, re.err
+		}
 		return nil, auth.ErrTokenInvalid
 	}
 
@@ -132,64 +319,201 @@ func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (*
 	if !ok || !token.Valid {
 		return nil, auth.ErrTokenInvalid
 	}
+	return claims, nil
+}
 
-	if a.config.BlacklistEnabled && a.blacklist != nil {
-		exists, _ := a.blacklist.Exists(ctx, "jwt:blacklist:"+tokenString)
+// checkRevoked 检查令牌黑名单与令牌族撤销
+//
+// 缓存不可用时返回原始错误，不能当作未撤销放行。
+func (a *Authenticator) checkRevoked(ctx context.Context, tokenString string, claims *jwtClaims) error {
+	if a.blacklist == nil {
+		return nil
+	}
+	var keys []string
+	if a.config.BlacklistEnabled {
+		keys = append(keys, blacklistPrefix+tokenString)
+	}
+	if claims.FamilyID != "" {
+		keys = append(keys, familyPrefix+claims.FamilyID)
+	}
+	for _, key := range keys {
+		exists, err := a.blacklist.Exists(ctx, key)
+		if err != nil {
+			return fmt.Errorf("jwt: check revocation: %w", err)
+		}
 		if exists {
-			return nil, auth.ErrTokenRevoked
+			return auth.ErrTokenRevoked
 		}
 	}
+	return nil
+}
+
+// Authenticate 验证访问令牌，刷新令牌不能用于访问
+func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (*auth.Claims, error) {
+	claims, err := a.parse(ctx, tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType == TokenTypeRefresh {
+		return nil, auth.ErrTokenInvalid
+	}
+	if err := a.checkRevoked(ctx, tokenString, claims); err != nil {
+		return nil, err
+	}
 
 	return &auth.Claims{
 		UserID:    claims.UserID,
 		Username:  claims.Username,
 		Roles:     claims.Roles,
+		Scopes:    claims.Scopes,
 		Extra:     claims.Extra,
 		IssuedAt:  claims.IssuedAt.Time,
 		ExpiresAt: claims.ExpiresAt.Time,
+		TokenID:   claims.ID,
+		FamilyID:  claims.FamilyID,
+		AMR:       claims.AMR,
+		ACR:       claims.ACR,
+		AuthTime:  numericTime(claims.AuthTime),
 	}, nil
 }
 
-func (a *Authenticator) GenerateToken(claims *auth.Claims) (string, error) {
-	now := time.Now()
-	expiresAt := now.Add(a.config.AccessTokenTTL)
+func numericTime(d *jwt.NumericDate) time.Time {
+	if d == nil {
+		return time.Time{}
+	}
+	return d.Time
+}
+
+// GenerateToken 签发访问令牌与刷新令牌，两者属于新的令牌族
+func (a *Authenticator) GenerateToken(claims *auth.Claims) (*auth.TokenPair, error) {
+	return a.issuePair(claims, uuid.New().String())
+}
+
+// issuePair 签发属于指定令牌族的令牌对
+func (a *Authenticator) issuePair(claims *auth.Claims, familyID string) (*auth.TokenPair, error) {
+	now := a.now()
+	pair := &auth.TokenPair{
+		TokenType:        "Bearer",
+		ExpiresAt:        now.Add(a.config.AccessTokenTTL),
+		RefreshExpiresAt: now.Add(a.config.RefreshTokenTTL),
+	}
+
+	var err error
+	pair.AccessToken, err = a.sign(claims, TokenTypeAccess, familyID, now, pair.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	pair.RefreshToken, err = a.sign(claims, TokenTypeRefresh, familyID, now, pair.RefreshExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
 
+// sign 签发单个令牌
+func (a *Authenticator) sign(claims *auth.Claims, tokenType, familyID string, issuedAt, expiresAt time.Time) (string, error) {
 	jwtClaims := &jwtClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			Issuer:    a.config.Issuer,
-			IssuedAt:  jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 		},
-		UserID:   claims.UserID,
-		Username: claims.Username,
-		Roles:    claims.Roles,
-		Extra:    claims.Extra,
+		UserID:    claims.UserID,
+		Username:  claims.Username,
+		Roles:     claims.Roles,
+		Scopes:    claims.Scopes,
+		Extra:     claims.Extra,
+		TokenType: tokenType,
+		FamilyID:  familyID,
+		AMR:       claims.AMR,
+		ACR:       claims.ACR,
+	}
+	if !claims.AuthTime.IsZero() {
+		jwtClaims.AuthTime = jwt.NewNumericDate(claims.AuthTime)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims)
-	return token.SignedString([]byte(a.config.SecretKey))
-}
+	if a.keys == nil {
+		if a.resolver != nil {
+			return "", ErrNoSigningKey
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims)
+		return token.SignedString([]byte(a.config.SecretKey))
+	}
 
-func (a *Authenticator) RefreshToken(refreshToken string) (string, error) {
-	token, err := jwt.ParseWithClaims(refreshToken, &jwtClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(a.config.SecretKey), nil
-	})
+	key, err := a.keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.signingMethod(), jwtClaims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
 
-	if err != nil || !token.Valid {
-		return "", auth.ErrTokenInvalid
+// RefreshToken 使用刷新令牌换取新的令牌对，令牌族不变
+//
+// 开启 RefreshReuseDetection 时刷新令牌只能使用一次；已使用的刷新令牌再次出现说明可能已泄露，
+// 此时撤销整个令牌族（包括仍在有效期内的访问令牌与最新的刷新令牌）并返回 ErrTokenReused。
+func (a *Authenticator) RefreshToken(ctx context.Context, refreshToken string) (*auth.TokenPair, error) {
+	claims, err := a.parse(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeRefresh {
+		return nil, auth.ErrTokenInvalid
+	}
+	if err := a.checkRevoked(ctx, refreshToken, claims); err != nil {
+		return nil, err
 	}
 
-	claims, ok := token.Claims.(*jwtClaims)
-	if !ok {
-		return "", auth.ErrTokenInvalid
+	if a.config.RefreshReuseDetection {
+		if a.blacklist == nil {
+			return nil, ErrNoTokenStore
+		}
+		ttl := claims.ExpiresAt.Time.Sub(a.now())
+		if ttl <= 0 {
+			return nil, auth.ErrTokenExpired
+		}
+		first, err := a.blacklist.SetNX(ctx, refreshUsedPrefix+claims.ID, claims.FamilyID, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			if err := a.RevokeFamily(ctx, claims.FamilyID); err != nil {
+				return nil, err
+			}
+			return nil, auth.ErrTokenReused
+		}
 	}
 
-	return a.GenerateToken(&auth.Claims{
+	familyID := claims.FamilyID
+	if familyID == "" {
+		familyID = uuid.New().String()
+	}
+	// 刷新不改变认证强度，AuthTime 保持为原始认证时间
+	return a.issuePair(&auth.Claims{
 		UserID:   claims.UserID,
 		Username: claims.Username,
 		Roles:    claims.Roles,
+		Scopes:   claims.Scopes,
 		Extra:    claims.Extra,
-	})
+		AMR:      claims.AMR,
+		ACR:      claims.ACR,
+		AuthTime: numericTime(claims.AuthTime),
+	}, familyID)
+}
+
+// RevokeFamily 撤销令牌族，该族已签发的访问令牌与刷新令牌均失效，用于登出所有会话或处置令牌泄露
+func (a *Authenticator) RevokeFamily(ctx context.Context, familyID string) error {
+	if a.blacklist == nil || familyID == "" {
+		return nil
+	}
+	// 族内令牌均在此刻之前签发，保留到其中寿命最长的令牌过期为止
+	ttl := a.config.RefreshTokenTTL
+	if a.config.AccessTokenTTL > ttl {
+		ttl = a.config.AccessTokenTTL
+	}
+	return a.blacklist.Set(ctx, familyPrefix+familyID, true, ttl)
 }
 
 func (a *Authenticator) RevokeToken(ctx context.Context, tokenString string) error {
@@ -197,22 +521,15 @@ func (a *Authenticator) RevokeToken(ctx context.Context, tokenString string) err
 		return nil
 	}
 
-	token, err := jwt.ParseWithClaims(tokenString, &jwtClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(a.config.SecretKey), nil
-	})
+	claims, err := a.parse(ctx, tokenString)
 	if err != nil {
 		return err
 	}
 
-	claims, ok := token.Claims.(*jwtClaims)
-	if !ok {
-		return auth.ErrTokenInvalid
-	}
-
-	ttl := time.Until(claims.ExpiresAt.Time)
+	ttl := claims.ExpiresAt.Time.Sub(a.now())
 	if ttl <= 0 {
 		return nil
 	}
 
-	return a.blacklist.Set(ctx, "jwt:blacklist:"+tokenString, true, ttl)
+	return a.blacklist.Set(ctx, blacklistPrefix+tokenString, true, ttl)
 }
@@ -2,10 +2,12 @@ package jwt
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/cache"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -26,6 +28,7 @@ func TestPlugin_Init(t *testing.T) {
 		"issuer":            "test-issuer",
 		"access_token_ttl":  "1h",
 		"blacklist_enabled": true,
+		"cache":             cache.NewMemoryCache(),
 	}
 
 	err := p.Init(config)
@@ -37,6 +40,35 @@ func TestPlugin_Init(t *testing.T) {
 	}
 }
 
+func TestPlugin_Init_RequiresCache(t *testing.T) {
+	secret := "test-secret-key-that-is-at-least-32-characters-long"
+
+	p := &Plugin{}
+	if err := p.Init(map[string]interface{}{"secret_key": secret}); !errors.Is(err, ErrNoTokenStore) {
+		t.Errorf("Init() error = %v, want ErrNoTokenStore", err)
+	}
+	if err := p.Init(map[string]interface{}{"secret_key": secret, "refresh_reuse_detection": false, "blacklist_enabled": true}); !errors.Is(err, ErrNoTokenStore) {
+		t.Errorf("Init(blacklist) error = %v, want ErrNoTokenStore", err)
+	}
+	if err := p.Init(map[string]interface{}{"secret_key": secret, "refresh_reuse_detection": false}); err != nil {
+		t.Errorf("Init(reuse detection disabled) error = %v", err)
+	}
+
+	// 通过插件创建的鉴权器同样检测刷新令牌复用
+	if err := p.Init(map[string]interface{}{"secret_key": secret, "cache": cache.NewMemoryCache()}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	a := p.Authenticator().(*Authenticator)
+	pair, _ := a.GenerateToken(&auth.Claims{UserID: "user-123"})
+	ctx := context.Background()
+	if _, err := a.RefreshToken(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if _, err := a.RefreshToken(ctx, pair.RefreshToken); err != auth.ErrTokenReused {
+		t.Errorf("RefreshToken(reused) error = %v, want ErrTokenReused", err)
+	}
+}
+
 func TestPlugin_Init_RejectsDefaultKey(t *testing.T) {
 	p := &Plugin{}
 	config := map[string]interface{}{}
@@ -70,13 +102,19 @@ func TestAuthenticator_GenerateAndAuthenticate(t *testing.T) {
 		Roles:    []string{"admin"},
 	}
 
-	token, err := a.GenerateToken(claims)
+	pair, err := a.GenerateToken(claims)
 	if err != nil {
 		t.Fatalf("GenerateToken() error = %v", err)
 	}
+	if pair.AccessToken == "" || pair.RefreshToken == "" || pair.TokenType != "Bearer" {
+		t.Fatalf("GenerateToken() = %+v", pair)
+	}
+	if got := pair.RefreshExpiresAt.Sub(pair.ExpiresAt); got != cfg.RefreshTokenTTL-cfg.AccessTokenTTL {
+		t.Errorf("refresh expires %v after access, want %v", got, cfg.RefreshTokenTTL-cfg.AccessTokenTTL)
+	}
 
 	ctx := context.Background()
-	result, err := a.Authenticate(ctx, token)
+	result, err := a.Authenticate(ctx, pair.AccessToken)
 	if err != nil {
 		t.Fatalf("Authenticate() error = %v", err)
 	}
@@ -84,6 +122,14 @@ func TestAuthenticator_GenerateAndAuthenticate(t *testing.T) {
 	if result.UserID != "user-123" {
 		t.Errorf("UserID = %s, want user-123", result.UserID)
 	}
+	if result.TokenID == "" || result.FamilyID == "" {
+		t.Errorf("TokenID = %q, FamilyID = %q, want both set", result.TokenID, result.FamilyID)
+	}
+
+	// 刷新令牌不能用于访问
+	if _, err := a.Authenticate(ctx, pair.RefreshToken); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(refresh token) error = %v, want ErrTokenInvalid", err)
+	}
 }
 
 func TestAuthenticator_InvalidToken(t *testing.T) {
@@ -98,20 +144,146 @@ func TestAuthenticator_InvalidToken(t *testing.T) {
 	}
 }
 
+func TestAuthenticator_Issuer(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SecretKey = "test-secret"
+	cfg.Issuer = "other-service"
+	pair, err := NewAuthenticator(cfg).GenerateToken(&auth.Claims{UserID: "user-123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg = DefaultConfig()
+	cfg.SecretKey = "test-secret"
+	ctx := context.Background()
+	if _, err := NewAuthenticator(cfg).Authenticate(ctx, pair.AccessToken); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(foreign issuer) error = %v, want ErrTokenInvalid", err)
+	}
+
+	cfg.Issuer = ""
+	if _, err := NewAuthenticator(cfg).Authenticate(ctx, pair.AccessToken); err != nil {
+		t.Errorf("未配置 Issuer 时不校验 iss, error = %v", err)
+	}
+}
+
 func TestAuthenticator_RefreshToken(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.SecretKey = "test-secret"
-	a := NewAuthenticator(cfg)
+	a := NewAuthenticator(cfg, WithBlacklist(cache.NewMemoryCache()))
 
 	claims := &auth.Claims{UserID: "user-123", Username: "testuser"}
-	token, _ := a.GenerateToken(claims)
+	pair, _ := a.GenerateToken(claims)
+	ctx := context.Background()
+
+	newPair, err := a.RefreshToken(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if newPair.AccessToken == "" || newPair.RefreshToken == pair.RefreshToken {
+		t.Errorf("RefreshToken() = %+v, want rotated pair", newPair)
+	}
+
+	old, _ := a.Authenticate(ctx, pair.AccessToken)
+	refreshed, err := a.Authenticate(ctx, newPair.AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate(refreshed) error = %v", err)
+	}
+	if refreshed.UserID != "user-123" || refreshed.FamilyID != old.FamilyID {
+		t.Errorf("refreshed claims = %+v, want same user and family", refreshed)
+	}
+
+	// 访问令牌不能用于刷新
+	if _, err := a.RefreshToken(ctx, pair.AccessToken); err != auth.ErrTokenInvalid {
+		t.Errorf("RefreshToken(access token) error = %v, want ErrTokenInvalid", err)
+	}
+
+	// 开启复用检测但未设置缓存时拒绝刷新，而不是允许重复使用
+	if _, err := NewAuthenticator(cfg).RefreshToken(ctx, newPair.RefreshToken); !errors.Is(err, ErrNoTokenStore) {
+		t.Errorf("RefreshToken(no cache) error = %v, want ErrNoTokenStore", err)
+	}
+}
 
-	newToken, err := a.RefreshToken(token)
+func TestAuthenticator_RefreshToken_ReuseRevokesFamily(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SecretKey = "test-secret"
+	store := cache.NewMemoryCache()
+	defer store.Close()
+	a := NewAuthenticator(cfg, WithBlacklist(store))
+	ctx := context.Background()
+
+	first, _ := a.GenerateToken(&auth.Claims{UserID: "user-123"})
+	other, _ := a.GenerateToken(&auth.Claims{UserID: "user-123"})
+	second, err := a.RefreshToken(ctx, first.RefreshToken)
 	if err != nil {
 		t.Fatalf("RefreshToken() error = %v", err)
 	}
-	if newToken == "" {
-		t.Error("New token should not be empty")
+	third, err := a.RefreshToken(ctx, second.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken(rotated) error = %v", err)
+	}
+
+	// 重放已使用的刷新令牌
+	if _, err := a.RefreshToken(ctx, first.RefreshToken); err != auth.ErrTokenReused {
+		t.Fatalf("RefreshToken(reused) error = %v, want ErrTokenReused", err)
+	}
+	for name, token := range map[string]string{"first": first.AccessToken, "third": third.AccessToken} {
+		if _, err := a.Authenticate(ctx, token); err != auth.ErrTokenRevoked {
+			t.Errorf("Authenticate(%s) error = %v, want ErrTokenRevoked", name, err)
+		}
+	}
+	if _, err := a.RefreshToken(ctx, third.RefreshToken); err != auth.ErrTokenRevoked {
+		t.Errorf("RefreshToken(latest) error = %v, want ErrTokenRevoked", err)
+	}
+
+	// 其他令牌族不受影响
+	if _, err := a.Authenticate(ctx, other.AccessToken); err != nil {
+		t.Errorf("Authenticate(other family) error = %v", err)
+	}
+	if _, err := a.RefreshToken(ctx, other.RefreshToken); err != nil {
+		t.Errorf("RefreshToken(other family) error = %v", err)
+	}
+}
+
+func TestAuthenticator_RefreshToken_Expired(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SecretKey = "test-secret"
+	a := NewAuthenticator(cfg, WithBlacklist(cache.NewMemoryCache()))
+	now := time.Now()
+	a.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	pair, _ := a.GenerateToken(&auth.Claims{UserID: "user-123"})
+	now = now.Add(cfg.AccessTokenTTL + time.Minute)
+	if _, err := a.Authenticate(ctx, pair.AccessToken); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(expired) error = %v, want ErrTokenInvalid", err)
+	}
+	if _, err := a.RefreshToken(ctx, pair.RefreshToken); err != nil {
+		t.Errorf("RefreshToken() after access expiry error = %v", err)
+	}
+
+	pair, _ = a.GenerateToken(&auth.Claims{UserID: "user-123"})
+	now = now.Add(cfg.RefreshTokenTTL + time.Minute)
+	if _, err := a.RefreshToken(ctx, pair.RefreshToken); err != auth.ErrTokenInvalid {
+		t.Errorf("RefreshToken(expired) error = %v, want ErrTokenInvalid", err)
+	}
+}
+
+func TestAuthenticator_RevokeFamily(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SecretKey = "test-secret"
+	a := NewAuthenticator(cfg, WithBlacklist(cache.NewMemoryCache()))
+	ctx := context.Background()
+
+	pair, _ := a.GenerateToken(&auth.Claims{UserID: "user-123"})
+	claims, _ := a.Authenticate(ctx, pair.AccessToken)
+	if err := a.RevokeFamily(ctx, claims.FamilyID); err != nil {
+		t.Fatalf("RevokeFamily() error = %v", err)
+	}
+	if _, err := a.Authenticate(ctx, pair.AccessToken); err != auth.ErrTokenRevoked {
+		t.Errorf("Authenticate() error = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := a.RefreshToken(ctx, pair.RefreshToken); err != auth.ErrTokenRevoked {
+		t.Errorf("RefreshToken() error = %v, want ErrTokenRevoked", err)
 	}
 }
 
@@ -123,7 +295,7 @@ func TestAuthenticator_RevokeToken_WithBlacklist(t *testing.T) {
 	mockCache := &mockCache{data: make(map[string]interface{})}
 	a := NewAuthenticator(cfg, WithBlacklist(mockCache))
 
-	token, _ := a.GenerateToken(&auth.Claims{UserID: "test"})
+	token := accessToken(t, a, &auth.Claims{UserID: "test"})
 
 	ctx := context.Background()
 	err := a.RevokeToken(ctx, token)
@@ -137,18 +309,44 @@ func TestAuthenticator_RevokeToken_WithBlacklist(t *testing.T) {
 	}
 }
 
+func TestAuthenticator_RevocationCheckFailure(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SecretKey = "test-secret"
+	cfg.BlacklistEnabled = true
+
+	errDown := errors.New("cache down")
+	mockCache := &mockCache{data: make(map[string]interface{}), existsErr: errDown}
+	a := NewAuthenticator(cfg, WithBlacklist(mockCache))
+	pair, _ := a.GenerateToken(&auth.Claims{UserID: "test"})
+
+	// 无法确认是否已撤销时不能放行
+	ctx := context.Background()
+	if _, err := a.Authenticate(ctx, pair.AccessToken); !errors.Is(err, errDown) {
+		t.Errorf("Authenticate() error = %v, want cache error", err)
+	}
+	if _, err := a.RefreshToken(ctx, pair.RefreshToken); !errors.Is(err, errDown) {
+		t.Errorf("RefreshToken() error = %v, want cache error", err)
+	}
+}
+
 // mockCache 模拟缓存
 type mockCache struct {
 	data map[string]interface{}
+	// existsErr 非空时 Exists 返回该错误，模拟缓存不可用
+	existsErr error
 }
 
 func (c *mockCache) Get(ctx context.Context, key string, value interface{}) error { return nil }
+func (c *mockCache) GetRaw(ctx context.Context, key string) (string, error)       { return "", nil }
 func (c *mockCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	c.data[key] = value
 	return nil
 }
 func (c *mockCache) Delete(ctx context.Context, key string) error { delete(c.data, key); return nil }
 func (c *mockCache) Exists(ctx context.Context, key string) (bool, error) {
+	if c.existsErr != nil {
+		return false, c.existsErr
+	}
 	_, ok := c.data[key]
 	return ok, nil
 }
@@ -172,5 +370,36 @@ func (c *mockCache) MDelete(ctx context.Context, keys []string) error { return n
 func (c *mockCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
 	return true, nil
 }
-func (c *mockCache) Close() error                    { return nil }
-func (c *mockCache) Ping(ctx context.Context) error { return nil }
+func (c *mockCache) Keys(ctx context.Context, pattern string) ([]string, error) { return nil, nil }
+func (c *mockCache) Close() error                                               { return nil }
+func (c *mockCache) Ping(ctx context.Context) error                             { return nil }
+
+func TestAuthenticator_AuthenticationStrength(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SecretKey = "test-secret"
+	a := NewAuthenticator(cfg, WithBlacklist(cache.NewMemoryCache()))
+
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	claims := &auth.Claims{UserID: "user-123", AMR: []string{auth.AMRPassword}, ACR: "urn:example:high"}
+	claims.AddAMR(authTime, auth.AMROTP)
+
+	pair, err := a.GenerateToken(claims)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	ctx := context.Background()
+	newPair, err := a.RefreshToken(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+
+	for _, token := range []string{pair.AccessToken, newPair.AccessToken} {
+		got, err := a.Authenticate(ctx, token)
+		if err != nil {
+			t.Fatalf("Authenticate() error = %v", err)
+		}
+		if !got.IsMFA() || !got.HasAMR(auth.AMROTP) || got.ACR != "urn:example:high" || !got.AuthTime.Equal(authTime) {
+			t.Errorf("claims = %+v, want amr/acr/auth_time preserved", got)
+		}
+	}
+}
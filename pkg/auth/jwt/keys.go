@@ -0,0 +1,460 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// 密钥相关错误
+var (
+	ErrKeyNotFound       = errors.New("jwt: key not found")
+	ErrNoSigningKey      = errors.New("jwt: no signing key")
+	ErrUnsupportedAlg    = errors.New("jwt: unsupported algorithm")
+	ErrAlgorithmMismatch = errors.New("jwt: algorithm does not match key")
+)
+
+// KeyResolver 按 kid 查找验签密钥
+type KeyResolver interface {
+	ResolveKey(ctx context.Context, kid string) (*Key, error)
+}
+
+// Key 非对称签名密钥，只有公钥时只能验签
+type Key struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// GenerateKey 生成指定算法的密钥，kid 为公钥的 RFC 7638 指纹
+func GenerateKey(alg string) (*Key, error) {
+	var signer crypto.Signer
+	var err error
+	switch alg {
+	case AlgRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewKey("", alg, signer)
+}
+
+// NewKey 从私钥创建签名密钥，kid 为空时使用公钥指纹
+func NewKey(kid, alg string, signer crypto.Signer) (*Key, error) {
+	k := &Key{ID: kid, Algorithm: alg, PrivateKey: signer, PublicKey: signer.Public()}
+	if err := k.check(); err != nil {
+		return nil, err
+	}
+	if k.ID == "" {
+		k.ID = k.Thumbprint()
+	}
+	return k, nil
+}
+
+// NewPublicKey 创建只能验签的密钥，kid 为空时使用公钥指纹
+func NewPublicKey(kid, alg string, pub crypto.PublicKey) (*Key, error) {
+	k := &Key{ID: kid, Algorithm: alg, PublicKey: pub}
+	if err := k.check(); err != nil {
+		return nil, err
+	}
+	if k.ID == "" {
+		k.ID = k.Thumbprint()
+	}
+	return k, nil
+}
+
+// ParsePrivateKeyPEM 解析 PEM 私钥（PKCS#8、PKCS#1 或 SEC 1），算法为空时按密钥类型推断
+func ParsePrivateKeyPEM(kid, alg string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jwt: invalid PEM private key")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jwt: parse private key: %w", err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedAlg, parsed)
+	}
+	if alg == "" {
+		alg = algorithmFor(signer.Public())
+	}
+	return NewKey(kid, alg, signer)
+}
+
+// ParsePublicKeyPEM 解析 PEM 公钥（PKIX），算法为空时按密钥类型推断
+func ParsePublicKeyPEM(kid, alg string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jwt: invalid PEM public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: parse public key: %w", err)
+	}
+	if alg == "" {
+		alg = algorithmFor(pub)
+	}
+	return NewPublicKey(kid, alg, pub)
+}
+
+// algorithmFor 按公钥类型推断签名算法
+func algorithmFor(pub crypto.PublicKey) string {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return AlgRS256
+	case *ecdsa.PublicKey:
+		return AlgES256
+	case ed25519.PublicKey:
+		return AlgEdDSA
+	}
+	return ""
+}
+
+// check 校验算法与公钥类型匹配
+func (k *Key) check() error {
+	switch pub := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if k.Algorithm != AlgRS256 {
+			return fmt.Errorf("%w: %s with RSA key", ErrAlgorithmMismatch, k.Algorithm)
+		}
+		if pub.N.BitLen() < 2048 {
+			return errors.New("jwt: RSA key must be at least 2048 bits")
+		}
+	case *ecdsa.PublicKey:
+		if k.Algorithm != AlgES256 || pub.Curve != elliptic.P256() {
+			return fmt.Errorf("%w: %s with ECDSA %s key", ErrAlgorithmMismatch, k.Algorithm, pub.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		if k.Algorithm != AlgEdDSA {
+			return fmt.Errorf("%w: %s with Ed25519 key", ErrAlgorithmMismatch, k.Algorithm)
+		}
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedAlg, k.PublicKey)
+	}
+	return nil
+}
+
+// signingMethod 密钥对应的签名方法
+func (k *Key) signingMethod() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// CanSign 是否持有私钥
+func (k *Key) CanSign() bool {
+	return k.PrivateKey != nil
+}
+
+// JWK 公钥的 JWK 表示，不包含私钥
+func (k *Key) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Alg: k.Algorithm, Use: "sig"}
+	switch pub := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64(pub.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = b64(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = b64(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = b64(pub)
+	}
+	return jwk
+}
+
+// Thumbprint 公钥的 RFC 7638 指纹（SHA-256，base64url）
+func (k *Key) Thumbprint() string {
+	jwk := k.JWK()
+	// 必需成员按字典序排列
+	var members string
+	switch jwk.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, jwk.Crv, jwk.X, jwk.Y)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Crv, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return b64(sum[:])
+}
+
+// JWK JSON Web Key（RFC 7517）公钥
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Key 将 JWK 转换为验签密钥，未声明 alg 时按密钥类型推断
+func (j JWK) Key() (*Key, error) {
+	var pub crypto.PublicKey
+	switch j.Kty {
+	case "RSA":
+		n, err := unb64(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := unb64(j.E)
+		if err != nil {
+			return nil, err
+		}
+		pub = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, fmt.Errorf("%w: curve %s", ErrUnsupportedAlg, j.Crv)
+		}
+		x, err := unb64(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := unb64(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		// 通过未压缩点编码校验点在曲线上
+		raw := append([]byte{4}, append(pad(x, 32), pad(y, 32)...)...)
+		ecKey, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), raw)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid EC key: %w", err)
+		}
+		pub = ecKey
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("%w: curve %s", ErrUnsupportedAlg, j.Crv)
+		}
+		x, err := unb64(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("jwt: invalid Ed25519 key size")
+		}
+		pub = ed25519.PublicKey(x)
+	default:
+		return nil, fmt.Errorf("%w: kty %s", ErrUnsupportedAlg, j.Kty)
+	}
+
+	alg := j.Alg
+	if alg == "" {
+		alg = algorithmFor(pub)
+	}
+	return NewPublicKey(j.Kid, alg, pub)
+}
+
+// ParseJWKS 解析 JWKS 文档，跳过不支持的密钥与加密用途的密钥
+func ParseJWKS(data []byte) (map[string]*Key, error) {
+	var set JWKS
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwt: parse jwks: %w", err)
+	}
+	keys := make(map[string]*Key, len(set.Keys))
+	for _, j := range set.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		k, err := j.Key()
+		if err != nil {
+			continue
+		}
+		keys[k.ID] = k
+	}
+	return keys, nil
+}
+
+// KeySet 按 kid 索引的密钥集合，当前签名密钥签发令牌，集合中所有密钥均可验签
+//
+// 轮换时先 Add 新密钥并发布到 JWKS，待各验证方刷新后 SetSigningKey 切换签名，
+// 旧密钥保留至其签发的令牌全部过期后再 Remove。Rotate 一步完成添加与切换。
+type KeySet struct {
+	mu         sync.RWMutex
+	keys       map[string]*Key
+	signingKID string
+}
+
+// NewKeySet 创建密钥集合，第一个可签名的密钥作为签名密钥
+func NewKeySet(keys ...*Key) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key)}
+	for _, k := range keys {
+		if err := ks.Add(k); err != nil {
+			return nil, err
+		}
+		if ks.signingKID == "" && k.CanSign() {
+			ks.signingKID = k.ID
+		}
+	}
+	return ks, nil
+}
+
+// Add 添加密钥，kid 已存在时返回错误
+func (ks *KeySet) Add(k *Key) error {
+	if k == nil || k.ID == "" {
+		return errors.New("jwt: key id is required")
+	}
+	if err := k.check(); err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, ok := ks.keys[k.ID]; ok {
+		return fmt.Errorf("jwt: duplicate key id %s", k.ID)
+	}
+	ks.keys[k.ID] = k
+	return nil
+}
+
+// SetSigningKey 切换签名密钥
+func (ks *KeySet) SetSigningKey(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	k, ok := ks.keys[kid]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if !k.CanSign() {
+		return fmt.Errorf("jwt: key %s has no private key", kid)
+	}
+	ks.signingKID = kid
+	return nil
+}
+
+// Rotate 添加新密钥并用其签名，旧密钥继续用于验签
+func (ks *KeySet) Rotate(k *Key) error {
+	if err := ks.Add(k); err != nil {
+		return err
+	}
+	return ks.SetSigningKey(k.ID)
+}
+
+// Remove 移除密钥，不能移除当前签名密钥
+func (ks *KeySet) Remove(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if kid == ks.signingKID {
+		return fmt.Errorf("jwt: cannot remove signing key %s", kid)
+	}
+	delete(ks.keys, kid)
+	return nil
+}
+
+// SigningKey 当前签名密钥
+func (ks *KeySet) SigningKey() (*Key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if k, ok := ks.keys[ks.signingKID]; ok {
+		return k, nil
+	}
+	return nil, ErrNoSigningKey
+}
+
+// ResolveKey 实现 KeyResolver
+func (ks *KeySet) ResolveKey(_ context.Context, kid string) (*Key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if k, ok := ks.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+// JWKS 所有密钥的公钥集合，按 kid 排序，签名密钥在前
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	ids := make([]string, 0, len(ks.keys))
+	for id := range ks.keys {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if (ids[i] == ks.signingKID) != (ids[j] == ks.signingKID) {
+			return ids[i] == ks.signingKID
+		}
+		return ids[i] < ids[j]
+	})
+
+	set := JWKS{Keys: make([]JWK, 0, len(ids))}
+	for _, id := range ids {
+		set.Keys = append(set.Keys, ks.keys[id].JWK())
+	}
+	return set
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func unb64(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: invalid base64url: %w", err)
+	}
+	return b, nil
+}
+
+// pad 左侧补零到指定长度
+func pad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
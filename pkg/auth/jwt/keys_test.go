@@ -0,0 +1,267 @@
+package jwt
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/cache"
+)
+
+// accessToken 签发令牌对并返回访问令牌
+func accessToken(t *testing.T, a auth.Authenticator, claims *auth.Claims) string {
+	t.Helper()
+	pair, err := a.GenerateToken(claims)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	return pair.AccessToken
+}
+
+func TestGenerateKey_SignAndVerify(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgES256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			key, err := GenerateKey(alg)
+			if err != nil {
+				t.Fatalf("GenerateKey() error = %v", err)
+			}
+			ks, err := NewKeySet(key)
+			if err != nil {
+				t.Fatalf("NewKeySet() error = %v", err)
+			}
+			a := NewAuthenticator(DefaultConfig(), WithKeySet(ks))
+
+			token := accessToken(t, a, &auth.Claims{UserID: "u1", Roles: []string{"admin"}})
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwtClaims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified() error = %v", err)
+			}
+			if parsed.Header["kid"] != key.ID || parsed.Header["alg"] != alg {
+				t.Errorf("header = %v, want kid %s alg %s", parsed.Header, key.ID, alg)
+			}
+
+			claims, err := a.Authenticate(context.Background(), token)
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if claims.UserID != "u1" || !claims.HasRole("admin") {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestGenerateKey_Unsupported(t *testing.T) {
+	if _, err := GenerateKey("HS512"); !errors.Is(err, ErrUnsupportedAlg) {
+		t.Errorf("GenerateKey(HS512) error = %v, want ErrUnsupportedAlg", err)
+	}
+}
+
+func TestKeySet_Rotate(t *testing.T) {
+	oldKey, _ := GenerateKey(AlgES256)
+	newKey, _ := GenerateKey(AlgEdDSA)
+	ks, _ := NewKeySet(oldKey)
+	a := NewAuthenticator(DefaultConfig(), WithKeySet(ks))
+	ctx := context.Background()
+
+	oldToken := accessToken(t, a, &auth.Claims{UserID: "u1"})
+	if err := ks.Rotate(newKey); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	newToken := accessToken(t, a, &auth.Claims{UserID: "u1"})
+
+	parsed, _, _ := jwt.NewParser().ParseUnverified(newToken, &jwtClaims{})
+	if parsed.Header["kid"] != newKey.ID {
+		t.Errorf("new token kid = %v, want %s", parsed.Header["kid"], newKey.ID)
+	}
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := a.Authenticate(ctx, token); err != nil {
+			t.Errorf("Authenticate(%s) error = %v", name, err)
+		}
+	}
+
+	if err := ks.Remove(newKey.ID); err == nil {
+		t.Error("Remove(signing key) should fail")
+	}
+	if err := ks.Remove(oldKey.ID); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := a.Authenticate(ctx, oldToken); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(removed key) error = %v, want ErrTokenInvalid", err)
+	}
+}
+
+func TestKeySet_AddDuplicate(t *testing.T) {
+	key, _ := GenerateKey(AlgEdDSA)
+	ks, _ := NewKeySet(key)
+	if err := ks.Add(key); err == nil {
+		t.Error("Add(duplicate) should fail")
+	}
+	if err := ks.SetSigningKey("missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("SetSigningKey(missing) error = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestKeySet_PublicOnlyCannotSign(t *testing.T) {
+	key, _ := GenerateKey(AlgES256)
+	pub, err := NewPublicKey(key.ID, key.Algorithm, key.PublicKey)
+	if err != nil {
+		t.Fatalf("NewPublicKey() error = %v", err)
+	}
+	ks, _ := NewKeySet(pub)
+	if err := ks.SetSigningKey(pub.ID); err == nil {
+		t.Error("SetSigningKey(public key) should fail")
+	}
+
+	a := NewAuthenticator(DefaultConfig(), WithKeySet(ks))
+	if _, err := a.GenerateToken(&auth.Claims{UserID: "u1"}); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("GenerateToken() error = %v, want ErrNoSigningKey", err)
+	}
+}
+
+func TestAuthenticator_RejectsAlgorithmConfusion(t *testing.T) {
+	key, _ := GenerateKey(AlgEdDSA)
+	ks, _ := NewKeySet(key)
+	a := NewAuthenticator(DefaultConfig(), WithKeySet(ks))
+	ctx := context.Background()
+
+	// 以公钥作为 HMAC 密钥签名的令牌
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwtClaims{UserID: "attacker"})
+	hs.Header["kid"] = key.ID
+	forged, _ := hs.SignedString([]byte(key.JWK().X))
+	if _, err := a.Authenticate(ctx, forged); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(HS256) error = %v, want ErrTokenInvalid", err)
+	}
+
+	// kid 指向 EdDSA 密钥但声明为 ES256
+	other, _ := GenerateKey(AlgES256)
+	es := jwt.NewWithClaims(jwt.SigningMethodES256, &jwtClaims{UserID: "attacker"})
+	es.Header["kid"] = key.ID
+	forged, _ = es.SignedString(other.PrivateKey)
+	if _, err := a.Authenticate(ctx, forged); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(alg mismatch) error = %v, want ErrTokenInvalid", err)
+	}
+
+	// HS256 模式不接受非对称令牌
+	cfg := DefaultConfig()
+	cfg.SecretKey = "test-secret"
+	token := accessToken(t, a, &auth.Claims{UserID: "u1"})
+	if _, err := NewAuthenticator(cfg).Authenticate(ctx, token); err != auth.ErrTokenInvalid {
+		t.Errorf("HS256 Authenticate(EdDSA) error = %v, want ErrTokenInvalid", err)
+	}
+}
+
+func TestJWK_RoundTrip(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgES256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			key, _ := GenerateKey(alg)
+			data, err := json.Marshal(key.JWK())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), `"d"`) {
+				t.Errorf("JWK should not contain private key: %s", data)
+			}
+
+			var jwk JWK
+			_ = json.Unmarshal(data, &jwk)
+			parsed, err := jwk.Key()
+			if err != nil {
+				t.Fatalf("JWK.Key() error = %v", err)
+			}
+			if parsed.ID != key.ID || parsed.Algorithm != alg || parsed.CanSign() {
+				t.Errorf("parsed = %+v", parsed)
+			}
+			if parsed.Thumbprint() != key.Thumbprint() {
+				t.Error("thumbprint should survive round trip")
+			}
+		})
+	}
+}
+
+func TestThumbprint_RFC7638(t *testing.T) {
+	// RFC 7638 第 3.1 节示例
+	jwk := JWK{
+		Kty: "RSA",
+		E:   "AQAB",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn6" +
+			"4tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91" +
+			"CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+	key, err := jwk.Key()
+	if err != nil {
+		t.Fatalf("JWK.Key() error = %v", err)
+	}
+	if got := key.Thumbprint(); got != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("Thumbprint() = %s", got)
+	}
+}
+
+func TestParsePEM(t *testing.T) {
+	key, _ := GenerateKey(AlgES256)
+	der, _ := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	der, _ = x509.MarshalPKIXPublicKey(key.PublicKey)
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	priv, err := ParsePrivateKeyPEM("k1", "", privPEM)
+	if err != nil {
+		t.Fatalf("ParsePrivateKeyPEM() error = %v", err)
+	}
+	if priv.ID != "k1" || priv.Algorithm != AlgES256 || !priv.CanSign() {
+		t.Errorf("private key = %+v", priv)
+	}
+	pub, err := ParsePublicKeyPEM("", "", pubPEM)
+	if err != nil {
+		t.Fatalf("ParsePublicKeyPEM() error = %v", err)
+	}
+	if pub.ID != key.ID {
+		t.Errorf("public key id = %s, want thumbprint %s", pub.ID, key.ID)
+	}
+
+	if _, err := ParsePrivateKeyPEM("", AlgRS256, privPEM); !errors.Is(err, ErrAlgorithmMismatch) {
+		t.Errorf("ParsePrivateKeyPEM(RS256, EC key) error = %v, want ErrAlgorithmMismatch", err)
+	}
+}
+
+func TestPlugin_Init_Asymmetric(t *testing.T) {
+	key, _ := GenerateKey(AlgRS256)
+	der, _ := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Plugin{}
+	err := p.Init(map[string]interface{}{
+		"algorithm":        "RS256",
+		"key_id":           "2024-01",
+		"private_key_file": path,
+		"cache":            cache.NewMemoryCache(),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if p.KeySet() == nil {
+		t.Fatal("KeySet() should not be nil")
+	}
+	token := accessToken(t, p.Authenticator(), &auth.Claims{UserID: "u1"})
+	if _, err := p.Authenticator().Authenticate(context.Background(), token); err != nil {
+		t.Errorf("Authenticate() error = %v", err)
+	}
+}
+
+func TestPlugin_Init_AsymmetricRequiresKey(t *testing.T) {
+	p := &Plugin{}
+	if err := p.Init(map[string]interface{}{"algorithm": "ES256"}); err == nil {
+		t.Error("Init() should require a private key or jwks_url")
+	}
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"slices"
+	"time"
+)
+
+// 认证方式（RFC 8176）
+const (
+	AMRPassword    = "pwd"
+	AMROTP         = "otp"
+	AMRHardwareKey = "hwk"
+	AMRSoftwareKey = "swk"
+	// AMRMFA 使用了多个认证因素
+	AMRMFA = "mfa"
+)
+
+// IsMFA 是否为多因素认证：包含 mfa，或包含两种及以上认证方式
+func IsMFA(amr []string) bool {
+	if slices.Contains(amr, AMRMFA) {
+		return true
+	}
+	var first string
+	for _, m := range amr {
+		if first == "" {
+			first = m
+		} else if m != first {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAMR 是否使用了指定认证方式
+func (c *Claims) HasAMR(method string) bool {
+	return slices.Contains(c.AMR, method)
+}
+
+// IsMFA 是否为多因素认证
+func (c *Claims) IsMFA() bool {
+	return IsMFA(c.AMR)
+}
+
+// AddAMR 记录完成的认证方式并更新 AuthTime，累计两种及以上方式时加入 mfa
+//
+// 二次验证通过后调用，再用新的 Claims 签发令牌或重建会话。
+func (c *Claims) AddAMR(at time.Time, methods ...string) {
+	for _, m := range methods {
+		if m != "" && !slices.Contains(c.AMR, m) {
+			c.AMR = append(c.AMR, m)
+		}
+	}
+	if !slices.Contains(c.AMR, AMRMFA) && IsMFA(c.AMR) {
+		c.AMR = append(c.AMR, AMRMFA)
+	}
+	c.AuthTime = at
+}
+
+// MFARequirement 敏感操作的认证强度要求，零值只要求多因素认证
+type MFARequirement struct {
+	// MaxAge 二次验证须在该时长内完成，0 表示不限
+	MaxAge time.Duration
+	// ACR 认证等级须为其中之一，空表示不限
+	ACR []string
+	// AMR 须使用其中一种认证方式（如只接受 hwk），空表示不限
+	AMR []string
+
+	now func() time.Time
+}
+
+// MFAOption 认证强度要求选项
+type MFAOption func(*MFARequirement)
+
+// WithMFAMaxAge 要求二次验证在 d 内完成
+func WithMFAMaxAge(d time.Duration) MFAOption {
+	return func(r *MFARequirement) {
+		r.MaxAge = d
+	}
+}
+
+// WithACR 要求认证等级为其中之一
+func WithACR(values ...string) MFAOption {
+	return func(r *MFARequirement) {
+		r.ACR = values
+	}
+}
+
+// WithAMR 要求使用其中一种认证方式
+func WithAMR(methods ...string) MFAOption {
+	return func(r *MFARequirement) {
+		r.AMR = methods
+	}
+}
+
+// NewMFARequirement 创建认证强度要求
+func NewMFARequirement(opts ...MFAOption) *MFARequirement {
+	r := &MFARequirement{now: time.Now}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Check 不满足要求时返回 ErrInsufficientAuth
+func (r *MFARequirement) Check(c *Claims) error {
+	if c == nil || !c.IsMFA() {
+		return ErrInsufficientAuth
+	}
+	if len(r.ACR) > 0 && !slices.Contains(r.ACR, c.ACR) {
+		return ErrInsufficientAuth
+	}
+	if len(r.AMR) > 0 && !slices.ContainsFunc(r.AMR, c.HasAMR) {
+		return ErrInsufficientAuth
+	}
+	if r.MaxAge > 0 {
+		now := time.Now
+		if r.now != nil {
+			now = r.now
+		}
+		if c.AuthTime.IsZero() || now().Sub(c.AuthTime) > r.MaxAge {
+			return ErrInsufficientAuth
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsMFA(t *testing.T) {
+	tests := []struct {
+		amr  []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{AMRPassword}, false},
+		{[]string{AMRPassword, AMRPassword}, false},
+		{[]string{AMRPassword, AMROTP}, true},
+		{[]string{AMRMFA}, true},
+	}
+	for _, tt := range tests {
+		if got := IsMFA(tt.amr); got != tt.want {
+			t.Errorf("IsMFA(%v) = %v, want %v", tt.amr, got, tt.want)
+		}
+	}
+}
+
+func TestClaims_AddAMR(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	c := &Claims{AMR: []string{AMRPassword}}
+
+	c.AddAMR(at, AMRPassword)
+	if c.IsMFA() || len(c.AMR) != 1 {
+		t.Fatalf("AMR = %v, want single factor", c.AMR)
+	}
+
+	c.AddAMR(at, AMROTP)
+	if !c.IsMFA() || !c.HasAMR(AMRMFA) || !c.HasAMR(AMROTP) || !c.AuthTime.Equal(at) {
+		t.Fatalf("claims = %+v, want mfa", c)
+	}
+
+	c.AddAMR(at.Add(time.Minute), AMROTP)
+	if len(c.AMR) != 3 {
+		t.Errorf("AMR = %v, want no duplicates", c.AMR)
+	}
+}
+
+func TestMFARequirement_Check(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	mfa := func(amr ...string) *Claims {
+		c := &Claims{AMR: []string{AMRPassword}, ACR: "urn:example:high"}
+		c.AddAMR(now.Add(-5*time.Minute), amr...)
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		opts   []MFAOption
+		claims *Claims
+		ok     bool
+	}{
+		{"nil claims", nil, nil, false},
+		{"single factor", nil, &Claims{AMR: []string{AMRPassword}}, false},
+		{"mfa", nil, mfa(AMROTP), true},
+		{"max age ok", []MFAOption{WithMFAMaxAge(10 * time.Minute)}, mfa(AMROTP), true},
+		{"max age exceeded", []MFAOption{WithMFAMaxAge(time.Minute)}, mfa(AMROTP), false},
+		{"max age without auth_time", []MFAOption{WithMFAMaxAge(time.Minute)}, &Claims{AMR: []string{AMRMFA}}, false},
+		{"acr ok", []MFAOption{WithACR("urn:example:high")}, mfa(AMROTP), true},
+		{"acr mismatch", []MFAOption{WithACR("urn:example:phr")}, mfa(AMROTP), false},
+		{"amr ok", []MFAOption{WithAMR(AMRHardwareKey)}, mfa(AMRHardwareKey), true},
+		{"amr mismatch", []MFAOption{WithAMR(AMRHardwareKey)}, mfa(AMROTP), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewMFARequirement(tt.opts...)
+			r.now = func() time.Time { return now }
+			err := r.Check(tt.claims)
+			if tt.ok && err != nil {
+				t.Errorf("Check() error = %v", err)
+			}
+			if !tt.ok && err != ErrInsufficientAuth {
+				t.Errorf("Check() error = %v, want ErrInsufficientAuth", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package oidc
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lookup 按点分路径读取声明，如 realm_access.roles、resource_access.api.roles
+//
+// 每一层优先匹配完整的剩余路径，因此 Auth0 等使用 URL 作为键的命名空间声明
+// （如 https://example.com/roles）也可以直接配置。
+func lookup(claims map[string]interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+	if v, ok := claims[path]; ok {
+		return v, true
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		child, ok := claims[path[:i]].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if v, ok := lookup(child, path[i+1:]); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// lookupString 读取字符串声明，数字按十进制转换（部分 IdP 的 sub 为数字）
+func lookupString(claims map[string]interface{}, path string) string {
+	v, ok := lookup(claims, path)
+	if !ok {
+		return ""
+	}
+	switch s := v.(type) {
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case json.Number:
+		return s.String()
+	}
+	return ""
+}
+
+// lookupStrings 读取字符串列表声明，支持 JSON 数组与空格分隔的字符串（RFC 6749 的 scope）
+func lookupStrings(claims map[string]interface{}, path string) []string {
+	v, ok := lookup(claims, path)
+	if !ok {
+		return nil
+	}
+	switch s := v.(type) {
+	case string:
+		return strings.Fields(s)
+	case []string:
+		return s
+	case []interface{}:
+		out := make([]string, 0, len(s))
+		for _, item := range s {
+			if str, ok := item.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// lookupTime 读取 NumericDate 声明
+func lookupTime(claims map[string]interface{}, name string) time.Time {
+	switch v := claims[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return time.Unix(n, 0)
+		}
+	}
+	return time.Time{}
+}
+
+// collect 合并多个路径下的字符串列表并去重
+func collect(claims map[string]interface{}, paths []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		for _, s := range lookupStrings(claims, p) {
+			if !seen[s] {
+				seen[s] = true
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package oidc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	claims := map[string]interface{}{
+		"sub":                        float64(1234567890),
+		"scope":                      "openid  profile orders:read",
+		"scp":                        []interface{}{"orders:write"},
+		"realm_access":               map[string]interface{}{"roles": []interface{}{"user", "admin"}},
+		"https://example.com/roles":  []interface{}{"editor"},
+		"https://example.com/tenant": "acme",
+		"resource_access": map[string]interface{}{
+			"orders.api": map[string]interface{}{"roles": []interface{}{"ops"}},
+		},
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"scope", []string{"openid", "profile", "orders:read"}},
+		{"scp", []string{"orders:write"}},
+		{"realm_access.roles", []string{"user", "admin"}},
+		{"https://example.com/roles", []string{"editor"}},
+		{"resource_access.orders.api.roles", []string{"ops"}},
+		{"realm_access.missing", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := lookupStrings(claims, tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lookupStrings(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if got := lookupString(claims, "sub"); got != "1234567890" {
+		t.Errorf("lookupString(sub) = %s", got)
+	}
+	if got := lookupString(claims, "https://example.com/tenant"); got != "acme" {
+		t.Errorf("lookupString(namespaced) = %s", got)
+	}
+
+	got := collect(claims, []string{"realm_access.roles", "https://example.com/roles", "realm_access.roles"})
+	if !reflect.DeepEqual(got, []string{"user", "admin", "editor"}) {
+		t.Errorf("collect() = %v", got)
+	}
+}
+
+func TestIsJWT(t *testing.T) {
+	tests := map[string]bool{
+		"eyJhbGciOiJSUzI1NiJ9.e30.c2ln": true,
+		"eyJ0eXAiOiJKV1QifQ.e30.c2ln":   false, // 缺少 alg
+		"opaque-token":                  false,
+		"a.b":                           false,
+		"not.base64!.sig":               false,
+	}
+	for token, want := range tests {
+		if got := isJWT(token); got != want {
+			t.Errorf("isJWT(%q) = %v, want %v", token, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiscoveryPath OpenID Connect 发现文档路径
+const DiscoveryPath = "/.well-known/openid-configuration"
+
+// discoveryRetryInterval 发现文档获取失败后的重试间隔，避免 IdP 故障时每个请求都访问 IdP
+const discoveryRetryInterval = 5 * time.Second
+
+// Discovery OpenID Connect 发现文档中使用到的字段
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	JWKSURI               string `json:"jwks_uri"`
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// discoverer 获取并缓存发现文档，成功后不再刷新（端点地址不会随密钥轮换变化）
+type discoverer struct {
+	url    string
+	issuer string
+	client *http.Client
+	now    func() time.Time
+
+	mu          sync.Mutex
+	doc         *Discovery
+	err         error
+	lastAttempt time.Time
+}
+
+// discoveryURL 由 issuer 推导发现文档地址
+func discoveryURL(issuer string) string {
+	return strings.TrimSuffix(issuer, "/") + DiscoveryPath
+}
+
+func (d *discoverer) get(ctx context.Context) (*Discovery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.doc != nil {
+		return d.doc, nil
+	}
+	if d.err != nil && d.now().Sub(d.lastAttempt) < discoveryRetryInterval {
+		return nil, d.err
+	}
+
+	d.lastAttempt = d.now()
+	d.doc, d.err = d.fetch(ctx)
+	return d.doc, d.err
+}
+
+func (d *discoverer) fetch(ctx context.Context) (*Discovery, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: fetch discovery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: fetch discovery: unexpected status %d", resp.StatusCode)
+	}
+
+	var doc Discovery
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("oidc: parse discovery: %w", err)
+	}
+	// OpenID Connect Discovery 第 4.3 节：文档中的 issuer 必须与配置完全一致
+	if doc.Issuer != d.issuer {
+		return nil, fmt.Errorf("oidc: discovery issuer %q does not match %q", doc.Issuer, d.issuer)
+	}
+	return &doc, nil
+}
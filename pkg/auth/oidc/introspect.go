@@ -0,0 +1,133 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/goupter/goupter/pkg/auth"
+)
+
+const introspectCachePrefix = "oidc:introspect:"
+
+// introspect 通过 RFC 7662 令牌内省验证不透明令牌，结果按令牌哈希缓存
+//
+// 有效结果的缓存时间不超过令牌剩余有效期；在缓存时间内 IdP 侧的吊销不会生效，
+// 需要及时吊销时应调小 IntrospectionCacheTTL。
+func (a *Authenticator) introspect(ctx context.Context, token string) (map[string]interface{}, error) {
+	cacheKey := introspectCachePrefix + tokenHash(token)
+	var resp map[string]interface{}
+	if err := a.cache.Get(ctx, cacheKey, &resp); err == nil {
+		return a.checkIntrospection(resp)
+	}
+
+	endpoint, err := a.introspectionURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err = a.requestIntrospection(ctx, endpoint, token)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := a.config.IntrospectionCacheTTL
+	if exp := lookupTime(resp, "exp"); !exp.IsZero() {
+		if remaining := exp.Sub(a.now()); remaining < ttl {
+			ttl = remaining
+		}
+	}
+	if ttl > 0 {
+		_ = a.cache.Set(ctx, cacheKey, resp, ttl)
+	}
+	return a.checkIntrospection(resp)
+}
+
+func (a *Authenticator) requestIntrospection(ctx context.Context, endpoint, token string) (map[string]interface{}, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// RFC 7662 第 2.1 节：资源服务器以 client_secret_basic 认证
+	req.SetBasicAuth(url.QueryEscape(a.config.ClientID), url.QueryEscape(a.config.ClientSecret))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: introspect: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: introspect: unexpected status %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("oidc: parse introspection: %w", err)
+	}
+	return result, nil
+}
+
+// checkIntrospection 校验内省结果的 active、exp、nbf、iss 与 aud
+func (a *Authenticator) checkIntrospection(resp map[string]interface{}) (map[string]interface{}, error) {
+	if active, _ := resp["active"].(bool); !active {
+		return nil, auth.ErrTokenInvalid
+	}
+	now := a.now()
+	if exp := lookupTime(resp, "exp"); !exp.IsZero() && !now.Before(exp.Add(a.config.Leeway)) {
+		return nil, auth.ErrTokenExpired
+	}
+	if nbf := lookupTime(resp, "nbf"); !nbf.IsZero() && now.Add(a.config.Leeway).Before(nbf) {
+		return nil, auth.ErrTokenInvalid
+	}
+	if iss, ok := resp["iss"].(string); ok && iss != a.config.Issuer {
+		return nil, auth.ErrTokenInvalid
+	}
+	if _, ok := resp["aud"]; ok && !a.config.SkipAudienceCheck && !a.audienceAllowed(lookupStrings(resp, "aud")) {
+		return nil, auth.ErrTokenInvalid
+	}
+	return resp, nil
+}
+
+func (a *Authenticator) audienceAllowed(aud []string) bool {
+	for _, want := range a.config.Audience {
+		for _, got := range aud {
+			if got == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// introspectionURL 内省端点，未配置时从发现文档获取
+func (a *Authenticator) introspectionURL(ctx context.Context) (string, error) {
+	if a.config.IntrospectionURL != "" {
+		return a.config.IntrospectionURL, nil
+	}
+	doc, err := a.discovery.get(ctx)
+	if err != nil {
+		return "", err
+	}
+	if doc.IntrospectionEndpoint == "" {
+		return "", ErrIntrospectionUnavailable
+	}
+	return doc.IntrospectionEndpoint, nil
+}
+
+// evictIntrospection 删除令牌的内省缓存，使下次请求重新向 IdP 内省
+func (a *Authenticator) evictIntrospection(ctx context.Context, token string) error {
+	return a.cache.Delete(ctx, introspectCachePrefix+tokenHash(token))
+}
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
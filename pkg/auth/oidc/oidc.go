@@ -0,0 +1,408 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/goupter/goupter/pkg/auth"
+	authjwt "github.com/goupter/goupter/pkg/auth/jwt"
+	"github.com/goupter/goupter/pkg/cache"
+	"github.com/goupter/goupter/pkg/server/middleware"
+)
+
+const PluginName = "oidc"
+
+// 错误定义
+var (
+	// ErrNotSupported 资源服务器只验证令牌，不签发或刷新
+	ErrNotSupported = errors.New("oidc: operation not supported by resource server")
+	// ErrIntrospectionUnavailable 未配置内省端点或客户端凭据
+	ErrIntrospectionUnavailable = errors.New("oidc: introspection endpoint unavailable")
+	// ErrAudienceRequired 未配置 Audience，且未显式设置 SkipAudienceCheck
+	ErrAudienceRequired = errors.New("oidc: audience is required, set skip_audience_check to accept tokens for any audience")
+)
+
+func init() {
+	auth.Register(&Plugin{})
+}
+
+// Plugin OAuth2/OIDC 资源服务器插件，验证外部 IdP（Keycloak、Auth0 等）签发的令牌
+type Plugin struct {
+	config        *Config
+	authenticator *Authenticator
+}
+
+// Config OIDC 配置
+//
+// JWT 访问令牌通过发现文档中的 jwks_uri 验签；不透明令牌在配置 ClientID/ClientSecret 后
+// 通过 RFC 7662 内省验证。声明路径为点分路径，如 Keycloak 的 realm_access.roles。
+type Config struct {
+	// Issuer IdP 地址，必须与令牌的 iss 完全一致
+	Issuer string `json:"issuer"`
+	// Audience 接受的 aud，任一匹配即可；必须配置，除非设置 SkipAudienceCheck
+	Audience []string `json:"audience"`
+	// SkipAudienceCheck 不校验 aud，IdP 为其他服务签发的令牌也会被接受，仅在 IdP 只服务本应用时使用
+	SkipAudienceCheck bool `json:"skip_audience_check"`
+	// DiscoveryURL 发现文档地址，默认 Issuer + /.well-known/openid-configuration
+	DiscoveryURL string `json:"discovery_url"`
+	// JWKSURL、IntrospectionURL 覆盖发现文档中的端点
+	JWKSURL          string `json:"jwks_url"`
+	IntrospectionURL string `json:"introspection_url"`
+	// ClientID、ClientSecret 内省时的客户端凭据
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+
+	UserIDClaim   string   `json:"user_id_claim"`
+	UsernameClaim string   `json:"username_claim"`
+	RolesClaims   []string `json:"roles_claims"`
+	ScopesClaims  []string `json:"scopes_claims"`
+
+	// Leeway 校验 exp/nbf 时允许的时钟偏差
+	Leeway                time.Duration `json:"leeway"`
+	JWKSCacheTTL          time.Duration `json:"jwks_cache_ttl"`
+	IntrospectionCacheTTL time.Duration `json:"introspection_cache_ttl"`
+	HTTPTimeout           time.Duration `json:"http_timeout"`
+}
+
+// DefaultConfig 默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		UserIDClaim:           "sub",
+		UsernameClaim:         "preferred_username",
+		RolesClaims:           []string{"roles"},
+		ScopesClaims:          []string{"scope", "scp"},
+		Leeway:                30 * time.Second,
+		JWKSCacheTTL:          10 * time.Minute,
+		IntrospectionCacheTTL: time.Minute,
+		HTTPTimeout:           10 * time.Second,
+	}
+}
+
+func (p *Plugin) Name() string { return PluginName }
+
+func (p *Plugin) Init(config map[string]interface{}) error {
+	p.config = DefaultConfig()
+
+	for key, dst := range map[string]*string{
+		"issuer":            &p.config.Issuer,
+		"discovery_url":     &p.config.DiscoveryURL,
+		"jwks_url":          &p.config.JWKSURL,
+		"introspection_url": &p.config.IntrospectionURL,
+		"client_id":         &p.config.ClientID,
+		"client_secret":     &p.config.ClientSecret,
+		"user_id_claim":     &p.config.UserIDClaim,
+		"username_claim":    &p.config.UsernameClaim,
+	} {
+		if v, ok := config[key].(string); ok && v != "" {
+			*dst = v
+		}
+	}
+	for key, dst := range map[string]*[]string{
+		"audience":      &p.config.Audience,
+		"roles_claims":  &p.config.RolesClaims,
+		"scopes_claims": &p.config.ScopesClaims,
+	} {
+		if v, ok := stringList(config[key]); ok {
+			*dst = v
+		}
+	}
+	for key, dst := range map[string]*time.Duration{
+		"leeway":                  &p.config.Leeway,
+		"jwks_cache_ttl":          &p.config.JWKSCacheTTL,
+		"introspection_cache_ttl": &p.config.IntrospectionCacheTTL,
+		"http_timeout":            &p.config.HTTPTimeout,
+	} {
+		if v, ok := config[key].(string); ok {
+			if d, err := time.ParseDuration(v); err == nil {
+				*dst = d
+			}
+		}
+	}
+
+	if v, ok := config["skip_audience_check"].(bool); ok {
+		p.config.SkipAudienceCheck = v
+	}
+
+	if p.config.Issuer == "" {
+		return errors.New("oidc: issuer is required")
+	}
+	if len(p.config.Audience) == 0 && !p.config.SkipAudienceCheck {
+		return ErrAudienceRequired
+	}
+
+	var opts []Option
+	// 使用注入的共享缓存，多实例部署时内省结果与吊销在实例间一致
+	if c, ok := config["cache"].(cache.Cache); ok {
+		opts = append(opts, WithCache(c))
+	}
+	p.authenticator = NewAuthenticator(p.config, opts...)
+	return nil
+}
+
+// stringList 配置值可以是字符串（逗号分隔）或字符串数组
+func stringList(v interface{}) ([]string, bool) {
+	switch s := v.(type) {
+	case string:
+		var out []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+		return out, true
+	case []string:
+		return s, true
+	case []interface{}:
+		out := make([]string, 0, len(s))
+		for _, item := range s {
+			out = append(out, fmt.Sprint(item))
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func (p *Plugin) Authenticator() auth.Authenticator {
+	return p.authenticator
+}
+
+// Authenticator OIDC 资源服务器鉴权器
+type Authenticator struct {
+	config    *Config
+	client    *http.Client
+	cache     cache.Cache
+	discovery *discoverer
+	keys      authjwt.KeyResolver
+	now       func() time.Time
+}
+
+type Option func(*Authenticator)
+
+// WithHTTPClient 设置访问 IdP 的 HTTP 客户端
+func WithHTTPClient(c *http.Client) Option {
+	return func(a *Authenticator) { a.client = c }
+}
+
+// WithCache 设置内省结果缓存，默认内存缓存；多实例部署时建议使用 Redis
+func WithCache(c cache.Cache) Option {
+	return func(a *Authenticator) { a.cache = c }
+}
+
+// WithKeyResolver 设置验签密钥来源，默认从 jwks_uri 获取
+func WithKeyResolver(r authjwt.KeyResolver) Option {
+	return func(a *Authenticator) { a.keys = r }
+}
+
+// NewAuthenticator 创建 OIDC 鉴权器，发现文档与 JWKS 在首次验证时获取
+//
+// 未配置 Audience 且未设置 SkipAudienceCheck 时，Authenticate 返回 ErrAudienceRequired。
+func NewAuthenticator(config *Config, opts ...Option) *Authenticator {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	a := &Authenticator{config: config, now: time.Now}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.client == nil {
+		a.client = &http.Client{Timeout: config.HTTPTimeout}
+	}
+	if a.cache == nil {
+		a.cache = cache.NewMemoryCache()
+	}
+
+	url := config.DiscoveryURL
+	if url == "" {
+		url = discoveryURL(config.Issuer)
+	}
+	a.discovery = &discoverer{url: url, issuer: config.Issuer, client: a.client, now: a.now}
+	if a.keys == nil {
+		a.keys = &lazyKeySet{a: a}
+	}
+	return a
+}
+
+// Discovery 获取发现文档
+func (a *Authenticator) Discovery(ctx context.Context) (*Discovery, error) {
+	return a.discovery.get(ctx)
+}
+
+// Authenticate 验证访问令牌：JWT 本地验签，其他令牌通过内省验证
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (*auth.Claims, error) {
+	var (
+		claims map[string]interface{}
+		err    error
+	)
+	if len(a.config.Audience) == 0 && !a.config.SkipAudienceCheck {
+		return nil, ErrAudienceRequired
+	}
+	if isJWT(token) {
+		claims, err = a.verifyJWT(ctx, token)
+	} else if a.config.ClientID != "" {
+		claims, err = a.introspect(ctx, token)
+	} else {
+		return nil, auth.ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.mapClaims(claims), nil
+}
+
+// verifyJWT 验签并校验 iss、aud、exp、nbf
+//
+// 获取发现文档或 JWKS 失败时原样返回错误，与内省一致，由上层按服务不可用处理。
+func (a *Authenticator) verifyJWT(ctx context.Context, token string) (map[string]interface{}, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{authjwt.AlgRS256, authjwt.AlgES256, authjwt.AlgEdDSA}),
+		jwt.WithIssuer(a.config.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(a.config.Leeway),
+		jwt.WithTimeFunc(a.now),
+	}
+	if !a.config.SkipAudienceCheck {
+		opts = append(opts, jwt.WithAudience(a.config.Audience...))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, authjwt.ErrKeyNotFound
+		}
+		key, err := a.keys.ResolveKey(ctx, kid)
+		if err != nil {
+			if errors.Is(err, authjwt.ErrKeyNotFound) {
+				return nil, err
+			}
+			return nil, &resolveError{err: err}
+		}
+		if key.Algorithm != t.Method.Alg() {
+			return nil, authjwt.ErrAlgorithmMismatch
+		}
+		return key.PublicKey, nil
+	}, opts...)
+	if err != nil {
+		var re *resolveError
+		if errors.As(err, &re) {
+			return nil, re.err
+		}
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, auth.ErrTokenExpired
+		}
+		return nil, auth.ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+// resolveError 验签密钥获取失败，与令牌本身无关
+type resolveError struct{ err error }
+
+func (e *resolveError) Error() string { return e.err.Error() }
+func (e *resolveError) Unwrap() error { return e.err }
+
+// mapClaims 按配置的声明路径转换为 auth.Claims，原始声明保存在 Extra
+func (a *Authenticator) mapClaims(claims map[string]interface{}) *auth.Claims {
+	username := lookupString(claims, a.config.UsernameClaim)
+	if username == "" {
+		// RFC 7662 内省响应使用 username
+		username = lookupString(claims, "username")
+	}
+	return &auth.Claims{
+		UserID:    lookupString(claims, a.config.UserIDClaim),
+		Username:  username,
+		Roles:     collect(claims, a.config.RolesClaims),
+		Scopes:    collect(claims, a.config.ScopesClaims),
+		Extra:     claims,
+		IssuedAt:  lookupTime(claims, "iat"),
+		ExpiresAt: lookupTime(claims, "exp"),
+		TokenID:   lookupString(claims, "jti"),
+		AMR:       lookupStrings(claims, "amr"),
+		ACR:       lookupString(claims, "acr"),
+		AuthTime:  lookupTime(claims, "auth_time"),
+	}
+}
+
+// GenerateToken 不支持，令牌由 IdP 签发
+func (a *Authenticator) GenerateToken(*auth.Claims) (*auth.TokenPair, error) {
+	return nil, ErrNotSupported
+}
+
+// RefreshToken 不支持，刷新由客户端向 IdP 发起
+func (a *Authenticator) RefreshToken(context.Context, string) (*auth.TokenPair, error) {
+	return nil, ErrNotSupported
+}
+
+// RevokeToken 删除本地内省缓存；IdP 侧的吊销需由客户端调用 IdP 完成
+func (a *Authenticator) RevokeToken(ctx context.Context, token string) error {
+	return a.evictIntrospection(ctx, token)
+}
+
+// Validator 转换为 HTTP 中间件的令牌验证器
+func (a *Authenticator) Validator() middleware.TokenValidator {
+	return middleware.AuthenticatorValidator(a)
+}
+
+// isJWT 判断令牌是否为 JWS 紧凑序列化，不透明令牌交给内省
+func isJWT(token string) bool {
+	header, _, ok := strings.Cut(token, ".")
+	if !ok || strings.Count(token, ".") != 2 {
+		return false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(header)
+	if err != nil {
+		return false
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	return json.Unmarshal(data, &h) == nil && h.Alg != ""
+}
+
+// lazyKeySet 在首次验签时根据发现文档创建 RemoteKeySet，获取失败时下次重试
+type lazyKeySet struct {
+	a *Authenticator
+
+	mu     sync.Mutex
+	remote *authjwt.RemoteKeySet
+}
+
+func (l *lazyKeySet) ResolveKey(ctx context.Context, kid string) (*authjwt.Key, error) {
+	remote, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return remote.ResolveKey(ctx, kid)
+}
+
+func (l *lazyKeySet) get(ctx context.Context) (*authjwt.RemoteKeySet, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.remote != nil {
+		return l.remote, nil
+	}
+
+	url := l.a.config.JWKSURL
+	if url == "" {
+		doc, err := l.a.discovery.get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if url = doc.JWKSURI; url == "" {
+			return nil, errors.New("oidc: discovery has no jwks_uri")
+		}
+	}
+	l.remote = authjwt.NewRemoteKeySet(url,
+		authjwt.WithHTTPClient(l.a.client),
+		authjwt.WithCacheTTL(l.a.config.JWKSCacheTTL),
+	)
+	return l.remote, nil
+}
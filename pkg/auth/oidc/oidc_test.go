@@ -0,0 +1,352 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/goupter/goupter/pkg/auth"
+	authjwt "github.com/goupter/goupter/pkg/auth/jwt"
+	"github.com/goupter/goupter/pkg/cache"
+)
+
+// stubIdP 本地 IdP：发现文档、JWKS 与内省端点
+type stubIdP struct {
+	*httptest.Server
+	key            *authjwt.Key
+	introspections map[string]map[string]interface{}
+	introspectHits atomic.Int32
+	discoveryHits  atomic.Int32
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	t.Helper()
+	key, err := authjwt.GenerateKey(authjwt.AlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, _ := authjwt.NewKeySet(key)
+	idp := &stubIdP{key: key, introspections: map[string]map[string]interface{}{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(DiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		idp.discoveryHits.Add(1)
+		_ = json.NewEncoder(w).Encode(Discovery{
+			Issuer:                idp.URL,
+			JWKSURI:               idp.URL + authjwt.JWKSPath,
+			IntrospectionEndpoint: idp.URL + "/introspect",
+		})
+	})
+	mux.Handle(authjwt.JWKSPath, authjwt.JWKSHandler(ks, time.Minute))
+	mux.HandleFunc("/introspect", func(w http.ResponseWriter, r *http.Request) {
+		idp.introspectHits.Add(1)
+		if id, secret, ok := r.BasicAuth(); !ok || id != "api" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp, ok := idp.introspections[r.PostFormValue("token")]
+		if !ok {
+			resp = map[string]interface{}{"active": false}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// sign 以 IdP 密钥签发令牌，未指定的 iss/exp 使用默认值
+func (idp *stubIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	if _, ok := claims["iss"]; !ok {
+		claims["iss"] = idp.URL
+	}
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = idp.key.ID
+	s, err := token.SignedString(idp.key.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func (idp *stubIdP) config() *Config {
+	cfg := DefaultConfig()
+	cfg.Issuer = idp.URL
+	cfg.Audience = []string{"orders-api"}
+	cfg.RolesClaims = []string{"realm_access.roles", "resource_access.orders-api.roles"}
+	return cfg
+}
+
+func TestAuthenticator_JWT(t *testing.T) {
+	idp := newStubIdP(t)
+	a := NewAuthenticator(idp.config())
+
+	token := idp.sign(t, jwt.MapClaims{
+		"sub":                "u1",
+		"preferred_username": "alice",
+		"aud":                []string{"account", "orders-api"},
+		"scope":              "openid orders:read",
+		"jti":                "t1",
+		"realm_access":       map[string]interface{}{"roles": []string{"user"}},
+		"resource_access": map[string]interface{}{
+			"orders-api": map[string]interface{}{"roles": []string{"admin", "user"}},
+		},
+	})
+	claims, err := a.Authenticate(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if claims.UserID != "u1" || claims.Username != "alice" || claims.TokenID != "t1" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if len(claims.Roles) != 2 || !claims.HasRole("admin") || !claims.HasRole("user") {
+		t.Errorf("roles = %v", claims.Roles)
+	}
+	if !claims.HasScope("orders:read") || !claims.HasScope("openid") {
+		t.Errorf("scopes = %v", claims.Scopes)
+	}
+	if claims.Extra["preferred_username"] != "alice" {
+		t.Errorf("extra = %v", claims.Extra)
+	}
+}
+
+func TestAuthenticator_JWT_Validation(t *testing.T) {
+	idp := newStubIdP(t)
+	a := NewAuthenticator(idp.config())
+	ctx := context.Background()
+	now := time.Now()
+
+	other, _ := authjwt.GenerateKey(authjwt.AlgRS256)
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": idp.URL, "aud": "orders-api", "exp": now.Add(time.Hour).Unix(),
+	})
+	forged.Header["kid"] = idp.key.ID
+	forgedToken, _ := forged.SignedString(other.PrivateKey)
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"wrong issuer", idp.sign(t, jwt.MapClaims{"iss": "https://evil.example.com", "aud": "orders-api"}), auth.ErrTokenInvalid},
+		{"wrong audience", idp.sign(t, jwt.MapClaims{"aud": "billing-api"}), auth.ErrTokenInvalid},
+		{"expired", idp.sign(t, jwt.MapClaims{"aud": "orders-api", "exp": now.Add(-time.Minute).Unix()}), auth.ErrTokenExpired},
+		{"not yet valid", idp.sign(t, jwt.MapClaims{"aud": "orders-api", "nbf": now.Add(time.Hour).Unix()}), auth.ErrTokenInvalid},
+		{"missing exp", idp.sign(t, jwt.MapClaims{"aud": "orders-api", "exp": nil}), auth.ErrTokenInvalid},
+		{"bad signature", forgedToken, auth.ErrTokenInvalid},
+		{"opaque without introspection", "opaque-token", auth.ErrTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.Authenticate(ctx, tt.token); err != tt.want {
+				t.Errorf("Authenticate() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_JWT_Leeway(t *testing.T) {
+	idp := newStubIdP(t)
+	a := NewAuthenticator(idp.config())
+	exp := time.Now().Add(time.Hour)
+	token := idp.sign(t, jwt.MapClaims{"sub": "u1", "aud": "orders-api", "exp": exp.Unix()})
+
+	a.now = func() time.Time { return exp.Add(10 * time.Second) }
+	if _, err := a.Authenticate(context.Background(), token); err != nil {
+		t.Errorf("Authenticate(within leeway) error = %v", err)
+	}
+	a.now = func() time.Time { return exp.Add(time.Minute) }
+	if _, err := a.Authenticate(context.Background(), token); err != auth.ErrTokenExpired {
+		t.Errorf("Authenticate(after leeway) error = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestAuthenticator_DiscoveryIssuerMismatch(t *testing.T) {
+	idp := newStubIdP(t)
+	cfg := idp.config()
+	cfg.DiscoveryURL = idp.URL + DiscoveryPath
+	cfg.Issuer = "https://other.example.com"
+	a := NewAuthenticator(cfg)
+
+	if _, err := a.Discovery(context.Background()); err == nil {
+		t.Fatal("Discovery() should reject a mismatched issuer")
+	}
+	// 发现文档不可用是配置或 IdP 故障，原样返回而不是令牌无效
+	token := idp.sign(t, jwt.MapClaims{"iss": cfg.Issuer, "aud": "orders-api"})
+	_, err := a.Authenticate(context.Background(), token)
+	var authErr *auth.AuthError
+	if err == nil || errors.As(err, &authErr) {
+		t.Errorf("Authenticate() error = %v, want discovery error", err)
+	}
+}
+
+func TestAuthenticator_JWKSUnavailable(t *testing.T) {
+	idp := newStubIdP(t)
+	cfg := idp.config()
+	cfg.JWKSURL = idp.URL + "/missing-jwks"
+	a := NewAuthenticator(cfg)
+
+	token := idp.sign(t, jwt.MapClaims{"sub": "u1", "aud": "orders-api"})
+	_, err := a.Authenticate(context.Background(), token)
+	var authErr *auth.AuthError
+	if err == nil || errors.As(err, &authErr) {
+		t.Errorf("Authenticate() error = %v, want jwks fetch error", err)
+	}
+}
+
+func TestAuthenticator_AudienceRequired(t *testing.T) {
+	idp := newStubIdP(t)
+	cfg := idp.config()
+	cfg.Audience = nil
+	token := idp.sign(t, jwt.MapClaims{"sub": "u1", "aud": "billing-api"})
+
+	if _, err := NewAuthenticator(cfg).Authenticate(context.Background(), token); err != ErrAudienceRequired {
+		t.Errorf("Authenticate(no audience) error = %v, want ErrAudienceRequired", err)
+	}
+
+	cfg.SkipAudienceCheck = true
+	if _, err := NewAuthenticator(cfg).Authenticate(context.Background(), token); err != nil {
+		t.Errorf("Authenticate(skip_audience_check) error = %v", err)
+	}
+}
+
+func TestAuthenticator_Introspection(t *testing.T) {
+	idp := newStubIdP(t)
+	exp := time.Now().Add(time.Hour).Unix()
+	idp.introspections["opaque-active"] = map[string]interface{}{
+		"active": true, "sub": "u2", "username": "bob", "scope": "orders:write",
+		"iss": idp.URL, "aud": "orders-api", "exp": exp, "roles": []string{"ops"},
+	}
+	idp.introspections["opaque-other-aud"] = map[string]interface{}{
+		"active": true, "sub": "u3", "aud": "billing-api", "exp": exp,
+	}
+
+	cfg := idp.config()
+	cfg.ClientID, cfg.ClientSecret = "api", "s3cret"
+	cfg.RolesClaims = []string{"roles"}
+	a := NewAuthenticator(cfg)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		claims, err := a.Authenticate(ctx, "opaque-active")
+		if err != nil {
+			t.Fatalf("Authenticate() error = %v", err)
+		}
+		if claims.UserID != "u2" || claims.Username != "bob" || !claims.HasScope("orders:write") || !claims.HasRole("ops") {
+			t.Errorf("claims = %+v", claims)
+		}
+	}
+	if hits := idp.introspectHits.Load(); hits != 1 {
+		t.Errorf("introspection requests = %d, want 1 (cached)", hits)
+	}
+
+	// 吊销后删除本地缓存，下次重新内省
+	delete(idp.introspections, "opaque-active")
+	if err := a.RevokeToken(ctx, "opaque-active"); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if _, err := a.Authenticate(ctx, "opaque-active"); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(revoked) error = %v, want ErrTokenInvalid", err)
+	}
+
+	if _, err := a.Authenticate(ctx, "opaque-other-aud"); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(wrong aud) error = %v, want ErrTokenInvalid", err)
+	}
+	if _, err := a.Authenticate(ctx, "opaque-unknown"); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(inactive) error = %v, want ErrTokenInvalid", err)
+	}
+	if hits := idp.discoveryHits.Load(); hits != 1 {
+		t.Errorf("discovery requests = %d, want 1", hits)
+	}
+}
+
+func TestAuthenticator_Introspection_BadCredentials(t *testing.T) {
+	idp := newStubIdP(t)
+	cfg := idp.config()
+	cfg.ClientID, cfg.ClientSecret = "api", "wrong"
+	a := NewAuthenticator(cfg)
+
+	if _, err := a.Authenticate(context.Background(), "opaque"); err == nil {
+		t.Error("Authenticate() should fail when the IdP rejects the client")
+	}
+}
+
+func TestAuthenticator_NotSupported(t *testing.T) {
+	a := NewAuthenticator(&Config{Issuer: "https://idp.example.com"})
+	if _, err := a.GenerateToken(&auth.Claims{UserID: "u1"}); err != ErrNotSupported {
+		t.Errorf("GenerateToken() error = %v, want ErrNotSupported", err)
+	}
+	if _, err := a.RefreshToken(context.Background(), "rt"); err != ErrNotSupported {
+		t.Errorf("RefreshToken() error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestPlugin_Init(t *testing.T) {
+	idp := newStubIdP(t)
+	p := &Plugin{}
+	err := p.Init(map[string]interface{}{
+		"issuer":       idp.URL,
+		"audience":     "orders-api",
+		"roles_claims": []interface{}{"realm_access.roles"},
+		"leeway":       "5s",
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	token := idp.sign(t, jwt.MapClaims{
+		"sub": "u1", "aud": "orders-api",
+		"realm_access": map[string]interface{}{"roles": []string{"admin"}},
+	})
+	claims, err := p.Authenticator().Authenticate(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if !claims.HasRole("admin") {
+		t.Errorf("roles = %v", claims.Roles)
+	}
+
+	if err := (&Plugin{}).Init(map[string]interface{}{}); err == nil {
+		t.Error("Init() should require issuer")
+	}
+	if err := (&Plugin{}).Init(map[string]interface{}{"issuer": idp.URL}); err != ErrAudienceRequired {
+		t.Errorf("Init(no audience) error = %v, want ErrAudienceRequired", err)
+	}
+	if err := (&Plugin{}).Init(map[string]interface{}{"issuer": idp.URL, "skip_audience_check": true}); err != nil {
+		t.Errorf("Init(skip_audience_check) error = %v", err)
+	}
+}
+
+func TestPlugin_Init_Cache(t *testing.T) {
+	idp := newStubIdP(t)
+	idp.introspections["opaque"] = map[string]interface{}{"active": true, "sub": "u1", "aud": "orders-api"}
+	shared := cache.NewMemoryCache()
+
+	// 共享同一缓存的两个实例只内省一次
+	for i := 0; i < 2; i++ {
+		p := &Plugin{}
+		if err := p.Init(map[string]interface{}{
+			"issuer": idp.URL, "audience": "orders-api",
+			"client_id": "api", "client_secret": "s3cret",
+			"cache": shared,
+		}); err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		if _, err := p.Authenticator().Authenticate(context.Background(), "opaque"); err != nil {
+			t.Fatalf("Authenticate() error = %v", err)
+		}
+	}
+	if hits := idp.introspectHits.Load(); hits != 1 {
+		t.Errorf("introspection requests = %d, want 1 (shared cache)", hits)
+	}
+}
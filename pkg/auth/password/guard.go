@@ -0,0 +1,257 @@
+package password
+
+import (
+	"context"
+	"time"
+
+	"github.com/goupter/goupter/pkg/cache"
+	"github.com/goupter/goupter/pkg/errors"
+)
+
+// Guard 默认值
+const (
+	DefaultGuardPrefix     = "login:"
+	DefaultMaxUserFailures = 5
+	DefaultMaxIPFailures   = 50
+	DefaultFailureWindow   = 15 * time.Minute
+	DefaultBaseLockout     = time.Minute
+	DefaultMaxLockout      = 24 * time.Hour
+)
+
+// Guard 登录防暴力破解
+//
+// 按用户与 IP 分别统计失败次数，两次失败间隔超过统计窗口后重新计数。达到阈值后锁定，
+// 锁定时长从 BaseLockout 起每次翻倍，最长 MaxLockout；锁定后失败次数清零，解锁后再次达到阈值
+// 才会继续锁定。用户被锁定返回 errors.ErrAccountLocked，IP 被锁定返回 errors.ErrTooManyRequests，
+// Details 中的 retry_after 为剩余秒数。计数保存在 cache.Cache 中，多实例部署应使用 Redis。
+type Guard struct {
+	cache           cache.Cache
+	prefix          string
+	maxUserFailures int64
+	maxIPFailures   int64
+	window          time.Duration
+	baseLockout     time.Duration
+	maxLockout      time.Duration
+	now             func() time.Time
+}
+
+// GuardOption Guard 选项
+type GuardOption func(*Guard)
+
+// WithGuardPrefix 设置缓存键前缀，默认 login:
+func WithGuardPrefix(prefix string) GuardOption {
+	return func(g *Guard) {
+		g.prefix = prefix
+	}
+}
+
+// WithMaxFailures 设置用户与 IP 的失败阈值，0 表示不限制
+func WithMaxFailures(user, ip int) GuardOption {
+	return func(g *Guard) {
+		g.maxUserFailures = int64(user)
+		g.maxIPFailures = int64(ip)
+	}
+}
+
+// WithFailureWindow 设置失败次数的统计窗口
+func WithFailureWindow(d time.Duration) GuardOption {
+	return func(g *Guard) {
+		g.window = d
+	}
+}
+
+// WithLockout 设置首次锁定时长与最长锁定时长
+func WithLockout(base, max time.Duration) GuardOption {
+	return func(g *Guard) {
+		g.baseLockout = base
+		g.maxLockout = max
+	}
+}
+
+// NewGuard 创建 Guard
+func NewGuard(c cache.Cache, opts ...GuardOption) *Guard {
+	g := &Guard{
+		cache:           c,
+		prefix:          DefaultGuardPrefix,
+		maxUserFailures: DefaultMaxUserFailures,
+		maxIPFailures:   DefaultMaxIPFailures,
+		window:          DefaultFailureWindow,
+		baseLockout:     DefaultBaseLockout,
+		maxLockout:      DefaultMaxLockout,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Login 在 Guard 保护下执行校验
+//
+// 用户或 IP 已锁定时不执行 verify。verify 返回错误码为 errors.CodePasswordError 的错误时记为一次失败，
+// 用户不存在也应返回 errors.ErrPasswordError，避免暴露用户是否存在；返回其他错误（如
+// errors.ErrUserDisabled）时原样返回，不计入失败；返回 nil 时清除该用户的失败记录。
+//
+//	err := guard.Login(ctx, req.Username, c.ClientIP(), func(ctx context.Context) error {
+//		user, err := users.FindByUsername(ctx, req.Username)
+//		if err != nil {
+//			_, err = hasher.Compare(req.Password, "")
+//			return err
+//		}
+//		...
+//	})
+func (g *Guard) Login(ctx context.Context, user, ip string, verify func(ctx context.Context) error) error {
+	if err := g.Check(ctx, user, ip); err != nil {
+		return err
+	}
+
+	err := verify(ctx)
+	switch {
+	case err == nil:
+		// 登录已成功；清除失败记录出错时，残留的计数最多使下次锁定提前
+		_ = g.Succeed(ctx, user)
+		return nil
+	case errors.IsCode(err, errors.CodePasswordError):
+		return g.Fail(ctx, user, ip)
+	default:
+		return err
+	}
+}
+
+// Check 检查用户与 IP 是否被锁定，空字符串不检查
+func (g *Guard) Check(ctx context.Context, user, ip string) error {
+	if user != "" {
+		if wait, err := g.lockRemaining(ctx, "user:"+user); err != nil {
+			return err
+		} else if wait > 0 {
+			return lockedError(errors.ErrAccountLocked, wait)
+		}
+	}
+	if ip != "" {
+		if wait, err := g.lockRemaining(ctx, "ip:"+ip); err != nil {
+			return err
+		} else if wait > 0 {
+			return lockedError(errors.ErrTooManyRequests, wait)
+		}
+	}
+	return nil
+}
+
+// Fail 记录一次失败
+//
+// 本次失败触发锁定时返回 errors.ErrAccountLocked 或 errors.ErrTooManyRequests，否则返回
+// errors.ErrPasswordError，Details 中的 remaining_attempts 为用户被锁定前的剩余次数。
+func (g *Guard) Fail(ctx context.Context, user, ip string) error {
+	var ipLocked time.Duration
+	if ip != "" && g.maxIPFailures > 0 {
+		_, d, err := g.fail(ctx, "ip:"+ip, g.maxIPFailures)
+		if err != nil {
+			return err
+		}
+		ipLocked = d
+	}
+
+	remaining := int64(-1)
+	if user != "" && g.maxUserFailures > 0 {
+		n, d, err := g.fail(ctx, "user:"+user, g.maxUserFailures)
+		if err != nil {
+			return err
+		}
+		if d > 0 {
+			return lockedError(errors.ErrAccountLocked, d)
+		}
+		remaining = g.maxUserFailures - n
+	}
+
+	if ipLocked > 0 {
+		return lockedError(errors.ErrTooManyRequests, ipLocked)
+	}
+	if remaining < 0 {
+		return errors.ErrPasswordError
+	}
+	return errors.ErrPasswordError.WithDetails(map[string]interface{}{"remaining_attempts": remaining})
+}
+
+// Succeed 登录成功，清除用户的失败次数与锁定级别
+//
+// IP 的失败次数不清除，否则攻击者可用自己的账号登录来重置计数。
+func (g *Guard) Succeed(ctx context.Context, user string) error {
+	if user == "" {
+		return nil
+	}
+	return g.cache.MDelete(ctx, []string{g.prefix + "fail:user:" + user, g.prefix + "level:user:" + user})
+}
+
+// Unlock 解除用户锁定并清除失败记录，供管理员使用
+func (g *Guard) Unlock(ctx context.Context, user string) error {
+	key := "user:" + user
+	return g.cache.MDelete(ctx, []string{g.prefix + "fail:" + key, g.prefix + "level:" + key, g.prefix + "lock:" + key})
+}
+
+// fail 增加失败次数，返回当前次数；达到阈值时锁定并返回锁定时长
+func (g *Guard) fail(ctx context.Context, key string, max int64) (int64, time.Duration, error) {
+	failKey := g.prefix + "fail:" + key
+	n, err := g.cache.Incr(ctx, failKey)
+	if err != nil {
+		return 0, 0, unavailable(err)
+	}
+	if err := g.cache.Expire(ctx, failKey, g.window); err != nil {
+		return 0, 0, unavailable(err)
+	}
+	if n < max {
+		return n, 0, nil
+	}
+
+	levelKey := g.prefix + "level:" + key
+	level, err := g.cache.Incr(ctx, levelKey)
+	if err != nil {
+		return 0, 0, unavailable(err)
+	}
+	// 长时间没有再被锁定后锁定级别归零
+	_ = g.cache.Expire(ctx, levelKey, 2*g.maxLockout)
+
+	d := g.lockout(level)
+	if err := g.cache.Set(ctx, g.prefix+"lock:"+key, g.now().Add(d).UnixMilli(), d); err != nil {
+		return 0, 0, unavailable(err)
+	}
+	_ = g.cache.Delete(ctx, failKey)
+	return n, d, nil
+}
+
+// lockout 第 level 次锁定的时长
+func (g *Guard) lockout(level int64) time.Duration {
+	d := g.baseLockout
+	for i := int64(1); i < level && d < g.maxLockout; i++ {
+		d *= 2
+	}
+	if g.maxLockout > 0 && d > g.maxLockout {
+		d = g.maxLockout
+	}
+	return d
+}
+
+// lockRemaining 剩余锁定时长，未锁定返回 0
+func (g *Guard) lockRemaining(ctx context.Context, key string) (time.Duration, error) {
+	var until int64
+	if err := g.cache.Get(ctx, g.prefix+"lock:"+key, &until); err != nil {
+		if cache.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, unavailable(err)
+	}
+	if wait := time.UnixMilli(until).Sub(g.now()); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// unavailable 缓存不可用时拒绝登录，而不是放行
+func unavailable(err error) *errors.Error {
+	return errors.Wrap(err, errors.CodeInternalError, "login guard unavailable")
+}
+
+func lockedError(base *errors.Error, wait time.Duration) *errors.Error {
+	return base.WithDetails(map[string]interface{}{
+		"retry_after": int64((wait + time.Second - 1) / time.Second),
+	})
+}
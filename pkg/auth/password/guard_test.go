@@ -0,0 +1,162 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/cache"
+	apperrors "github.com/goupter/goupter/pkg/errors"
+)
+
+// newTestGuard 使用可控时钟的 Guard
+func newTestGuard(opts ...GuardOption) (*Guard, *time.Time) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := NewGuard(cache.NewMemoryCache(), opts...)
+	g.now = func() time.Time { return now }
+	return g, &now
+}
+
+func retryAfter(err error) int64 {
+	e := apperrors.FromError(err)
+	if d, ok := e.Details.(map[string]interface{}); ok {
+		v, _ := d["retry_after"].(int64)
+		return v
+	}
+	return 0
+}
+
+func TestGuard_LockoutBackoff(t *testing.T) {
+	g, now := newTestGuard(WithMaxFailures(3, 0), WithLockout(time.Minute, 3*time.Minute))
+	ctx := context.Background()
+	wrong := func(ctx context.Context) error { return apperrors.ErrPasswordError }
+
+	for i := 0; i < 2; i++ {
+		err := g.Login(ctx, "alice", "", wrong)
+		e := apperrors.FromError(err)
+		if e.Code != apperrors.CodePasswordError || e.Details.(map[string]interface{})["remaining_attempts"] != int64(2-i) {
+			t.Fatalf("attempt %d: error = %v, details = %v", i, err, e.Details)
+		}
+	}
+
+	// 第三次失败触发锁定
+	err := g.Login(ctx, "alice", "", wrong)
+	if apperrors.GetCode(err) != apperrors.CodeAccountLocked || retryAfter(err) != 60 {
+		t.Fatalf("error = %v, retry_after = %d", err, retryAfter(err))
+	}
+
+	// 锁定期间不执行校验，即使密码正确
+	called := false
+	err = g.Login(ctx, "alice", "", func(ctx context.Context) error { called = true; return nil })
+	if apperrors.GetCode(err) != apperrors.CodeAccountLocked || called {
+		t.Errorf("locked: error = %v, called = %v", err, called)
+	}
+	if err := g.Check(ctx, "bob", ""); err != nil {
+		t.Errorf("其他用户不受影响: %v", err)
+	}
+
+	// 解锁后再次达到阈值，锁定时长翻倍，最长 3 分钟
+	for _, want := range []int64{120, 180, 180} {
+		*now = now.Add(4 * time.Minute)
+		for i := 0; i < 3; i++ {
+			err = g.Login(ctx, "alice", "", wrong)
+		}
+		if apperrors.GetCode(err) != apperrors.CodeAccountLocked || retryAfter(err) != want {
+			t.Errorf("retry_after = %d, want %d", retryAfter(err), want)
+		}
+	}
+
+	// 登录成功后锁定级别归零
+	*now = now.Add(4 * time.Minute)
+	if err := g.Login(ctx, "alice", "", func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		err = g.Login(ctx, "alice", "", wrong)
+	}
+	if retryAfter(err) != 60 {
+		t.Errorf("retry_after after success = %d, want 60", retryAfter(err))
+	}
+
+	if err := g.Unlock(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Check(ctx, "alice", ""); err != nil {
+		t.Errorf("Unlock 后应可登录: %v", err)
+	}
+}
+
+func TestGuard_IP(t *testing.T) {
+	g, now := newTestGuard(WithMaxFailures(0, 3))
+	ctx := context.Background()
+	wrong := func(ctx context.Context) error { return apperrors.ErrPasswordError }
+
+	var err error
+	for _, user := range []string{"a", "b", "c"} {
+		err = g.Login(ctx, user, "10.0.0.1", wrong)
+	}
+	if apperrors.GetCode(err) != apperrors.CodeTooManyRequests {
+		t.Fatalf("error = %v", err)
+	}
+	if err := g.Check(ctx, "d", "10.0.0.1"); apperrors.GetCode(err) != apperrors.CodeTooManyRequests {
+		t.Errorf("IP 锁定应作用于所有用户: %v", err)
+	}
+	if err := g.Check(ctx, "a", "10.0.0.2"); err != nil {
+		t.Errorf("其他 IP 不受影响: %v", err)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if err := g.Check(ctx, "a", "10.0.0.1"); err != nil {
+		t.Errorf("锁定到期后应放行: %v", err)
+	}
+}
+
+func TestGuard_PassThrough(t *testing.T) {
+	g, _ := newTestGuard(WithMaxFailures(1, 0))
+	ctx := context.Background()
+
+	// 非密码错误不计入失败
+	disabled := func(ctx context.Context) error { return apperrors.ErrUserDisabled }
+	for i := 0; i < 3; i++ {
+		if err := g.Login(ctx, "alice", "", disabled); apperrors.GetCode(err) != apperrors.CodeUserDisabled {
+			t.Fatalf("error = %v", err)
+		}
+	}
+	boom := errors.New("db down")
+	if err := g.Login(ctx, "alice", "", func(ctx context.Context) error { return boom }); err != boom {
+		t.Errorf("error = %v", err)
+	}
+	if err := g.Check(ctx, "alice", ""); err != nil {
+		t.Errorf("不应被锁定: %v", err)
+	}
+}
+
+func TestGuard_WithHasher(t *testing.T) {
+	g, _ := newTestGuard(WithMaxFailures(2, 0))
+	h := NewHasher(WithArgon2Params(testArgon2))
+	stored, _ := h.Hash("secret")
+	ctx := context.Background()
+
+	login := func(user, plain string) error {
+		return g.Login(ctx, user, "", func(ctx context.Context) error {
+			encoded := ""
+			if user == "alice" {
+				encoded = stored
+			}
+			_, err := h.Compare(plain, encoded)
+			return err
+		})
+	}
+
+	if err := login("alice", "secret"); err != nil {
+		t.Errorf("Login() error = %v", err)
+	}
+	if err := login("mallory", "secret"); apperrors.GetCode(err) != apperrors.CodePasswordError {
+		t.Errorf("不存在的用户应返回密码错误: %v", err)
+	}
+	login("alice", "wrong")
+	if err := login("alice", "wrong"); apperrors.GetCode(err) != apperrors.CodeAccountLocked {
+		t.Errorf("error = %v", err)
+	}
+}
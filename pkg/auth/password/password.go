@@ -0,0 +1,226 @@
+// Package password 密码哈希、强度策略与登录防暴力破解
+//
+// Hasher 生成自描述的哈希字符串（argon2id 为 PHC 格式，bcrypt 为标准格式），字符串中带有算法、
+// 版本与参数，调整算法或参数后旧哈希仍可校验，并在登录成功时透明地升级：
+//
+//	hasher := password.NewHasher(password.WithPolicy(password.Complexity{MinLength: 10, MinClasses: 3}))
+//
+//	if err := hasher.Validate(ctx, plain); err != nil { ... }   // 注册、修改密码时校验策略
+//	hash, err := hasher.Hash(plain)
+//
+//	rehash, err := hasher.Compare(plain, user.Password)         // 不匹配时返回 errors.ErrPasswordError
+//	if err == nil && rehash != "" { /* 保存 rehash */ }
+//
+// Guard 在 cache.Cache 中按用户与 IP 统计登录失败次数，超过阈值后按指数退避锁定。
+package password
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"sync"
+
+	"github.com/goupter/goupter/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algorithm 哈希算法
+type Algorithm string
+
+const (
+	Argon2id Algorithm = "argon2id"
+	Bcrypt   Algorithm = "bcrypt"
+)
+
+// 错误定义
+var (
+	ErrInvalidHash          = stderrors.New("password: invalid hash")
+	ErrUnsupportedAlgorithm = stderrors.New("password: unsupported algorithm")
+)
+
+// Argon2Params argon2id 参数
+type Argon2Params struct {
+	// Memory 内存开销，单位 KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params 默认参数，取自 RFC 9106 推荐的第二组参数（64 MiB 内存）
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 4,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// DefaultBcryptCost 默认 bcrypt 代价
+const DefaultBcryptCost = 12
+
+// Hasher 密码哈希器，并发安全
+type Hasher struct {
+	algorithm  Algorithm
+	argon2     Argon2Params
+	bcryptCost int
+	policies   []Policy
+
+	dummyOnce sync.Once
+	dummy     string
+}
+
+// Option 哈希器选项
+type Option func(*Hasher)
+
+// WithAlgorithm 设置新哈希使用的算法，默认 argon2id；已有哈希无论算法都可校验
+func WithAlgorithm(alg Algorithm) Option {
+	return func(h *Hasher) {
+		h.algorithm = alg
+	}
+}
+
+// WithArgon2Params 设置 argon2id 参数
+func WithArgon2Params(p Argon2Params) Option {
+	return func(h *Hasher) {
+		h.argon2 = p
+	}
+}
+
+// WithBcryptCost 设置 bcrypt 代价
+func WithBcryptCost(cost int) Option {
+	return func(h *Hasher) {
+		h.bcryptCost = cost
+	}
+}
+
+// WithPolicy 添加密码策略，由 Validate 依次检查
+func WithPolicy(policies ...Policy) Option {
+	return func(h *Hasher) {
+		h.policies = append(h.policies, policies...)
+	}
+}
+
+// NewHasher 创建哈希器
+func NewHasher(opts ...Option) *Hasher {
+	h := &Hasher{
+		algorithm:  Argon2id,
+		argon2:     DefaultArgon2Params(),
+		bcryptCost: DefaultBcryptCost,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Hash 生成密码哈希，不检查策略
+func (h *Hasher) Hash(password string) (string, error) {
+	switch h.algorithm {
+	case Argon2id:
+		return hashArgon2(password, h.argon2)
+	case Bcrypt:
+		b, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return "", ErrUnsupportedAlgorithm
+	}
+}
+
+// Compare 校验密码，不匹配时返回 errors.ErrPasswordError
+//
+// 校验成功且哈希的算法或参数与当前配置不同时，返回用当前配置重新生成的哈希，调用方应保存；
+// 重新生成失败不影响本次登录，返回空字符串。encoded 为空（如用户不存在）时仍执行一次哈希运算
+// 再返回 errors.ErrPasswordError，避免通过响应时间判断用户是否存在。
+func (h *Hasher) Compare(password, encoded string) (string, error) {
+	if encoded == "" {
+		h.dummyOnce.Do(func() {
+			h.dummy, _ = h.Hash("dummy password")
+		})
+		_, _ = verify(password, h.dummy)
+		return "", errors.ErrPasswordError
+	}
+
+	ok, err := verify(password, encoded)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errors.ErrPasswordError
+	}
+	if !h.NeedsRehash(encoded) {
+		return "", nil
+	}
+	rehash, err := h.Hash(password)
+	if err != nil {
+		return "", nil
+	}
+	return rehash, nil
+}
+
+// NeedsRehash 哈希的算法或参数是否与当前配置不同，无法解析的哈希也返回 true
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	switch identify(encoded) {
+	case Argon2id:
+		if h.algorithm != Argon2id {
+			return true
+		}
+		p, _, _, err := decodeArgon2(encoded)
+		return err != nil || p != h.argon2
+	case Bcrypt:
+		if h.algorithm != Bcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || cost != h.bcryptCost
+	default:
+		return true
+	}
+}
+
+// Validate 依次检查密码策略，返回第一个不满足的策略的错误
+func (h *Hasher) Validate(ctx context.Context, password string) error {
+	for _, p := range h.policies {
+		if err := p.Validate(ctx, password); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// identify 根据哈希前缀识别算法
+func identify(encoded string) Algorithm {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return Argon2id
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return Bcrypt
+	default:
+		return ""
+	}
+}
+
+// verify 按哈希自身的算法与参数校验密码
+func verify(password, encoded string) (bool, error) {
+	switch identify(encoded) {
+	case Argon2id:
+		return verifyArgon2(password, encoded)
+	case Bcrypt:
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if err == nil {
+			return true, nil
+		}
+		if stderrors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return false, ErrInvalidHash
+	default:
+		return false, ErrUnsupportedAlgorithm
+	}
+}
@@ -0,0 +1,132 @@
+package password
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	apperrors "github.com/goupter/goupter/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2 降低开销的测试参数
+var testArgon2 = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHasher_Argon2id(t *testing.T) {
+	h := NewHasher(WithArgon2Params(testArgon2))
+
+	encoded, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("encoded = %s", encoded)
+	}
+	if other, _ := h.Hash("correct horse"); other == encoded {
+		t.Error("每次哈希应使用不同的盐")
+	}
+
+	if rehash, err := h.Compare("correct horse", encoded); err != nil || rehash != "" {
+		t.Errorf("Compare() = %q, %v", rehash, err)
+	}
+	if _, err := h.Compare("wrong", encoded); !apperrors.Is(err, apperrors.ErrPasswordError) {
+		t.Errorf("Compare(wrong) error = %v", err)
+	}
+	if h.NeedsRehash(encoded) {
+		t.Error("参数一致时不需要重新哈希")
+	}
+}
+
+func TestHasher_Bcrypt(t *testing.T) {
+	h := NewHasher(WithAlgorithm(Bcrypt), WithBcryptCost(bcrypt.MinCost))
+
+	encoded, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rehash, err := h.Compare("correct horse", encoded); err != nil || rehash != "" {
+		t.Errorf("Compare() = %q, %v", rehash, err)
+	}
+	if _, err := h.Compare("wrong", encoded); !apperrors.Is(err, apperrors.ErrPasswordError) {
+		t.Errorf("Compare(wrong) error = %v", err)
+	}
+}
+
+func TestHasher_Rehash(t *testing.T) {
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	h := NewHasher(WithArgon2Params(testArgon2))
+
+	// bcrypt 升级为 argon2id
+	rehash, err := h.Compare("secret", string(legacy))
+	if err != nil || !strings.HasPrefix(rehash, "$argon2id$") {
+		t.Fatalf("Compare(bcrypt) = %q, %v", rehash, err)
+	}
+	if _, err := h.Compare("secret", rehash); err != nil {
+		t.Errorf("新哈希应可校验: %v", err)
+	}
+
+	// 提高 argon2id 参数
+	stronger := testArgon2
+	stronger.Iterations = 2
+	h2 := NewHasher(WithArgon2Params(stronger))
+	if !h2.NeedsRehash(rehash) {
+		t.Error("参数变化后应重新哈希")
+	}
+	if upgraded, err := h2.Compare("secret", rehash); err != nil || !strings.Contains(upgraded, "t=2") {
+		t.Errorf("Compare() = %q, %v", upgraded, err)
+	}
+
+	// 提高 bcrypt 代价
+	hb := NewHasher(WithAlgorithm(Bcrypt), WithBcryptCost(bcrypt.MinCost+1))
+	if !hb.NeedsRehash(string(legacy)) {
+		t.Error("代价变化后应重新哈希")
+	}
+
+	// 密码错误时不返回新哈希
+	if rehash, err := h.Compare("wrong", string(legacy)); err == nil || rehash != "" {
+		t.Errorf("Compare(wrong) = %q, %v", rehash, err)
+	}
+}
+
+func TestHasher_InvalidHash(t *testing.T) {
+	h := NewHasher(WithArgon2Params(testArgon2))
+
+	if _, err := h.Compare("x", ""); !apperrors.Is(err, apperrors.ErrPasswordError) {
+		t.Errorf("Compare(empty) error = %v", err)
+	}
+	if _, err := h.Compare("x", "plaintext"); !stderrors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Compare(plaintext) error = %v", err)
+	}
+	for _, encoded := range []string{
+		"$argon2id$v=19$m=1024,t=1,p=1$!!$abc",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=0,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$c2FsdA$a2V5",
+	} {
+		if _, err := h.Compare("x", encoded); !stderrors.Is(err, ErrInvalidHash) {
+			t.Errorf("Compare(%s) error = %v", encoded, err)
+		}
+	}
+	if !h.NeedsRehash("plaintext") {
+		t.Error("无法解析的哈希应重新哈希")
+	}
+}
+
+func TestHasher_Validate(t *testing.T) {
+	var called bool
+	h := NewHasher(WithPolicy(
+		Complexity{MinLength: 8},
+		PolicyFunc(func(ctx context.Context, password string) error {
+			called = true
+			return nil
+		}),
+	))
+
+	if err := h.Validate(context.Background(), "short"); !apperrors.Is(err, ErrWeakPassword) || called {
+		t.Errorf("Validate(short) error = %v, called = %v", err, called)
+	}
+	if err := h.Validate(context.Background(), "long enough"); err != nil || !called {
+		t.Errorf("Validate() error = %v, called = %v", err, called)
+	}
+}
@@ -0,0 +1,168 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goupter/goupter/pkg/errors"
+)
+
+// 策略错误，均为 400
+var (
+	ErrWeakPassword  = errors.New(errors.CodeBadRequest, "password does not meet complexity requirements")
+	ErrPwnedPassword = errors.New(errors.CodeBadRequest, "password has appeared in a data breach")
+)
+
+// Policy 密码策略，不满足时返回错误
+type Policy interface {
+	Validate(ctx context.Context, password string) error
+}
+
+// PolicyFunc 函数类型的策略
+type PolicyFunc func(ctx context.Context, password string) error
+
+func (f PolicyFunc) Validate(ctx context.Context, password string) error {
+	return f(ctx, password)
+}
+
+// Complexity 复杂度策略，长度按字符计算，零值的规则不检查
+type Complexity struct {
+	MinLength int
+	MaxLength int
+	// MinClasses 至少包含的字符类别数：大写字母、小写字母、数字、其他字符
+	MinClasses int
+}
+
+// Validate 不满足时返回 ErrWeakPassword，Details 为全部未满足的规则
+func (p Complexity) Validate(ctx context.Context, password string) error {
+	var violations []string
+
+	n := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && n < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && n > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d characters", p.MaxLength))
+	}
+	if p.MinClasses > 0 && classes(password) < p.MinClasses {
+		violations = append(violations, fmt.Sprintf("must contain at least %d of uppercase, lowercase, digits and symbols", p.MinClasses))
+	}
+
+	if len(violations) > 0 {
+		return ErrWeakPassword.WithDetails(violations)
+	}
+	return nil
+}
+
+func classes(password string) int {
+	var upper, lower, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, ok := range []bool{upper, lower, digit, other} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// PwnedChecker 检查密码是否出现在已泄露的密码库中
+type PwnedChecker interface {
+	Pwned(ctx context.Context, password string) (bool, error)
+}
+
+// PwnedFunc 函数类型的检查器
+type PwnedFunc func(ctx context.Context, password string) (bool, error)
+
+func (f PwnedFunc) Pwned(ctx context.Context, password string) (bool, error) {
+	return f(ctx, password)
+}
+
+// Pwned 泄露密码策略，命中时返回 ErrPwnedPassword
+//
+// 检查器出错时返回内部错误；希望检查服务不可用时放行的，可在检查器中忽略错误。
+func Pwned(checker PwnedChecker) Policy {
+	return PolicyFunc(func(ctx context.Context, password string) error {
+		pwned, err := checker.Pwned(ctx, password)
+		if err != nil {
+			return errors.Wrap(err, errors.CodeInternalError, "password breach check failed")
+		}
+		if pwned {
+			return ErrPwnedPassword
+		}
+		return nil
+	})
+}
+
+// DefaultHIBPEndpoint Have I Been Pwned 密码接口
+const DefaultHIBPEndpoint = "https://api.pwnedpasswords.com"
+
+// HIBP 通过 Have I Been Pwned 的 k-匿名接口检查，只发送 SHA-1 的前 5 位
+type HIBP struct {
+	// Endpoint 接口地址，默认 DefaultHIBPEndpoint，可指向自建镜像
+	Endpoint string
+	// Client HTTP 客户端，默认 http.DefaultClient，建议设置超时
+	Client *http.Client
+}
+
+func (h *HIBP) Pwned(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:5], digest[5:]
+
+	endpoint := h.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultHIBPEndpoint
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(endpoint, "/")+"/range/"+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	// 填充响应，避免通过响应长度推断前缀
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("password: hibp returned status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		hashSuffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || !strings.EqualFold(hashSuffix, suffix) {
+			continue
+		}
+		// 填充的条目次数为 0
+		n, _ := strconv.Atoi(count)
+		return n > 0, nil
+	}
+	return false, scanner.Err()
+}
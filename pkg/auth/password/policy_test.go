@@ -0,0 +1,109 @@
+package password
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apperrors "github.com/goupter/goupter/pkg/errors"
+)
+
+func TestComplexity(t *testing.T) {
+	p := Complexity{MinLength: 8, MaxLength: 16, MinClasses: 3}
+	tests := []struct {
+		password   string
+		violations int
+	}{
+		{"Abcdefg1", 0},
+		{"密码Abc123!", 0},
+		{"abcdefgh", 1},
+		{"ab1", 2},
+		{"Abcdefgh1234567890", 1},
+	}
+	for _, tt := range tests {
+		err := p.Validate(context.Background(), tt.password)
+		if tt.violations == 0 {
+			if err != nil {
+				t.Errorf("Validate(%q) error = %v", tt.password, err)
+			}
+			continue
+		}
+		e := apperrors.FromError(err)
+		if e == nil || e.Code != apperrors.CodeBadRequest {
+			t.Errorf("Validate(%q) error = %v", tt.password, err)
+			continue
+		}
+		if v, _ := e.Details.([]string); len(v) != tt.violations {
+			t.Errorf("Validate(%q) violations = %v", tt.password, e.Details)
+		}
+	}
+}
+
+func TestPwned(t *testing.T) {
+	p := Pwned(PwnedFunc(func(ctx context.Context, password string) (bool, error) {
+		switch password {
+		case "password":
+			return true, nil
+		case "boom":
+			return false, errors.New("unavailable")
+		}
+		return false, nil
+	}))
+
+	if err := p.Validate(context.Background(), "password"); err != ErrPwnedPassword {
+		t.Errorf("Validate(pwned) error = %v", err)
+	}
+	if err := p.Validate(context.Background(), "boom"); apperrors.GetCode(err) != apperrors.CodeInternalError {
+		t.Errorf("Validate(error) error = %v", err)
+	}
+	if err := p.Validate(context.Background(), "unique"); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestHIBP(t *testing.T) {
+	sum := sha1.Sum([]byte("password"))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	paddingSum := sha1.Sum([]byte("padding"))
+	padding := strings.ToUpper(hex.EncodeToString(paddingSum[:]))
+
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Error("应请求填充响应")
+		}
+		if r.URL.Path == "/range/"+digest[:5] {
+			fmt.Fprintf(w, "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n%s:3730471\r\n", digest[5:])
+			return
+		}
+		fmt.Fprintf(w, "%s:0\r\n", padding[5:])
+	}))
+	defer srv.Close()
+
+	h := &HIBP{Endpoint: srv.URL, Client: srv.Client()}
+	if pwned, err := h.Pwned(context.Background(), "password"); err != nil || !pwned {
+		t.Errorf("Pwned(password) = %v, %v", pwned, err)
+	}
+	if pwned, err := h.Pwned(context.Background(), "padding"); err != nil || pwned {
+		t.Errorf("填充条目不应视为泄露: %v, %v", pwned, err)
+	}
+	for _, p := range paths {
+		if len(p) != len("/range/")+5 {
+			t.Errorf("只应发送哈希前缀: %s", p)
+		}
+	}
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	if _, err := h.Pwned(context.Background(), "password"); err == nil {
+		t.Error("服务异常应返回错误")
+	}
+}
@@ -11,12 +11,12 @@ func (m *mockAuthenticator) Authenticate(ctx context.Context, token string) (*Cl
 	return &Claims{UserID: "test"}, nil
 }
 
-func (m *mockAuthenticator) GenerateToken(claims *Claims) (string, error) {
-	return "test-token", nil
+func (m *mockAuthenticator) GenerateToken(claims *Claims) (*TokenPair, error) {
+	return &TokenPair{AccessToken: "test-token"}, nil
 }
 
-func (m *mockAuthenticator) RefreshToken(token string) (string, error) {
-	return "new-token", nil
+func (m *mockAuthenticator) RefreshToken(ctx context.Context, token string) (*TokenPair, error) {
+	return &TokenPair{AccessToken: "new-token"}, nil
 }
 
 func (m *mockAuthenticator) RevokeToken(ctx context.Context, token string) error {
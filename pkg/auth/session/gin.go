@@ -0,0 +1,174 @@
+package session
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/server/middleware"
+)
+
+// csrfFormField 表单提交时携带 CSRF 令牌的字段
+const csrfFormField = "csrf_token"
+
+const contextKeySession = "auth_session"
+
+// Login 为用户创建会话并写入 Cookie，请求中已有的会话会被销毁（会话 ID 重新生成）
+func (a *Authenticator) Login(c *gin.Context, claims *auth.Claims) (*Session, error) {
+	if id, err := c.Cookie(a.config.CookieName); err == nil && id != "" {
+		_ = a.Destroy(c.Request.Context(), id)
+	}
+	s, err := a.Create(c.Request.Context(), claims)
+	if err != nil {
+		return nil, err
+	}
+	a.setCookies(c, s)
+	c.Set(contextKeySession, s)
+	return s, nil
+}
+
+// RegenerateCookie 为当前请求的会话换发新 ID 并更新 Cookie，用于提权等敏感操作之后
+func (a *Authenticator) RegenerateCookie(c *gin.Context) (*Session, error) {
+	id, err := c.Cookie(a.config.CookieName)
+	if err != nil {
+		return nil, auth.ErrTokenInvalid
+	}
+	s, err := a.Regenerate(c.Request.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	a.setCookies(c, s)
+	c.Set(contextKeySession, s)
+	return s, nil
+}
+
+// Logout 销毁当前会话并清除 Cookie
+func (a *Authenticator) Logout(c *gin.Context) error {
+	var err error
+	if id, cookieErr := c.Cookie(a.config.CookieName); cookieErr == nil && id != "" {
+		err = a.Destroy(c.Request.Context(), id)
+	}
+	a.clearCookies(c)
+	return err
+}
+
+// Middleware 从 Cookie 加载会话，写入 auth.Claims 与 middleware.AuthInfo，
+// 因此 middleware.RequireRoles 等中间件可以直接使用
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := c.Cookie(a.config.CookieName)
+		if err != nil || id == "" {
+			abortUnauthorized(c, middleware.ErrMissingToken)
+			return
+		}
+		s, err := a.Get(c.Request.Context(), id)
+		if err != nil {
+			var authErr *auth.AuthError
+			if !errors.As(err, &authErr) {
+				// 会话存储不可用时不清除 Cookie，恢复后会话仍然有效
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+					"code":    http.StatusServiceUnavailable,
+					"message": "session store unavailable",
+				})
+				return
+			}
+			a.clearCookies(c)
+			abortUnauthorized(c, err)
+			return
+		}
+
+		c.Set(contextKeySession, s)
+		c.Request = c.Request.WithContext(auth.ContextWithClaims(c.Request.Context(), s.Claims))
+		middleware.SetAuthInfo(c, middleware.AuthInfoFromClaims(s.Claims))
+		c.Next()
+	}
+}
+
+// CSRF 校验会话的 CSRF 令牌，需放在 Middleware 之后
+//
+// GET、HEAD、OPTIONS、TRACE 不校验；其他方法须通过请求头（默认 X-CSRF-Token）或表单字段
+// csrf_token 提交登录时下发的令牌。未携带会话的请求不受 Cookie 跨站提交影响，直接放行。
+func (a *Authenticator) CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			c.Next()
+			return
+		}
+		s, ok := GetSession(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		token := c.GetHeader(a.config.CSRFHeader)
+		if token == "" {
+			token = c.PostForm(csrfFormField)
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.CSRFToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    http.StatusForbidden,
+				"message": "invalid csrf token",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetSession 获取 Middleware 加载的会话
+func GetSession(c *gin.Context) (*Session, bool) {
+	if v, ok := c.Get(contextKeySession); ok {
+		if s, ok := v.(*Session); ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// CSRFToken 当前会话的 CSRF 令牌，供服务端渲染的表单使用
+func CSRFToken(c *gin.Context) string {
+	if s, ok := GetSession(c); ok {
+		return s.CSRFToken
+	}
+	return ""
+}
+
+// setCookies 写入会话 Cookie（HttpOnly）与 CSRF Cookie（前端可读，用于回填请求头）
+func (a *Authenticator) setCookies(c *gin.Context, s *Session) {
+	maxAge := int(s.ExpiresAt.Sub(a.now()).Seconds())
+	a.writeCookie(c, a.config.CookieName, s.ID, maxAge, true)
+	if a.config.CSRFCookieName != "" {
+		a.writeCookie(c, a.config.CSRFCookieName, s.CSRFToken, maxAge, false)
+	}
+}
+
+func (a *Authenticator) clearCookies(c *gin.Context) {
+	a.writeCookie(c, a.config.CookieName, "", -1, true)
+	if a.config.CSRFCookieName != "" {
+		a.writeCookie(c, a.config.CSRFCookieName, "", -1, false)
+	}
+}
+
+func (a *Authenticator) writeCookie(c *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	sameSite, _ := parseSameSite(a.config.SameSite)
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     a.config.CookiePath,
+		Domain:   a.config.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   a.config.Secure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite,
+	})
+}
+
+func abortUnauthorized(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"code":    http.StatusUnauthorized,
+		"message": err.Error(),
+	})
+}
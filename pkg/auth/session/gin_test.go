@@ -0,0 +1,157 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/server/middleware"
+)
+
+func newTestRouter(a *Authenticator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", func(c *gin.Context) {
+		if _, err := a.Login(c, &auth.Claims{UserID: "u1", Roles: []string{"admin"}}); err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	admin := r.Group("/admin", a.Middleware(), a.CSRF(), middleware.RequireRoles("admin"))
+	admin.GET("/me", func(c *gin.Context) {
+		claims, _ := auth.ClaimsFromContext(c.Request.Context())
+		c.String(http.StatusOK, claims.UserID)
+	})
+	admin.POST("/items", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	admin.POST("/logout", func(c *gin.Context) {
+		_ = a.Logout(c)
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+func cookies(w *httptest.ResponseRecorder) map[string]*http.Cookie {
+	out := make(map[string]*http.Cookie)
+	for _, c := range w.Result().Cookies() {
+		out[c.Name] = c
+	}
+	return out
+}
+
+func do(r *gin.Engine, method, path string, cs map[string]*http.Cookie, header http.Header, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for _, c := range cs {
+		req.AddCookie(c)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestLogin_SetsSecureCookies(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	w := do(newTestRouter(a), "POST", "/login", nil, nil, "")
+
+	cs := cookies(w)
+	sid, csrf := cs["goupter_session"], cs["csrf_token"]
+	if sid == nil || csrf == nil {
+		t.Fatalf("cookies = %v", w.Header().Values("Set-Cookie"))
+	}
+	if !sid.HttpOnly || !sid.Secure || sid.SameSite != http.SameSiteLaxMode || sid.Path != "/" || sid.MaxAge <= 0 {
+		t.Errorf("session cookie = %+v", sid)
+	}
+	if csrf.HttpOnly || !csrf.Secure {
+		t.Errorf("csrf cookie = %+v", csrf)
+	}
+}
+
+func TestLogin_RegeneratesSessionID(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	r := newTestRouter(a)
+
+	first := cookies(do(r, "POST", "/login", nil, nil, ""))
+	second := cookies(do(r, "POST", "/login", first, nil, ""))
+	if second["goupter_session"].Value == first["goupter_session"].Value {
+		t.Fatal("login should issue a new session id")
+	}
+	if w := do(r, "GET", "/admin/me", first, nil, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("old session status = %d, want 401", w.Code)
+	}
+	if w := do(r, "GET", "/admin/me", second, nil, ""); w.Code != http.StatusOK || w.Body.String() != "u1" {
+		t.Errorf("new session status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMiddleware_RequiresSession(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	r := newTestRouter(a)
+
+	if w := do(r, "GET", "/admin/me", nil, nil, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("no cookie status = %d, want 401", w.Code)
+	}
+	forged := map[string]*http.Cookie{"s": {Name: "goupter_session", Value: "forged"}}
+	w := do(r, "GET", "/admin/me", forged, nil, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("forged cookie status = %d, want 401", w.Code)
+	}
+	if c := cookies(w)["goupter_session"]; c == nil || c.MaxAge >= 0 {
+		t.Error("invalid session cookie should be cleared")
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	r := newTestRouter(a)
+	cs := cookies(do(r, "POST", "/login", nil, nil, ""))
+	token := cs["csrf_token"].Value
+
+	form := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   int
+	}{
+		{"missing token", nil, "", http.StatusForbidden},
+		{"wrong token", http.Header{"X-Csrf-Token": {"wrong"}}, "", http.StatusForbidden},
+		{"header token", http.Header{"X-Csrf-Token": {token}}, "", http.StatusCreated},
+		{"form token", form, url.Values{"csrf_token": {token}}.Encode(), http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(r, "POST", "/admin/items", cs, tt.header, tt.body); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	if w := do(r, "GET", "/admin/me", cs, nil, ""); w.Code != http.StatusOK {
+		t.Errorf("GET should not require csrf token, status = %d", w.Code)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	r := newTestRouter(a)
+	cs := cookies(do(r, "POST", "/login", nil, nil, ""))
+
+	w := do(r, "POST", "/admin/logout", cs, http.Header{"X-Csrf-Token": {cs["csrf_token"].Value}}, "")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("logout status = %d", w.Code)
+	}
+	if c := cookies(w)["goupter_session"]; c == nil || c.MaxAge >= 0 {
+		t.Error("logout should clear the session cookie")
+	}
+	if w := do(r, "GET", "/admin/me", cs, nil, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("after logout status = %d, want 401", w.Code)
+	}
+}
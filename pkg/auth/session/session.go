@@ -0,0 +1,371 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/cache"
+)
+
+const PluginName = "session"
+
+// TokenType 会话令牌类型
+const TokenType = "Session"
+
+const (
+	sessionPrefix    = "session:"
+	generationPrefix = "session:user:gen:"
+)
+
+// ErrNoSessionStore 插件未获得会话缓存，且未显式允许使用进程内存保存会话
+var ErrNoSessionStore = errors.New("session: session cache required, set memory_store to use process memory")
+
+func init() {
+	auth.Register(&Plugin{})
+}
+
+// Plugin 服务端会话鉴权插件，会话保存在 cache.Cache 中
+type Plugin struct {
+	config        *Config
+	authenticator *Authenticator
+}
+
+// Config 会话配置
+type Config struct {
+	CookieName   string `json:"cookie_name"`
+	CookieDomain string `json:"cookie_domain"`
+	CookiePath   string `json:"cookie_path"`
+	// Secure 仅通过 HTTPS 发送 Cookie，本地开发可关闭
+	Secure bool `json:"secure"`
+	// SameSite strict、lax 或 none（none 要求 Secure）
+	SameSite string `json:"same_site"`
+	// IdleTimeout 空闲超时，每次访问后重新计时（滑动过期）
+	IdleTimeout time.Duration `json:"idle_timeout"`
+	// AbsoluteTimeout 自创建起的最长有效期，滑动过期不会超过该时间
+	AbsoluteTimeout time.Duration `json:"absolute_timeout"`
+	// TouchInterval 最后访问时间的最小更新间隔，避免每个请求都写缓存
+	TouchInterval time.Duration `json:"touch_interval"`
+	// CSRFHeader、CSRFCookieName 提交与下发 CSRF 令牌使用的请求头与 Cookie
+	CSRFHeader     string `json:"csrf_header"`
+	CSRFCookieName string `json:"csrf_cookie_name"`
+	// MemoryStore 插件未获得缓存时使用进程内存保存会话，重启即丢失且不在副本间共享，仅适用于单实例与本地开发
+	MemoryStore bool `json:"memory_store"`
+}
+
+// DefaultConfig 默认配置
+func DefaultConfig() *Config {
+	return &Config{
+		CookieName:      "goupter_session",
+		CookiePath:      "/",
+		Secure:          true,
+		SameSite:        "lax",
+		IdleTimeout:     30 * time.Minute,
+		AbsoluteTimeout: 24 * time.Hour,
+		TouchInterval:   time.Minute,
+		CSRFHeader:      "X-CSRF-Token",
+		CSRFCookieName:  "csrf_token",
+	}
+}
+
+func (p *Plugin) Name() string { return PluginName }
+
+func (p *Plugin) Init(config map[string]interface{}) error {
+	p.config = DefaultConfig()
+
+	for key, dst := range map[string]*string{
+		"cookie_name":      &p.config.CookieName,
+		"cookie_domain":    &p.config.CookieDomain,
+		"cookie_path":      &p.config.CookiePath,
+		"same_site":        &p.config.SameSite,
+		"csrf_header":      &p.config.CSRFHeader,
+		"csrf_cookie_name": &p.config.CSRFCookieName,
+	} {
+		if v, ok := config[key].(string); ok && v != "" {
+			*dst = v
+		}
+	}
+	if v, ok := config["secure"].(bool); ok {
+		p.config.Secure = v
+	}
+	if v, ok := config["memory_store"].(bool); ok {
+		p.config.MemoryStore = v
+	}
+	for key, dst := range map[string]*time.Duration{
+		"idle_timeout":     &p.config.IdleTimeout,
+		"absolute_timeout": &p.config.AbsoluteTimeout,
+		"touch_interval":   &p.config.TouchInterval,
+	} {
+		if v, ok := config[key].(string); ok {
+			if d, err := time.ParseDuration(v); err == nil {
+				*dst = d
+			}
+		}
+	}
+
+	if _, err := parseSameSite(p.config.SameSite); err != nil {
+		return err
+	}
+	if p.config.SameSite == "none" && !p.config.Secure {
+		return errors.New("session: same_site=none requires secure cookies")
+	}
+
+	// 会话须在重启与多副本间保持一致，默认要求注入缓存（app.Builder 注入 InitCache 创建的缓存）
+	var opts []Option
+	if c, ok := config["cache"].(cache.Cache); ok && c != nil {
+		opts = append(opts, WithCache(c))
+	} else if !p.config.MemoryStore {
+		return ErrNoSessionStore
+	}
+	p.authenticator = NewAuthenticator(p.config, opts...)
+	return nil
+}
+
+func (p *Plugin) Authenticator() auth.Authenticator {
+	return p.authenticator
+}
+
+func parseSameSite(s string) (http.SameSite, error) {
+	switch strings.ToLower(s) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, errors.New("session: invalid same_site " + s)
+}
+
+// Session 服务端会话
+type Session struct {
+	ID         string       `json:"-"`
+	Claims     *auth.Claims `json:"claims"`
+	CSRFToken  string       `json:"csrf_token"`
+	Generation int64        `json:"generation"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastSeenAt time.Time    `json:"last_seen_at"`
+	// ExpiresAt 绝对过期时间
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Authenticator 会话鉴权器，会话 ID 即令牌
+type Authenticator struct {
+	config *Config
+	cache  cache.Cache
+	now    func() time.Time
+}
+
+type Option func(*Authenticator)
+
+// WithCache 设置会话存储，默认内存缓存；多实例部署时应使用 Redis
+func WithCache(c cache.Cache) Option {
+	return func(a *Authenticator) { a.cache = c }
+}
+
+// NewAuthenticator 创建会话鉴权器
+func NewAuthenticator(config *Config, opts ...Option) *Authenticator {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	a := &Authenticator{config: config, now: time.Now}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.cache == nil {
+		a.cache = cache.NewMemoryCache()
+	}
+	return a
+}
+
+// Create 为用户创建新会话
+func (a *Authenticator) Create(ctx context.Context, claims *auth.Claims) (*Session, error) {
+	if claims == nil || claims.UserID == "" {
+		return nil, errors.New("session: user id is required")
+	}
+	id, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	csrf, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	gen, err := a.generation(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	stored := *claims
+	stored.IssuedAt = now
+	stored.ExpiresAt = now.Add(a.config.AbsoluteTimeout)
+	s := &Session{
+		ID:         id,
+		Claims:     &stored,
+		CSRFToken:  csrf,
+		Generation: gen,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  stored.ExpiresAt,
+	}
+	if err := a.save(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get 获取并校验会话，按 TouchInterval 延长空闲过期时间
+func (a *Authenticator) Get(ctx context.Context, id string) (*Session, error) {
+	if id == "" {
+		return nil, auth.ErrTokenInvalid
+	}
+	var s Session
+	if err := a.cache.Get(ctx, sessionKey(id), &s); err != nil {
+		if cache.IsNotFound(err) {
+			return nil, auth.ErrTokenInvalid
+		}
+		return nil, err
+	}
+	if s.Claims == nil {
+		return nil, auth.ErrTokenInvalid
+	}
+	s.ID = id
+
+	now := a.now()
+	if !now.Before(s.ExpiresAt) || now.Sub(s.LastSeenAt) >= a.config.IdleTimeout {
+		_ = a.cache.Delete(ctx, sessionKey(id))
+		return nil, auth.ErrTokenExpired
+	}
+	gen, err := a.generation(ctx, s.Claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Generation < gen {
+		_ = a.cache.Delete(ctx, sessionKey(id))
+		return nil, auth.ErrTokenRevoked
+	}
+
+	if now.Sub(s.LastSeenAt) >= a.config.TouchInterval {
+		s.LastSeenAt = now
+		if err := a.save(ctx, &s); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// Regenerate 以新 ID 替换会话并作废旧 ID，用于登录与权限变更，防止会话固定攻击
+func (a *Authenticator) Regenerate(ctx context.Context, id string) (*Session, error) {
+	old, err := a.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	newID, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	csrf, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	s := *old
+	s.ID = newID
+	s.CSRFToken = csrf
+	if err := a.save(ctx, &s); err != nil {
+		return nil, err
+	}
+	if err := a.Destroy(ctx, id); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Destroy 删除会话
+func (a *Authenticator) Destroy(ctx context.Context, id string) error {
+	return a.cache.Delete(ctx, sessionKey(id))
+}
+
+// LogoutAll 使用户的所有会话失效（退出所有设备）
+//
+// 用户维度的代数计数器不设过期时间，会话创建时记录当前代数，代数落后的会话视为已吊销。
+func (a *Authenticator) LogoutAll(ctx context.Context, userID string) error {
+	_, err := a.cache.Incr(ctx, generationPrefix+userID)
+	return err
+}
+
+// Authenticate 验证会话 ID
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (*auth.Claims, error) {
+	s, err := a.Get(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.Claims, nil
+}
+
+// GenerateToken 创建会话，会话没有刷新令牌
+func (a *Authenticator) GenerateToken(claims *auth.Claims) (*auth.TokenPair, error) {
+	s, err := a.Create(context.Background(), claims)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.TokenPair{AccessToken: s.ID, TokenType: TokenType, ExpiresAt: s.ExpiresAt}, nil
+}
+
+// RefreshToken 重新生成会话 ID
+func (a *Authenticator) RefreshToken(ctx context.Context, token string) (*auth.TokenPair, error) {
+	s, err := a.Regenerate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.TokenPair{AccessToken: s.ID, TokenType: TokenType, ExpiresAt: s.ExpiresAt}, nil
+}
+
+// RevokeToken 删除会话
+func (a *Authenticator) RevokeToken(ctx context.Context, token string) error {
+	return a.Destroy(ctx, token)
+}
+
+// save 写入会话，缓存过期时间为空闲超时且不超过绝对过期时间
+func (a *Authenticator) save(ctx context.Context, s *Session) error {
+	ttl := a.config.IdleTimeout
+	if remaining := s.ExpiresAt.Sub(a.now()); remaining < ttl {
+		ttl = remaining
+	}
+	return a.cache.Set(ctx, sessionKey(s.ID), s, ttl)
+}
+
+// generation 用户当前的会话代数
+func (a *Authenticator) generation(ctx context.Context, userID string) (int64, error) {
+	raw, err := a.cache.GetRaw(ctx, generationPrefix+userID)
+	if err != nil {
+		if cache.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.ParseInt(strings.Trim(raw, `"`), 10, 64)
+}
+
+// sessionKey 缓存中只保存会话 ID 的哈希，缓存数据泄露时无法直接冒用会话
+func sessionKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return sessionPrefix + hex.EncodeToString(sum[:])
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,182 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/cache"
+)
+
+// testClock 可手动推进的时钟
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time { return c.t }
+
+func newTestAuthenticator(cfg *Config) (*Authenticator, *testClock) {
+	clock := &testClock{t: time.Now()}
+	a := NewAuthenticator(cfg, WithCache(cache.NewMemoryCache()))
+	a.now = clock.now
+	return a, clock
+}
+
+func TestAuthenticator_CreateAndGet(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	ctx := context.Background()
+
+	s, err := a.Create(ctx, &auth.Claims{UserID: "u1", Username: "alice", Roles: []string{"admin"}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(s.ID) < 40 || s.CSRFToken == "" || s.CSRFToken == s.ID {
+		t.Errorf("session = %+v", s)
+	}
+
+	claims, err := a.Authenticate(ctx, s.ID)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if claims.UserID != "u1" || !claims.HasRole("admin") || !claims.ExpiresAt.Equal(s.ExpiresAt) {
+		t.Errorf("claims = %+v", claims)
+	}
+	if _, err := a.Authenticate(ctx, "unknown"); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(unknown) error = %v, want ErrTokenInvalid", err)
+	}
+}
+
+func TestAuthenticator_SlidingExpiry(t *testing.T) {
+	a, clock := newTestAuthenticator(&Config{
+		IdleTimeout:     30 * time.Minute,
+		AbsoluteTimeout: 2 * time.Hour,
+		TouchInterval:   time.Minute,
+	})
+	ctx := context.Background()
+	s, _ := a.Create(ctx, &auth.Claims{UserID: "u1"})
+
+	// 每 20 分钟访问一次，空闲超时不断顺延
+	for i := 0; i < 5; i++ {
+		clock.t = clock.t.Add(20 * time.Minute)
+		if _, err := a.Get(ctx, s.ID); err != nil {
+			t.Fatalf("Get() after %d accesses error = %v", i, err)
+		}
+	}
+
+	// 超过绝对有效期后即使持续访问也失效
+	clock.t = s.CreatedAt.Add(2 * time.Hour)
+	if _, err := a.Get(ctx, s.ID); err != auth.ErrTokenExpired {
+		t.Errorf("Get(after absolute timeout) error = %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestAuthenticator_IdleTimeout(t *testing.T) {
+	a, clock := newTestAuthenticator(nil)
+	ctx := context.Background()
+	s, _ := a.Create(ctx, &auth.Claims{UserID: "u1"})
+
+	clock.t = clock.t.Add(31 * time.Minute)
+	if _, err := a.Get(ctx, s.ID); err != auth.ErrTokenExpired {
+		t.Errorf("Get(idle) error = %v, want ErrTokenExpired", err)
+	}
+	if _, err := a.Get(ctx, s.ID); err != auth.ErrTokenInvalid {
+		t.Errorf("Get(deleted) error = %v, want ErrTokenInvalid", err)
+	}
+}
+
+func TestAuthenticator_Regenerate(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	ctx := context.Background()
+	old, _ := a.Create(ctx, &auth.Claims{UserID: "u1"})
+
+	s, err := a.Regenerate(ctx, old.ID)
+	if err != nil {
+		t.Fatalf("Regenerate() error = %v", err)
+	}
+	if s.ID == old.ID || s.CSRFToken == old.CSRFToken || !s.ExpiresAt.Equal(old.ExpiresAt) {
+		t.Errorf("regenerated = %+v, old = %+v", s, old)
+	}
+	if _, err := a.Get(ctx, old.ID); err != auth.ErrTokenInvalid {
+		t.Errorf("Get(old) error = %v, want ErrTokenInvalid", err)
+	}
+	if _, err := a.Get(ctx, s.ID); err != nil {
+		t.Errorf("Get(new) error = %v", err)
+	}
+}
+
+func TestAuthenticator_LogoutAll(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	ctx := context.Background()
+	laptop, _ := a.Create(ctx, &auth.Claims{UserID: "u1"})
+	phone, _ := a.Create(ctx, &auth.Claims{UserID: "u1"})
+	other, _ := a.Create(ctx, &auth.Claims{UserID: "u2"})
+
+	if err := a.LogoutAll(ctx, "u1"); err != nil {
+		t.Fatalf("LogoutAll() error = %v", err)
+	}
+	for name, id := range map[string]string{"laptop": laptop.ID, "phone": phone.ID} {
+		if _, err := a.Get(ctx, id); err != auth.ErrTokenRevoked {
+			t.Errorf("Get(%s) error = %v, want ErrTokenRevoked", name, err)
+		}
+	}
+	if _, err := a.Get(ctx, other.ID); err != nil {
+		t.Errorf("Get(other user) error = %v", err)
+	}
+
+	// 之后的登录不受影响
+	s, _ := a.Create(ctx, &auth.Claims{UserID: "u1"})
+	if _, err := a.Get(ctx, s.ID); err != nil {
+		t.Errorf("Get(new session) error = %v", err)
+	}
+}
+
+func TestAuthenticator_TokenInterface(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	ctx := context.Background()
+
+	pair, err := a.GenerateToken(&auth.Claims{UserID: "u1"})
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if pair.TokenType != TokenType || pair.RefreshToken != "" {
+		t.Errorf("pair = %+v", pair)
+	}
+	refreshed, err := a.RefreshToken(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if err := a.RevokeToken(ctx, refreshed.AccessToken); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if _, err := a.Authenticate(ctx, refreshed.AccessToken); err != auth.ErrTokenInvalid {
+		t.Errorf("Authenticate(revoked) error = %v, want ErrTokenInvalid", err)
+	}
+}
+
+func TestPlugin_Init(t *testing.T) {
+	c := cache.NewMemoryCache()
+	p := &Plugin{}
+	if err := p.Init(map[string]interface{}{"cookie_name": "sid", "secure": false, "idle_timeout": "10m", "cache": c}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if p.config.CookieName != "sid" || p.config.Secure || p.config.IdleTimeout != 10*time.Minute {
+		t.Errorf("config = %+v", p.config)
+	}
+	if p.authenticator.cache != c {
+		t.Error("应使用注入的缓存保存会话")
+	}
+
+	if err := (&Plugin{}).Init(map[string]interface{}{}); !errors.Is(err, ErrNoSessionStore) {
+		t.Errorf("未注入缓存时 Init() error = %v, want ErrNoSessionStore", err)
+	}
+	if err := (&Plugin{}).Init(map[string]interface{}{"memory_store": true}); err != nil {
+		t.Errorf("显式允许内存存储时 Init() error = %v", err)
+	}
+
+	if err := (&Plugin{}).Init(map[string]interface{}{"same_site": "none", "secure": false}); err == nil {
+		t.Error("Init() should reject same_site=none without secure")
+	}
+	if err := (&Plugin{}).Init(map[string]interface{}{"same_site": "loose"}); err == nil {
+		t.Error("Init() should reject invalid same_site")
+	}
+}
@@ -0,0 +1,64 @@
+package totp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// DefaultRecoveryCodes 默认生成的恢复码数量
+const DefaultRecoveryCodes = 10
+
+const (
+	recoveryAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+	recoveryLength   = 12
+)
+
+// GenerateRecoveryCodes 生成 n 个恢复码及其哈希
+//
+// 恢复码格式为 xxxx-xxxx-xxxx（60 位随机数），明文只展示一次，服务端只保存哈希。
+// 认证器丢失时可用恢复码代替验证码，每个恢复码只能使用一次。
+func GenerateRecoveryCodes(n int) (codes, hashes []string, err error) {
+	if n <= 0 {
+		n = DefaultRecoveryCodes
+	}
+	buf := make([]byte, recoveryLength)
+	for i := 0; i < n; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		var b strings.Builder
+		for j, c := range buf {
+			if j > 0 && j%4 == 0 {
+				b.WriteByte('-')
+			}
+			b.WriteByte(recoveryAlphabet[c&31])
+		}
+		code := b.String()
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode 计算恢复码哈希，忽略大小写、空格与连字符
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// UseRecoveryCode 校验恢复码，返回移除已使用恢复码后的哈希列表，调用方应保存
+func UseRecoveryCode(hashes []string, code string) ([]string, error) {
+	h := []byte(HashRecoveryCode(code))
+	for i, stored := range hashes {
+		if subtle.ConstantTimeCompare([]byte(stored), h) == 1 {
+			remaining := make([]string, 0, len(hashes)-1)
+			remaining = append(remaining, hashes[:i]...)
+			return append(remaining, hashes[i+1:]...), nil
+		}
+	}
+	return hashes, ErrInvalidCode
+}
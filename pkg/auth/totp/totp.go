@@ -0,0 +1,254 @@
+// Package totp 基于时间的一次性密码（RFC 6238）与恢复码
+//
+// 绑定：Generate 生成密钥与 otpauth:// 链接（展示为二维码），用户输入认证器上的验证码，
+// Validate 通过后保存密钥与返回的时间步。登录：Validate 传入上次使用的时间步，
+// 同一验证码不能重复使用；通过后调用 claims.AddAMR(now, auth.AMROTP) 再签发令牌。
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Algorithm HMAC 算法，多数认证器只支持 SHA1
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+// 默认值
+const (
+	DefaultDigits = 6
+	DefaultPeriod = 30 * time.Second
+	DefaultSkew   = 1
+	// SecretSize 密钥字节数，与 SHA1 输出长度一致（RFC 4226 建议至少 160 位）
+	SecretSize = 20
+)
+
+// 错误定义
+var (
+	ErrInvalidCode   = errors.New("totp: invalid code")
+	ErrCodeReused    = errors.New("totp: code already used")
+	ErrInvalidSecret = errors.New("totp: invalid secret")
+)
+
+// b32 认证器使用的无填充 Base32
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTP 验证码生成与校验
+type TOTP struct {
+	issuer    string
+	digits    int
+	period    time.Duration
+	skew      int
+	algorithm Algorithm
+	now       func() time.Time
+}
+
+// Option TOTP 选项
+type Option func(*TOTP)
+
+// WithIssuer 设置签发方，显示在认证器中
+func WithIssuer(issuer string) Option {
+	return func(t *TOTP) {
+		t.issuer = issuer
+	}
+}
+
+// WithDigits 设置验证码位数，默认 6
+func WithDigits(digits int) Option {
+	return func(t *TOTP) {
+		t.digits = digits
+	}
+}
+
+// WithPeriod 设置时间步长，默认 30 秒
+func WithPeriod(period time.Duration) Option {
+	return func(t *TOTP) {
+		t.period = period
+	}
+}
+
+// WithSkew 设置允许前后偏差的时间步数，默认 1，用于容忍时钟误差与输入延迟
+func WithSkew(skew int) Option {
+	return func(t *TOTP) {
+		t.skew = skew
+	}
+}
+
+// WithAlgorithm 设置 HMAC 算法，默认 SHA1
+func WithAlgorithm(alg Algorithm) Option {
+	return func(t *TOTP) {
+		t.algorithm = alg
+	}
+}
+
+// WithClock 设置时钟，用于测试
+func WithClock(now func() time.Time) Option {
+	return func(t *TOTP) {
+		t.now = now
+	}
+}
+
+// New 创建 TOTP
+func New(opts ...Option) *TOTP {
+	t := &TOTP{
+		digits:    DefaultDigits,
+		period:    DefaultPeriod,
+		skew:      DefaultSkew,
+		algorithm: SHA1,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	// RFC 4226 要求 6 到 8 位
+	if t.digits < 6 || t.digits > 8 {
+		t.digits = DefaultDigits
+	}
+	if t.period < time.Second {
+		t.period = DefaultPeriod
+	}
+	return t
+}
+
+// Key 新生成的密钥
+type Key struct {
+	// Secret Base32 编码的密钥，需加密保存
+	Secret string `json:"secret"`
+	// URL otpauth:// 链接，展示为二维码供认证器扫描
+	URL string `json:"url"`
+}
+
+// Generate 为账号生成新密钥
+func (t *TOTP) Generate(account string) (*Key, error) {
+	buf := make([]byte, SecretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	secret := b32.EncodeToString(buf)
+	return &Key{Secret: secret, URL: t.URL(account, secret)}, nil
+}
+
+// URL 生成 otpauth:// 链接（Key URI Format）
+func (t *TOTP) URL(account, secret string) string {
+	label := account
+	if t.issuer != "" {
+		label = t.issuer + ":" + account
+	}
+	q := url.Values{}
+	q.Set("secret", secret)
+	if t.issuer != "" {
+		q.Set("issuer", t.issuer)
+	}
+	q.Set("algorithm", string(t.algorithm))
+	q.Set("digits", strconv.Itoa(t.digits))
+	q.Set("period", strconv.Itoa(int(t.period/time.Second)))
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: q.Encode()}
+	return u.String()
+}
+
+// Code 计算 at 时刻的验证码
+func (t *TOTP) Code(secret string, at time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return t.hotp(key, t.step(at)), nil
+}
+
+// Validate 校验当前时刻的验证码，返回匹配的时间步
+//
+// lastStep 为该用户上次验证通过的时间步（首次为 0），不大于它的时间步不再接受，防止验证码被重放；
+// 调用方应保存返回值作为下次的 lastStep。
+func (t *TOTP) Validate(secret, code string, lastStep int64) (int64, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != t.digits {
+		return 0, ErrInvalidCode
+	}
+
+	current := t.step(t.now())
+	reused := false
+	for i := -t.skew; i <= t.skew; i++ {
+		step := current + int64(i)
+		if step < 0 {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(t.hotp(key, step)), []byte(code)) != 1 {
+			continue
+		}
+		if step <= lastStep {
+			reused = true
+			continue
+		}
+		return step, nil
+	}
+	if reused {
+		return 0, ErrCodeReused
+	}
+	return 0, ErrInvalidCode
+}
+
+func (t *TOTP) step(at time.Time) int64 {
+	return at.Unix() / int64(t.period/time.Second)
+}
+
+// hotp RFC 4226 动态截断
+func (t *TOTP) hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(t.hashFunc(), key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < t.digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", t.digits, bin%mod)
+}
+
+func (t *TOTP) hashFunc() func() hash.Hash {
+	switch t.algorithm {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
+
+// decodeSecret 解码 Base32 密钥，忽略大小写、空格与填充
+func decodeSecret(secret string) ([]byte, error) {
+	s := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := b32.DecodeString(strings.TrimRight(s, "="))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return key, nil
+}
@@ -0,0 +1,147 @@
+package totp
+
+import (
+	"encoding/base32"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCode_RFC6238 RFC 6238 附录 B 的测试向量
+func TestCode_RFC6238(t *testing.T) {
+	secrets := map[Algorithm]string{
+		SHA1:   "12345678901234567890",
+		SHA256: "12345678901234567890123456789012",
+		SHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	tests := []struct {
+		unix int64
+		want map[Algorithm]string
+	}{
+		{59, map[Algorithm]string{SHA1: "94287082", SHA256: "46119246", SHA512: "90693936"}},
+		{1111111109, map[Algorithm]string{SHA1: "07081804", SHA256: "68084774", SHA512: "25091201"}},
+		{1111111111, map[Algorithm]string{SHA1: "14050471", SHA256: "67062674", SHA512: "99943326"}},
+		{1234567890, map[Algorithm]string{SHA1: "89005924", SHA256: "91819424", SHA512: "93441116"}},
+		{2000000000, map[Algorithm]string{SHA1: "69279037", SHA256: "90698825", SHA512: "38618901"}},
+		{20000000000, map[Algorithm]string{SHA1: "65353130", SHA256: "77737706", SHA512: "47863826"}},
+	}
+	for _, tt := range tests {
+		for alg, want := range tt.want {
+			secret := base32.StdEncoding.EncodeToString([]byte(secrets[alg]))
+			got, err := New(WithAlgorithm(alg), WithDigits(8)).Code(secret, time.Unix(tt.unix, 0))
+			if err != nil || got != want {
+				t.Errorf("%s@%d = %s, %v, want %s", alg, tt.unix, got, err, want)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+	totp := New(WithClock(clock))
+
+	key, err := totp.Generate("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _ := totp.Code(key.Secret, now)
+	step, err := totp.Validate(key.Secret, code, 0)
+	if err != nil || step != now.Unix()/30 {
+		t.Fatalf("Validate() = %d, %v", step, err)
+	}
+
+	// 同一验证码不能重复使用
+	if _, err := totp.Validate(key.Secret, code, step); !errors.Is(err, ErrCodeReused) {
+		t.Errorf("replay error = %v", err)
+	}
+
+	// 允许前后一个时间步的偏差
+	prev, _ := totp.Code(key.Secret, now.Add(-30*time.Second))
+	if _, err := totp.Validate(key.Secret, prev, 0); err != nil {
+		t.Errorf("previous step error = %v", err)
+	}
+	old, _ := totp.Code(key.Secret, now.Add(-90*time.Second))
+	if _, err := totp.Validate(key.Secret, old, 0); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("expired code error = %v", err)
+	}
+
+	// 下一个时间步的验证码在时钟前进后仍可使用一次
+	next, _ := totp.Code(key.Secret, now.Add(30*time.Second))
+	if s, err := totp.Validate(key.Secret, next, step); err != nil || s != step+1 {
+		t.Errorf("next step = %d, %v", s, err)
+	}
+
+	if _, err := totp.Validate(key.Secret, "12345", 0); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("short code error = %v", err)
+	}
+	if _, err := totp.Validate("not base32!", code, 0); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("invalid secret error = %v", err)
+	}
+	if _, err := New(WithClock(clock), WithSkew(0)).Validate(key.Secret, prev, 0); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("skew 0 error = %v", err)
+	}
+}
+
+func TestValidate_SecretFormat(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	totp := New(WithClock(func() time.Time { return now }))
+	key, _ := totp.Generate("alice")
+	code, _ := totp.Code(key.Secret, now)
+
+	// 用户手动输入的密钥可能带空格、小写
+	loose := strings.ToLower(key.Secret[:4] + " " + key.Secret[4:])
+	if _, err := totp.Validate(loose, code[:3]+" "+code[3:], 0); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestURL(t *testing.T) {
+	totp := New(WithIssuer("Goupter Admin"))
+	key, err := totp.Generate("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(key.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Goupter Admin:alice@example.com" {
+		t.Errorf("url = %s", key.URL)
+	}
+	if q.Get("secret") != key.Secret || q.Get("issuer") != "Goupter Admin" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("query = %v", q)
+	}
+	if len(key.Secret) != 32 || strings.Contains(key.Secret, "=") {
+		t.Errorf("secret = %s", key.Secret)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != DefaultRecoveryCodes || len(hashes) != DefaultRecoveryCodes {
+		t.Fatalf("generated %d codes", len(codes))
+	}
+	if len(codes[0]) != 14 || codes[0][4] != '-' || codes[0] == codes[1] {
+		t.Errorf("codes = %v", codes[:2])
+	}
+
+	remaining, err := UseRecoveryCode(hashes, strings.ToUpper(codes[3]))
+	if err != nil || len(remaining) != len(hashes)-1 {
+		t.Fatalf("UseRecoveryCode() = %d, %v", len(remaining), err)
+	}
+	if _, err := UseRecoveryCode(remaining, codes[3]); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("恢复码只能使用一次: %v", err)
+	}
+	if _, err := UseRecoveryCode(remaining, strings.ReplaceAll(codes[0], "-", "")); err != nil {
+		t.Errorf("应忽略连字符: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package webauthn
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"slices"
+)
+
+// 认证器数据标志位
+const (
+	flagUserPresent   = 0x01
+	flagUserVerified  = 0x04
+	flagAttestedData  = 0x40
+	flagExtensionData = 0x80
+)
+
+// authenticatorData 认证器数据
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+
+	// 仅注册时存在
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+func (a *authenticatorData) userPresent() bool {
+	return a.flags&flagUserPresent != 0
+}
+
+func (a *authenticatorData) userVerified() bool {
+	return a.flags&flagUserVerified != 0
+}
+
+// parseAuthenticatorData 解析认证器数据：rpIdHash(32) | flags(1) | signCount(4) | [凭证数据] | [扩展]
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, ErrInvalidResponse
+	}
+	a := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if a.flags&flagAttestedData != 0 {
+		// aaguid(16) | credentialIdLength(2) | credentialId | credentialPublicKey
+		if len(rest) < 18 {
+			return nil, ErrInvalidResponse
+		}
+		a.aaguid = rest[:16]
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+			return nil, ErrInvalidResponse
+		}
+		a.credentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidResponse
+		}
+		a.publicKey = rest[:n]
+		rest = rest[n:]
+	}
+	if a.flags&flagExtensionData != 0 {
+		// 扩展输出不做处理，只校验其格式
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidResponse
+		}
+		rest = rest[n:]
+	}
+	if len(rest) != 0 {
+		return nil, ErrInvalidResponse
+	}
+	return a, nil
+}
+
+// collectedClientData 浏览器收集的客户端数据
+type collectedClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// verifyClientData 校验仪式类型、挑战与来源
+func (w *WebAuthn) verifyClientData(raw []byte, ceremony string, challenge []byte) error {
+	var cd collectedClientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return ErrInvalidResponse
+	}
+	if cd.Type != ceremony {
+		return ErrInvalidResponse
+	}
+	got, err := decodeBase64URL(cd.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return ErrChallengeMismatch
+	}
+	if cd.CrossOrigin || !slices.Contains(w.origins, cd.Origin) {
+		return ErrOriginMismatch
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// errCBOR CBOR 数据无效
+var errCBOR = errors.New("webauthn: invalid cbor")
+
+// maxCBORDepth 嵌套层数上限，认证器数据的实际深度不超过 4
+const maxCBORDepth = 16
+
+// decodeCBOR 解码一个 CBOR 数据项，返回值与消耗的字节数
+//
+// 只实现 WebAuthn 需要的子集（CTAP2 规范编码）：整数为 int64，字节串为 []byte，文本为 string，
+// 数组为 []interface{}，映射为 map[interface{}]interface{}（键为 int64 或 string），
+// 不支持不定长编码。
+func decodeCBOR(data []byte) (interface{}, int, error) {
+	d := &cborDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return v, d.pos, nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) remaining() int {
+	return len(d.data) - d.pos
+}
+
+// head 读取数据项头部，返回主类型、附加信息与参数
+func (d *cborDecoder) head() (major byte, info byte, arg uint64, err error) {
+	if d.remaining() < 1 {
+		return 0, 0, 0, errCBOR
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info = b>>5, b&0x1f
+
+	var n int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 24:
+		n = 1
+	case info == 25:
+		n = 2
+	case info == 26:
+		n = 4
+	case info == 27:
+		n = 8
+	default:
+		// 不定长与保留值
+		return 0, 0, 0, errCBOR
+	}
+	if d.remaining() < n {
+		return 0, 0, 0, errCBOR
+	}
+	buf := d.data[d.pos : d.pos+n]
+	d.pos += n
+	switch n {
+	case 1:
+		arg = uint64(buf[0])
+	case 2:
+		arg = uint64(binary.BigEndian.Uint16(buf))
+	case 4:
+		arg = uint64(binary.BigEndian.Uint32(buf))
+	default:
+		arg = binary.BigEndian.Uint64(buf)
+	}
+	return major, info, arg, nil
+}
+
+func (d *cborDecoder) value(depth int) (interface{}, error) {
+	if depth > maxCBORDepth {
+		return nil, errCBOR
+	}
+	major, info, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0: // 无符号整数
+		if arg > math.MaxInt64 {
+			return nil, errCBOR
+		}
+		return int64(arg), nil
+	case 1: // 负整数
+		if arg > math.MaxInt64 {
+			return nil, errCBOR
+		}
+		return -1 - int64(arg), nil
+	case 2, 3: // 字节串、文本
+		if arg > uint64(d.remaining()) {
+			return nil, errCBOR
+		}
+		b := d.data[d.pos : d.pos+int(arg)]
+		d.pos += int(arg)
+		if major == 3 {
+			return string(b), nil
+		}
+		return append([]byte(nil), b...), nil
+	case 4: // 数组
+		// 每个元素至少 1 字节，避免恶意长度导致大量分配
+		if arg > uint64(d.remaining()) {
+			return nil, errCBOR
+		}
+		arr := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case 5: // 映射
+		if arg > uint64(d.remaining()/2) {
+			return nil, errCBOR
+		}
+		m := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			k, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, errCBOR
+			}
+			if _, dup := m[k]; dup {
+				return nil, errCBOR
+			}
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	case 6: // 标签，取其内容
+		return d.value(depth + 1)
+	default: // 简单值与浮点数，不支持半精度
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		case 26:
+			return float64(math.Float32frombits(uint32(arg))), nil
+		case 27:
+			return math.Float64frombits(arg), nil
+		}
+		return nil, errCBOR
+	}
+}
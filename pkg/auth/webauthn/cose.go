@@ -0,0 +1,109 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+)
+
+// COSE 算法（RFC 9053）
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms 支持的公钥算法，按优先顺序
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE 键参数
+const (
+	coseKty = 1
+	coseAlg = 3
+	coseCrv = -1 // EC2、OKP 的曲线，RSA 的 n
+	coseX   = -2 // EC2、OKP 的 x，RSA 的 e
+	coseY   = -3
+
+	ktyOKP = 1
+	ktyEC2 = 2
+	ktyRSA = 3
+
+	crvP256    = 1
+	crvEd25519 = 6
+)
+
+// coseKey 解析后的凭证公钥
+type coseKey struct {
+	alg int64
+	pub crypto.PublicKey
+}
+
+// parseCOSEKey 解析 COSE_Key 编码的公钥
+func parseCOSEKey(data []byte) (*coseKey, error) {
+	v, n, err := decodeCBOR(data)
+	if err != nil || n != len(data) {
+		return nil, ErrInvalidResponse
+	}
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrInvalidResponse
+	}
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+	crv, _ := m[int64(coseCrv)].(int64)
+	x, _ := m[int64(coseX)].([]byte)
+
+	key := &coseKey{alg: alg}
+	switch {
+	case alg == AlgES256 && kty == ktyEC2 && crv == crvP256:
+		y, _ := m[int64(coseY)].([]byte)
+		if len(x) != 32 || len(y) != 32 {
+			return nil, ErrInvalidResponse
+		}
+		pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, ErrInvalidResponse
+		}
+		key.pub = pub
+	case alg == AlgEdDSA && kty == ktyOKP && crv == crvEd25519:
+		if len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidResponse
+		}
+		key.pub = ed25519.PublicKey(x)
+	case alg == AlgRS256 && kty == ktyRSA:
+		n, _ := m[int64(coseCrv)].([]byte)
+		e, _ := m[int64(coseX)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrInvalidResponse
+		}
+		key.pub = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	return key, nil
+}
+
+// verify 校验签名
+func (k *coseKey) verify(data, sig []byte) error {
+	switch pub := k.pub.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if ed25519.Verify(pub, data, sig) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,453 @@
+// Package webauthn WebAuthn 注册与认证仪式的服务端校验
+//
+// 注册：BeginRegistration 生成选项返回给浏览器（navigator.credentials.create），挑战由调用方
+// 保存在会话中；FinishRegistration 校验浏览器的响应并返回凭证，调用方保存凭证。
+// 认证：BeginLogin 生成选项（navigator.credentials.get），FinishLogin 校验签名并返回新的签名计数，
+// 通过后调用 claims.AddAMR(now, auth.AMRHardwareKey) 再签发令牌。
+//
+// 只支持 attestation 为 none：不校验认证器的证明声明，适用于不限制认证器型号的场景。
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// 仪式类型，与 clientDataJSON 的 type 一致
+const (
+	CeremonyCreate = "webauthn.create"
+	CeremonyGet    = "webauthn.get"
+)
+
+// 用户验证要求
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+// 默认值
+const (
+	DefaultTimeout = 5 * time.Minute
+	// ChallengeSize 挑战字节数
+	ChallengeSize = 32
+)
+
+// 错误定义
+var (
+	ErrChallengeExpired     = errors.New("webauthn: challenge expired")
+	ErrChallengeMismatch    = errors.New("webauthn: challenge mismatch")
+	ErrOriginMismatch       = errors.New("webauthn: origin mismatch")
+	ErrRPIDMismatch         = errors.New("webauthn: rp id mismatch")
+	ErrUserNotPresent       = errors.New("webauthn: user not present")
+	ErrUserNotVerified      = errors.New("webauthn: user not verified")
+	ErrInvalidSignature     = errors.New("webauthn: invalid signature")
+	ErrCredentialNotAllowed = errors.New("webauthn: credential not allowed")
+	ErrUnsupportedAlgorithm = errors.New("webauthn: unsupported algorithm")
+	ErrInvalidResponse      = errors.New("webauthn: invalid response")
+	// ErrSignCount 签名计数回退，凭证可能被克隆
+	ErrSignCount = errors.New("webauthn: sign count regression")
+)
+
+// Base64URL JSON 中以无填充 base64url 表示的字节串
+type Base64URL []byte
+
+// MarshalJSON 实现 json.Marshaler
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := decodeBase64URL(s)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// User 注册凭证的用户
+type User struct {
+	// ID 用户句柄，不应包含邮箱等个人信息，最长 64 字节
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// Credential 已注册的凭证，由调用方保存
+type Credential struct {
+	ID         []byte   `json:"id"`
+	PublicKey  []byte   `json:"public_key"` // COSE_Key 编码
+	Algorithm  int64    `json:"algorithm"`
+	SignCount  uint32   `json:"sign_count"`
+	UserID     []byte   `json:"user_id"`
+	AAGUID     []byte   `json:"aaguid,omitempty"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// Challenge 进行中的仪式，由调用方保存在会话中，仪式结束后删除
+type Challenge struct {
+	Challenge        []byte    `json:"challenge"`
+	Ceremony         string    `json:"ceremony"`
+	UserID           []byte    `json:"user_id,omitempty"`
+	AllowCredentials [][]byte  `json:"allow_credentials,omitempty"`
+	UserVerification string    `json:"user_verification"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// RelyingParty 依赖方
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity 选项中的用户
+type UserEntity struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+// CredentialParameter 可接受的公钥算法
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// CredentialDescriptor 凭证描述
+type CredentialDescriptor struct {
+	Type       string    `json:"type"`
+	ID         Base64URL `json:"id"`
+	Transports []string  `json:"transports,omitempty"`
+}
+
+// AuthenticatorSelection 认证器要求
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey,omitempty"`
+	UserVerification string `json:"userVerification,omitempty"`
+}
+
+// CreationOptions navigator.credentials.create 的 publicKey 选项
+type CreationOptions struct {
+	Challenge              Base64URL              `json:"challenge"`
+	RP                     RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout,omitempty"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions navigator.credentials.get 的 publicKey 选项
+type RequestOptions struct {
+	Challenge        Base64URL              `json:"challenge"`
+	Timeout          int64                  `json:"timeout,omitempty"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification,omitempty"`
+}
+
+// RegistrationResponse 注册响应（PublicKeyCredential.toJSON()）
+type RegistrationResponse struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON"`
+		AttestationObject Base64URL `json:"attestationObject"`
+		Transports        []string  `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// LoginResponse 认证响应（PublicKeyCredential.toJSON()）
+type LoginResponse struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON"`
+		AuthenticatorData Base64URL `json:"authenticatorData"`
+		Signature         Base64URL `json:"signature"`
+		UserHandle        Base64URL `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// WebAuthn 依赖方配置
+type WebAuthn struct {
+	rpID             string
+	rpName           string
+	origins          []string
+	userVerification string
+	timeout          time.Duration
+	now              func() time.Time
+}
+
+// Option WebAuthn 选项
+type Option func(*WebAuthn)
+
+// WithRPName 设置依赖方名称，默认为 rpID
+func WithRPName(name string) Option {
+	return func(w *WebAuthn) {
+		w.rpName = name
+	}
+}
+
+// WithOrigins 设置允许的来源，默认 https://{rpID}
+func WithOrigins(origins ...string) Option {
+	return func(w *WebAuthn) {
+		w.origins = origins
+	}
+}
+
+// WithUserVerification 设置用户验证要求，默认 preferred；
+// 设为 required 时认证器须验证 PIN 或生物特征
+func WithUserVerification(uv string) Option {
+	return func(w *WebAuthn) {
+		w.userVerification = uv
+	}
+}
+
+// WithTimeout 设置仪式超时时间，默认 5 分钟
+func WithTimeout(d time.Duration) Option {
+	return func(w *WebAuthn) {
+		w.timeout = d
+	}
+}
+
+// WithClock 设置时钟，用于测试
+func WithClock(now func() time.Time) Option {
+	return func(w *WebAuthn) {
+		w.now = now
+	}
+}
+
+// New 创建 WebAuthn，rpID 为站点的可注册域名（如 example.com）
+func New(rpID string, opts ...Option) *WebAuthn {
+	w := &WebAuthn{
+		rpID:             rpID,
+		rpName:           rpID,
+		userVerification: UserVerificationPreferred,
+		timeout:          DefaultTimeout,
+		now:              time.Now,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if len(w.origins) == 0 {
+		w.origins = []string{"https://" + rpID}
+	}
+	return w
+}
+
+// BeginRegistration 开始注册，exclude 为用户已有凭证，避免同一认证器重复注册
+func (w *WebAuthn) BeginRegistration(user User, exclude []Credential) (*CreationOptions, *Challenge, error) {
+	ch, err := w.newChallenge(CeremonyCreate)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch.UserID = user.ID
+
+	opts := &CreationOptions{
+		Challenge:          ch.Challenge,
+		RP:                 RelyingParty{ID: w.rpID, Name: w.rpName},
+		User:               UserEntity{ID: user.ID, Name: user.Name, DisplayName: user.DisplayName},
+		Timeout:            w.timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(exclude),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: w.userVerification,
+		},
+		Attestation: "none",
+	}
+	for _, alg := range SupportedAlgorithms {
+		opts.PubKeyCredParams = append(opts.PubKeyCredParams, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+	return opts, ch, nil
+}
+
+// FinishRegistration 校验注册响应，返回新凭证
+func (w *WebAuthn) FinishRegistration(ch *Challenge, resp *RegistrationResponse) (*Credential, error) {
+	if err := w.checkChallenge(ch, CeremonyCreate); err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Type != "public-key" {
+		return nil, ErrInvalidResponse
+	}
+	if err := w.verifyClientData(resp.Response.ClientDataJSON, CeremonyCreate, ch.Challenge); err != nil {
+		return nil, err
+	}
+
+	v, n, err := decodeCBOR(resp.Response.AttestationObject)
+	if err != nil || n != len(resp.Response.AttestationObject) {
+		return nil, ErrInvalidResponse
+	}
+	obj, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrInvalidResponse
+	}
+	raw, ok := obj["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalidResponse
+	}
+	ad, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.checkAuthenticatorData(ad, ch.UserVerification); err != nil {
+		return nil, err
+	}
+	if ad.credentialID == nil || !bytes.Equal(ad.credentialID, resp.RawID) {
+		return nil, ErrInvalidResponse
+	}
+	key, err := parseCOSEKey(ad.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:         bytes.Clone(ad.credentialID),
+		PublicKey:  bytes.Clone(ad.publicKey),
+		Algorithm:  key.alg,
+		SignCount:  ad.signCount,
+		UserID:     ch.UserID,
+		AAGUID:     bytes.Clone(ad.aaguid),
+		Transports: resp.Response.Transports,
+	}, nil
+}
+
+// BeginLogin 开始认证，allowed 为用户的凭证；为空时由认证器选择可发现凭证（免用户名登录）
+func (w *WebAuthn) BeginLogin(allowed []Credential) (*RequestOptions, *Challenge, error) {
+	ch, err := w.newChallenge(CeremonyGet)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, c := range allowed {
+		ch.AllowCredentials = append(ch.AllowCredentials, c.ID)
+	}
+	return &RequestOptions{
+		Challenge:        ch.Challenge,
+		Timeout:          w.timeout.Milliseconds(),
+		RPID:             w.rpID,
+		AllowCredentials: descriptors(allowed),
+		UserVerification: w.userVerification,
+	}, ch, nil
+}
+
+// FinishLogin 校验认证响应，返回新的签名计数，调用方应保存
+//
+// cred 为按 resp.RawID 查到的凭证。
+func (w *WebAuthn) FinishLogin(ch *Challenge, cred *Credential, resp *LoginResponse) (uint32, error) {
+	if err := w.checkChallenge(ch, CeremonyGet); err != nil {
+		return 0, err
+	}
+	if cred == nil || resp == nil || resp.Type != "public-key" {
+		return 0, ErrInvalidResponse
+	}
+	if !bytes.Equal(resp.RawID, cred.ID) {
+		return 0, ErrCredentialNotAllowed
+	}
+	if len(ch.AllowCredentials) > 0 && !containsID(ch.AllowCredentials, cred.ID) {
+		return 0, ErrCredentialNotAllowed
+	}
+	if len(resp.Response.UserHandle) > 0 && !bytes.Equal(resp.Response.UserHandle, cred.UserID) {
+		return 0, ErrCredentialNotAllowed
+	}
+	if err := w.verifyClientData(resp.Response.ClientDataJSON, CeremonyGet, ch.Challenge); err != nil {
+		return 0, err
+	}
+
+	ad, err := parseAuthenticatorData(resp.Response.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	if err := w.checkAuthenticatorData(ad, ch.UserVerification); err != nil {
+		return 0, err
+	}
+
+	key, err := parseCOSEKey(cred.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	signed := append(bytes.Clone(resp.Response.AuthenticatorData), clientDataHash[:]...)
+	if err := key.verify(signed, resp.Response.Signature); err != nil {
+		return 0, err
+	}
+
+	// 不支持计数的认证器始终返回 0
+	if (ad.signCount != 0 || cred.SignCount != 0) && ad.signCount <= cred.SignCount {
+		return 0, ErrSignCount
+	}
+	return ad.signCount, nil
+}
+
+func (w *WebAuthn) newChallenge(ceremony string) (*Challenge, error) {
+	buf := make([]byte, ChallengeSize)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	return &Challenge{
+		Challenge:        buf,
+		Ceremony:         ceremony,
+		UserVerification: w.userVerification,
+		ExpiresAt:        w.now().Add(w.timeout),
+	}, nil
+}
+
+func (w *WebAuthn) checkChallenge(ch *Challenge, ceremony string) error {
+	if ch == nil || ch.Ceremony != ceremony || len(ch.Challenge) == 0 {
+		return ErrChallengeMismatch
+	}
+	if !w.now().Before(ch.ExpiresAt) {
+		return ErrChallengeExpired
+	}
+	return nil
+}
+
+func (w *WebAuthn) checkAuthenticatorData(ad *authenticatorData, uv string) error {
+	rpIDHash := sha256.Sum256([]byte(w.rpID))
+	if subtle.ConstantTimeCompare(ad.rpIDHash, rpIDHash[:]) != 1 {
+		return ErrRPIDMismatch
+	}
+	if !ad.userPresent() {
+		return ErrUserNotPresent
+	}
+	if uv == UserVerificationRequired && !ad.userVerified() {
+		return ErrUserNotVerified
+	}
+	return nil
+}
+
+func descriptors(creds []Credential) []CredentialDescriptor {
+	var out []CredentialDescriptor
+	for _, c := range creds {
+		out = append(out, CredentialDescriptor{Type: "public-key", ID: c.ID, Transports: c.Transports})
+	}
+	return out
+}
+
+func containsID(ids [][]byte, id []byte) bool {
+	for _, v := range ids {
+		if bytes.Equal(v, id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,365 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+// kv 有序映射项，测试编码器按给定顺序输出
+type kv struct {
+	k, v interface{}
+}
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n < 1<<8:
+		return []byte{major<<5 | 24, byte(n)}
+	case n < 1<<16:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	}
+}
+
+func cborEncode(v interface{}) []byte {
+	switch x := v.(type) {
+	case int:
+		if x < 0 {
+			return cborHead(1, uint64(-1-x))
+		}
+		return cborHead(0, uint64(x))
+	case int64:
+		return cborEncode(int(x))
+	case []byte:
+		return append(cborHead(2, uint64(len(x))), x...)
+	case string:
+		return append(cborHead(3, uint64(len(x))), x...)
+	case []kv:
+		out := cborHead(5, uint64(len(x)))
+		for _, e := range x {
+			out = append(out, cborEncode(e.k)...)
+			out = append(out, cborEncode(e.v)...)
+		}
+		return out
+	}
+	panic("unsupported")
+}
+
+// fakeAuthenticator 模拟认证器
+type fakeAuthenticator struct {
+	id     []byte
+	alg    int64
+	signer crypto.Signer
+	count  uint32
+	flags  byte
+	rpID   string
+	origin string
+}
+
+func newFakeAuthenticator(t *testing.T, alg int64) *fakeAuthenticator {
+	t.Helper()
+	var signer crypto.Signer
+	var err error
+	switch alg {
+	case AlgES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &fakeAuthenticator{
+		id:     id,
+		alg:    alg,
+		signer: signer,
+		flags:  flagUserPresent | flagUserVerified,
+		rpID:   testRPID,
+		origin: testOrigin,
+	}
+}
+
+func (a *fakeAuthenticator) coseKey() []byte {
+	switch pub := a.signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		raw, _ := pub.Bytes()
+		return cborEncode([]kv{{1, ktyEC2}, {3, AlgES256}, {-1, crvP256}, {-2, raw[1:33]}, {-3, raw[33:]}})
+	case ed25519.PublicKey:
+		return cborEncode([]kv{{1, ktyOKP}, {3, AlgEdDSA}, {-1, crvEd25519}, {-2, []byte(pub)}})
+	case *rsa.PublicKey:
+		return cborEncode([]kv{{1, ktyRSA}, {3, AlgRS256}, {-1, pub.N.Bytes()}, {-2, big.NewInt(int64(pub.E)).Bytes()}})
+	}
+	panic("unsupported")
+}
+
+func (a *fakeAuthenticator) authData(attested bool) []byte {
+	h := sha256.Sum256([]byte(a.rpID))
+	flags := a.flags
+	if attested {
+		flags |= flagAttestedData
+	}
+	out := append(h[:], flags)
+	out = binary.BigEndian.AppendUint32(out, a.count)
+	if attested {
+		out = append(out, make([]byte, 16)...)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(a.id)))
+		out = append(out, a.id...)
+		out = append(out, a.coseKey()...)
+	}
+	return out
+}
+
+func (a *fakeAuthenticator) clientData(ceremony string, challenge []byte) []byte {
+	b, _ := json.Marshal(collectedClientData{
+		Type:      ceremony,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    a.origin,
+	})
+	return b
+}
+
+func (a *fakeAuthenticator) create(opts *CreationOptions) *RegistrationResponse {
+	resp := &RegistrationResponse{ID: base64.RawURLEncoding.EncodeToString(a.id), RawID: a.id, Type: "public-key"}
+	resp.Response.ClientDataJSON = a.clientData(CeremonyCreate, opts.Challenge)
+	resp.Response.AttestationObject = cborEncode([]kv{
+		{"fmt", "none"},
+		{"attStmt", []kv{}},
+		{"authData", a.authData(true)},
+	})
+	return resp
+}
+
+func (a *fakeAuthenticator) get(opts *RequestOptions, userHandle []byte) *LoginResponse {
+	a.count++
+	resp := &LoginResponse{ID: base64.RawURLEncoding.EncodeToString(a.id), RawID: a.id, Type: "public-key"}
+	resp.Response.ClientDataJSON = a.clientData(CeremonyGet, opts.Challenge)
+	resp.Response.AuthenticatorData = a.authData(false)
+	resp.Response.UserHandle = userHandle
+
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	msg := append(append([]byte(nil), resp.Response.AuthenticatorData...), clientDataHash[:]...)
+	var sig []byte
+	var err error
+	if a.alg == AlgEdDSA {
+		sig, err = a.signer.Sign(rand.Reader, msg, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(msg)
+		sig, err = a.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		panic(err)
+	}
+	resp.Response.Signature = sig
+	return resp
+}
+
+func newTestWebAuthn(now *time.Time, opts ...Option) *WebAuthn {
+	opts = append([]Option{WithClock(func() time.Time { return *now })}, opts...)
+	return New(testRPID, opts...)
+}
+
+func register(t *testing.T, w *WebAuthn, a *fakeAuthenticator) *Credential {
+	t.Helper()
+	opts, ch, err := w.BeginRegistration(User{ID: []byte("user-1"), Name: "alice"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred, err := w.FinishRegistration(ch, a.create(opts))
+	if err != nil {
+		t.Fatalf("FinishRegistration: %v", err)
+	}
+	return cred
+}
+
+func TestCeremonies(t *testing.T) {
+	for _, alg := range SupportedAlgorithms {
+		now := time.Unix(1700000000, 0)
+		w := newTestWebAuthn(&now)
+		a := newFakeAuthenticator(t, alg)
+
+		cred := register(t, w, a)
+		if cred.Algorithm != alg || string(cred.UserID) != "user-1" || string(cred.ID) != string(a.id) {
+			t.Fatalf("alg %d: unexpected credential %+v", alg, cred)
+		}
+
+		opts, ch, err := w.BeginLogin([]Credential{*cred})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(opts.AllowCredentials) != 1 || opts.RPID != testRPID {
+			t.Fatalf("alg %d: unexpected request options %+v", alg, opts)
+		}
+		count, err := w.FinishLogin(ch, cred, a.get(opts, cred.UserID))
+		if err != nil {
+			t.Fatalf("alg %d: FinishLogin: %v", alg, err)
+		}
+		if count != 1 {
+			t.Fatalf("alg %d: sign count = %d", alg, count)
+		}
+	}
+}
+
+func TestFinishLoginFailures(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	w := newTestWebAuthn(&now, WithUserVerification(UserVerificationRequired))
+	a := newFakeAuthenticator(t, AlgES256)
+	cred := register(t, w, a)
+
+	tests := []struct {
+		name   string
+		mutate func(ch *Challenge, cred *Credential, resp *LoginResponse)
+		before func()
+		want   error
+	}{
+		{"bad signature", func(_ *Challenge, _ *Credential, r *LoginResponse) {
+			r.Response.Signature[len(r.Response.Signature)-1] ^= 1
+		}, nil, ErrInvalidSignature},
+		{"challenge mismatch", func(ch *Challenge, _ *Credential, _ *LoginResponse) {
+			ch.Challenge = []byte("other")
+		}, nil, ErrChallengeMismatch},
+		{"wrong ceremony", func(ch *Challenge, _ *Credential, _ *LoginResponse) {
+			ch.Ceremony = CeremonyCreate
+		}, nil, ErrChallengeMismatch},
+		{"expired", func(*Challenge, *Credential, *LoginResponse) {
+			now = now.Add(DefaultTimeout)
+		}, nil, ErrChallengeExpired},
+		{"origin", nil, func() { a.origin = "https://evil.com" }, ErrOriginMismatch},
+		{"rp id", nil, func() { a.rpID = "evil.com" }, ErrRPIDMismatch},
+		{"user not present", nil, func() { a.flags = 0 }, ErrUserNotPresent},
+		{"user not verified", nil, func() { a.flags = flagUserPresent }, ErrUserNotVerified},
+		{"sign count regression", func(_ *Challenge, c *Credential, _ *LoginResponse) {
+			c.SignCount = 100
+		}, nil, ErrSignCount},
+		{"not allowed", func(ch *Challenge, _ *Credential, _ *LoginResponse) {
+			ch.AllowCredentials = [][]byte{[]byte("other")}
+		}, nil, ErrCredentialNotAllowed},
+		{"user handle", func(_ *Challenge, _ *Credential, r *LoginResponse) {
+			r.Response.UserHandle = []byte("user-2")
+		}, nil, ErrCredentialNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = time.Unix(1700000000, 0)
+			a.origin, a.rpID, a.flags = testOrigin, testRPID, flagUserPresent|flagUserVerified
+			if tt.before != nil {
+				tt.before()
+			}
+			c := *cred
+			opts, ch, err := w.BeginLogin([]Credential{c})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp := a.get(opts, c.UserID)
+			c.SignCount = a.count - 1
+			if tt.mutate != nil {
+				tt.mutate(ch, &c, resp)
+			}
+			if _, err := w.FinishLogin(ch, &c, resp); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFinishRegistrationFailures(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	w := newTestWebAuthn(&now, WithOrigins("https://app.example.com"))
+	a := newFakeAuthenticator(t, AlgES256)
+
+	opts, ch, _ := w.BeginRegistration(User{ID: []byte("user-1")}, nil)
+	if _, err := w.FinishRegistration(ch, a.create(opts)); !errors.Is(err, ErrOriginMismatch) {
+		t.Fatalf("expected ErrOriginMismatch, got %v", err)
+	}
+
+	a.origin = "https://app.example.com"
+	resp := a.create(opts)
+	resp.RawID = []byte("other")
+	if _, err := w.FinishRegistration(ch, resp); !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("expected ErrInvalidResponse, got %v", err)
+	}
+
+	resp = a.create(opts)
+	resp.Response.AttestationObject = resp.Response.AttestationObject[:10]
+	if _, err := w.FinishRegistration(ch, resp); !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("expected ErrInvalidResponse, got %v", err)
+	}
+
+	_, loginCh, _ := w.BeginLogin(nil)
+	if _, err := w.FinishRegistration(loginCh, a.create(opts)); !errors.Is(err, ErrChallengeMismatch) {
+		t.Fatalf("expected ErrChallengeMismatch, got %v", err)
+	}
+}
+
+func TestUnsupportedAlgorithm(t *testing.T) {
+	key := cborEncode([]kv{{1, ktyEC2}, {3, -35}, {-1, 2}, {-2, make([]byte, 48)}, {-3, make([]byte, 48)}})
+	if _, err := parseCOSEKey(key); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Fatalf("expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+}
+
+func TestOptionsJSON(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	w := newTestWebAuthn(&now, WithRPName("Admin"))
+	opts, ch, err := w.BeginRegistration(User{ID: []byte{0xfb, 0xff}, Name: "alice", DisplayName: "Alice"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ch.ExpiresAt.Equal(now.Add(DefaultTimeout)) {
+		t.Fatalf("unexpected expiry %v", ch.ExpiresAt)
+	}
+	b, _ := json.Marshal(opts)
+	var m map[string]interface{}
+	json.Unmarshal(b, &m)
+	if m["user"].(map[string]interface{})["id"] != "-_8" || m["attestation"] != "none" || m["timeout"] != float64(300000) {
+		t.Fatalf("unexpected options %s", b)
+	}
+	var decoded CreationOptions
+	if err := json.Unmarshal(b, &decoded); err != nil || string(decoded.Challenge) != string(ch.Challenge) {
+		t.Fatalf("round trip failed: %v", err)
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	invalid := [][]byte{
+		{0x5f},                         // 不定长字节串
+		{0x42, 0x01},                   // 长度越界
+		{0xa2, 0x01, 0x02},             // 映射项不足
+		{0xa2, 0x01, 0x02, 0x01, 0x03}, // 重复键
+	}
+	for _, data := range invalid {
+		if _, _, err := decodeCBOR(data); err == nil {
+			t.Errorf("expected error for %x", data)
+		}
+	}
+	v, n, err := decodeCBOR([]byte{0xa2, 0x63, 'f', 'm', 't', 0x20, 0x01, 0xf5, 0xff})
+	if err != nil || n != 8 {
+		t.Fatalf("decode failed: %v %d", err, n)
+	}
+	m := v.(map[interface{}]interface{})
+	if m["fmt"] != int64(-1) || m[int64(1)] != true {
+		t.Fatalf("unexpected value %v", m)
+	}
+}
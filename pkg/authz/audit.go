@@ -0,0 +1,73 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/goupter/goupter/pkg/log"
+)
+
+// Auditor 决策审计，每次决策后同步调用，实现不应阻塞
+type Auditor interface {
+	Audit(ctx context.Context, d *Decision)
+}
+
+// AuditorFunc 函数类型的审计
+type AuditorFunc func(ctx context.Context, d *Decision)
+
+func (f AuditorFunc) Audit(ctx context.Context, d *Decision) {
+	f(ctx, d)
+}
+
+// LogAuditor 将决策写入日志，拒绝记为 Warn，允许记为 Info
+type LogAuditor struct {
+	logger   log.Logger
+	denyOnly bool
+}
+
+// LogAuditorOption 日志审计选项
+type LogAuditorOption func(*LogAuditor)
+
+// WithDenyOnly 只记录拒绝的决策
+func WithDenyOnly() LogAuditorOption {
+	return func(a *LogAuditor) {
+		a.denyOnly = true
+	}
+}
+
+// NewLogAuditor 创建日志审计，日志带上请求上下文中的追踪 ID
+func NewLogAuditor(logger log.Logger, opts ...LogAuditorOption) *LogAuditor {
+	a := &LogAuditor{logger: logger}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Audit 实现 Auditor
+func (a *LogAuditor) Audit(ctx context.Context, d *Decision) {
+	if d.Allowed && a.denyOnly {
+		return
+	}
+
+	fields := []log.Field{
+		log.Bool("allowed", d.Allowed),
+		log.String("reason", d.Reason),
+		log.UserID(d.UserID),
+		log.String("action", d.Action),
+		log.String("resource_type", d.ResourceType),
+		log.String("resource_id", d.ResourceID),
+	}
+	if d.PolicyID != "" {
+		fields = append(fields, log.String("policy_id", d.PolicyID))
+	}
+	if d.Permission != "" {
+		fields = append(fields, log.String("role", d.Role), log.String("permission", d.Permission))
+	}
+
+	logger := a.logger.WithContext(ctx)
+	if d.Allowed {
+		logger.Info("authz decision", fields...)
+	} else {
+		logger.Warn("authz decision", fields...)
+	}
+}
@@ -0,0 +1,222 @@
+// Package authz 基于策略的授权（RBAC + ABAC）
+//
+// 授权请求由主体（认证信息）、动作与资源组成，按以下顺序求值：
+//
+//  1. 命中任一 deny 策略则拒绝
+//  2. 主体角色（含继承的角色）拥有匹配的权限则允许
+//  3. 命中任一 allow 策略则允许
+//  4. 默认拒绝
+//
+// 权限格式为 "<资源类型>:<动作>"，两段均可使用 "*" 通配，如 "article:read"、"article:*"、"*"。
+// 策略可按角色、动作、资源类型限定，并通过属性条件比较主体、资源与动作的属性：
+//
+//	# 作者可以编辑自己的文章
+//	- id: article-owner
+//	  actions: [update, delete]
+//	  resources: [article]
+//	  conditions:
+//	    - {attr: resource.owner_id, op: eq, ref: subject.id}
+//
+// 属性路径：action、subject.id、subject.username、subject.roles、subject.scopes、
+// subject.<Extra 键>、resource.type、resource.id、resource.<Attributes 键>，嵌套字段以 "." 分隔。
+package authz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goupter/goupter/pkg/server/middleware"
+)
+
+// Effect 策略效果
+type Effect string
+
+const (
+	// EffectAllow 允许
+	EffectAllow Effect = "allow"
+	// EffectDeny 拒绝，优先于任何允许
+	EffectDeny Effect = "deny"
+)
+
+// 决策原因
+const (
+	ReasonUnauthenticated = "unauthenticated"
+	ReasonDenyPolicy      = "deny_policy"
+	ReasonRole            = "role"
+	ReasonAllowPolicy     = "allow_policy"
+	ReasonNoMatch         = "no_match"
+)
+
+// Wildcard 匹配任意角色、动作或资源类型
+const Wildcard = "*"
+
+// ErrInvalidPolicy 策略定义不合法
+var ErrInvalidPolicy = errors.New("invalid authorization policy")
+
+// Role 角色，继承的角色的权限一并生效
+type Role struct {
+	Name        string   `json:"name" mapstructure:"name"`
+	Description string   `json:"description,omitempty" mapstructure:"description"`
+	Inherits    []string `json:"inherits,omitempty" mapstructure:"inherits"`
+	Permissions []string `json:"permissions,omitempty" mapstructure:"permissions"`
+}
+
+// Policy 属性策略
+type Policy struct {
+	ID          string `json:"id" mapstructure:"id"`
+	Description string `json:"description,omitempty" mapstructure:"description"`
+	// Effect allow 或 deny，默认 allow
+	Effect Effect `json:"effect,omitempty" mapstructure:"effect"`
+	// Roles 适用的角色（含继承该角色的角色），为空时适用于所有已认证主体
+	Roles []string `json:"roles,omitempty" mapstructure:"roles"`
+	// Actions、Resources 适用的动作与资源类型，为空或 "*" 表示任意
+	Actions   []string `json:"actions,omitempty" mapstructure:"actions"`
+	Resources []string `json:"resources,omitempty" mapstructure:"resources"`
+	// Conditions 属性条件，全部满足才命中
+	Conditions []Condition `json:"conditions,omitempty" mapstructure:"conditions"`
+	// When 通过 WithCondition 注册的命名条件，全部满足才命中
+	When []string `json:"when,omitempty" mapstructure:"when"`
+}
+
+// PolicySet 角色与策略集合，可从配置段或数据库加载
+type PolicySet struct {
+	Roles    []Role   `json:"roles" mapstructure:"roles"`
+	Policies []Policy `json:"policies" mapstructure:"policies"`
+}
+
+// Resource 被访问的资源
+type Resource struct {
+	Type string
+	ID   string
+	// Attributes 资源属性，如 owner_id、tenant_id，供属性条件使用
+	Attributes map[string]interface{}
+}
+
+// NewResource 创建资源
+func NewResource(typ, id string, attrs map[string]interface{}) *Resource {
+	return &Resource{Type: typ, ID: id, Attributes: attrs}
+}
+
+// Request 授权请求
+type Request struct {
+	// Subject 主体，为 nil 表示未认证
+	Subject  *middleware.AuthInfo
+	Action   string
+	Resource *Resource
+}
+
+// Decision 授权决策，同时作为审计记录
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+	// PolicyID 决定结果的策略
+	PolicyID string `json:"policy_id,omitempty"`
+	// Role、Permission 角色授权时命中的角色与权限
+	Role         string    `json:"role,omitempty"`
+	Permission   string    `json:"permission,omitempty"`
+	UserID       string    `json:"user_id,omitempty"`
+	Action       string    `json:"action"`
+	ResourceType string    `json:"resource_type,omitempty"`
+	ResourceID   string    `json:"resource_id,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+// Validate 校验策略集合，条件名称由引擎在加载时校验
+func (s *PolicySet) Validate() error {
+	roles := make(map[string]*Role, len(s.Roles))
+	for i := range s.Roles {
+		r := &s.Roles[i]
+		if r.Name == "" || r.Name == Wildcard {
+			return fmt.Errorf("%w: 角色 %d 名称不合法", ErrInvalidPolicy, i)
+		}
+		if roles[r.Name] != nil {
+			return fmt.Errorf("%w: 角色 %s 重复", ErrInvalidPolicy, r.Name)
+		}
+		roles[r.Name] = r
+		for _, p := range r.Permissions {
+			if _, _, err := parsePermission(p); err != nil {
+				return fmt.Errorf("%w: 角色 %s: %v", ErrInvalidPolicy, r.Name, err)
+			}
+		}
+	}
+	for _, r := range s.Roles {
+		for _, parent := range r.Inherits {
+			if roles[parent] == nil {
+				return fmt.Errorf("%w: 角色 %s 继承的角色 %s 不存在", ErrInvalidPolicy, r.Name, parent)
+			}
+		}
+		if cycle := findCycle(roles, r.Name, nil); cycle != nil {
+			return fmt.Errorf("%w: 角色继承存在环: %s", ErrInvalidPolicy, strings.Join(cycle, " -> "))
+		}
+	}
+
+	ids := make(map[string]bool, len(s.Policies))
+	for i := range s.Policies {
+		p := &s.Policies[i]
+		if p.ID == "" {
+			return fmt.Errorf("%w: 策略 %d 缺少 id", ErrInvalidPolicy, i)
+		}
+		if ids[p.ID] {
+			return fmt.Errorf("%w: 策略 %s 重复", ErrInvalidPolicy, p.ID)
+		}
+		ids[p.ID] = true
+		switch p.Effect {
+		case "", EffectAllow, EffectDeny:
+		default:
+			return fmt.Errorf("%w: 策略 %s: 未知效果 %q", ErrInvalidPolicy, p.ID, p.Effect)
+		}
+		for j := range p.Conditions {
+			if err := p.Conditions[j].validate(); err != nil {
+				return fmt.Errorf("%w: 策略 %s: 条件 %d: %v", ErrInvalidPolicy, p.ID, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+// findCycle 沿继承链深度优先查找环，返回环上的角色
+func findCycle(roles map[string]*Role, name string, path []string) []string {
+	for i, n := range path {
+		if n == name {
+			return append(path[i:], name)
+		}
+	}
+	r := roles[name]
+	if r == nil {
+		return nil
+	}
+	path = append(path, name)
+	for _, parent := range r.Inherits {
+		if cycle := findCycle(roles, parent, path); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// parsePermission 解析 "<资源类型>:<动作>"，"*" 等价于 "*:*"
+func parsePermission(p string) (resource, action string, err error) {
+	if p == Wildcard {
+		return Wildcard, Wildcard, nil
+	}
+	resource, action, ok := strings.Cut(p, ":")
+	if !ok || resource == "" || action == "" {
+		return "", "", fmt.Errorf("权限 %q 格式应为 <资源类型>:<动作>", p)
+	}
+	return resource, action, nil
+}
+
+// matchAny 列表为空或包含 "*" 或包含 v
+func matchAny(patterns []string, v string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if p == Wildcard || p == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package authz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Operator 条件运算符
+type Operator string
+
+const (
+	// OpEq 相等，数字与字符串按字面值比较，如 42 与 "42" 相等
+	OpEq Operator = "eq"
+	// OpNe 不相等
+	OpNe Operator = "ne"
+	// OpIn 左值属于右侧列表
+	OpIn Operator = "in"
+	// OpNotIn 左值不属于右侧列表
+	OpNotIn Operator = "not_in"
+	// OpContains 左侧列表包含右值
+	OpContains Operator = "contains"
+	// OpExists 属性存在且非空，不需要右值
+	OpExists Operator = "exists"
+)
+
+// Condition 属性条件
+//
+// 右值为字面量 Value 或属性路径 Ref，二者择一。属性不存在时除 exists 外的条件均不满足，
+// ne、not_in 也不例外，避免缺失属性被当作"不相等"而放行。
+type Condition struct {
+	Attr  string      `json:"attr" mapstructure:"attr"`
+	Op    Operator    `json:"op" mapstructure:"op"`
+	Value interface{} `json:"value,omitempty" mapstructure:"value"`
+	Ref   string      `json:"ref,omitempty" mapstructure:"ref"`
+}
+
+// ConditionFunc 命名条件，用于无法用属性比较表达的规则
+type ConditionFunc func(req *Request) bool
+
+func (c *Condition) validate() error {
+	if err := validatePath(c.Attr); err != nil {
+		return err
+	}
+	switch c.Op {
+	case OpExists:
+		return nil
+	case OpEq, OpNe, OpIn, OpNotIn, OpContains:
+	default:
+		return fmt.Errorf("未知运算符 %q", c.Op)
+	}
+	if c.Ref != "" {
+		if c.Value != nil {
+			return errors.New("value 与 ref 只能设置一个")
+		}
+		return validatePath(c.Ref)
+	}
+	if c.Value == nil {
+		return errors.New("缺少 value 或 ref")
+	}
+	return nil
+}
+
+func validatePath(path string) error {
+	root, rest, _ := strings.Cut(path, ".")
+	switch root {
+	case "action":
+		if rest == "" {
+			return nil
+		}
+	case "subject", "resource":
+		if rest != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("属性路径 %q 不合法", path)
+}
+
+// eval 对请求求值
+func (c *Condition) eval(req *Request) bool {
+	left, ok := resolve(req, c.Attr)
+	if c.Op == OpExists {
+		return ok && !isEmpty(left)
+	}
+	if !ok {
+		return false
+	}
+
+	right := c.Value
+	if c.Ref != "" {
+		if right, ok = resolve(req, c.Ref); !ok {
+			return false
+		}
+	}
+
+	switch c.Op {
+	case OpEq:
+		return equal(left, right)
+	case OpNe:
+		return !equal(left, right)
+	case OpIn:
+		return contains(right, left)
+	case OpNotIn:
+		return !contains(right, left)
+	case OpContains:
+		return contains(left, right)
+	}
+	return false
+}
+
+// resolve 按属性路径取值
+func resolve(req *Request, path string) (interface{}, bool) {
+	root, rest, _ := strings.Cut(path, ".")
+	switch root {
+	case "action":
+		return req.Action, req.Action != ""
+	case "subject":
+		s := req.Subject
+		if s == nil {
+			return nil, false
+		}
+		switch rest {
+		case "id":
+			return s.UserID, s.UserID != ""
+		case "username":
+			return s.Username, s.Username != ""
+		case "roles":
+			return s.Roles, true
+		case "scopes":
+			return s.Scopes, true
+		}
+		return lookup(s.Extra, rest)
+	case "resource":
+		r := req.Resource
+		if r == nil {
+			return nil, false
+		}
+		switch rest {
+		case "type":
+			return r.Type, r.Type != ""
+		case "id":
+			return r.ID, r.ID != ""
+		}
+		return lookup(r.Attributes, rest)
+	}
+	return nil, false
+}
+
+// lookup 按 "." 分隔的路径查找嵌套字段
+func lookup(m map[string]interface{}, path string) (interface{}, bool) {
+	var v interface{} = m
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = obj[key]; !ok || v == nil {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func equal(a, b interface{}) bool {
+	if isList(a) || isList(b) {
+		return reflect.DeepEqual(a, b)
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// contains 列表是否包含元素
+func contains(list, v interface{}) bool {
+	rv := reflect.ValueOf(list)
+	if !isList(list) {
+		return false
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if equal(rv.Index(i).Interface(), v) {
+			return true
+		}
+	}
+	return false
+}
+
+func isList(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	k := reflect.TypeOf(v).Kind()
+	return k == reflect.Slice || k == reflect.Array
+}
+
+func isEmpty(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return x == ""
+	}
+	if isList(v) {
+		return reflect.ValueOf(v).Len() == 0
+	}
+	return false
+}
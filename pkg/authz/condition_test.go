@@ -0,0 +1,63 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/goupter/goupter/pkg/server/middleware"
+)
+
+func TestCondition_Eval(t *testing.T) {
+	req := &Request{
+		Subject: &middleware.AuthInfo{
+			UserID: "42",
+			Roles:  []string{"editor"},
+			Extra: map[string]interface{}{
+				"tenant_id": "t1",
+				"org":       map[string]interface{}{"region": "eu"},
+			},
+		},
+		Action: "update",
+		Resource: NewResource("article", "7", map[string]interface{}{
+			"owner_id": 42,
+			"tags":     []interface{}{"draft", "tech"},
+			"status":   "draft",
+		}),
+	}
+
+	tests := []struct {
+		name string
+		cond Condition
+		want bool
+	}{
+		{"eq number and string", Condition{Attr: "resource.owner_id", Op: OpEq, Ref: "subject.id"}, true},
+		{"eq literal", Condition{Attr: "action", Op: OpEq, Value: "update"}, true},
+		{"ne", Condition{Attr: "resource.status", Op: OpNe, Value: "published"}, true},
+		{"in", Condition{Attr: "resource.status", Op: OpIn, Value: []interface{}{"draft", "review"}}, true},
+		{"not in", Condition{Attr: "resource.status", Op: OpNotIn, Value: []string{"draft"}}, false},
+		{"in attribute list", Condition{Attr: "resource.type", Op: OpIn, Value: []string{"comment"}}, false},
+		{"contains", Condition{Attr: "resource.tags", Op: OpContains, Value: "tech"}, true},
+		{"contains subject roles", Condition{Attr: "subject.roles", Op: OpContains, Value: "admin"}, false},
+		{"nested", Condition{Attr: "subject.org.region", Op: OpEq, Value: "eu"}, true},
+		{"exists", Condition{Attr: "subject.tenant_id", Op: OpExists}, true},
+		{"not exists", Condition{Attr: "resource.tenant_id", Op: OpExists}, false},
+		{"missing attribute ne", Condition{Attr: "resource.tenant_id", Op: OpNe, Value: "t2"}, false},
+		{"missing ref", Condition{Attr: "subject.tenant_id", Op: OpEq, Ref: "resource.tenant_id"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cond.validate(); err != nil {
+				t.Fatalf("validate() error = %v", err)
+			}
+			if got := tt.cond.eval(req); got != tt.want {
+				t.Errorf("eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCondition_Anonymous(t *testing.T) {
+	c := Condition{Attr: "resource.owner_id", Op: OpEq, Ref: "subject.id"}
+	if c.eval(&Request{Action: "read", Resource: NewResource("article", "1", map[string]interface{}{"owner_id": ""})}) {
+		t.Error("condition referencing a missing subject should not match")
+	}
+}
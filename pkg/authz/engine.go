@@ -0,0 +1,285 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/errors"
+	"github.com/goupter/goupter/pkg/server/middleware"
+)
+
+// Engine 授权引擎，策略集合可随时重新加载
+type Engine struct {
+	auditor    Auditor
+	conditions map[string]ConditionFunc
+	now        func() time.Time
+
+	mu       sync.RWMutex
+	compiled *compiled
+}
+
+// compiled 预处理后的策略集合
+type compiled struct {
+	// ancestors 角色及其直接、间接继承的全部角色
+	ancestors   map[string][]string
+	permissions map[string][]permission
+	deny, allow []*Policy
+}
+
+type permission struct {
+	raw, resource, action string
+}
+
+// Option 引擎选项
+type Option func(*Engine)
+
+// WithAuditor 设置决策审计
+func WithAuditor(a Auditor) Option {
+	return func(e *Engine) {
+		e.auditor = a
+	}
+}
+
+// WithCondition 注册命名条件，策略通过 when 引用
+func WithCondition(name string, fn ConditionFunc) Option {
+	return func(e *Engine) {
+		e.conditions[name] = fn
+	}
+}
+
+// NewEngine 创建授权引擎，加载策略前拒绝所有请求
+func NewEngine(opts ...Option) *Engine {
+	e := &Engine{
+		conditions: make(map[string]ConditionFunc),
+		now:        time.Now,
+		compiled:   &compiled{},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Load 校验并替换策略集合，校验失败时保留原策略
+func (e *Engine) Load(set *PolicySet) error {
+	if set == nil {
+		set = &PolicySet{}
+	}
+	if err := set.Validate(); err != nil {
+		return err
+	}
+	for _, p := range set.Policies {
+		for _, name := range p.When {
+			if e.conditions[name] == nil {
+				return fmt.Errorf("%w: 策略 %s: 条件 %s 未注册", ErrInvalidPolicy, p.ID, name)
+			}
+		}
+	}
+
+	c, err := compile(set)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.compiled = c
+	e.mu.Unlock()
+	return nil
+}
+
+// LoadStore 从存储加载策略集合
+func (e *Engine) LoadStore(ctx context.Context, store Store) error {
+	set, err := store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("加载授权策略失败: %w", err)
+	}
+	return e.Load(set)
+}
+
+func compile(set *PolicySet) (*compiled, error) {
+	c := &compiled{
+		ancestors:   make(map[string][]string, len(set.Roles)),
+		permissions: make(map[string][]permission, len(set.Roles)),
+	}
+	inherits := make(map[string][]string, len(set.Roles))
+	for _, r := range set.Roles {
+		inherits[r.Name] = r.Inherits
+		for _, raw := range r.Permissions {
+			resource, action, err := parsePermission(raw)
+			if err != nil {
+				return nil, err
+			}
+			c.permissions[r.Name] = append(c.permissions[r.Name], permission{raw: raw, resource: resource, action: action})
+		}
+	}
+	for _, r := range set.Roles {
+		seen := map[string]bool{}
+		queue := []string{r.Name}
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			c.ancestors[r.Name] = append(c.ancestors[r.Name], name)
+			queue = append(queue, inherits[name]...)
+		}
+	}
+
+	for i := range set.Policies {
+		p := set.Policies[i]
+		if p.Effect == EffectDeny {
+			c.deny = append(c.deny, &p)
+		} else {
+			c.allow = append(c.allow, &p)
+		}
+	}
+	return c, nil
+}
+
+// Decide 求值授权请求并审计
+func (e *Engine) Decide(ctx context.Context, req *Request) *Decision {
+	d := &Decision{Action: req.Action, Time: e.now()}
+	if req.Resource != nil {
+		d.ResourceType = req.Resource.Type
+		d.ResourceID = req.Resource.ID
+	}
+	e.decide(req, d)
+	if e.auditor != nil {
+		e.auditor.Audit(ctx, d)
+	}
+	return d
+}
+
+func (e *Engine) decide(req *Request, d *Decision) {
+	if req.Subject == nil {
+		d.Reason = ReasonUnauthenticated
+		return
+	}
+	d.UserID = req.Subject.UserID
+
+	e.mu.RLock()
+	c := e.compiled
+	e.mu.RUnlock()
+
+	roles := c.expand(req.Subject.Roles)
+	resourceType := ""
+	if req.Resource != nil {
+		resourceType = req.Resource.Type
+	}
+
+	for _, p := range c.deny {
+		if e.matches(p, req, roles, resourceType) {
+			d.Reason = ReasonDenyPolicy
+			d.PolicyID = p.ID
+			return
+		}
+	}
+
+	for _, role := range roles {
+		for _, perm := range c.permissions[role] {
+			if (perm.resource == Wildcard || perm.resource == resourceType) &&
+				(perm.action == Wildcard || perm.action == req.Action) {
+				d.Allowed = true
+				d.Reason = ReasonRole
+				d.Role = role
+				d.Permission = perm.raw
+				return
+			}
+		}
+	}
+
+	for _, p := range c.allow {
+		if e.matches(p, req, roles, resourceType) {
+			d.Allowed = true
+			d.Reason = ReasonAllowPolicy
+			d.PolicyID = p.ID
+			return
+		}
+	}
+	d.Reason = ReasonNoMatch
+}
+
+// expand 主体角色加上继承的角色，未定义的角色只代表自身
+func (c *compiled) expand(roles []string) []string {
+	seen := make(map[string]bool, len(roles))
+	var out []string
+	for _, role := range roles {
+		ancestors, ok := c.ancestors[role]
+		if !ok {
+			ancestors = []string{role}
+		}
+		for _, r := range ancestors {
+			if !seen[r] {
+				seen[r] = true
+				out = append(out, r)
+			}
+		}
+	}
+	return out
+}
+
+func (e *Engine) matches(p *Policy, req *Request, roles []string, resourceType string) bool {
+	if len(p.Roles) > 0 && !intersects(p.Roles, roles) {
+		return false
+	}
+	if !matchAny(p.Actions, req.Action) || !matchAny(p.Resources, resourceType) {
+		return false
+	}
+	for i := range p.Conditions {
+		if !p.Conditions[i].eval(req) {
+			return false
+		}
+	}
+	for _, name := range p.When {
+		if fn := e.conditions[name]; fn == nil || !fn(req) {
+			return false
+		}
+	}
+	return true
+}
+
+func intersects(patterns, roles []string) bool {
+	for _, p := range patterns {
+		if p == Wildcard {
+			return true
+		}
+		for _, r := range roles {
+			if p == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Check 对上下文中的主体求值，拒绝时返回 errors.ErrUnauthorized 或 errors.ErrForbidden
+func (e *Engine) Check(ctx context.Context, action string, resource *Resource) error {
+	d := e.Decide(ctx, &Request{Subject: SubjectFromContext(ctx), Action: action, Resource: resource})
+	return decisionError(d)
+}
+
+func decisionError(d *Decision) error {
+	switch {
+	case d.Allowed:
+		return nil
+	case d.Reason == ReasonUnauthenticated:
+		return errors.ErrUnauthorized
+	default:
+		return errors.ErrForbidden
+	}
+}
+
+// SubjectFromContext 从上下文获取主体，依次读取认证信息与 auth.Claims
+func SubjectFromContext(ctx context.Context) *middleware.AuthInfo {
+	if info, ok := middleware.GetAuthInfoFromContext(ctx); ok {
+		return info
+	}
+	if claims, ok := auth.ClaimsFromContext(ctx); ok {
+		return middleware.AuthInfoFromClaims(claims)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/config"
+	apperrors "github.com/goupter/goupter/pkg/errors"
+	"github.com/goupter/goupter/pkg/server/middleware"
+)
+
+func testPolicySet() *PolicySet {
+	return &PolicySet{
+		Roles: []Role{
+			{Name: "viewer", Permissions: []string{"article:read"}},
+			{Name: "editor", Inherits: []string{"viewer"}, Permissions: []string{"article:create"}},
+			{Name: "admin", Inherits: []string{"editor"}, Permissions: []string{"*"}},
+			{Name: "tenant_admin", Inherits: []string{"viewer"}},
+		},
+		Policies: []Policy{
+			{
+				ID:         "article-owner",
+				Actions:    []string{"update", "delete"},
+				Resources:  []string{"article"},
+				Conditions: []Condition{{Attr: "resource.owner_id", Op: OpEq, Ref: "subject.id"}},
+			},
+			{
+				ID:         "tenant-admin",
+				Roles:      []string{"tenant_admin"},
+				Conditions: []Condition{{Attr: "resource.tenant_id", Op: OpEq, Ref: "subject.tenant_id"}},
+			},
+			{
+				ID:         "no-delete-archived",
+				Effect:     EffectDeny,
+				Actions:    []string{"delete"},
+				Conditions: []Condition{{Attr: "resource.archived", Op: OpEq, Value: true}},
+			},
+		},
+	}
+}
+
+func newTestEngine(t *testing.T, opts ...Option) *Engine {
+	t.Helper()
+	e := NewEngine(opts...)
+	if err := e.Load(testPolicySet()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return e
+}
+
+func TestEngine_Decide(t *testing.T) {
+	e := newTestEngine(t)
+	article := func(owner string, attrs ...interface{}) *Resource {
+		r := NewResource("article", "1", map[string]interface{}{"owner_id": owner, "tenant_id": "t1"})
+		for i := 0; i+1 < len(attrs); i += 2 {
+			r.Attributes[attrs[i].(string)] = attrs[i+1]
+		}
+		return r
+	}
+	viewer := &middleware.AuthInfo{UserID: "u1", Roles: []string{"viewer"}}
+	editor := &middleware.AuthInfo{UserID: "u2", Roles: []string{"editor"}}
+	admin := &middleware.AuthInfo{UserID: "u3", Roles: []string{"admin"}}
+	tenantAdmin := &middleware.AuthInfo{UserID: "u4", Roles: []string{"tenant_admin"}, Extra: map[string]interface{}{"tenant_id": "t1"}}
+
+	tests := []struct {
+		name     string
+		subject  *middleware.AuthInfo
+		action   string
+		resource *Resource
+		allowed  bool
+		reason   string
+	}{
+		{"unauthenticated", nil, "read", article("u1"), false, ReasonUnauthenticated},
+		{"role permission", viewer, "read", article("u9"), true, ReasonRole},
+		{"missing permission", viewer, "create", article("u9"), false, ReasonNoMatch},
+		{"inherited permission", editor, "read", article("u9"), true, ReasonRole},
+		{"transitive wildcard", admin, "publish", NewResource("comment", "", nil), true, ReasonRole},
+		{"owner update", viewer, "update", article("u1"), true, ReasonAllowPolicy},
+		{"non-owner update", editor, "update", article("u9"), false, ReasonNoMatch},
+		{"tenant admin own tenant", tenantAdmin, "delete", article("u9"), true, ReasonAllowPolicy},
+		{"tenant admin other tenant", tenantAdmin, "delete", NewResource("article", "2", map[string]interface{}{"tenant_id": "t2"}), false, ReasonNoMatch},
+		{"deny overrides owner", viewer, "delete", article("u1", "archived", true), false, ReasonDenyPolicy},
+		{"deny overrides role", admin, "delete", article("u9", "archived", true), false, ReasonDenyPolicy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := e.Decide(context.Background(), &Request{Subject: tt.subject, Action: tt.action, Resource: tt.resource})
+			if d.Allowed != tt.allowed || d.Reason != tt.reason {
+				t.Errorf("Decide() = %+v, want allowed=%v reason=%s", d, tt.allowed, tt.reason)
+			}
+		})
+	}
+}
+
+func TestEngine_DecisionDetails(t *testing.T) {
+	e := newTestEngine(t)
+	d := e.Decide(context.Background(), &Request{
+		Subject:  &middleware.AuthInfo{UserID: "u2", Roles: []string{"editor"}},
+		Action:   "read",
+		Resource: NewResource("article", "7", nil),
+	})
+	if d.Role != "viewer" || d.Permission != "article:read" || d.UserID != "u2" || d.ResourceID != "7" {
+		t.Errorf("decision = %+v", d)
+	}
+
+	d = e.Decide(context.Background(), &Request{
+		Subject:  &middleware.AuthInfo{UserID: "u1"},
+		Action:   "delete",
+		Resource: NewResource("article", "7", map[string]interface{}{"owner_id": "u1", "archived": true}),
+	})
+	if d.PolicyID != "no-delete-archived" {
+		t.Errorf("decision = %+v", d)
+	}
+}
+
+func TestEngine_NamedCondition(t *testing.T) {
+	e := NewEngine(WithCondition("business_hours", func(req *Request) bool {
+		return req.Resource != nil && req.Resource.Attributes["hour"] == 10
+	}))
+	err := e.Load(&PolicySet{Policies: []Policy{{ID: "p", Actions: []string{"approve"}, When: []string{"business_hours"}}}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	subject := &middleware.AuthInfo{UserID: "u1"}
+	if d := e.Decide(context.Background(), &Request{Subject: subject, Action: "approve", Resource: NewResource("invoice", "", map[string]interface{}{"hour": 10})}); !d.Allowed {
+		t.Errorf("Decide() = %+v, want allowed", d)
+	}
+	if d := e.Decide(context.Background(), &Request{Subject: subject, Action: "approve", Resource: NewResource("invoice", "", map[string]interface{}{"hour": 23})}); d.Allowed {
+		t.Errorf("Decide() = %+v, want denied", d)
+	}
+
+	err = NewEngine().Load(&PolicySet{Policies: []Policy{{ID: "p", When: []string{"business_hours"}}}})
+	if !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("Load(unregistered condition) error = %v", err)
+	}
+}
+
+func TestEngine_LoadRejectsInvalid(t *testing.T) {
+	tests := map[string]*PolicySet{
+		"unknown parent":   {Roles: []Role{{Name: "a", Inherits: []string{"b"}}}},
+		"cycle":            {Roles: []Role{{Name: "a", Inherits: []string{"b"}}, {Name: "b", Inherits: []string{"a"}}}},
+		"duplicate role":   {Roles: []Role{{Name: "a"}, {Name: "a"}}},
+		"bad permission":   {Roles: []Role{{Name: "a", Permissions: []string{"article"}}}},
+		"missing id":       {Policies: []Policy{{}}},
+		"unknown effect":   {Policies: []Policy{{ID: "p", Effect: "maybe"}}},
+		"unknown operator": {Policies: []Policy{{ID: "p", Conditions: []Condition{{Attr: "subject.id", Op: "like", Value: "x"}}}}},
+		"bad attribute":    {Policies: []Policy{{ID: "p", Conditions: []Condition{{Attr: "user.id", Op: OpEq, Value: "x"}}}}},
+		"value and ref":    {Policies: []Policy{{ID: "p", Conditions: []Condition{{Attr: "subject.id", Op: OpEq, Value: "x", Ref: "resource.id"}}}}},
+	}
+	for name, set := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := newTestEngine(t)
+			if err := e.Load(set); !errors.Is(err, ErrInvalidPolicy) {
+				t.Fatalf("Load() error = %v, want ErrInvalidPolicy", err)
+			}
+			// 校验失败时保留原策略
+			d := e.Decide(context.Background(), &Request{Subject: &middleware.AuthInfo{Roles: []string{"viewer"}}, Action: "read", Resource: NewResource("article", "", nil)})
+			if !d.Allowed {
+				t.Error("previous policies should remain in effect")
+			}
+		})
+	}
+}
+
+func TestEngine_DefaultDeny(t *testing.T) {
+	e := NewEngine()
+	d := e.Decide(context.Background(), &Request{Subject: &middleware.AuthInfo{UserID: "u1", Roles: []string{"admin"}}, Action: "read"})
+	if d.Allowed || d.Reason != ReasonNoMatch {
+		t.Errorf("Decide() = %+v", d)
+	}
+}
+
+func TestEngine_CheckAndAudit(t *testing.T) {
+	var decisions []*Decision
+	e := newTestEngine(t, WithAuditor(AuditorFunc(func(ctx context.Context, d *Decision) {
+		decisions = append(decisions, d)
+	})))
+
+	if err := e.Check(context.Background(), "read", NewResource("article", "1", nil)); err != apperrors.ErrUnauthorized {
+		t.Errorf("Check(anonymous) error = %v", err)
+	}
+
+	ctx := auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: "u1", Roles: []string{"viewer"}})
+	if err := e.Check(ctx, "read", NewResource("article", "1", nil)); err != nil {
+		t.Errorf("Check(read) error = %v", err)
+	}
+	if err := e.Check(ctx, "create", NewResource("article", "", nil)); err != apperrors.ErrForbidden {
+		t.Errorf("Check(create) error = %v", err)
+	}
+
+	if len(decisions) != 3 {
+		t.Fatalf("audited %d decisions, want 3", len(decisions))
+	}
+	if decisions[1].UserID != "u1" || !decisions[1].Allowed || decisions[2].Allowed || decisions[2].Time.IsZero() {
+		t.Errorf("decisions = %+v, %+v", decisions[1], decisions[2])
+	}
+}
+
+func TestEngine_LoadFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	content := `
+authz:
+  roles:
+    - name: viewer
+      permissions: ["article:read"]
+    - name: editor
+      inherits: [viewer]
+  policies:
+    - id: review-drafts
+      roles: [editor]
+      actions: [review]
+      resources: [article]
+      conditions:
+        - {attr: resource.status, op: in, value: [draft, pending]}
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Load(config.WithConfigFile("config"), config.WithConfigPaths(dir)); err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+
+	var set PolicySet
+	if err := config.Bind("authz", &set); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	e := NewEngine()
+	if err := e.Load(&set); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	editor := &middleware.AuthInfo{UserID: "u1", Roles: []string{"editor"}}
+	for status, want := range map[string]bool{"draft": true, "published": false} {
+		d := e.Decide(context.Background(), &Request{
+			Subject:  editor,
+			Action:   "review",
+			Resource: NewResource("article", "1", map[string]interface{}{"status": status}),
+		})
+		if d.Allowed != want {
+			t.Errorf("review %s: %+v", status, d)
+		}
+	}
+	if d := e.Decide(context.Background(), &Request{Subject: editor, Action: "read", Resource: NewResource("article", "1", nil)}); !d.Allowed {
+		t.Errorf("inherited read: %+v", d)
+	}
+}
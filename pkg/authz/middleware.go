@@ -0,0 +1,208 @@
+package authz
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/errors"
+	"github.com/goupter/goupter/pkg/server/interceptor"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type engineKey struct{}
+
+// WithEngine 将引擎放入上下文
+func WithEngine(ctx context.Context, e *Engine) context.Context {
+	return context.WithValue(ctx, engineKey{}, e)
+}
+
+// FromContext 从上下文获取引擎
+func FromContext(ctx context.Context) (*Engine, bool) {
+	e, ok := ctx.Value(engineKey{}).(*Engine)
+	return e, ok
+}
+
+// Check 使用上下文中的引擎对当前主体求值，供业务代码做资源级检查
+//
+// 未经过 Middleware 或拦截器时返回 errors.ErrForbidden（默认拒绝）。
+func Check(ctx context.Context, action string, resource *Resource) error {
+	e, ok := FromContext(ctx)
+	if !ok {
+		return errors.ErrForbidden
+	}
+	return e.Check(ctx, action, resource)
+}
+
+// ResourceFunc 从请求构造资源，如按路由参数查询资源的归属
+//
+// 返回 *errors.Error（如 errors.ErrNotFound）时按其状态码响应，其他错误响应 500。
+type ResourceFunc func(c *gin.Context) (*Resource, error)
+
+// ResourceParam 按路由参数构造只有类型与 ID 的资源
+func ResourceParam(typ, param string) ResourceFunc {
+	return func(c *gin.Context) (*Resource, error) {
+		return &Resource{Type: typ, ID: c.Param(param)}, nil
+	}
+}
+
+// Middleware Gin 中间件，将引擎放入请求上下文，使处理函数可以调用 authz.Check
+func Middleware(e *Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(WithEngine(c.Request.Context(), e))
+		c.Next()
+	}
+}
+
+// Authorize Gin 中间件，要求当前主体对资源具有指定动作的权限，需放在认证中间件之后
+//
+// resource 为 nil 时只按动作求值；返回错误时中止请求，响应见 ResourceFunc。
+func Authorize(e *Engine, action string, resource ResourceFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := WithEngine(c.Request.Context(), e)
+		c.Request = c.Request.WithContext(ctx)
+
+		var res *Resource
+		if resource != nil {
+			var err error
+			if res, err = resource(c); err != nil {
+				e := resourceError(err)
+				c.AbortWithStatusJSON(e.HTTPStatus(), gin.H{
+					"code":    e.Code,
+					"message": e.Message,
+				})
+				return
+			}
+		}
+
+		d := e.Decide(ctx, &Request{Subject: SubjectFromContext(ctx), Action: action, Resource: res})
+		if !d.Allowed {
+			if d.Reason == ReasonUnauthenticated {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"code":    http.StatusUnauthorized,
+					"message": "authentication required",
+				})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    http.StatusForbidden,
+				"message": "insufficient permissions",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// MethodRule gRPC 方法的授权规则
+type MethodRule struct {
+	Action string
+	// ResourceType 资源类型，Resource 为 nil 时使用
+	ResourceType string
+	// Resource 从请求消息构造资源，流式方法的 req 为 nil；
+	// 返回 *errors.Error 时按其状态码转换为 gRPC 状态码，其他错误为 codes.Internal
+	Resource func(ctx context.Context, req interface{}) (*Resource, error)
+}
+
+// MethodRules 按完整方法名（/pkg.Service/Method）或服务前缀（/pkg.Service/）配置的规则，
+// 完整方法名优先；未配置的方法不做检查，但仍可在处理函数中调用 authz.Check
+type MethodRules map[string]MethodRule
+
+func (r MethodRules) lookup(fullMethod string) (MethodRule, bool) {
+	if rule, ok := r[fullMethod]; ok {
+		return rule, true
+	}
+	if i := strings.LastIndex(fullMethod, "/"); i > 0 {
+		rule, ok := r[fullMethod[:i+1]]
+		return rule, ok
+	}
+	return MethodRule{}, false
+}
+
+// UnaryInterceptor gRPC 一元拦截器，需放在认证拦截器之后
+func UnaryInterceptor(e *Engine, rules MethodRules) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = WithEngine(ctx, e)
+		if rule, ok := rules.lookup(info.FullMethod); ok {
+			if err := e.authorize(ctx, rule, req); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor gRPC 流式拦截器，需放在认证拦截器之后
+func StreamInterceptor(e *Engine, rules MethodRules) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := WithEngine(ss.Context(), e)
+		if rule, ok := rules.lookup(info.FullMethod); ok {
+			if err := e.authorize(ctx, rule, nil); err != nil {
+				return err
+			}
+		}
+		return handler(srv, interceptor.WrapServerStream(ss, ctx))
+	}
+}
+
+// authorize 按方法规则求值，返回 gRPC 状态错误
+func (e *Engine) authorize(ctx context.Context, rule MethodRule, req interface{}) error {
+	res := &Resource{Type: rule.ResourceType}
+	if rule.Resource != nil {
+		var err error
+		if res, err = rule.Resource(ctx, req); err != nil {
+			e := resourceError(err)
+			return status.Error(grpcCode(e.HTTPStatus()), e.Message)
+		}
+	}
+
+	d := e.Decide(ctx, &Request{Subject: SubjectFromContext(ctx), Action: rule.Action, Resource: res})
+	if !d.Allowed {
+		if d.Reason == ReasonUnauthenticated {
+			return status.Error(codes.Unauthenticated, "authentication required")
+		}
+		return status.Error(codes.PermissionDenied, "insufficient permissions")
+	}
+	return nil
+}
+
+// resourceError 加载资源失败的响应错误，*errors.Error 原样使用，其他错误视为内部错误
+func resourceError(err error) *errors.Error {
+	var e *errors.Error
+	if stderrors.As(err, &e) {
+		return e
+	}
+	return errors.New(errors.CodeInternalError, "load resource failed")
+}
+
+// grpcCode HTTP 状态码转换为 gRPC 状态码
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound, http.StatusGone:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	if httpStatus >= 400 && httpStatus < 500 {
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
@@ -0,0 +1,165 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/auth"
+	apperrors "github.com/goupter/goupter/pkg/errors"
+	"github.com/goupter/goupter/pkg/server/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// articles 测试用的文章归属
+var articles = map[string]string{"1": "u1", "2": "u2"}
+
+// findArticle 按 ID 查询文章，id 为 err 时模拟数据库故障
+func findArticle(id string) (*Resource, error) {
+	if id == "err" {
+		return nil, errors.New("db down")
+	}
+	owner, ok := articles[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	return NewResource("article", id, map[string]interface{}{"owner_id": owner}), nil
+}
+
+func loadArticle(c *gin.Context) (*Resource, error) {
+	return findArticle(c.Param("id"))
+}
+
+func newTestRouter(e *Engine) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			middleware.SetAuthInfo(c, &middleware.AuthInfo{UserID: user, Roles: []string{c.GetHeader("X-Role")}})
+		}
+	})
+	r.PUT("/articles/:id", Authorize(e, "update", loadArticle), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/articles/:id", Middleware(e), func(c *gin.Context) {
+		if err := Check(c.Request.Context(), "read", NewResource("article", c.Param("id"), nil)); err != nil {
+			c.Status(err.(*apperrors.Error).HTTPStatus())
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestAuthorize(t *testing.T) {
+	r := newTestRouter(newTestEngine(t))
+	tests := []struct {
+		name, method, path, user, role string
+		want                           int
+	}{
+		{"owner", "PUT", "/articles/1", "u1", "", http.StatusNoContent},
+		{"not owner", "PUT", "/articles/2", "u1", "", http.StatusForbidden},
+		{"anonymous", "PUT", "/articles/1", "", "", http.StatusUnauthorized},
+		{"resource not found", "PUT", "/articles/404", "u1", "", http.StatusNotFound},
+		{"resource error", "PUT", "/articles/err", "u1", "", http.StatusInternalServerError},
+		{"check allowed", "GET", "/articles/2", "u1", "viewer", http.StatusOK},
+		{"check denied", "GET", "/articles/2", "u1", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.user != "" {
+				req.Header.Set("X-User", tt.user)
+				req.Header.Set("X-Role", tt.role)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck_WithoutEngine(t *testing.T) {
+	if err := Check(context.Background(), "read", nil); err != apperrors.ErrForbidden {
+		t.Errorf("Check() error = %v, want ErrForbidden", err)
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	e := newTestEngine(t)
+	interceptor := UnaryInterceptor(e, MethodRules{
+		"/blog.v1.ArticleService/": {Action: "read", ResourceType: "article"},
+		"/blog.v1.ArticleService/UpdateArticle": {
+			Action: "update",
+			Resource: func(ctx context.Context, req interface{}) (*Resource, error) {
+				return findArticle(req.(string))
+			},
+		},
+	})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		if _, ok := FromContext(ctx); !ok {
+			t.Error("engine should be in context")
+		}
+		return "ok", nil
+	}
+	viewer := auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: "u1", Roles: []string{"viewer"}})
+	guest := auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: "u9"})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		req    interface{}
+		want   codes.Code
+	}{
+		{"service rule allowed", viewer, "/blog.v1.ArticleService/GetArticle", nil, codes.OK},
+		{"service rule denied", guest, "/blog.v1.ArticleService/GetArticle", nil, codes.PermissionDenied},
+		{"method rule owner", viewer, "/blog.v1.ArticleService/UpdateArticle", "1", codes.OK},
+		{"method rule not owner", viewer, "/blog.v1.ArticleService/UpdateArticle", "2", codes.PermissionDenied},
+		{"resource not found", viewer, "/blog.v1.ArticleService/UpdateArticle", "404", codes.NotFound},
+		{"resource error", viewer, "/blog.v1.ArticleService/UpdateArticle", "err", codes.Internal},
+		{"unauthenticated", context.Background(), "/blog.v1.ArticleService/GetArticle", nil, codes.Unauthenticated},
+		{"no rule", context.Background(), "/grpc.health.v1.Health/Check", nil, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interceptor(tt.ctx, tt.req, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.want {
+				t.Errorf("code = %v, want %v", status.Code(err), tt.want)
+			}
+		})
+	}
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (m *mockServerStream) Context() context.Context { return m.ctx }
+
+func TestStreamInterceptor(t *testing.T) {
+	interceptor := StreamInterceptor(newTestEngine(t), MethodRules{
+		"/blog.v1.ArticleService/WatchArticles": {Action: "read", ResourceType: "article"},
+	})
+	info := &grpc.StreamServerInfo{FullMethod: "/blog.v1.ArticleService/WatchArticles"}
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		return Check(ss.Context(), "read", NewResource("article", "1", nil))
+	}
+
+	viewer := auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: "u1", Roles: []string{"viewer"}})
+	if err := interceptor(nil, &mockServerStream{ctx: viewer}, info, handler); err != nil {
+		t.Errorf("viewer error = %v", err)
+	}
+	guest := auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: "u9"})
+	if err := interceptor(nil, &mockServerStream{ctx: guest}, info, handler); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("guest error = %v, want PermissionDenied", err)
+	}
+}
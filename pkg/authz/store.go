@@ -0,0 +1,142 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/goupter/goupter/pkg/model"
+	"gorm.io/gorm"
+)
+
+// Store 策略存储
+type Store interface {
+	Load(ctx context.Context) (*PolicySet, error)
+}
+
+// RoleRecord 角色数据库模型
+//
+//	CREATE TABLE `authz_roles` (
+//	  `name` varchar(64) NOT NULL,
+//	  `description` varchar(255) NOT NULL DEFAULT '',
+//	  `inherits` varchar(1024) NOT NULL DEFAULT '',
+//	  `permissions` text NOT NULL,
+//	  PRIMARY KEY (`name`)
+//	);
+type RoleRecord struct {
+	Name        string `gorm:"column:name;primaryKey;size:64" json:"name"`
+	Description string `gorm:"column:description;size:255" json:"description"`
+	Inherits    string `gorm:"column:inherits;size:1024" json:"inherits"`
+	Permissions string `gorm:"column:permissions;type:text" json:"permissions"`
+}
+
+// TableName 表名
+func (RoleRecord) TableName() string {
+	return "authz_roles"
+}
+
+// PolicyRecord 策略数据库模型，conditions 为 JSON 数组，named_conditions 对应 Policy.When
+//
+//	CREATE TABLE `authz_policies` (
+//	  `id` varchar(64) NOT NULL,
+//	  `description` varchar(255) NOT NULL DEFAULT '',
+//	  `effect` varchar(8) NOT NULL DEFAULT 'allow',
+//	  `roles` varchar(1024) NOT NULL DEFAULT '',
+//	  `actions` varchar(1024) NOT NULL DEFAULT '',
+//	  `resources` varchar(1024) NOT NULL DEFAULT '',
+//	  `conditions` text NOT NULL,
+//	  `named_conditions` varchar(1024) NOT NULL DEFAULT '',
+//	  PRIMARY KEY (`id`)
+//	);
+type PolicyRecord struct {
+	ID          string `gorm:"column:id;primaryKey;size:64" json:"id"`
+	Description string `gorm:"column:description;size:255" json:"description"`
+	Effect      string `gorm:"column:effect;size:8" json:"effect"`
+	Roles       string `gorm:"column:roles;size:1024" json:"roles"`
+	Actions     string `gorm:"column:actions;size:1024" json:"actions"`
+	Resources   string `gorm:"column:resources;size:1024" json:"resources"`
+	Conditions  string `gorm:"column:conditions;type:text" json:"conditions"`
+	When        string `gorm:"column:named_conditions;size:1024" json:"when"`
+}
+
+// TableName 表名
+func (PolicyRecord) TableName() string {
+	return "authz_policies"
+}
+
+// GormStore 数据库存储
+type GormStore struct {
+	roles    *model.BaseModel[RoleRecord]
+	policies *model.BaseModel[PolicyRecord]
+}
+
+// NewGormStore 创建数据库存储
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{
+		roles:    model.NewBaseModel[RoleRecord](db),
+		policies: model.NewBaseModel[PolicyRecord](db),
+	}
+}
+
+// Load 实现 Store
+func (s *GormStore) Load(ctx context.Context) (*PolicySet, error) {
+	roles, err := s.roles.FindAll(ctx, "name", "")
+	if err != nil {
+		return nil, err
+	}
+	policies, err := s.policies.FindAll(ctx, "id", "")
+	if err != nil {
+		return nil, err
+	}
+
+	set := &PolicySet{}
+	for _, r := range roles {
+		set.Roles = append(set.Roles, r.toRole())
+	}
+	for _, r := range policies {
+		p, err := r.toPolicy()
+		if err != nil {
+			return nil, err
+		}
+		set.Policies = append(set.Policies, p)
+	}
+	return set, nil
+}
+
+func (r *RoleRecord) toRole() Role {
+	return Role{
+		Name:        r.Name,
+		Description: r.Description,
+		Inherits:    splitList(r.Inherits),
+		Permissions: splitList(r.Permissions),
+	}
+}
+
+func (r *PolicyRecord) toPolicy() (Policy, error) {
+	p := Policy{
+		ID:          r.ID,
+		Description: r.Description,
+		Effect:      Effect(r.Effect),
+		Roles:       splitList(r.Roles),
+		Actions:     splitList(r.Actions),
+		Resources:   splitList(r.Resources),
+		When:        splitList(r.When),
+	}
+	if r.Conditions != "" {
+		if err := json.Unmarshal([]byte(r.Conditions), &p.Conditions); err != nil {
+			return p, fmt.Errorf("%w: 策略 %s: 条件: %v", ErrInvalidPolicy, r.ID, err)
+		}
+	}
+	return p, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,138 @@
+package authz
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// table 测试用的表数据
+type table struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// tableConnector 按 SQL 中的表名返回固定结果的只读连接
+type tableConnector struct {
+	tables map[string]table
+}
+
+func (c *tableConnector) Connect(context.Context) (driver.Conn, error) { return &tableConn{c}, nil }
+func (c *tableConnector) Driver() driver.Driver                        { return nil }
+
+type tableConn struct{ c *tableConnector }
+
+func (c *tableConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *tableConn) Close() error                        { return nil }
+func (c *tableConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *tableConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	for name, t := range c.c.tables {
+		if strings.Contains(query, "`"+name+"`") {
+			return &tableRows{table: t}, nil
+		}
+	}
+	return nil, errors.New("unknown table: " + query)
+}
+
+type tableRows struct {
+	table
+	next int
+}
+
+func (r *tableRows) Columns() []string { return r.columns }
+func (r *tableRows) Close() error      { return nil }
+
+func (r *tableRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+func newTableDB(t *testing.T, tables map[string]table) *gorm.DB {
+	t.Helper()
+	sqlDB := sql.OpenDB(&tableConnector{tables: tables})
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+var (
+	roleColumns   = []string{"name", "description", "inherits", "permissions"}
+	policyColumns = []string{"id", "description", "effect", "roles", "actions", "resources", "conditions", "named_conditions"}
+)
+
+func TestGormStore_Load(t *testing.T) {
+	db := newTableDB(t, map[string]table{
+		"authz_roles": {columns: roleColumns, rows: [][]driver.Value{
+			{"editor", "", "viewer", "article:update, article:create"},
+			{"viewer", "只读", "", "article:read"},
+		}},
+		"authz_policies": {columns: policyColumns, rows: [][]driver.Value{
+			{"owner-update", "", "allow", "", "update", "article",
+				`[{"attr":"resource.owner_id","op":"eq","ref":"subject.id"}]`, "business_hours"},
+		}},
+	})
+
+	set, err := NewGormStore(db).Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	wantRoles := []Role{
+		{Name: "editor", Inherits: []string{"viewer"}, Permissions: []string{"article:update", "article:create"}},
+		{Name: "viewer", Description: "只读", Permissions: []string{"article:read"}},
+	}
+	if !reflect.DeepEqual(set.Roles, wantRoles) {
+		t.Errorf("Roles = %+v, want %+v", set.Roles, wantRoles)
+	}
+	wantPolicy := Policy{
+		ID:         "owner-update",
+		Effect:     EffectAllow,
+		Actions:    []string{"update"},
+		Resources:  []string{"article"},
+		Conditions: []Condition{{Attr: "resource.owner_id", Op: OpEq, Ref: "subject.id"}},
+		When:       []string{"business_hours"},
+	}
+	if len(set.Policies) != 1 || !reflect.DeepEqual(set.Policies[0], wantPolicy) {
+		t.Errorf("Policies = %+v, want %+v", set.Policies, wantPolicy)
+	}
+}
+
+func TestGormStore_Load_InvalidConditions(t *testing.T) {
+	db := newTableDB(t, map[string]table{
+		"authz_roles": {columns: roleColumns},
+		"authz_policies": {columns: policyColumns, rows: [][]driver.Value{
+			{"broken", "", "allow", "", "", "", "{", ""},
+		}},
+	})
+
+	if _, err := NewGormStore(db).Load(context.Background()); !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("Load() error = %v, want ErrInvalidPolicy", err)
+	}
+}
+
+func TestPolicyRecord_ToPolicy(t *testing.T) {
+	p, err := (&PolicyRecord{ID: "p1", Effect: "deny", Roles: " a ,, b "}).toPolicy()
+	if err != nil {
+		t.Fatalf("toPolicy() error = %v", err)
+	}
+	if p.Effect != EffectDeny || !reflect.DeepEqual(p.Roles, []string{"a", "b"}) || p.Conditions != nil || p.Actions != nil {
+		t.Errorf("toPolicy() = %+v", p)
+	}
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器处于打开状态
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	// StateClosed 关闭（正常放行）
+	StateClosed BreakerState = iota
+	// StateOpen 打开（拒绝请求）
+	StateOpen
+	// StateHalfOpen 半开（放行探测请求）
+	StateHalfOpen
+)
+
+// String 返回状态字符串
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig 熔断器配置
+type BreakerConfig struct {
+	// FailureThreshold 连续失败多少次后打开熔断器
+	FailureThreshold int
+	// OpenTimeout 打开状态持续时间，到期后进入半开状态
+	OpenTimeout time.Duration
+	// HalfOpenRequests 半开状态允许的探测请求数
+	HalfOpenRequests int
+}
+
+// DefaultBreakerConfig 默认熔断器配置
+func DefaultBreakerConfig() *BreakerConfig {
+	return &BreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// CircuitBreaker 基于连续失败次数的熔断器
+type CircuitBreaker struct {
+	config   *BreakerConfig
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probes   int
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(cfg *BreakerConfig) *CircuitBreaker {
+	if cfg == nil {
+		cfg = DefaultBreakerConfig()
+	}
+	return &CircuitBreaker{config: cfg, now: time.Now}
+}
+
+// State 获取当前状态
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.transition()
+	return b.state
+}
+
+// Allow 判断是否放行请求，放行后必须调用 Record 上报结果
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.transition()
+	switch b.state {
+	case StateOpen:
+		return ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probes >= b.config.HalfOpenRequests {
+			return ErrCircuitOpen
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// Record 上报请求结果
+func (b *CircuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.state = StateClosed
+		b.failures = 0
+		b.probes = 0
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+		b.probes = 0
+	}
+}
+
+// transition 打开状态超时后转为半开（调用方需持有锁）
+func (b *CircuitBreaker) transition() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.config.OpenTimeout {
+		b.state = StateHalfOpen
+		b.probes = 0
+	}
+}
+
+// breakerGroup 按实例地址维护熔断器
+type breakerGroup struct {
+	config   *BreakerConfig
+	breakers map[string]*CircuitBreaker
+	mu       sync.Mutex
+}
+
+func newBreakerGroup(cfg *BreakerConfig) *breakerGroup {
+	return &breakerGroup{config: cfg, breakers: make(map[string]*CircuitBreaker)}
+}
+
+func (g *breakerGroup) get(key string) *CircuitBreaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b, ok := g.breakers[key]
+	if !ok {
+		b = NewCircuitBreaker(g.config)
+		g.breakers[key] = b
+	}
+	return b
+}
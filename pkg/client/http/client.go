@@ -0,0 +1,479 @@
+// Package http 提供服务感知的出站 HTTP 客户端
+//
+// 基础地址既可以是普通 URL，也可以是服务发现地址 goupter://<service>/<prefix>。
+// 客户端会从 ctx 透传请求ID、追踪ID，鉴权令牌只透传给服务发现目标（普通 URL
+// 需通过 WithForwardAuth 显式开启，避免令牌泄露给外部服务），支持单次调用超时、
+// 幂等方法的抖动退避重试、按实例熔断，并将框架的 response.Response
+// 响应体解码回 errors.Error。
+package http
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	nethttp "net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/discovery"
+	"github.com/goupter/goupter/pkg/errors"
+	"github.com/goupter/goupter/pkg/log"
+)
+
+// DiscoveryScheme 服务发现地址的 scheme
+const DiscoveryScheme = "goupter"
+
+// 透传的请求头
+const (
+	HeaderRequestID     = "X-Request-ID"
+	HeaderTraceID       = "X-Trace-ID"
+	HeaderAuthorization = "Authorization"
+)
+
+// RetryConfig 重试配置
+type RetryConfig struct {
+	// MaxAttempts 最大尝试次数（含首次请求）
+	MaxAttempts int
+	// InitialBackoff 首次重试的退避时间
+	InitialBackoff time.Duration
+	// MaxBackoff 最大退避时间
+	MaxBackoff time.Duration
+	// RetryableStatus 可重试的 HTTP 状态码
+	RetryableStatus []int
+}
+
+// DefaultRetryConfig 默认重试配置
+func DefaultRetryConfig() *RetryConfig {
+	return &RetryConfig{
+		MaxAttempts:     3,
+		InitialBackoff:  100 * time.Millisecond,
+		MaxBackoff:      2 * time.Second,
+		RetryableStatus: []int{nethttp.StatusBadGateway, nethttp.StatusServiceUnavailable, nethttp.StatusGatewayTimeout},
+	}
+}
+
+// backoff 计算第 n 次重试前的等待时间（等量抖动）
+func (c *RetryConfig) backoff(n int) time.Duration {
+	d := c.InitialBackoff << n
+	if d <= 0 || (c.MaxBackoff > 0 && d > c.MaxBackoff) {
+		d = c.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func (c *RetryConfig) retryableStatus(code int) bool {
+	for _, s := range c.RetryableStatus {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Client 服务感知的 HTTP 客户端
+type Client struct {
+	base       *url.URL
+	service    string
+	scheme     string
+	resolver   *discovery.Resolver
+	httpClient *nethttp.Client
+	timeout    time.Duration
+	retry      *RetryConfig
+	breakers   *breakerGroup
+	headers    nethttp.Header
+	logger     log.Logger
+	// forwardAuth 是否透传 ctx 中的鉴权令牌
+	forwardAuth bool
+}
+
+// Option 客户端选项
+type Option func(*Client)
+
+// WithResolver 设置服务解析器（服务发现模式必需）
+func WithResolver(r *discovery.Resolver) Option {
+	return func(c *Client) { c.resolver = r }
+}
+
+// WithDiscovery 基于服务发现创建解析器，并启用异常实例摘除
+func WithDiscovery(d discovery.Discovery) Option {
+	return func(c *Client) {
+		c.resolver = discovery.NewResolver(d, discovery.WithOutlierDetection(nil))
+	}
+}
+
+// WithHTTPClient 设置底层 http.Client
+func WithHTTPClient(hc *nethttp.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithTimeout 设置默认单次调用超时（含重试）
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.timeout = d }
+}
+
+// WithRetry 设置重试配置，nil 表示禁用重试
+func WithRetry(cfg *RetryConfig) Option {
+	return func(c *Client) { c.retry = cfg }
+}
+
+// WithBreaker 设置按实例熔断配置，nil 表示禁用熔断
+func WithBreaker(cfg *BreakerConfig) Option {
+	return func(c *Client) {
+		if cfg == nil {
+			c.breakers = nil
+			return
+		}
+		c.breakers = newBreakerGroup(cfg)
+	}
+}
+
+// WithHeader 设置每次请求携带的请求头
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.headers.Set(key, value) }
+}
+
+// WithInstanceScheme 设置服务发现模式下访问实例使用的协议（默认 http）
+func WithInstanceScheme(scheme string) Option {
+	return func(c *Client) { c.scheme = scheme }
+}
+
+// WithForwardAuth 向普通 URL 目标透传 ctx 中的鉴权令牌，仅用于可信的内部服务
+//
+// 服务发现目标默认透传，无需设置。
+func WithForwardAuth() Option {
+	return func(c *Client) { c.forwardAuth = true }
+}
+
+// WithLogger 设置日志
+func WithLogger(logger log.Logger) Option {
+	return func(c *Client) { c.logger = logger }
+}
+
+// New 创建 HTTP 客户端
+//
+// target 为 http(s)://host[:port]/prefix 或 goupter://<service>/prefix。
+func New(target string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("parse target %q failed: %w", target, err)
+	}
+
+	c := &Client{
+		base:       u,
+		scheme:     "http",
+		httpClient: &nethttp.Client{},
+		timeout:    10 * time.Second,
+		retry:      DefaultRetryConfig(),
+		breakers:   newBreakerGroup(DefaultBreakerConfig()),
+		headers:    make(nethttp.Header),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	switch u.Scheme {
+	case DiscoveryScheme:
+		c.service = u.Host
+		if c.service == "" {
+			return nil, fmt.Errorf("missing service name in target %q", target)
+		}
+		if c.resolver == nil {
+			return nil, fmt.Errorf("target %q requires a discovery resolver", target)
+		}
+		c.forwardAuth = true
+	case "http", "https":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing host in target %q", target)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q in target %q", u.Scheme, target)
+	}
+
+	return c, nil
+}
+
+// CallOption 单次调用选项
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	timeout   time.Duration
+	headers   nethttp.Header
+	query     url.Values
+	retryable *bool
+}
+
+// WithCallTimeout 设置本次调用超时（含重试）
+func WithCallTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) { o.timeout = d }
+}
+
+// WithCallHeader 设置本次调用的请求头
+func WithCallHeader(key, value string) CallOption {
+	return func(o *callOptions) { o.headers.Set(key, value) }
+}
+
+// WithQuery 设置本次调用的查询参数
+func WithQuery(query url.Values) CallOption {
+	return func(o *callOptions) { o.query = query }
+}
+
+// WithRetryable 显式声明本次调用是否可重试（默认仅幂等方法重试）
+func WithRetryable(retryable bool) CallOption {
+	return func(o *callOptions) { o.retryable = &retryable }
+}
+
+// Response HTTP 响应
+type Response struct {
+	StatusCode int
+	Header     nethttp.Header
+	Body       []byte
+}
+
+// Do 发送请求
+//
+// body 可以是 nil、[]byte、string、io.Reader 或任意可 JSON 序列化的值。
+// 传输错误与 5xx 响应计为实例失败；返回的 error 为 *errors.Error。
+func (c *Client) Do(ctx context.Context, method, path string, body any, opts ...CallOption) (*Response, error) {
+	co := &callOptions{timeout: c.timeout, headers: make(nethttp.Header)}
+	for _, opt := range opts {
+		opt(co)
+	}
+
+	payload, contentType, err := encodeBody(body)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.CodeBadRequest, "encode request body failed")
+	}
+
+	if co.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, co.timeout)
+		defer cancel()
+	}
+
+	retryable := isIdempotent(method)
+	if co.retryable != nil {
+		retryable = *co.retryable
+	}
+
+	attempts := 1
+	if c.retry != nil && c.retry.MaxAttempts > 1 {
+		attempts = c.retry.MaxAttempts
+	}
+
+	var resp *Response
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			wait := c.retry.backoff(i - 1)
+			if c.logger != nil {
+				c.logger.Debug("retry http request",
+					log.String("method", method),
+					log.String("path", path),
+					log.Int("attempt", i+1),
+					log.Duration("backoff", wait),
+				)
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, c.wrapError(ctx.Err())
+			case <-timer.C:
+			}
+		}
+
+		var sent bool
+		resp, sent, err = c.attempt(ctx, method, path, payload, contentType, co)
+		if err == nil && (resp.StatusCode < 500 || c.retry == nil || !c.retry.retryableStatus(resp.StatusCode)) {
+			return resp, nil
+		}
+		// 请求未发出（熔断）时任何方法都可以安全重试
+		if (!retryable && sent) || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, c.wrapError(err)
+	}
+	return resp, nil
+}
+
+// attempt 执行一次请求，sent 表示请求是否已发往实例
+func (c *Client) attempt(ctx context.Context, method, path string, payload []byte, contentType string, co *callOptions) (*Response, bool, error) {
+	host := c.base.Host
+	scheme := c.base.Scheme
+	done := func(error) {}
+	if c.service != "" {
+		instance, pickDone, err := c.resolver.Pick(ctx, c.service)
+		if err != nil {
+			return nil, false, err
+		}
+		host, scheme, done = instance.Endpoint("http"), c.scheme, pickDone
+	}
+
+	var breaker *CircuitBreaker
+	if c.breakers != nil {
+		breaker = c.breakers.get(host)
+		if err := breaker.Allow(); err != nil {
+			done(err)
+			return nil, false, err
+		}
+	}
+
+	resp, err := c.send(ctx, method, scheme, host, path, payload, contentType, co)
+
+	var failure error
+	switch {
+	case err != nil:
+		failure = err
+	case resp.StatusCode >= 500:
+		failure = fmt.Errorf("server error: %d", resp.StatusCode)
+	}
+	if breaker != nil {
+		breaker.Record(failure == nil)
+	}
+	done(failure)
+
+	return resp, true, err
+}
+
+// send 构造并发送 HTTP 请求
+func (c *Client) send(ctx context.Context, method, scheme, host, path string, payload []byte, contentType string, co *callOptions) (*Response, error) {
+	u := url.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   strings.TrimSuffix(c.base.Path, "/") + "/" + strings.TrimPrefix(path, "/"),
+	}
+	query := c.base.Query()
+	for k, vs := range co.query {
+		for _, v := range vs {
+			query.Add(k, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := nethttp.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, vs := range c.headers {
+		req.Header[k] = vs
+	}
+	for k, vs := range co.headers {
+		req.Header[k] = vs
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	propagate(ctx, req.Header, c.forwardAuth)
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		StatusCode: httpResp.StatusCode,
+		Header:     httpResp.Header,
+		Body:       data,
+	}, nil
+}
+
+// propagate 从上下文透传请求ID、追踪ID，forwardAuth 时透传鉴权令牌
+func propagate(ctx context.Context, header nethttp.Header, forwardAuth bool) {
+	if header.Get(HeaderRequestID) == "" {
+		if id := log.RequestIDFromContext(ctx); id != "" {
+			header.Set(HeaderRequestID, id)
+		}
+	}
+	if header.Get(HeaderTraceID) == "" {
+		if id := log.TraceIDFromContext(ctx); id != "" {
+			header.Set(HeaderTraceID, id)
+		}
+	}
+	if forwardAuth && header.Get(HeaderAuthorization) == "" {
+		if token := auth.TokenFromContext(ctx); token != "" {
+			header.Set(HeaderAuthorization, "Bearer "+token)
+		}
+	}
+}
+
+// wrapError 将传输层错误转换为业务错误
+func (c *Client) wrapError(err error) error {
+	target := c.service
+	if target == "" {
+		target = c.base.Host
+	}
+
+	switch {
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return errors.Wrapf(err, errors.CodeExternalTimeout, "request %s timeout", target)
+	case stderrors.Is(err, ErrCircuitOpen), stderrors.Is(err, discovery.ErrNoAvailableInstance):
+		return errors.Wrapf(err, errors.CodeServiceUnavailable, "service %s unavailable", target)
+	default:
+		return errors.Wrapf(err, errors.CodeExternalError, "request %s failed", target)
+	}
+}
+
+// Get 发送 GET 请求并解码响应
+func (c *Client) Get(ctx context.Context, path string, out any, opts ...CallOption) error {
+	return c.invoke(ctx, nethttp.MethodGet, path, nil, out, opts...)
+}
+
+// Post 发送 POST 请求并解码响应
+func (c *Client) Post(ctx context.Context, path string, body, out any, opts ...CallOption) error {
+	return c.invoke(ctx, nethttp.MethodPost, path, body, out, opts...)
+}
+
+// Put 发送 PUT 请求并解码响应
+func (c *Client) Put(ctx context.Context, path string, body, out any, opts ...CallOption) error {
+	return c.invoke(ctx, nethttp.MethodPut, path, body, out, opts...)
+}
+
+// Patch 发送 PATCH 请求并解码响应
+func (c *Client) Patch(ctx context.Context, path string, body, out any, opts ...CallOption) error {
+	return c.invoke(ctx, nethttp.MethodPatch, path, body, out, opts...)
+}
+
+// Delete 发送 DELETE 请求并解码响应
+func (c *Client) Delete(ctx context.Context, path string, out any, opts ...CallOption) error {
+	return c.invoke(ctx, nethttp.MethodDelete, path, nil, out, opts...)
+}
+
+func (c *Client) invoke(ctx context.Context, method, path string, body, out any, opts ...CallOption) error {
+	resp, err := c.Do(ctx, method, path, body, opts...)
+	if err != nil {
+		return err
+	}
+	return resp.Decode(out)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case nethttp.MethodGet, nethttp.MethodHead, nethttp.MethodOptions,
+		nethttp.MethodPut, nethttp.MethodDelete, nethttp.MethodTrace:
+		return true
+	}
+	return false
+}
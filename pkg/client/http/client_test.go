@@ -0,0 +1,275 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/discovery"
+	"github.com/goupter/goupter/pkg/errors"
+	"github.com/goupter/goupter/pkg/log"
+	"github.com/goupter/goupter/pkg/response"
+)
+
+func writeJSON(w nethttp.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func fastRetry() *RetryConfig {
+	cfg := DefaultRetryConfig()
+	cfg.InitialBackoff = time.Millisecond
+	cfg.MaxBackoff = 2 * time.Millisecond
+	return cfg
+}
+
+func TestClient_DecodeEnvelope(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/api/users/1":
+			writeJSON(w, 200, response.Response{Code: 0, Message: "success", Data: map[string]any{"name": "tom"}})
+		case "/api/users/2":
+			writeJSON(w, 200, response.Response{Code: errors.CodeUserNotFound, Message: "user not found",
+				Data: map[string]any{"id": 2}})
+		default:
+			w.WriteHeader(nethttp.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL + "/api")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var user struct {
+		Name string `json:"name"`
+	}
+	if err := c.Get(context.Background(), "/users/1", &user); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if user.Name != "tom" {
+		t.Errorf("name = %s, want tom", user.Name)
+	}
+
+	err = c.Get(context.Background(), "/users/2", &user)
+	if !errors.IsCode(err, errors.CodeUserNotFound) {
+		t.Fatalf("应返回 CodeUserNotFound, got %v", err)
+	}
+	if details, ok := err.(*errors.Error).Details.(map[string]any); !ok || details["id"] != float64(2) {
+		t.Errorf("Details = %v", err.(*errors.Error).Details)
+	}
+
+	if err := c.Get(context.Background(), "/missing", nil); !errors.IsCode(err, 404) {
+		t.Errorf("应返回 404 错误, got %v", err)
+	}
+}
+
+func TestClient_Propagation(t *testing.T) {
+	var got nethttp.Header
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		got = r.Header.Clone()
+		writeJSON(w, 200, response.Response{Code: 0})
+	}))
+	defer srv.Close()
+
+	c, _ := New(srv.URL, WithHeader("X-Caller", "order"), WithForwardAuth())
+
+	ctx := log.WithRequestID(context.Background(), "req-1")
+	ctx = log.WithTraceID(ctx, "trace-1")
+	ctx = auth.ContextWithToken(ctx, "tok")
+	if err := c.Post(ctx, "/echo", map[string]string{"a": "b"}, nil); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	checks := map[string]string{
+		"X-Request-Id":  "req-1",
+		"X-Trace-Id":    "trace-1",
+		"Authorization": "Bearer tok",
+		"X-Caller":      "order",
+		"Content-Type":  "application/json",
+	}
+	for k, want := range checks {
+		if got.Get(k) != want {
+			t.Errorf("header %s = %q, want %q", k, got.Get(k), want)
+		}
+	}
+}
+
+func TestClient_ForwardAuth(t *testing.T) {
+	var got nethttp.Header
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		got = r.Header.Clone()
+		writeJSON(w, 200, response.Response{Code: 0})
+	}))
+	defer srv.Close()
+	ctx := auth.ContextWithToken(context.Background(), "tok")
+
+	// 普通 URL 可能是外部服务，默认不透传令牌
+	external, _ := New(srv.URL)
+	if err := external.Get(ctx, "/echo", nil); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if v := got.Get(HeaderAuthorization); v != "" {
+		t.Errorf("外部地址不应携带 Authorization, got %q", v)
+	}
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	d := &staticDiscovery{services: []*discovery.ServiceInfo{{ID: "a", Address: u.Hostname(), Port: port}}}
+	internal, err := New("goupter://user-service", WithDiscovery(d))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := internal.Get(ctx, "/echo", nil); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if v := got.Get(HeaderAuthorization); v != "Bearer tok" {
+		t.Errorf("服务发现目标 Authorization = %q, want Bearer tok", v)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(nethttp.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, 200, response.Response{Code: 0})
+	}))
+	defer srv.Close()
+
+	c, _ := New(srv.URL, WithRetry(fastRetry()), WithBreaker(nil))
+
+	if err := c.Get(context.Background(), "/", nil); err != nil {
+		t.Fatalf("GET 重试后应成功, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+
+	// POST 非幂等，不重试
+	atomic.StoreInt32(&calls, 0)
+	if err := c.Post(context.Background(), "/", nil, nil); !errors.IsCode(err, 503) {
+		t.Errorf("POST 应直接返回 503, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	c, _ := New(srv.URL, WithRetry(nil))
+	err := c.Get(context.Background(), "/", nil, WithCallTimeout(20*time.Millisecond))
+	if !errors.IsCode(err, errors.CodeExternalTimeout) {
+		t.Errorf("应返回 CodeExternalTimeout, got %v", err)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker(&BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Second, HalfOpenRequests: 1})
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("关闭状态应放行: %v", err)
+		}
+		b.Record(false)
+	}
+	if b.State() != StateOpen || b.Allow() != ErrCircuitOpen {
+		t.Fatal("连续失败后应打开")
+	}
+
+	now = now.Add(time.Second)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %s, want half_open", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatal("半开状态应放行一个探测请求")
+	}
+	if b.Allow() != ErrCircuitOpen {
+		t.Error("半开状态探测数已满应拒绝")
+	}
+	b.Record(true)
+	if b.State() != StateClosed {
+		t.Errorf("探测成功后应关闭, got %s", b.State())
+	}
+}
+
+// staticDiscovery 返回固定实例列表的服务发现
+type staticDiscovery struct {
+	services []*discovery.ServiceInfo
+}
+
+func (d *staticDiscovery) Register(context.Context, *discovery.ServiceInfo) error { return nil }
+func (d *staticDiscovery) Deregister(context.Context, string) error               { return nil }
+func (d *staticDiscovery) GetService(context.Context, string) ([]*discovery.ServiceInfo, error) {
+	return d.services, nil
+}
+func (d *staticDiscovery) Watch(context.Context, string, func([]*discovery.ServiceInfo)) error {
+	return nil
+}
+func (d *staticDiscovery) Close() error { return nil }
+
+func TestClient_DiscoveryFailover(t *testing.T) {
+	var badCalls, goodCalls int32
+	bad := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		atomic.AddInt32(&badCalls, 1)
+		w.WriteHeader(nethttp.StatusInternalServerError)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		atomic.AddInt32(&goodCalls, 1)
+		writeJSON(w, 200, response.Response{Code: 0})
+	}))
+	defer good.Close()
+
+	instance := func(id, raw string) *discovery.ServiceInfo {
+		u, _ := url.Parse(raw)
+		port, _ := strconv.Atoi(u.Port())
+		return &discovery.ServiceInfo{ID: id, Address: u.Hostname(), Port: port}
+	}
+	d := &staticDiscovery{services: []*discovery.ServiceInfo{instance("bad", bad.URL), instance("good", good.URL)}}
+
+	retry := fastRetry()
+	retry.RetryableStatus = append(retry.RetryableStatus, nethttp.StatusInternalServerError)
+	c, err := New("goupter://user-service", WithDiscovery(d), WithRetry(retry),
+		WithBreaker(&BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 1}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := c.Get(context.Background(), "/ping", nil); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	if badCalls != 1 {
+		t.Errorf("故障实例熔断后不应再被调用, badCalls = %d", badCalls)
+	}
+	if goodCalls != 10 {
+		t.Errorf("goodCalls = %d, want 10", goodCalls)
+	}
+
+	if _, err := New("goupter://user-service"); err == nil {
+		t.Error("服务发现地址缺少解析器应返回错误")
+	}
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	nethttp "net/http"
+
+	"github.com/goupter/goupter/pkg/errors"
+)
+
+// envelope 框架统一响应结构（对应 response.Response）
+type envelope struct {
+	Code    *int            `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	TraceID string          `json:"trace_id"`
+}
+
+// Decode 解码响应
+//
+// 若响应体为框架统一响应结构：code 非 0 时返回对应的 *errors.Error（Details 为 data），
+// 否则将 data 解码到 out。非统一结构的响应在 4xx/5xx 时返回以状态码为错误码的错误，
+// 否则将整个响应体解码到 out。
+func (r *Response) Decode(out any) error {
+	var env envelope
+	if bytes.HasPrefix(bytes.TrimSpace(r.Body), []byte("{")) &&
+		json.Unmarshal(r.Body, &env) == nil && env.Code != nil {
+		if *env.Code != errors.CodeSuccess {
+			e := errors.New(*env.Code, env.Message)
+			if len(env.Data) > 0 && !bytes.Equal(env.Data, []byte("null")) {
+				var details any
+				if json.Unmarshal(env.Data, &details) == nil {
+					e.Details = details
+				}
+			}
+			return e
+		}
+		if out != nil && len(env.Data) > 0 {
+			if err := json.Unmarshal(env.Data, out); err != nil {
+				return errors.Wrap(err, errors.CodeExternalError, "decode response data failed")
+			}
+		}
+		return nil
+	}
+
+	if r.StatusCode >= 400 {
+		message := nethttp.StatusText(r.StatusCode)
+		if len(r.Body) > 0 && len(r.Body) <= 512 {
+			message = string(bytes.TrimSpace(r.Body))
+		}
+		return errors.New(r.StatusCode, message)
+	}
+
+	if out != nil && len(r.Body) > 0 {
+		if err := json.Unmarshal(r.Body, out); err != nil {
+			return errors.Wrap(err, errors.CodeExternalError, "decode response body failed")
+		}
+	}
+	return nil
+}
+
+// encodeBody 序列化请求体，返回内容与 Content-Type
+func encodeBody(body any) ([]byte, string, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, "", nil
+	case []byte:
+		return b, "application/octet-stream", nil
+	case string:
+		return []byte(b), "text/plain; charset=utf-8", nil
+	case io.Reader:
+		data, err := io.ReadAll(b)
+		return data, "application/octet-stream", err
+	default:
+		data, err := json.Marshal(b)
+		return data, "application/json", err
+	}
+}
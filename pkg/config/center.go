@@ -0,0 +1,403 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/goupter/goupter/pkg/log"
+	"github.com/spf13/viper"
+)
+
+// ConfigSource 配置源，如 Consul KV、本地文件
+type ConfigSource interface {
+	// Name 配置源名称，带类型前缀，如 consul:config/app、file:/etc/app/config.yaml
+	Name() string
+	// Load 读取当前内容
+	Load() ([]byte, error)
+	// Watch 监听变更并回调 SetWatchCallback 设置的函数，阻塞直到 ctx 结束或 StopWatch
+	Watch(ctx context.Context) error
+	// SetWatchCallback 设置变更回调
+	SetWatchCallback(fn func([]byte))
+	// StopWatch 停止监听
+	StopWatch()
+}
+
+// ReloadStatus 配置热更新状态
+type ReloadStatus struct {
+	Applied      int       `json:"applied"`
+	Rejected     int       `json:"rejected"`
+	LastApplied  time.Time `json:"last_applied,omitempty"`
+	LastRejected time.Time `json:"last_rejected,omitempty"`
+	// LastError 最近一次被拒绝的原因，之后成功应用新配置时清空
+	LastError error `json:"-"`
+}
+
+// ConfigCenter 配置中心，监听配置源并热更新配置
+//
+// 配置变更按 解析 -> 校验 -> 替换 -> 通知 的顺序应用：解析或校验失败的配置被拒绝，
+// 继续使用最近一次有效的配置，并记录到 ReloadStatus 与日志；内容无变化时不通知订阅者。
+type ConfigCenter struct {
+	source          ConfigSource
+	config          *Config
+	mu              sync.RWMutex
+	callbacks       []func(*Config)
+	diffCallbacks   []func(*ConfigChange)
+	validator       *ConfigValidator
+	logger          log.Logger
+	status          ReloadStatus
+	ctx             context.Context
+	cancel          context.CancelFunc
+	isWatching      bool
+	format          string // yaml, json
+	autoRefresh     bool
+	refreshInterval time.Duration
+	// local 配置源为 Load 读取的本地文件，变更时重新合并各层配置而非作为远端配置层
+	local bool
+}
+
+// ConfigCenterOption 配置中心选项
+type ConfigCenterOption func(*ConfigCenter)
+
+// WithFormat 设置配置格式
+func WithFormat(format string) ConfigCenterOption {
+	return func(c *ConfigCenter) {
+		c.format = format
+	}
+}
+
+// WithAutoRefresh 设置自动刷新
+func WithAutoRefresh(interval time.Duration) ConfigCenterOption {
+	return func(c *ConfigCenter) {
+		c.autoRefresh = true
+		c.refreshInterval = interval
+	}
+}
+
+// WithValidator 设置配置校验器，默认使用 NewConfigValidator()
+func WithValidator(v *ConfigValidator) ConfigCenterOption {
+	return func(c *ConfigCenter) {
+		c.validator = v
+	}
+}
+
+// WithReloadLogger 设置热更新日志
+func WithReloadLogger(logger log.Logger) ConfigCenterOption {
+	return func(c *ConfigCenter) {
+		c.logger = logger
+	}
+}
+
+// NewConfigCenter 创建配置中心
+func NewConfigCenter(source ConfigSource, opts ...ConfigCenterOption) *ConfigCenter {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &ConfigCenter{
+		source:          source,
+		callbacks:       make([]func(*Config), 0),
+		validator:       NewConfigValidator(),
+		ctx:             ctx,
+		cancel:          cancel,
+		format:          "yaml",
+		refreshInterval: time.Minute,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// NewFileConfigCenter 创建监听 Load 读取的配置文件（基础、环境与本地文件）的配置中心
+//
+// 任一文件变化时像 Reload 一样重新合并各层配置（沿用最近一次的 Consul 配置），再经过与 Consul 相同的
+// 解析 -> 校验 -> 替换 -> 通知 流程，成功后同时回调 OnReload 注册的函数。需在 Load 之后调用。
+func NewFileConfigCenter(opts ...ConfigCenterOption) (*ConfigCenter, error) {
+	configMu.RLock()
+	files := loadedFiles
+	configMu.RUnlock()
+	if len(files) == 0 {
+		return nil, fmt.Errorf("未找到配置文件")
+	}
+
+	source := &localFilesSource{}
+	for _, file := range files {
+		source.sources = append(source.sources, NewFileSource(file, WithFileLogger(log.Default())))
+	}
+	c := NewConfigCenter(source, opts...)
+	c.local = true
+	c.config = Get()
+	return c, nil
+}
+
+// Load 从配置源加载配置
+func (c *ConfigCenter) Load() (*Config, error) {
+	data, err := c.source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, l, err := c.stage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.config = cfg
+	c.mu.Unlock()
+
+	c.commit(cfg, l, data)
+	return cfg, nil
+}
+
+// stage 解析并校验配置
+func (c *ConfigCenter) stage(data []byte) (*Config, *layeredConfig, error) {
+	cfg := &Config{}
+	l, err := c.parseConfig(data, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	if c.validator != nil {
+		if errs := c.validator.ValidateConfig(cfg); errs.HasErrors() {
+			return nil, nil, errs
+		}
+	}
+	if errs := checkSections(cfg); errs.HasErrors() {
+		return nil, nil, errs
+	}
+	return cfg, l, nil
+}
+
+// commit 更新全局配置与配置段
+func (c *ConfigCenter) commit(cfg *Config, l *layeredConfig, data []byte) {
+	configMu.Lock()
+	globalConfig = cfg
+	if c.local {
+		if l != nil {
+			loadedFiles = l.files
+			v = loadOpts.viper(l)
+		}
+	} else {
+		lastRemote = c.remoteLayer(data)
+	}
+	configMu.Unlock()
+	reloadSections(cfg)
+}
+
+// apply 应用变更的配置
+//
+// 校验失败时保留当前配置并记录拒绝原因；成功时替换配置并通知订阅者。
+func (c *ConfigCenter) apply(data []byte) (*ConfigChange, error) {
+	cfg, l, err := c.stage(data)
+	if err != nil {
+		c.mu.Lock()
+		c.status.Rejected++
+		c.status.LastRejected = time.Now()
+		c.status.LastError = err
+		c.mu.Unlock()
+
+		if c.logger != nil {
+			c.logger.Error("config reload rejected, keeping last known good config",
+				log.String("source", c.source.Name()), log.Error(err))
+		}
+		return nil, err
+	}
+
+	c.mu.Lock()
+	old := c.config
+	if old == nil || c.local {
+		// 尚未从 Consul 加载时以当前全局配置为基线；本地文件与其他配置源共同决定全局配置，始终以其为基线
+		old = Get()
+	}
+	change := &ConfigChange{Old: old, New: cfg, Changes: Diff(old, cfg)}
+	c.config = cfg
+	c.status.Applied++
+	c.status.LastApplied = time.Now()
+	c.status.LastError = nil
+	callbacks := make([]func(*Config), len(c.callbacks))
+	copy(callbacks, c.callbacks)
+	diffCallbacks := make([]func(*ConfigChange), len(c.diffCallbacks))
+	copy(diffCallbacks, c.diffCallbacks)
+	c.mu.Unlock()
+
+	if len(change.Changes) == 0 {
+		return change, nil
+	}
+
+	c.commit(cfg, l, data)
+
+	if c.logger != nil {
+		c.logger.Info("config reloaded",
+			log.String("source", c.source.Name()), log.Strings("changed_keys", change.Keys()))
+	}
+
+	for _, fn := range callbacks {
+		fn(cfg)
+	}
+	for _, fn := range diffCallbacks {
+		fn(change)
+	}
+	if c.local {
+		reloadMu.Lock()
+		reloadFns := make([]func(*ConfigChange), len(reloadCallbacks))
+		copy(reloadFns, reloadCallbacks)
+		reloadMu.Unlock()
+		for _, fn := range reloadFns {
+			fn(change)
+		}
+	}
+	return change, nil
+}
+
+// ReloadStatus 获取热更新状态
+func (c *ConfigCenter) ReloadStatus() ReloadStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.status
+}
+
+// parseConfig 解析配置，返回本地配置模式下合并的各层配置
+//
+// 已通过 Load 加载本地配置时，配置源的内容作为一层与本地文件、环境变量、命令行参数按优先级合并；
+// 否则未出现在配置内容中的键使用默认值。ENC[...] 加密值在每次解析时解密，密钥引用在每次解析时重新读取。
+// 配置源为本地文件时重新读取各层，data 不单独作为一层。
+func (c *ConfigCenter) parseConfig(data []byte, cfg *Config) (*layeredConfig, error) {
+	configMu.RLock()
+	o := loadOpts
+	configMu.RUnlock()
+	if c.local {
+		if o == nil {
+			return nil, fmt.Errorf("配置未加载")
+		}
+		loaded, l, err := o.load(nil)
+		if err != nil {
+			return nil, err
+		}
+		*cfg = *loaded
+		return l, nil
+	}
+	if o != nil {
+		loaded, _, err := o.load(c.remoteLayer(data))
+		if err != nil {
+			return nil, err
+		}
+		*cfg = *loaded
+		return nil, nil
+	}
+
+	settings, err := readSettings(data, c.format)
+	if err != nil {
+		return nil, err
+	}
+
+	vp := viper.New()
+	setDefaults(vp, nil)
+	if err := vp.MergeConfigMap(settings); err != nil {
+		return nil, err
+	}
+	if err := vp.Unmarshal(cfg); err != nil {
+		return nil, err
+	}
+	if err := decryptConfig(cfg, nil); err != nil {
+		return nil, fmt.Errorf("解密配置失败: %w", err)
+	}
+	// 未加载本地配置时全部内容来自配置源，不能引用本机文件与环境变量
+	if err := resolveSecrets(cfg, newOptions().allowSecret(nil)); err != nil {
+		return nil, fmt.Errorf("解析密钥引用失败: %w", err)
+	}
+	return nil, nil
+}
+
+// remoteLayer 将配置源的内容包装为配置层
+func (c *ConfigCenter) remoteLayer(data []byte) *remoteLayer {
+	name := "remote"
+	if c.source != nil {
+		name = c.source.Name()
+	}
+	return &remoteLayer{name: name, data: data, format: c.format}
+}
+
+// Get 获取当前配置
+func (c *ConfigCenter) Get() *Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.config
+}
+
+// Watch 开始监听配置变更
+func (c *ConfigCenter) Watch() error {
+	c.mu.Lock()
+	if c.isWatching {
+		c.mu.Unlock()
+		return nil
+	}
+	c.isWatching = true
+	c.mu.Unlock()
+
+	// 设置变更回调
+	c.source.SetWatchCallback(func(data []byte) {
+		_, _ = c.apply(data)
+	})
+
+	// 启动监听
+	go func() {
+		_ = c.source.Watch(c.ctx)
+	}()
+
+	// 如果启用自动刷新
+	if c.autoRefresh {
+		go c.autoRefreshLoop()
+	}
+
+	return nil
+}
+
+// autoRefreshLoop 自动刷新循环
+func (c *ConfigCenter) autoRefreshLoop() {
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if data, err := c.source.Load(); err == nil {
+				_, _ = c.apply(data)
+			}
+		}
+	}
+}
+
+// Stop 停止监听
+func (c *ConfigCenter) Stop() {
+	c.cancel()
+	c.source.StopWatch()
+
+	c.mu.Lock()
+	c.isWatching = false
+	c.mu.Unlock()
+}
+
+// OnChange 注册配置变更回调
+func (c *ConfigCenter) OnChange(fn func(*Config)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.callbacks = append(c.callbacks, fn)
+}
+
+// OnChangeDiff 注册配置变更回调，回调参数包含新旧配置与变更的键
+func (c *ConfigCenter) OnChangeDiff(fn func(*ConfigChange)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diffCallbacks = append(c.diffCallbacks, fn)
+}
+
+// IsWatching 是否正在监听
+func (c *ConfigCenter) IsWatching() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.isWatching
+}
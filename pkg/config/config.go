@@ -7,20 +7,26 @@ import (
 	"sync"
 	"time"
 
+	"github.com/goupter/goupter/pkg/log"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 // Config 应用配置结构
 type Config struct {
-	App      AppConfig      `mapstructure:"app"`
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Consul   ConsulConfig   `mapstructure:"consul"`
-	NATS     NATSConfig     `mapstructure:"nats"`
-	Auth     AuthConfig     `mapstructure:"auth"`
-	Log      LogConfig      `mapstructure:"log"`
-	Trace    TraceConfig    `mapstructure:"trace"`
+	App       AppConfig       `mapstructure:"app"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Consul    ConsulConfig    `mapstructure:"consul"`
+	Discovery DiscoveryConfig `mapstructure:"discovery"`
+	NATS      NATSConfig      `mapstructure:"nats"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	Log       LogConfig       `mapstructure:"log"`
+	Trace     TraceConfig     `mapstructure:"trace"`
+
+	// Extra 未在上述结构中声明的顶层配置，供 Section/Bind 解析为应用自定义的配置段
+	Extra map[string]any `mapstructure:",remain"`
 }
 
 // AppConfig 应用配置
@@ -46,6 +52,26 @@ type HTTPConfig struct {
 	ReadTimeout   time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout  time.Duration `mapstructure:"write_timeout"`
 	IdleTimeout   time.Duration `mapstructure:"idle_timeout"`
+	// 跨域与限流，支持热更新
+	CORS      CORSConfig      `mapstructure:"cors"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+}
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	AllowOrigins     []string `mapstructure:"allow_origins"`
+	AllowMethods     []string `mapstructure:"allow_methods"`
+	AllowHeaders     []string `mapstructure:"allow_headers"`
+	ExposeHeaders    []string `mapstructure:"expose_headers"`
+	AllowCredentials bool     `mapstructure:"allow_credentials"`
+	MaxAge           int      `mapstructure:"max_age"`
+}
+
+// RateLimitConfig 限流配置（按客户端IP的令牌桶）
+type RateLimitConfig struct {
+	Enabled bool    `mapstructure:"enabled"`
+	Rate    float64 `mapstructure:"rate"`  // 每秒生成的令牌数
+	Burst   int     `mapstructure:"burst"` // 桶容量
 }
 
 // GRPCConfig gRPC服务器配置
@@ -116,6 +142,62 @@ type ConsulServiceConfig struct {
 	CheckInterval                  string   `mapstructure:"check_interval"`
 	CheckTimeout                   string   `mapstructure:"check_timeout"`
 	DeregisterCriticalServiceAfter string   `mapstructure:"deregister_critical_service_after"`
+	// 检查模式：pull（默认，由 Consul 主动探测）或 ttl（由服务主动上报心跳）
+	CheckMode string `mapstructure:"check_mode"`
+	TTL       string `mapstructure:"ttl"`
+}
+
+// DiscoveryConfig 服务发现配置
+type DiscoveryConfig struct {
+	// Type 服务发现类型：consul（默认）、etcd、dns、static
+	Type   string                `mapstructure:"type"`
+	Etcd   EtcdConfig            `mapstructure:"etcd"`
+	DNS    DNSDiscoveryConfig    `mapstructure:"dns"`
+	Static StaticDiscoveryConfig `mapstructure:"static"`
+}
+
+// EtcdConfig etcd配置
+type EtcdConfig struct {
+	Endpoints   []string      `mapstructure:"endpoints"`
+	Username    string        `mapstructure:"username"`
+	Password    string        `mapstructure:"password"`
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+	// Prefix 服务注册键前缀，键格式为 <prefix>/<service>/<id>
+	Prefix string `mapstructure:"prefix"`
+	// TTL 注册租约时长
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// DNSDiscoveryConfig DNS服务发现配置（只读，适用于 Kubernetes Service 等）
+type DNSDiscoveryConfig struct {
+	// Mode 查询方式：srv（默认）或 a
+	Mode string `mapstructure:"mode"`
+	// Domain 附加到服务名后的域名后缀，如 default.svc.cluster.local
+	Domain string `mapstructure:"domain"`
+	// PortName/Protocol SRV 查询的端口名与协议，组成 _<port_name>._<protocol>.<service>.<domain>
+	PortName string `mapstructure:"port_name"`
+	Protocol string `mapstructure:"protocol"`
+	// Port A 记录查询时使用的端口
+	Port            int           `mapstructure:"port"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// StaticDiscoveryConfig 静态服务发现配置
+type StaticDiscoveryConfig struct {
+	// File YAML 服务列表文件，变更时自动重新加载
+	File string `mapstructure:"file"`
+	// Services 内联的服务列表，按服务名
+	Services map[string][]StaticInstance `mapstructure:"services"`
+}
+
+// StaticInstance 静态服务实例
+type StaticInstance struct {
+	ID       string            `mapstructure:"id" yaml:"id"`
+	Address  string            `mapstructure:"address" yaml:"address"`
+	Port     int               `mapstructure:"port" yaml:"port"`
+	Weight   int               `mapstructure:"weight" yaml:"weight"`
+	Tags     []string          `mapstructure:"tags" yaml:"tags"`
+	Metadata map[string]string `mapstructure:"metadata" yaml:"metadata"`
 }
 
 // NATSConfig NATS配置
@@ -130,7 +212,7 @@ type NATSConfig struct {
 
 // AuthConfig 鉴权配置
 type AuthConfig struct {
-	Type   string                 `mapstructure:"type"` // jwt, apikey, oauth2, session
+	Type   string                 `mapstructure:"type"` // jwt, apikey, oidc, session
 	Config map[string]interface{} `mapstructure:"config"`
 }
 
@@ -163,6 +245,28 @@ type options struct {
 	configPaths  []string
 	envPrefix    string
 	defaultValue *Config
+	profileName  string
+	consulSource *ConsulSource
+	remoteConsul bool
+	flags        *pflag.FlagSet
+	cipher       Cipher
+	// remoteSecretSchemes 远端配置层允许使用的本机密钥引用
+	remoteSecretSchemes []string
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		configFile:  "config",
+		configType:  "yaml",
+		configPaths: []string{".", "./config", "/etc/flit"},
+		envPrefix:   "FLIT",
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
 }
 
 // WithConfigFile 设置配置文件路径
@@ -200,61 +304,204 @@ func WithDefault(cfg *Config) Option {
 	}
 }
 
+// WithProfile 设置环境名，加载 config.<profile>.yaml
+//
+// 未设置时依次取环境变量 <前缀>_APP_ENV 与配置文件中的 app.env。
+func WithProfile(profile string) Option {
+	return func(o *options) {
+		o.profileName = profile
+	}
+}
+
+// WithConsulSource 从 Consul KV 加载一层配置，优先级高于本地文件、低于环境变量
+func WithConsulSource(source *ConsulSource) Option {
+	return func(o *options) {
+		o.consulSource = source
+	}
+}
+
+// WithRemoteConsul 按本地配置中的 consul.config_path 从 Consul KV 加载一层配置
+func WithRemoteConsul() Option {
+	return func(o *options) {
+		o.remoteConsul = true
+	}
+}
+
+// WithFlags 使用命令行参数覆盖配置，仅显式设置的参数生效，参数名即配置键（如 --server.http.port）
+func WithFlags(flags *pflag.FlagSet) Option {
+	return func(o *options) {
+		o.flags = flags
+	}
+}
+
+// WithCipher 设置解密 ENC[...] 配置值的密钥，未设置时读取 GOUPTER_CONFIG_KEY 或 GOUPTER_CONFIG_KEY_FILE
+func WithCipher(c Cipher) Option {
+	return func(o *options) {
+		o.cipher = c
+	}
+}
+
+// WithRemoteSecretSchemes 允许远端配置层（如 Consul）的值使用 file、env 密钥引用
+//
+// 默认只有本地文件、环境变量与命令行参数中的值可以引用本机文件与环境变量，
+// 远端配置层中的此类引用使加载或热更新失败；其他 scheme（如 vault）不受限制。
+func WithRemoteSecretSchemes(schemes ...string) Option {
+	return func(o *options) {
+		o.remoteSecretSchemes = schemes
+	}
+}
+
 var (
 	globalConfig *Config
 	configMu     sync.RWMutex
 	v            *viper.Viper
+	loadOpts     *options
+	loadedFiles  []string
+
+	reloadMu        sync.Mutex
+	reloadCallbacks []func(*ConfigChange)
+
+	fileWatchMu     sync.Mutex
+	fileWatchCenter *ConfigCenter
 )
 
 // Load 加载配置
+//
+// 按优先级从低到高合并：默认值、config.yaml、config.<env>.yaml、config.local.yaml、Consul KV、
+// 环境变量、命令行参数，文件不存在时跳过。使用 Explain 查看每个配置键的来源。
 func Load(opts ...Option) (*Config, error) {
-	o := &options{
-		configFile:  "config",
-		configType:  "yaml",
-		configPaths: []string{".", "./config", "/etc/flit"},
-		envPrefix:   "FLIT",
-	}
+	o := newOptions(opts...)
 
-	for _, opt := range opts {
-		opt(o)
+	// 重新加载时不沿用之前配置中心下发的内容
+	configMu.Lock()
+	lastRemote = nil
+	configMu.Unlock()
+
+	cfg, l, err := o.load(nil)
+	if err != nil {
+		return nil, err
 	}
 
-	v = viper.New()
+	configMu.Lock()
+	globalConfig = cfg
+	loadOpts = o
+	loadedFiles = l.files
+	v = o.viper(l)
+	configMu.Unlock()
+
+	reloadSections(cfg)
+	return cfg, nil
+}
+
+// viper 创建供 GetString 等函数读取的 viper 实例
+func (o *options) viper(l *layeredConfig) *viper.Viper {
+	vp := viper.New()
+	vp.SetEnvPrefix(o.envPrefix)
+	vp.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	vp.AutomaticEnv()
+	_ = vp.MergeConfigMap(l.settings)
+	return vp
+}
 
-	// 设置配置文件
-	v.SetConfigName(o.configFile)
-	v.SetConfigType(o.configType)
-	for _, path := range o.configPaths {
-		v.AddConfigPath(path)
+// Reload 重新加载配置文件、环境变量与最近一次的 Consul 配置，并重新解析密钥引用（密钥轮换后调用即可生效）
+//
+// 新配置（包括已注册的配置段）校验失败时保留当前配置并返回错误；
+// 成功且有变更时更新全局配置与配置段，并回调 OnReload 注册的函数。
+func Reload() (*ConfigChange, error) {
+	return reload(nil)
+}
+
+// reload 重新合并各层配置并应用，remote 参见 options.load
+//
+// 配置段与 OnReload 回调在释放 reloadMu 后执行，回调中可以再次调用 Reload 或 OnReload。
+func reload(remote *remoteLayer) (*ConfigChange, error) {
+	change, callbacks, err := swapConfig(remote)
+	if err != nil || len(change.Changes) == 0 {
+		return change, err
 	}
 
-	// 设置环境变量
-	v.SetEnvPrefix(o.envPrefix)
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	v.AutomaticEnv()
+	reloadSections(change.New)
+	for _, fn := range callbacks {
+		fn(change)
+	}
+	return change, nil
+}
 
-	// 设置默认值
-	setDefaults(v, o.defaultValue)
+// swapConfig 在 reloadMu 下合并、校验并替换全局配置，返回变更与此时注册的回调
+func swapConfig(remote *remoteLayer) (*ConfigChange, []func(*ConfigChange), error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
 
-	// 读取配置文件
-	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("读取配置文件失败: %w", err)
-		}
-		// 配置文件不存在时使用默认值
+	configMu.RLock()
+	o := loadOpts
+	configMu.RUnlock()
+	if o == nil {
+		return nil, nil, fmt.Errorf("配置未加载")
 	}
 
-	// 解析配置
-	cfg := &Config{}
-	if err := v.Unmarshal(cfg); err != nil {
-		return nil, fmt.Errorf("解析配置失败: %w", err)
+	cfg, l, err := o.load(remote)
+	if err != nil {
+		return nil, nil, err
+	}
+	if errs := ValidateConfig(cfg); errs.HasErrors() {
+		return nil, nil, errs
 	}
+	if errs := checkSections(cfg); errs.HasErrors() {
+		return nil, nil, errs
+	}
+
+	old := Get()
+	change := &ConfigChange{Old: old, New: cfg, Changes: Diff(old, cfg)}
 
 	configMu.Lock()
-	globalConfig = cfg
+	if remote != nil {
+		lastRemote = remote
+	}
+	loadedFiles = l.files
+	v = o.viper(l)
+	if len(change.Changes) > 0 {
+		globalConfig = cfg
+	}
 	configMu.Unlock()
 
-	return cfg, nil
+	callbacks := make([]func(*ConfigChange), len(reloadCallbacks))
+	copy(callbacks, reloadCallbacks)
+	return change, callbacks, nil
+}
+
+// OnReload 注册 Reload 成功后的回调
+func OnReload(fn func(*ConfigChange)) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadCallbacks = append(reloadCallbacks, fn)
+}
+
+// WatchFile 监听 Load 读取的配置文件（基础、环境与本地文件），文件变化时自动重新加载
+//
+// 基于 NewFileConfigCenter，变更经过与配置中心相同的校验流程，成功后回调 OnReload 注册的函数。
+// 使用 FileSource 监听，支持 Kubernetes ConfigMap 的符号链接切换。重复调用时先停止之前的监听。
+func WatchFile() error {
+	center, err := NewFileConfigCenter(WithReloadLogger(log.Default()))
+	if err != nil {
+		return err
+	}
+
+	StopWatchFile()
+
+	fileWatchMu.Lock()
+	fileWatchCenter = center
+	fileWatchMu.Unlock()
+	return center.Watch()
+}
+
+// StopWatchFile 停止 WatchFile 启动的监听
+func StopWatchFile() {
+	fileWatchMu.Lock()
+	defer fileWatchMu.Unlock()
+	if fileWatchCenter != nil {
+		fileWatchCenter.Stop()
+		fileWatchCenter = nil
+	}
 }
 
 // Get 获取全局配置
@@ -278,6 +525,12 @@ func setDefaults(v *viper.Viper, defaults *Config) {
 	v.SetDefault("server.http.read_timeout", "30s")
 	v.SetDefault("server.http.write_timeout", "30s")
 	v.SetDefault("server.http.idle_timeout", "120s")
+	v.SetDefault("server.http.cors.allow_origins", []string{"*"})
+	v.SetDefault("server.http.cors.allow_credentials", true)
+	v.SetDefault("server.http.cors.max_age", 86400)
+	v.SetDefault("server.http.rate_limit.enabled", false)
+	v.SetDefault("server.http.rate_limit.rate", 100)
+	v.SetDefault("server.http.rate_limit.burst", 200)
 
 	// gRPC服务器默认值
 	v.SetDefault("server.grpc.host", "0.0.0.0")
@@ -314,6 +567,17 @@ func setDefaults(v *viper.Viper, defaults *Config) {
 	v.SetDefault("consul.service.check_interval", "10s")
 	v.SetDefault("consul.service.check_timeout", "5s")
 	v.SetDefault("consul.service.deregister_critical_service_after", "30s")
+	v.SetDefault("consul.service.ttl", "15s")
+
+	// 服务发现默认值
+	v.SetDefault("discovery.type", "consul")
+	v.SetDefault("discovery.etcd.endpoints", []string{"localhost:2379"})
+	v.SetDefault("discovery.etcd.dial_timeout", "5s")
+	v.SetDefault("discovery.etcd.prefix", "/goupter/services")
+	v.SetDefault("discovery.etcd.ttl", "15s")
+	v.SetDefault("discovery.dns.mode", "srv")
+	v.SetDefault("discovery.dns.protocol", "tcp")
+	v.SetDefault("discovery.dns.refresh_interval", "10s")
 
 	// NATS默认值
 	v.SetDefault("nats.url", "nats://localhost:4222")
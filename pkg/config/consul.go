@@ -1,18 +1,20 @@
 package config
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/hashicorp/consul/api"
-	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
+// ErrKeyNotFound 配置键不存在
+var ErrKeyNotFound = errors.New("config: key not found")
+
 // ConsulSource Consul配置源
 type ConsulSource struct {
 	client     *api.Client
@@ -146,6 +148,16 @@ func (s *ConsulSource) Watch(ctx context.Context) error {
 	}
 }
 
+// Name 配置源名称
+func (s *ConsulSource) Name() string {
+	return sourceConsul + s.path
+}
+
+// SetWatchCallback 设置配置变更回调
+func (s *ConsulSource) SetWatchCallback(fn func([]byte)) {
+	s.onChangeFn = fn
+}
+
 // StopWatch 停止监听
 func (s *ConsulSource) StopWatch() {
 	close(s.watchStop)
@@ -191,193 +203,22 @@ func (s *ConsulSource) Path() string {
 }
 
 // ConsulConfigCenter Consul配置中心
+//
+// 在 ConfigCenter 的热更新流程之上提供 Consul KV 的读写。
 type ConsulConfigCenter struct {
-	source          *ConsulSource
-	config          *Config
-	mu              sync.RWMutex
-	callbacks       []func(*Config)
-	ctx             context.Context
-	cancel          context.CancelFunc
-	isWatching      bool
-	format          string // yaml, json
-	autoRefresh     bool
-	refreshInterval time.Duration
+	*ConfigCenter
+	source *ConsulSource
 }
 
 // ConsulConfigCenterOption Consul配置中心选项
-type ConsulConfigCenterOption func(*ConsulConfigCenter)
-
-// WithFormat 设置配置格式
-func WithFormat(format string) ConsulConfigCenterOption {
-	return func(c *ConsulConfigCenter) {
-		c.format = format
-	}
-}
-
-// WithAutoRefresh 设置自动刷新
-func WithAutoRefresh(interval time.Duration) ConsulConfigCenterOption {
-	return func(c *ConsulConfigCenter) {
-		c.autoRefresh = true
-		c.refreshInterval = interval
-	}
-}
+type ConsulConfigCenterOption = ConfigCenterOption
 
 // NewConsulConfigCenter 创建Consul配置中心
 func NewConsulConfigCenter(source *ConsulSource, opts ...ConsulConfigCenterOption) *ConsulConfigCenter {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	c := &ConsulConfigCenter{
-		source:          source,
-		callbacks:       make([]func(*Config), 0),
-		ctx:             ctx,
-		cancel:          cancel,
-		format:          "yaml",
-		refreshInterval: time.Minute,
-	}
-
-	for _, opt := range opts {
-		opt(c)
-	}
-
-	return c
-}
-
-// Load 从Consul加载配置
-func (c *ConsulConfigCenter) Load() (*Config, error) {
-	data, err := c.source.Load()
-	if err != nil {
-		return nil, err
-	}
-
-	cfg := &Config{}
-	if err := c.parseConfig(data, cfg); err != nil {
-		return nil, fmt.Errorf("解析配置失败: %w", err)
-	}
-
-	c.mu.Lock()
-	c.config = cfg
-	c.mu.Unlock()
-
-	// 更新全局配置
-	configMu.Lock()
-	globalConfig = cfg
-	configMu.Unlock()
-
-	return cfg, nil
-}
-
-// parseConfig 解析配置
-func (c *ConsulConfigCenter) parseConfig(data []byte, cfg *Config) error {
-	format := c.format
-	if format == "" {
-		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
-			format = "json"
-		} else {
-			format = "yaml"
-		}
-	}
-	if format == "yml" {
-		format = "yaml"
-	}
-
-	vp := viper.New()
-	vp.SetConfigType(format)
-	if err := vp.ReadConfig(bytes.NewReader(data)); err != nil {
-		return err
-	}
-	return vp.Unmarshal(cfg)
-}
-
-// Get 获取当前配置
-func (c *ConsulConfigCenter) Get() *Config {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.config
-}
-
-// Watch 开始监听配置变更
-func (c *ConsulConfigCenter) Watch() error {
-	c.mu.Lock()
-	if c.isWatching {
-		c.mu.Unlock()
-		return nil
-	}
-	c.isWatching = true
-	c.mu.Unlock()
-
-	// 设置变更回调
-	c.source.onChangeFn = func(data []byte) {
-		cfg := &Config{}
-		if err := c.parseConfig(data, cfg); err != nil {
-			return
-		}
-
-		c.mu.Lock()
-		oldConfig := c.config
-		c.config = cfg
-		c.mu.Unlock()
-
-		// 更新全局配置
-		configMu.Lock()
-		globalConfig = cfg
-		configMu.Unlock()
-
-		// 调用回调（传入旧配置以便比较）
-		_ = oldConfig // 可用于未来扩展
-
-		c.mu.RLock()
-		callbacks := make([]func(*Config), len(c.callbacks))
-		copy(callbacks, c.callbacks)
-		c.mu.RUnlock()
-
-		for _, fn := range callbacks {
-			fn(cfg)
-		}
+	return &ConsulConfigCenter{
+		ConfigCenter: NewConfigCenter(source, opts...),
+		source:       source,
 	}
-
-	// 启动监听
-	go func() {
-		_ = c.source.Watch(c.ctx)
-	}()
-
-	// 如果启用自动刷新
-	if c.autoRefresh {
-		go c.autoRefreshLoop()
-	}
-
-	return nil
-}
-
-// autoRefreshLoop 自动刷新循环
-func (c *ConsulConfigCenter) autoRefreshLoop() {
-	ticker := time.NewTicker(c.refreshInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-c.ctx.Done():
-			return
-		case <-ticker.C:
-			_, _ = c.Load()
-		}
-	}
-}
-
-// Stop 停止监听
-func (c *ConsulConfigCenter) Stop() {
-	c.cancel()
-	c.source.StopWatch()
-
-	c.mu.Lock()
-	c.isWatching = false
-	c.mu.Unlock()
-}
-
-// OnChange 注册配置变更回调
-func (c *ConsulConfigCenter) OnChange(fn func(*Config)) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.callbacks = append(c.callbacks, fn)
 }
 
 // Save 保存配置到Consul
@@ -401,7 +242,7 @@ func (c *ConsulConfigCenter) Save(cfg *Config) error {
 	return c.source.Put(data)
 }
 
-// GetKey 获取指定路径的配置值
+// GetKey 获取指定路径的配置值，键不存在时返回 ErrKeyNotFound
 func (c *ConsulConfigCenter) GetKey(key string) ([]byte, error) {
 	kv := c.source.client.KV()
 	pair, _, err := kv.Get(key, nil)
@@ -409,7 +250,7 @@ func (c *ConsulConfigCenter) GetKey(key string) ([]byte, error) {
 		return nil, err
 	}
 	if pair == nil {
-		return nil, fmt.Errorf("key %s not found", key)
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
 	}
 	return pair.Value, nil
 }
@@ -442,9 +283,43 @@ func (c *ConsulConfigCenter) List(prefix string) ([]string, error) {
 	return keys, nil
 }
 
-// IsWatching 是否正在监听
-func (c *ConsulConfigCenter) IsWatching() bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.isWatching
+// WatchPrefix 监听指定前缀下的全部配置，阻塞直到 ctx 结束
+//
+// 每次前缀下任一键变更时回调该前缀下的全部键值。请求失败时短暂等待后重试。
+func (c *ConsulConfigCenter) WatchPrefix(ctx context.Context, prefix string, fn func(map[string][]byte)) error {
+	kv := c.source.client.KV()
+
+	var lastIndex uint64
+	for {
+		opts := (&api.QueryOptions{
+			WaitIndex: lastIndex,
+			WaitTime:  time.Minute,
+		}).WithContext(ctx)
+
+		pairs, meta, err := kv.List(prefix, opts)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		// 索引回退时重新开始，参见 Consul 阻塞查询文档
+		if meta.LastIndex < lastIndex {
+			lastIndex = 0
+			continue
+		}
+		if meta.LastIndex == lastIndex {
+			continue
+		}
+		lastIndex = meta.LastIndex
+
+		values := make(map[string][]byte, len(pairs))
+		for _, pair := range pairs {
+			values[pair.Key] = pair.Value
+		}
+		fn(values)
+	}
 }
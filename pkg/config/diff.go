@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeyChange 单个配置键的变更
+type KeyChange struct {
+	Key string `json:"key"`
+	Old any    `json:"old"`
+	New any    `json:"new"`
+}
+
+// ConfigChange 配置变更
+type ConfigChange struct {
+	Old     *Config
+	New     *Config
+	Changes []KeyChange
+}
+
+// Keys 返回变更的配置键
+func (c *ConfigChange) Keys() []string {
+	keys := make([]string, 0, len(c.Changes))
+	for _, ch := range c.Changes {
+		keys = append(keys, ch.Key)
+	}
+	return keys
+}
+
+// Changed 是否有配置键位于任一前缀下，如 Changed("log.level", "database")
+func (c *ConfigChange) Changed(prefixes ...string) bool {
+	for _, ch := range c.Changes {
+		for _, prefix := range prefixes {
+			if ch.Key == prefix || strings.HasPrefix(ch.Key, prefix+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Diff 比较两份配置，返回按键排序的变更列表
+//
+// 键名与配置文件一致（取 mapstructure 标签，以 . 连接），切片整体比较。
+func Diff(old, new *Config) []KeyChange {
+	oldValues := make(map[string]any)
+	newValues := make(map[string]any)
+	if old != nil {
+		flatten(reflect.ValueOf(old).Elem(), "", oldValues)
+	}
+	if new != nil {
+		flatten(reflect.ValueOf(new).Elem(), "", newValues)
+	}
+
+	keys := make(map[string]struct{}, len(newValues))
+	for k := range oldValues {
+		keys[k] = struct{}{}
+	}
+	for k := range newValues {
+		keys[k] = struct{}{}
+	}
+
+	changes := make([]KeyChange, 0)
+	for k := range keys {
+		o, n := oldValues[k], newValues[k]
+		if !reflect.DeepEqual(o, n) {
+			changes = append(changes, KeyChange{Key: k, Old: o, New: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// flatten 将结构体展开为 键 -> 值
+func flatten(v reflect.Value, prefix string, out map[string]any) {
+	join := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "." + name
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			if prefix != "" {
+				out[prefix] = nil
+			}
+			return
+		}
+		flatten(v.Elem(), prefix, out)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			out[prefix] = v.Interface()
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			tag := strings.Split(field.Tag.Get("mapstructure"), ",")
+			name := tag[0]
+			if name == "-" {
+				continue
+			}
+			// remain/squash 字段的键与父级同级
+			if containsString(tag[1:], "remain") || containsString(tag[1:], "squash") {
+				flatten(v.Field(i), prefix, out)
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			flatten(v.Field(i), join(name), out)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			out[prefix] = v.Interface()
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			flatten(iter.Value(), join(fmt.Sprint(iter.Key().Interface())), out)
+		}
+	default:
+		if prefix != "" {
+			out[prefix] = v.Interface()
+		}
+	}
+}
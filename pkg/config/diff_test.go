@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := &Config{
+		Server: ServerConfig{HTTP: HTTPConfig{Port: 8080}},
+		Log:    LogConfig{Level: "info"},
+		Consul: ConsulConfig{Service: ConsulServiceConfig{Tags: []string{"a"}}},
+	}
+	new := &Config{
+		Server: ServerConfig{HTTP: HTTPConfig{Port: 8080}},
+		Log:    LogConfig{Level: "debug"},
+		Consul: ConsulConfig{Service: ConsulServiceConfig{Tags: []string{"a", "b"}}},
+		Auth:   AuthConfig{Config: map[string]any{"secret": "x"}},
+	}
+
+	change := &ConfigChange{Old: old, New: new, Changes: Diff(old, new)}
+	want := []string{"auth.config.secret", "consul.service.tags", "log.level"}
+	if !reflect.DeepEqual(change.Keys(), want) {
+		t.Fatalf("Keys() = %v, want %v", change.Keys(), want)
+	}
+	if c := change.Changes[2]; c.Old != "info" || c.New != "debug" {
+		t.Errorf("log.level change = %+v", c)
+	}
+	if !change.Changed("log") || !change.Changed("auth.config") || change.Changed("server", "lo") {
+		t.Error("Changed() 前缀匹配错误")
+	}
+
+	if len(Diff(old, old)) != 0 {
+		t.Error("相同配置不应有变更")
+	}
+}
+
+func newTestConfigCenter() *ConsulConfigCenter {
+	return NewConsulConfigCenter(&ConsulSource{path: "config/test"})
+}
+
+func TestConsulConfigCenter_ApplyValidates(t *testing.T) {
+	c := newTestConfigCenter()
+
+	var changes []*ConfigChange
+	var configs []*Config
+	c.OnChangeDiff(func(ch *ConfigChange) { changes = append(changes, ch) })
+	c.OnChange(func(cfg *Config) { configs = append(configs, cfg) })
+
+	if _, err := c.apply([]byte("server:\n  http:\n    port: 8081\n")); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	if c.Get().Server.HTTP.Port != 8081 || c.Get().Server.GRPC.Port != 9090 {
+		t.Errorf("未出现的键应使用默认值, got %+v", c.Get().Server)
+	}
+	if len(changes) != 1 || len(configs) != 1 {
+		t.Fatalf("回调次数 = %d/%d, want 1", len(changes), len(configs))
+	}
+
+	// 非法端口被拒绝，保留原配置
+	_, err := c.apply([]byte("server:\n  http:\n    port: 70000\n"))
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || verrs[0].Field != "server.http.port" {
+		t.Fatalf("应返回校验错误, got %v", err)
+	}
+	if c.Get().Server.HTTP.Port != 8081 || Get().Server.HTTP.Port != 8081 {
+		t.Error("拒绝后应保留最近一次有效配置")
+	}
+	status := c.ReloadStatus()
+	if status.Rejected != 1 || status.LastError == nil {
+		t.Errorf("status = %+v", status)
+	}
+
+	// 无法解析的内容同样被拒绝
+	if _, err := c.apply([]byte("server: [")); err == nil {
+		t.Error("无法解析的配置应被拒绝")
+	}
+
+	// 内容无变化不通知
+	if _, err := c.apply([]byte("server:\n  http:\n    port: 8081\n")); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	if len(changes) != 1 {
+		t.Errorf("无变更时不应通知, got %d", len(changes))
+	}
+	if c.ReloadStatus().LastError != nil {
+		t.Error("成功应用后应清空 LastError")
+	}
+
+	if _, err := c.apply([]byte("log:\n  level: debug\n")); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	last := changes[len(changes)-1]
+	if !last.Changed("log.level") || !last.Changed("server.http.port") {
+		t.Errorf("changed keys = %v", last.Keys())
+	}
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+)
+
+// 加密配置值的密钥来源（环境变量），值与文件内容均可以是 AES-256 密钥（base64 或 hex）或 age 私钥
+const (
+	EnvConfigKey     = "GOUPTER_CONFIG_KEY"
+	EnvConfigKeyFile = "GOUPTER_CONFIG_KEY_FILE"
+)
+
+// 加密算法标识，写在 ENC[<算法>:<密文>] 中
+const (
+	AlgorithmAESGCM = "aes256gcm"
+	AlgorithmAge    = "age"
+)
+
+// encryptedPattern 匹配完整的加密值 ENC[<算法>:<base64 密文>]
+var encryptedPattern = regexp.MustCompile(`^ENC\[([a-z0-9]+):([A-Za-z0-9+/]+=*)\]$`)
+
+// ErrNoConfigKey 配置中有加密值但未提供密钥
+var ErrNoConfigKey = errors.New("未配置解密密钥，请设置 " + EnvConfigKey + " 或 " + EnvConfigKeyFile)
+
+// Cipher 配置值加解密
+type Cipher interface {
+	// Algorithm 算法标识
+	Algorithm() string
+	// Encrypt 加密明文，返回不含 ENC[] 包装的密文
+	Encrypt(plaintext []byte) ([]byte, error)
+	// Decrypt 解密 Encrypt 返回的密文
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// IsEncrypted 是否为 ENC[...] 加密值
+func IsEncrypted(value string) bool {
+	return encryptedPattern.MatchString(value)
+}
+
+// EncryptValue 加密配置值，返回 ENC[<算法>:<base64 密文>]
+func EncryptValue(c Cipher, plaintext string) (string, error) {
+	ct, err := c.Encrypt([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return "ENC[" + c.Algorithm() + ":" + base64.StdEncoding.EncodeToString(ct) + "]", nil
+}
+
+// DecryptValue 解密 ENC[...] 值，非加密值原样返回
+func DecryptValue(c Cipher, value string) (string, error) {
+	m := encryptedPattern.FindStringSubmatch(value)
+	if m == nil {
+		return value, nil
+	}
+	if c == nil {
+		return "", ErrNoConfigKey
+	}
+	if m[1] != c.Algorithm() {
+		return "", fmt.Errorf("密钥类型 %s 与加密算法 %s 不匹配", c.Algorithm(), m[1])
+	}
+	ct, err := base64.StdEncoding.DecodeString(m[2])
+	if err != nil {
+		return "", fmt.Errorf("密文格式错误: %w", err)
+	}
+	pt, err := c.Decrypt(ct)
+	if err != nil {
+		return "", err
+	}
+	return string(pt), nil
+}
+
+// AESCipher AES-256-GCM，密文为 nonce 与 GCM 输出的拼接
+type AESCipher struct {
+	aead cipher.AEAD
+}
+
+// NewAESCipher 创建 AES-256-GCM 加解密，key 必须为 32 字节
+func NewAESCipher(key []byte) (*AESCipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("AES-256 密钥长度必须为 32 字节，当前 %d 字节", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AESCipher{aead: aead}, nil
+}
+
+// Algorithm 实现 Cipher
+func (c *AESCipher) Algorithm() string {
+	return AlgorithmAESGCM
+}
+
+// Encrypt 实现 Cipher
+func (c *AESCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt 实现 Cipher
+func (c *AESCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, errors.New("密文长度不足")
+	}
+	pt, err := c.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+	if err != nil {
+		return nil, errors.New("解密失败，密钥不正确或密文已损坏")
+	}
+	return pt, nil
+}
+
+// AgeCipher age 加解密（X25519），每个值使用独立的文件密钥并由接收方公钥封装
+//
+// 只有公钥时只能加密，适合运维人员在不持有私钥的机器上生成加密值。
+type AgeCipher struct {
+	identities []age.Identity
+	recipients []age.Recipient
+}
+
+// NewAgeCipher 从 age 私钥创建加解密，私钥可以是多行（支持 age-keygen 生成的带注释的文件）
+func NewAgeCipher(identities string) (*AgeCipher, error) {
+	ids, err := age.ParseIdentities(strings.NewReader(identities))
+	if err != nil {
+		return nil, fmt.Errorf("解析 age 私钥失败: %w", err)
+	}
+	c := &AgeCipher{identities: ids}
+	for _, id := range ids {
+		if x, ok := id.(*age.X25519Identity); ok {
+			c.recipients = append(c.recipients, x.Recipient())
+		}
+	}
+	return c, nil
+}
+
+// NewAgeEncrypter 从 age 公钥（age1...）创建只能加密的 AgeCipher
+func NewAgeEncrypter(recipients ...string) (*AgeCipher, error) {
+	c := &AgeCipher{}
+	for _, r := range recipients {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(r))
+		if err != nil {
+			return nil, fmt.Errorf("解析 age 公钥失败: %w", err)
+		}
+		c.recipients = append(c.recipients, recipient)
+	}
+	if len(c.recipients) == 0 {
+		return nil, errors.New("至少需要一个 age 公钥")
+	}
+	return c, nil
+}
+
+// Algorithm 实现 Cipher
+func (c *AgeCipher) Algorithm() string {
+	return AlgorithmAge
+}
+
+// Encrypt 实现 Cipher
+func (c *AgeCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	if len(c.recipients) == 0 {
+		return nil, errors.New("没有可用的 age 公钥")
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, c.recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decrypt 实现 Cipher
+func (c *AgeCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(c.identities) == 0 {
+		return nil, errors.New("没有可用的 age 私钥")
+	}
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), c.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败: %w", err)
+	}
+	return io.ReadAll(r)
+}
+
+// ParseCipherKey 解析密钥：age 私钥（AGE-SECRET-KEY-1...）或 32 字节 AES 密钥的 base64/hex 编码
+func ParseCipherKey(key string) (Cipher, error) {
+	key = strings.TrimSpace(key)
+	if strings.Contains(key, "AGE-SECRET-KEY-") {
+		return NewAgeCipher(key)
+	}
+
+	if raw, err := base64.StdEncoding.DecodeString(key); err == nil && len(raw) == 32 {
+		return NewAESCipher(raw)
+	}
+	if raw, err := hex.DecodeString(key); err == nil && len(raw) == 32 {
+		return NewAESCipher(raw)
+	}
+	return nil, errors.New("无法识别的密钥，需为 age 私钥或 32 字节 AES 密钥的 base64/hex 编码")
+}
+
+// CipherFromEnv 从 GOUPTER_CONFIG_KEY 或 GOUPTER_CONFIG_KEY_FILE 读取密钥，均未设置时返回 nil
+func CipherFromEnv() (Cipher, error) {
+	if key := os.Getenv(EnvConfigKey); key != "" {
+		c, err := ParseCipherKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvConfigKey, err)
+		}
+		return c, nil
+	}
+	if path := os.Getenv(EnvConfigKeyFile); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+		}
+		c, err := ParseCipherKey(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return c, nil
+	}
+	return nil, nil
+}
+
+// decryptConfig 解密配置中所有 ENC[...] 值，c 为 nil 时按需从环境变量读取密钥
+//
+// 错误信息只包含配置键，不包含密文或明文。
+func decryptConfig(cfg *Config, c Cipher) error {
+	var once sync.Once
+	var keyErr error
+	return walkStrings(reflect.ValueOf(cfg), "", func(s, path string) (string, error) {
+		if !strings.HasPrefix(s, "ENC[") || !IsEncrypted(s) {
+			return s, nil
+		}
+		once.Do(func() {
+			if c == nil {
+				c, keyErr = CipherFromEnv()
+			}
+		})
+		if keyErr != nil {
+			return "", keyErr
+		}
+		plaintext, err := DecryptValue(c, s)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		return plaintext, nil
+	})
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func newTestAESKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestAESCipher(t *testing.T) {
+	c, err := NewAESCipher(newTestAESKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := EncryptValue(c, "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(encrypted) || !strings.HasPrefix(encrypted, "ENC[aes256gcm:") {
+		t.Fatalf("encrypted = %s", encrypted)
+	}
+	if again, _ := EncryptValue(c, "s3cr3t"); again == encrypted {
+		t.Error("相同明文每次加密结果应不同")
+	}
+	if plaintext, err := DecryptValue(c, encrypted); err != nil || plaintext != "s3cr3t" {
+		t.Errorf("DecryptValue() = %q, %v", plaintext, err)
+	}
+
+	// 篡改与错误的密钥
+	raw, _ := base64.StdEncoding.DecodeString(encrypted[len("ENC[aes256gcm:") : len(encrypted)-1])
+	raw[len(raw)-1] ^= 1
+	if _, err := DecryptValue(c, "ENC[aes256gcm:"+base64.StdEncoding.EncodeToString(raw)+"]"); err == nil {
+		t.Error("篡改的密文应解密失败")
+	}
+	other, _ := NewAESCipher(newTestAESKey(t))
+	if _, err := DecryptValue(other, encrypted); err == nil {
+		t.Error("错误的密钥应解密失败")
+	}
+
+	if _, err := NewAESCipher([]byte("short")); err == nil {
+		t.Error("密钥长度不正确应返回错误")
+	}
+	if v, err := DecryptValue(nil, "plain"); err != nil || v != "plain" {
+		t.Errorf("非加密值应原样返回: %q, %v", v, err)
+	}
+	if _, err := DecryptValue(nil, encrypted); !errors.Is(err, ErrNoConfigKey) {
+		t.Errorf("error = %v, want ErrNoConfigKey", err)
+	}
+}
+
+func TestAgeCipher(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypter, err := NewAgeEncrypter(identity.Recipient().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := EncryptValue(encrypter, "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encrypted, "ENC[age:") {
+		t.Fatalf("encrypted = %s", encrypted)
+	}
+	if _, err := DecryptValue(encrypter, encrypted); err == nil {
+		t.Error("只有公钥时不能解密")
+	}
+
+	// age-keygen 生成的文件带注释
+	c, err := ParseCipherKey("# created: 2024-01-01\n# public key: " + identity.Recipient().String() + "\n" + identity.String() + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := DecryptValue(c, encrypted); err != nil || plaintext != "s3cr3t" {
+		t.Errorf("DecryptValue() = %q, %v", plaintext, err)
+	}
+
+	aes, _ := NewAESCipher(newTestAESKey(t))
+	if _, err := DecryptValue(aes, encrypted); err == nil {
+		t.Error("密钥类型不匹配应返回错误")
+	}
+}
+
+func TestParseCipherKey(t *testing.T) {
+	key := newTestAESKey(t)
+	for _, s := range []string{base64.StdEncoding.EncodeToString(key), hex.EncodeToString(key) + "\n"} {
+		c, err := ParseCipherKey(s)
+		if err != nil || c.Algorithm() != AlgorithmAESGCM {
+			t.Errorf("ParseCipherKey(%q) = %v, %v", s, c, err)
+		}
+	}
+	if _, err := ParseCipherKey("not-a-key"); err == nil {
+		t.Error("无效密钥应返回错误")
+	}
+}
+
+func TestLoad_EncryptedValues(t *testing.T) {
+	key := newTestAESKey(t)
+	c, _ := NewAESCipher(key)
+	password, _ := EncryptValue(c, "db-pass")
+	token, _ := EncryptValue(c, "consul-token")
+
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": "database:\n  password: " + password + "\nconsul:\n  token: " + token + "\nauth:\n  config:\n    secret: " + password + "\n",
+	})
+
+	cfg, err := Load(WithConfigFile("config"), WithConfigPaths(dir), WithCipher(c))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.Password != "db-pass" || cfg.Consul.Token != "consul-token" || cfg.Auth.Config["secret"] != "db-pass" {
+		t.Errorf("password = %q, token = %q, secret = %v", cfg.Database.Password, cfg.Consul.Token, cfg.Auth.Config["secret"])
+	}
+
+	// 未提供密钥
+	t.Setenv(EnvConfigKey, "")
+	t.Setenv(EnvConfigKeyFile, "")
+	if _, err := Load(WithConfigFile("config"), WithConfigPaths(dir)); !errors.Is(err, ErrNoConfigKey) {
+		t.Errorf("Load() error = %v, want ErrNoConfigKey", err)
+	}
+
+	// 从密钥文件读取
+	keyFile := filepath.Join(t.TempDir(), "config.key")
+	if err := os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvConfigKeyFile, keyFile)
+	cfg, err = Load(WithConfigFile("config"), WithConfigPaths(dir))
+	if err != nil || cfg.Database.Password != "db-pass" {
+		t.Fatalf("Load() = %v, %v", cfg, err)
+	}
+
+	// 生效值来源中不显示明文
+	values, err := Explain(WithConfigFile("config"), WithConfigPaths(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := sourcesByKey(values)["auth.config.secret"]; v.Value != redactedValue {
+		t.Errorf("auth.config.secret = %v", v.Value)
+	}
+}
+
+func TestConfigCenter_ParseConfigEncrypted(t *testing.T) {
+	key := newTestAESKey(t)
+	c, _ := NewAESCipher(key)
+	password, _ := EncryptValue(c, "from-consul")
+	t.Setenv(EnvConfigKey, hex.EncodeToString(key))
+
+	center := &ConfigCenter{format: "yaml"}
+	cfg := &Config{}
+	if _, err := center.parseConfig([]byte("redis:\n  password: "+password+"\n"), cfg); err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	if cfg.Redis.Password != "from-consul" {
+		t.Errorf("Redis.Password = %q", cfg.Redis.Password)
+	}
+
+	t.Setenv(EnvConfigKey, hex.EncodeToString(newTestAESKey(t)))
+	_, err := center.parseConfig([]byte("redis:\n  password: "+password+"\n"), &Config{})
+	if err == nil || strings.Contains(err.Error(), password) || !strings.Contains(err.Error(), "redis.password") {
+		t.Errorf("error = %v", err)
+	}
+}
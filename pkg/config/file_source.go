@@ -0,0 +1,275 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/goupter/goupter/pkg/log"
+)
+
+// defaultFileDebounce 文件变更后的合并延迟，编辑器保存或 ConfigMap 切换会产生多次事件
+const defaultFileDebounce = 200 * time.Millisecond
+
+// FileSource 本地文件配置源
+//
+// 监听文件所在目录而非文件本身，因此文件被替换（编辑器原子保存、Kubernetes ConfigMap 的 ..data
+// 符号链接切换）后仍能收到事件。文件是指向其他目录的符号链接时同时监听目标所在目录。
+// 事件在合并延迟后统一处理，内容未变化时不回调。
+type FileSource struct {
+	path       string
+	debounce   time.Duration
+	logger     log.Logger
+	onChangeFn func([]byte)
+	watchStop  chan struct{}
+	stopOnce   sync.Once
+	mu         sync.Mutex
+	last       []byte
+}
+
+// FileSourceOption 文件配置源选项
+type FileSourceOption func(*FileSource)
+
+// WithFileDebounce 设置合并延迟，默认 200ms
+func WithFileDebounce(d time.Duration) FileSourceOption {
+	return func(s *FileSource) {
+		s.debounce = d
+	}
+}
+
+// WithFileWatchCallback 设置配置变更回调
+func WithFileWatchCallback(fn func([]byte)) FileSourceOption {
+	return func(s *FileSource) {
+		s.onChangeFn = fn
+	}
+}
+
+// WithFileLogger 设置日志
+func WithFileLogger(logger log.Logger) FileSourceOption {
+	return func(s *FileSource) {
+		s.logger = logger
+	}
+}
+
+// NewFileSource 创建文件配置源
+func NewFileSource(path string, opts ...FileSourceOption) *FileSource {
+	s := &FileSource{
+		path:      path,
+		debounce:  defaultFileDebounce,
+		watchStop: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Name 配置源名称
+func (s *FileSource) Name() string {
+	return sourceFile + s.path
+}
+
+// Path 获取文件路径
+func (s *FileSource) Path() string {
+	return s.path
+}
+
+// Load 读取文件内容
+func (s *FileSource) Load() ([]byte, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	s.mu.Lock()
+	s.last = data
+	s.mu.Unlock()
+
+	return data, nil
+}
+
+// SetWatchCallback 设置配置变更回调
+func (s *FileSource) SetWatchCallback(fn func([]byte)) {
+	s.onChangeFn = fn
+}
+
+// Watch 监听文件变更，阻塞直到 ctx 结束或 StopWatch
+func (s *FileSource) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监听失败: %w", err)
+	}
+	defer watcher.Close()
+
+	dir := filepath.Dir(s.path)
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("监听目录 %s 失败: %w", dir, err)
+	}
+	target := s.watchTarget(watcher, dir, "")
+
+	s.mu.Lock()
+	if s.last == nil {
+		s.last, _ = os.ReadFile(s.path)
+	}
+	s.mu.Unlock()
+
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.watchStop:
+			return nil
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(s.debounce, s.check)
+			// 符号链接切换后目标目录可能变化
+			target = s.watchTarget(watcher, dir, target)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			if s.logger != nil {
+				s.logger.Warn("watch config file failed", log.String("file", s.path), log.Error(err))
+			}
+		}
+	}
+}
+
+// watchTarget 监听符号链接目标所在目录，返回当前监听的目标目录
+func (s *FileSource) watchTarget(watcher *fsnotify.Watcher, dir, current string) string {
+	real, err := filepath.EvalSymlinks(s.path)
+	if err != nil {
+		return current
+	}
+	target := filepath.Dir(real)
+	if target == current {
+		return current
+	}
+	if current != "" && current != dir {
+		_ = watcher.Remove(current)
+	}
+	if target != dir {
+		if err := watcher.Add(target); err != nil {
+			return ""
+		}
+	}
+	return target
+}
+
+// check 读取文件，内容变化时回调
+func (s *FileSource) check() {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		// 替换过程中文件可能短暂不存在，等待下一次事件
+		if s.logger != nil {
+			s.logger.Warn("read config file failed", log.String("file", s.path), log.Error(err))
+		}
+		return
+	}
+
+	s.mu.Lock()
+	if bytes.Equal(data, s.last) {
+		s.mu.Unlock()
+		return
+	}
+	s.last = data
+	fn := s.onChangeFn
+	s.mu.Unlock()
+
+	if fn != nil {
+		fn(data)
+	}
+}
+
+// StopWatch 停止监听
+func (s *FileSource) StopWatch() {
+	s.stopOnce.Do(func() {
+		close(s.watchStop)
+	})
+}
+
+// localFilesSource Load 读取的多个配置文件，任一文件变化时回调
+type localFilesSource struct {
+	sources []*FileSource
+}
+
+// Name 配置源名称
+func (s *localFilesSource) Name() string {
+	paths := make([]string, len(s.sources))
+	for i, src := range s.sources {
+		paths[i] = src.Path()
+	}
+	return sourceFile + strings.Join(paths, ",")
+}
+
+// Load 读取全部文件内容
+func (s *localFilesSource) Load() ([]byte, error) {
+	var buf bytes.Buffer
+	for _, src := range s.sources {
+		data, err := src.Load()
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// SetWatchCallback 设置配置变更回调
+func (s *localFilesSource) SetWatchCallback(fn func([]byte)) {
+	for _, src := range s.sources {
+		src.SetWatchCallback(fn)
+	}
+}
+
+// Watch 监听全部文件，阻塞直到全部监听结束
+func (s *localFilesSource) Watch(ctx context.Context) error {
+	errs := make(chan error, len(s.sources))
+	for _, src := range s.sources {
+		go func(src *FileSource) {
+			err := src.Watch(ctx)
+			if err != nil && ctx.Err() == nil && src.logger != nil {
+				src.logger.Warn("watch config file failed", log.String("file", src.Path()), log.Error(err))
+			}
+			errs <- err
+		}(src)
+	}
+
+	var first error
+	for range s.sources {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// StopWatch 停止监听
+func (s *localFilesSource) StopWatch() {
+	for _, src := range s.sources {
+		src.StopWatch()
+	}
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recvData 等待回调数据
+func recvData(t *testing.T, ch <-chan []byte) string {
+	t.Helper()
+	select {
+	case data := <-ch:
+		return string(data)
+	case <-time.After(3 * time.Second):
+		t.Fatal("等待配置变更超时")
+		return ""
+	}
+}
+
+// startFileSource 启动文件监听，测试结束时停止
+func startFileSource(t *testing.T, path string) <-chan []byte {
+	t.Helper()
+	ch := make(chan []byte, 10)
+	s := NewFileSource(path, WithFileDebounce(50*time.Millisecond), WithFileWatchCallback(func(data []byte) {
+		ch <- data
+	}))
+	if _, err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = s.Watch(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	// 等待监听建立
+	time.Sleep(50 * time.Millisecond)
+	return ch
+}
+
+func TestFileSource_WatchDebounce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("v: 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ch := startFileSource(t, path)
+
+	for i := 1; i <= 3; i++ {
+		if err := os.WriteFile(path, []byte("v: "+string(rune('0'+i))+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := recvData(t, ch); got != "v: 3\n" {
+		t.Errorf("data = %q, want v: 3", got)
+	}
+
+	// 连续写入合并为一次回调
+	select {
+	case data := <-ch:
+		t.Errorf("不应有多余回调: %q", data)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// 内容未变化时不回调
+	if err := os.WriteFile(path, []byte("v: 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-ch:
+		t.Errorf("内容未变化不应回调: %q", data)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestFileSource_ConfigMapSymlinkSwap(t *testing.T) {
+	dir := t.TempDir()
+	writeVersion := func(version, content string) {
+		t.Helper()
+		if err := os.Mkdir(filepath.Join(dir, version), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, version, "config.yaml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 模拟 kubelet 的目录结构：config.yaml -> ..data/config.yaml，..data -> ..v1
+	writeVersion("..v1", "v: 1\n")
+	if err := os.Symlink("..v1", filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.Symlink(filepath.Join("..data", "config.yaml"), path); err != nil {
+		t.Fatal(err)
+	}
+	ch := startFileSource(t, path)
+
+	// 原子切换：新建 ..v2，..data_tmp -> ..v2，重命名为 ..data，删除 ..v1
+	writeVersion("..v2", "v: 2\n")
+	if err := os.Symlink("..v2", filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "..v1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := recvData(t, ch); got != "v: 2\n" {
+		t.Errorf("data = %q, want v: 2", got)
+	}
+}
+
+func TestConfigCenter_FileSource(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": "app:\n  name: base\n",
+		"remote.yaml": "database:\n  host: db.v1\n",
+	})
+	if _, err := Load(WithConfigFile("config"), WithConfigPaths(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "remote.yaml")
+	center := NewConfigCenter(NewFileSource(path, WithFileDebounce(50*time.Millisecond)))
+	if _, err := center.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg := Get(); cfg.App.Name != "base" || cfg.Database.Host != "db.v1" {
+		t.Fatalf("app.name = %s, database.host = %s", cfg.App.Name, cfg.Database.Host)
+	}
+
+	var mu sync.Mutex
+	changes := make(chan *ConfigChange, 10)
+	center.OnChangeDiff(func(change *ConfigChange) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes <- change
+	})
+	if err := center.Watch(); err != nil {
+		t.Fatal(err)
+	}
+	defer center.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	// 校验失败的内容被拒绝
+	if err := os.WriteFile(path, []byte("server:\n  http:\n    port: 70000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for center.ReloadStatus().Rejected == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if status := center.ReloadStatus(); status.Rejected != 1 || status.LastError == nil {
+		t.Fatalf("status = %+v", status)
+	}
+
+	if err := os.WriteFile(path, []byte("database:\n  host: db.v2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case change := <-changes:
+		if !change.Changed("database.host") || change.New.Database.Host != "db.v2" || change.New.App.Name != "base" {
+			t.Errorf("change = %v", change.Keys())
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("等待配置变更超时")
+	}
+	if status := center.ReloadStatus(); status.LastError != nil {
+		t.Errorf("成功应用后应清除错误: %v", status.LastError)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": "app:\n  name: v1\n",
+	})
+	if _, err := Load(WithConfigFile("config"), WithConfigPaths(dir)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		reloadMu.Lock()
+		reloadCallbacks = nil
+		reloadMu.Unlock()
+	})
+
+	changes := make(chan *ConfigChange, 10)
+	OnReload(func(change *ConfigChange) { changes <- change })
+	if err := WatchFile(); err != nil {
+		t.Fatal(err)
+	}
+	defer StopWatchFile()
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("app:\n  name: v2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case change := <-changes:
+		if change.New.App.Name != "v2" || Get().App.Name != "v2" {
+			t.Errorf("app.name = %s", change.New.App.Name)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("等待配置变更超时")
+	}
+}
+
+func TestFileConfigCenter(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml":            "app:\n  name: base\n  env: production\ndatabase:\n  host: db.v1\n",
+		"config.production.yaml": "app:\n  name: prod\n",
+		"config.local.yaml":      "log:\n  level: debug\n",
+	})
+	if _, err := Load(WithConfigFile("config"), WithConfigPaths(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	center, err := NewFileConfigCenter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := make(chan *ConfigChange, 10)
+	center.OnChangeDiff(func(change *ConfigChange) { changes <- change })
+	if err := center.Watch(); err != nil {
+		t.Fatal(err)
+	}
+	defer center.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	// 校验失败的修改被拒绝并计入状态
+	base := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(base, []byte("app:\n  env: production\nserver:\n  http:\n    port: 70000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for center.ReloadStatus().Rejected == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if status := center.ReloadStatus(); status.Rejected != 1 || status.LastError == nil {
+		t.Fatalf("status = %+v", status)
+	}
+	if Get().Database.Host != "db.v1" {
+		t.Errorf("拒绝后应保留原配置, database.host = %s", Get().Database.Host)
+	}
+
+	// 重新合并各层，环境文件仍覆盖基础文件
+	if err := os.WriteFile(base, []byte("app:\n  name: base\n  env: production\ndatabase:\n  host: db.v2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case change := <-changes:
+		if !change.Changed("database.host") || change.New.App.Name != "prod" || change.New.Log.Level != "debug" {
+			t.Errorf("change = %v, app.name = %s", change.Keys(), change.New.App.Name)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("等待配置变更超时, status = %+v", center.ReloadStatus())
+	}
+	if status := center.ReloadStatus(); status.Applied != 1 || status.LastError != nil {
+		t.Errorf("status = %+v", status)
+	}
+	if Get().Database.Host != "db.v2" {
+		t.Errorf("database.host = %s", Get().Database.Host)
+	}
+}
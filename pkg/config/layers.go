@@ -0,0 +1,405 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// 配置来源，按优先级从低到高：
+//
+//	default                      内置默认值
+//	file:<path>                  config.yaml
+//	file:<path>                  config.<env>.yaml（env 取 WithProfile、<前缀>_APP_ENV 或已合并的 app.env）
+//	file:<path>                  config.local.yaml
+//	consul:<path>                Consul KV（WithConsulSource/WithRemoteConsul 或配置中心下发）
+//	env:<NAME>                   环境变量，如 FLIT_SERVER_HTTP_PORT
+//	flag:--<name>                命令行参数（WithFlags），参数名即配置键，如 --server.http.port
+const (
+	SourceDefault = "default"
+	sourceFile    = "file:"
+	sourceConsul  = "consul:"
+	sourceEnv     = "env:"
+	sourceFlag    = "flag:--"
+)
+
+// redactedValue 脱敏后的显示值
+const redactedValue = "******"
+
+// secretKeyNames 视为敏感信息的配置键（最后一段）
+var secretKeyNames = []string{"password", "token", "secret", "secret_key", "private_key", "api_key", "access_key", "credentials"}
+
+// ValueSource 配置键的生效值与来源
+type ValueSource struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// remoteLayer 配置中心下发的配置层
+type remoteLayer struct {
+	// name 来源名称，如 consul:config/app
+	name   string
+	data   []byte
+	format string
+}
+
+// lastRemote 配置中心最近一次生效的内容，文件重新加载时沿用
+var lastRemote *remoteLayer
+
+// layeredConfig 逐层合并的配置
+type layeredConfig struct {
+	settings map[string]any
+	sources  map[string]string
+	// refs 原始值包含密钥引用或为加密值的键
+	refs  map[string]bool
+	files []string
+	// remote 远端配置层的来源名称
+	remote string
+}
+
+func newLayeredConfig() *layeredConfig {
+	return &layeredConfig{
+		settings: make(map[string]any),
+		sources:  make(map[string]string),
+		refs:     make(map[string]bool),
+	}
+}
+
+// merge 合并一层配置，settings 为嵌套 map
+func (l *layeredConfig) merge(settings map[string]any, source string) {
+	leaves := make(map[string]any)
+	flatten(reflect.ValueOf(settings), "", leaves)
+	for key, value := range leaves {
+		l.set(key, value, source)
+	}
+}
+
+// set 设置单个配置键
+func (l *layeredConfig) set(key string, value any, source string) {
+	key = strings.ToLower(key)
+	parts := strings.Split(key, ".")
+	m := l.settings
+	for i, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]any)
+		if !ok {
+			// 原来的叶子值被子键覆盖
+			delete(l.sources, strings.Join(parts[:i+1], "."))
+			next = make(map[string]any)
+			m[part] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = value
+
+	for k := range l.sources {
+		if strings.HasPrefix(k, key+".") {
+			delete(l.sources, k)
+			delete(l.refs, k)
+		}
+	}
+	l.sources[key] = source
+	if s, ok := value.(string); ok && (secretRefPattern.MatchString(s) || IsEncrypted(s)) {
+		l.refs[key] = true
+	} else {
+		delete(l.refs, key)
+	}
+}
+
+// get 获取已合并的配置值
+func (l *layeredConfig) get(key string) any {
+	var current any = l.settings
+	for _, part := range strings.Split(key, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+	return current
+}
+
+// decode 解析为 Config，不解析密钥引用
+func (l *layeredConfig) decode() (*Config, error) {
+	vp := viper.New()
+	if err := vp.MergeConfigMap(l.settings); err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := vp.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	return cfg, nil
+}
+
+// readSettings 读取 YAML/JSON 内容为嵌套 map
+func readSettings(data []byte, format string) (map[string]any, error) {
+	if format == "" {
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			format = "json"
+		} else {
+			format = "yaml"
+		}
+	}
+	if format == "yml" {
+		format = "yaml"
+	}
+
+	vp := viper.New()
+	vp.SetConfigType(format)
+	if err := vp.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return vp.AllSettings(), nil
+}
+
+// findFile 在搜索路径中查找 <name>.<type>
+func (o *options) findFile(name string) string {
+	for _, dir := range o.configPaths {
+		path := filepath.Join(dir, name+"."+o.configType)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// mergeFile 合并配置文件，文件不存在时跳过
+func (o *options) mergeFile(l *layeredConfig, name string) error {
+	path := o.findFile(name)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	settings, err := readSettings(data, o.configType)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %s: %w", path, err)
+	}
+	l.merge(settings, sourceFile+path)
+	l.files = append(l.files, path)
+	return nil
+}
+
+// profile 获取环境名
+func (o *options) profile(l *layeredConfig) string {
+	if o.profileName != "" {
+		return o.profileName
+	}
+	if env := os.Getenv(o.envName("app.env")); env != "" {
+		return env
+	}
+	env, _ := l.get("app.env").(string)
+	return env
+}
+
+// envName 配置键对应的环境变量名
+func (o *options) envName(key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if o.envPrefix == "" {
+		return name
+	}
+	return strings.ToUpper(o.envPrefix) + "_" + name
+}
+
+// load 按优先级逐层合并配置
+//
+// remote 为配置中心下发的内容，为 nil 时使用最近一次下发的内容或 WithConsulSource 指定的配置源。
+func (o *options) load(remote *remoteLayer) (*Config, *layeredConfig, error) {
+	l := newLayeredConfig()
+
+	dv := viper.New()
+	setDefaults(dv, o.defaultValue)
+	l.merge(dv.AllSettings(), SourceDefault)
+
+	if err := o.mergeFile(l, o.configFile); err != nil {
+		return nil, nil, err
+	}
+	if profile := o.profile(l); profile != "" {
+		if err := o.mergeFile(l, o.configFile+"."+profile); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := o.mergeFile(l, o.configFile+".local"); err != nil {
+		return nil, nil, err
+	}
+
+	if remote == nil {
+		configMu.RLock()
+		remote = lastRemote
+		configMu.RUnlock()
+	}
+	if remote == nil {
+		var err error
+		if remote, err = o.loadRemote(l); err != nil {
+			return nil, nil, err
+		}
+	}
+	if remote != nil {
+		settings, err := readSettings(remote.data, remote.format)
+		if err != nil {
+			return nil, nil, fmt.Errorf("解析远端配置失败: %w", err)
+		}
+		l.merge(settings, remote.name)
+		l.remote = remote.name
+	}
+
+	o.mergeEnv(l)
+	o.mergeFlags(l)
+
+	cfg, err := l.decode()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := decryptConfig(cfg, o.cipher); err != nil {
+		return nil, nil, fmt.Errorf("解密配置失败: %w", err)
+	}
+	if err := resolveSecrets(cfg, o.allowSecret(l)); err != nil {
+		return nil, nil, fmt.Errorf("解析密钥引用失败: %w", err)
+	}
+	return cfg, l, nil
+}
+
+// allowSecret 远端配置层的值只能使用 WithRemoteSecretSchemes 允许的本机密钥引用
+//
+// l 为 nil 时全部配置键视为来自远端。
+func (o *options) allowSecret(l *layeredConfig) func(path, scheme string) bool {
+	return func(path, scheme string) bool {
+		if !containsString(localSecretSchemes, scheme) || containsString(o.remoteSecretSchemes, scheme) {
+			return true
+		}
+		return l != nil && !l.fromRemote(path)
+	}
+}
+
+// fromRemote 配置键的生效值是否来自远端配置层，path 可以是切片元素或 map 中的键
+func (l *layeredConfig) fromRemote(path string) bool {
+	if l.remote == "" {
+		return false
+	}
+	key := strings.ToLower(path)
+	if i := strings.IndexByte(key, '['); i >= 0 {
+		key = key[:i]
+	}
+	for key != "" {
+		if source, ok := l.sources[key]; ok {
+			return source == l.remote
+		}
+		i := strings.LastIndexByte(key, '.')
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return false
+}
+
+// loadRemote 从 Consul 读取远端配置层
+func (o *options) loadRemote(l *layeredConfig) (*remoteLayer, error) {
+	source := o.consulSource
+	if source == nil && o.remoteConsul {
+		cfg, err := l.decode()
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Consul.ConfigPath == "" {
+			return nil, nil
+		}
+		if source, err = NewConsulSourceWithConfig(&cfg.Consul); err != nil {
+			return nil, err
+		}
+	}
+	if source == nil {
+		return nil, nil
+	}
+
+	data, err := source.Load()
+	if err != nil {
+		return nil, fmt.Errorf("读取远端配置失败: %w", err)
+	}
+	return &remoteLayer{name: source.Name(), data: data}, nil
+}
+
+// mergeEnv 合并环境变量，覆盖 Config 中声明的键与各层出现过的键
+func (o *options) mergeEnv(l *layeredConfig) {
+	keys := make(map[string]any)
+	flatten(reflect.ValueOf(&Config{}).Elem(), "", keys)
+	for key := range l.sources {
+		keys[key] = nil
+	}
+
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	for _, key := range names {
+		name := o.envName(key)
+		if value, ok := os.LookupEnv(name); ok {
+			l.set(key, value, sourceEnv+name)
+		}
+	}
+}
+
+// mergeFlags 合并命令行参数中显式设置的配置键
+func (o *options) mergeFlags(l *layeredConfig) {
+	if o.flags == nil {
+		return
+	}
+	o.flags.Visit(func(f *pflag.Flag) {
+		var value any = f.Value.String()
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			value = sv.GetSlice()
+		}
+		l.set(f.Name, value, sourceFlag+f.Name)
+	})
+}
+
+// explain 生成每个配置键的生效值与来源，敏感值脱敏
+func (l *layeredConfig) explain(cfg *Config) []ValueSource {
+	values := make(map[string]any)
+	flatten(reflect.ValueOf(cfg).Elem(), "", values)
+
+	result := make([]ValueSource, 0, len(values))
+	for key, value := range values {
+		source := l.sources[key]
+		if source == "" {
+			source = SourceDefault
+		}
+		if l.refs[key] || isSecretKey(key) {
+			if s, ok := value.(string); !ok || s != "" {
+				value = redactedValue
+			}
+		}
+		result = append(result, ValueSource{Key: key, Value: value, Source: source})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// isSecretKey 是否为敏感配置键
+func isSecretKey(key string) bool {
+	name := key[strings.LastIndex(key, ".")+1:]
+	return containsString(secretKeyNames, name)
+}
+
+// Explain 按 Load 的规则加载配置，返回每个配置键的生效值与来源，敏感值已脱敏
+//
+// 不修改全局配置。
+func Explain(opts ...Option) ([]ValueSource, error) {
+	cfg, l, err := newOptions(opts...).load(nil)
+	if err != nil {
+		return nil, err
+	}
+	return l.explain(cfg), nil
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// writeConfigFiles 在临时目录中写入配置文件
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func sourcesByKey(values []ValueSource) map[string]ValueSource {
+	m := make(map[string]ValueSource, len(values))
+	for _, v := range values {
+		m[v.Key] = v
+	}
+	return m
+}
+
+func TestLoad_Layers(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+app:
+  name: base
+  env: production
+server:
+  http:
+    port: 8000
+    read_timeout: 10s
+database:
+  host: db.base
+  port: 3306
+  password: ${env:TEST_LAYER_DB_PASSWORD}
+redis:
+  host: redis.base
+`,
+		"config.production.yaml": `
+server:
+  http:
+    port: 8100
+database:
+  host: db.prod
+`,
+		"config.local.yaml": `
+database:
+  host: db.local
+`,
+	})
+	t.Setenv("TEST_LAYER_DB_PASSWORD", "p@ss")
+	t.Setenv("FLIT_REDIS_HOST", "redis.env")
+	t.Setenv("FLIT_DATABASE_PORT", "3307")
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Int("server.http.port", 0, "")
+	flags.Int("database.port", 0, "")
+	if err := flags.Parse([]string{"--database.port=3308"}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := []Option{WithConfigFile("config"), WithConfigPaths(dir), WithFlags(flags)}
+	cfg, err := Load(opts...)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.App.Name != "base" || cfg.Server.HTTP.ReadTimeout.String() != "10s" {
+		t.Errorf("基础配置未生效: %+v", cfg.App)
+	}
+	if cfg.Server.HTTP.Port != 8100 {
+		t.Errorf("Port = %d, want 8100（环境配置覆盖，未设置的参数不生效）", cfg.Server.HTTP.Port)
+	}
+	if cfg.Database.Host != "db.local" {
+		t.Errorf("Database.Host = %s, want db.local", cfg.Database.Host)
+	}
+	if cfg.Redis.Host != "redis.env" {
+		t.Errorf("Redis.Host = %s, want redis.env", cfg.Redis.Host)
+	}
+	if cfg.Database.Port != 3308 {
+		t.Errorf("Database.Port = %d, want 3308（命令行参数优先于环境变量）", cfg.Database.Port)
+	}
+	if cfg.Database.Password != "p@ss" {
+		t.Errorf("Database.Password = %s", cfg.Database.Password)
+	}
+
+	values, err := Explain(opts...)
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	got := sourcesByKey(values)
+	want := map[string]string{
+		"app.name":          "file:" + filepath.Join(dir, "config.yaml"),
+		"server.http.port":  "file:" + filepath.Join(dir, "config.production.yaml"),
+		"database.host":     "file:" + filepath.Join(dir, "config.local.yaml"),
+		"redis.host":        "env:FLIT_REDIS_HOST",
+		"database.port":     "flag:--database.port",
+		"log.level":         SourceDefault,
+		"database.database": SourceDefault,
+	}
+	for key, source := range want {
+		if got[key].Source != source {
+			t.Errorf("%s 来源 = %s, want %s", key, got[key].Source, source)
+		}
+	}
+	if got["database.password"].Value != redactedValue {
+		t.Errorf("database.password 应脱敏, got %v", got["database.password"].Value)
+	}
+	if got["database.host"].Value != "db.local" {
+		t.Errorf("database.host = %v", got["database.host"].Value)
+	}
+}
+
+func TestLoad_ProfileOverride(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml":         "app:\n  env: production\n",
+		"config.staging.yaml": "app:\n  name: staging-app\n",
+	})
+
+	cfg, err := Load(WithConfigFile("config"), WithConfigPaths(dir), WithProfile("staging"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.App.Name != "staging-app" {
+		t.Errorf("App.Name = %s, want staging-app", cfg.App.Name)
+	}
+
+	t.Setenv("FLIT_APP_ENV", "staging")
+	cfg, err = Load(WithConfigFile("config"), WithConfigPaths(dir))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.App.Name != "staging-app" {
+		t.Errorf("App.Name = %s, want staging-app（FLIT_APP_ENV 选择环境）", cfg.App.Name)
+	}
+}
+
+func TestLoad_ConsulLayer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/config/app" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Consul-Index", "1")
+		_ = json.NewEncoder(w).Encode([]map[string]any{{
+			"Key":   "config/app",
+			"Value": []byte("database:\n  host: db.consul\nredis:\n  host: redis.consul\n"),
+		}})
+	}))
+	defer srv.Close()
+
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml":       "database:\n  host: db.base\n",
+		"config.local.yaml": "redis:\n  host: redis.local\n",
+	})
+	t.Setenv("FLIT_REDIS_HOST", "redis.env")
+
+	source, err := NewConsulSource(strings.TrimPrefix(srv.URL, "http://"), "config/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := []Option{WithConfigFile("config"), WithConfigPaths(dir), WithConsulSource(source)}
+	cfg, err := Load(opts...)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Consul 覆盖本地文件，环境变量覆盖 Consul
+	if cfg.Database.Host != "db.consul" || cfg.Redis.Host != "redis.env" {
+		t.Errorf("database.host = %s, redis.host = %s", cfg.Database.Host, cfg.Redis.Host)
+	}
+
+	values, err := Explain(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sourcesByKey(values)["database.host"].Source; got != "consul:config/app" {
+		t.Errorf("database.host 来源 = %s", got)
+	}
+}
+
+func TestConsulConfigCenter_MergesLocalLayers(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": "app:\n  name: local-app\ndatabase:\n  host: db.base\n",
+	})
+	t.Setenv("FLIT_REDIS_HOST", "redis.env")
+	if _, err := Load(WithConfigFile("config"), WithConfigPaths(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	source, _ := NewConsulSource("127.0.0.1:1", "config/app")
+	center := NewConsulConfigCenter(source)
+	if _, err := center.apply([]byte("database:\n  host: db.consul\nredis:\n  host: redis.consul\n")); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+
+	cfg := Get()
+	if cfg.App.Name != "local-app" || cfg.Database.Host != "db.consul" || cfg.Redis.Host != "redis.env" {
+		t.Errorf("app.name = %s, database.host = %s, redis.host = %s", cfg.App.Name, cfg.Database.Host, cfg.Redis.Host)
+	}
+
+	// 重新加载本地文件时沿用 Consul 下发的内容
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("app:\n  name: renamed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if cfg := Get(); cfg.App.Name != "renamed" || cfg.Database.Host != "db.consul" {
+		t.Errorf("app.name = %s, database.host = %s", cfg.App.Name, cfg.Database.Host)
+	}
+}
@@ -0,0 +1,359 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// schemaDraft 生成的 JSON Schema 版本，编辑器（如 VS Code YAML 插件）支持最完整
+const schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// durationPattern time.Duration 的字符串形式，如 30s、1h30m
+const durationPattern = `^-?([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$|^-?0$`
+
+// nonNegativeDurationPattern 非负的 time.Duration
+const nonNegativeDurationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$|^0$`
+
+// 常用的约束
+var (
+	portRule        = map[string]any{"minimum": 0, "maximum": 65535}
+	nonNegativeRule = map[string]any{"minimum": 0}
+	notEmptyRule    = map[string]any{"minLength": 1}
+	durationRule    = map[string]any{"pattern": nonNegativeDurationPattern}
+)
+
+// enumRule 枚举约束，allowEmpty 为 true 时允许空字符串（校验器对空值不做检查）
+func enumRule(allowEmpty bool, values ...string) map[string]any {
+	if allowEmpty {
+		values = append(values, "")
+	}
+	return map[string]any{"enum": values}
+}
+
+// schemaRules 与 ConfigValidator 中逐字段规则对应的 JSON Schema 约束，修改校验规则时同步更新
+//
+// 跨字段的规则（如 max_idle_conns 不大于 max_open_conns）无法用 JSON Schema 表达，只由校验器检查。
+var schemaRules = map[string]map[string]any{
+	"app.name":    notEmptyRule,
+	"app.version": notEmptyRule,
+	"app.env":     enumRule(true, "development", "staging", "production", "test"),
+
+	"server.http.port":          portRule,
+	"server.http.read_timeout":  nonNegativeRule,
+	"server.http.write_timeout": nonNegativeRule,
+	"server.grpc.port":          portRule,
+
+	"database.driver":               enumRule(true, "mysql", "postgres", "sqlite", "sqlserver"),
+	"database.port":                 portRule,
+	"database.max_idle_conns":       nonNegativeRule,
+	"database.max_open_conns":       nonNegativeRule,
+	"database.conn_max_lifetime":    nonNegativeRule,
+	"database.log_level":            enumRule(true, "silent", "error", "warn", "info"),
+	"database.slow_query_threshold": nonNegativeRule,
+
+	"redis.port":         portRule,
+	"redis.db":           {"minimum": 0, "maximum": 15},
+	"redis.pool_size":    nonNegativeRule,
+	"redis.mode":         enumRule(true, "standalone", "cluster", "sentinel"),
+	"redis.dial_timeout": nonNegativeRule,
+
+	"consul.port":                   portRule,
+	"consul.service.check_interval": durationRule,
+	"consul.service.check_timeout":  durationRule,
+	"consul.service.check_mode":     enumRule(true, "pull", "ttl"),
+	"consul.service.ttl":            durationRule,
+
+	"discovery.type": enumRule(true, "consul", "etcd", "dns", "static"),
+
+	"nats.url":             {"pattern": `^$|^(nats|tls)://`},
+	"nats.max_reconnects":  {"minimum": -1},
+	"nats.reconnect_wait":  nonNegativeRule,
+	"nats.connect_timeout": nonNegativeRule,
+
+	"log.level":       enumRule(true, "debug", "info", "warn", "error", "fatal"),
+	"log.format":      enumRule(true, "json", "console"),
+	"log.output":      enumRule(true, "stdout", "file", "both"),
+	"log.max_size":    nonNegativeRule,
+	"log.max_backups": nonNegativeRule,
+	"log.max_age":     nonNegativeRule,
+
+	"trace.sample_rate": {"minimum": 0, "maximum": 1},
+}
+
+// schemaConditions 与校验器中条件规则对应的 if/then 约束，按所在对象的配置键
+var schemaConditions = map[string][]map[string]any{
+	"redis": {
+		whenEquals("mode", "cluster", map[string]any{
+			"required":   []string{"addrs"},
+			"properties": map[string]any{"addrs": map[string]any{"minItems": 1}},
+		}),
+		whenEquals("mode", "sentinel", map[string]any{
+			"required": []string{"master_name", "sentinel_addrs"},
+			"properties": map[string]any{
+				"master_name":    notEmptyRule,
+				"sentinel_addrs": map[string]any{"minItems": 1},
+			},
+		}),
+	},
+	"discovery": {
+		whenEquals("type", "etcd", map[string]any{
+			"properties": map[string]any{
+				"etcd": map[string]any{"properties": map[string]any{"endpoints": map[string]any{"minItems": 1}}},
+			},
+		}),
+		whenEquals("type", "dns", map[string]any{
+			"properties": map[string]any{
+				"dns": map[string]any{
+					"properties": map[string]any{"mode": enumRule(true, "srv", "a")},
+					"allOf": []map[string]any{whenEquals("mode", "a", map[string]any{
+						"required":   []string{"port"},
+						"properties": map[string]any{"port": map[string]any{"minimum": 1, "maximum": 65535}},
+					})},
+				},
+			},
+		}),
+	},
+	"trace": {
+		whenEquals("enabled", true, map[string]any{
+			"required": []string{"service_name", "endpoint"},
+			"properties": map[string]any{
+				"service_name": notEmptyRule,
+				"endpoint":     notEmptyRule,
+			},
+		}),
+	},
+}
+
+// whenEquals 生成 “字段等于 value 时满足 then” 的条件约束
+func whenEquals(field string, value any, then map[string]any) map[string]any {
+	return map[string]any{
+		"if": map[string]any{
+			"required":   []string{field},
+			"properties": map[string]any{field: map[string]any{"const": value}},
+		},
+		"then": then,
+	}
+}
+
+// JSONSchema 生成配置文件的 JSON Schema（draft-07）
+//
+// 包含 Config 的全部字段、内置默认值、ConfigValidator 的规则，以及已通过 Section 注册的配置段
+// （按 default/validate 标签生成）。可用于编辑器补全与 CI 中的预检查。
+func JSONSchema() ([]byte, error) {
+	dv := viper.New()
+	setDefaults(dv, nil)
+	defaults := newLayeredConfig()
+	defaults.merge(dv.AllSettings(), SourceDefault)
+
+	schema := typeSchema(reflect.TypeOf(Config{}), "", defaults)
+	schema["$schema"] = schemaDraft
+	schema["title"] = "goupter configuration"
+
+	sectionsMu.RLock()
+	for _, s := range sections {
+		addSectionSchema(schema, s.sectionKey(), sectionSchema(s.sectionType()))
+	}
+	sectionsMu.RUnlock()
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// typeSchema 生成类型对应的 schema，path 为配置键
+func typeSchema(t reflect.Type, path string, defaults *layeredConfig) map[string]any {
+	var schema map[string]any
+	switch {
+	case t == durationType:
+		schema = map[string]any{
+			"type":    []string{"string", "integer"},
+			"pattern": durationPattern,
+		}
+	case t == timeType:
+		schema = map[string]any{"type": "string", "format": "date-time"}
+	default:
+		switch t.Kind() {
+		case reflect.Ptr:
+			return typeSchema(t.Elem(), path, defaults)
+		case reflect.Struct:
+			schema = structSchema(t, path, defaults)
+		case reflect.Map:
+			schema = map[string]any{"type": "object"}
+			if t.Elem().Kind() != reflect.Interface {
+				schema["additionalProperties"] = typeSchema(t.Elem(), "", nil)
+			}
+		case reflect.Slice, reflect.Array:
+			schema = map[string]any{"type": "array", "items": typeSchema(t.Elem(), "", nil)}
+			if t.Elem().Kind() == reflect.String {
+				// 环境变量与命令行参数中以逗号分隔
+				schema = map[string]any{"type": []string{"array", "string"}, "items": map[string]any{"type": "string"}}
+			}
+		case reflect.String:
+			schema = map[string]any{"type": "string"}
+		case reflect.Bool:
+			schema = map[string]any{"type": "boolean"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			schema = map[string]any{"type": "integer"}
+		case reflect.Float32, reflect.Float64:
+			schema = map[string]any{"type": "number"}
+		default:
+			schema = map[string]any{}
+		}
+	}
+
+	if path == "" {
+		return schema
+	}
+	if defaults != nil && t.Kind() != reflect.Struct {
+		if value := defaults.get(path); value != nil {
+			schema["default"] = value
+		}
+	}
+	for k, v := range schemaRules[path] {
+		schema[k] = v
+	}
+	if t == durationType && schemaRules[path]["minimum"] != nil {
+		schema["pattern"] = nonNegativeDurationPattern
+	}
+	if conditions := schemaConditions[path]; len(conditions) > 0 {
+		schema["allOf"] = conditions
+	}
+	return schema
+}
+
+// structSchema 生成结构体的 schema，remain 字段允许任意额外属性
+func structSchema(t reflect.Type, path string, defaults *layeredConfig) map[string]any {
+	properties := make(map[string]any)
+	schema := map[string]any{"type": "object", "properties": properties}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("mapstructure"), ",")
+		if containsString(tag[1:], "remain") {
+			continue
+		}
+		name := fieldName(field)
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		properties[name] = typeSchema(field.Type, fieldPath, defaults)
+	}
+	return schema
+}
+
+// sectionSchema 按 default/validate 标签生成配置段的 schema
+func sectionSchema(t reflect.Type) map[string]any {
+	schema := typeSchema(t, "", nil)
+	if t.Kind() != reflect.Struct || t == durationType || t == timeType {
+		return schema
+	}
+
+	properties := schema["properties"].(map[string]any)
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := fieldName(field)
+		fs := sectionSchema(field.Type)
+
+		def, hasDefault := field.Tag.Lookup("default")
+		if hasDefault {
+			fs["default"] = tagDefault(field.Type, def)
+		}
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			ruleName, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			switch ruleName {
+			case "required":
+				if !hasDefault {
+					required = append(required, name)
+				}
+				if field.Type.Kind() == reflect.String {
+					fs["minLength"] = 1
+				}
+			case "min", "max":
+				n, err := strconv.Atoi(arg)
+				if err != nil {
+					continue
+				}
+				fs[boundKeyword(field.Type, ruleName)] = n
+			case "oneof":
+				fs["enum"] = strings.Fields(arg)
+			case "email":
+				fs["format"] = "email"
+			case "url":
+				fs["format"] = "uri"
+			case "ip":
+				fs["anyOf"] = []map[string]any{{"format": "ipv4"}, {"format": "ipv6"}}
+			case "port":
+				fs["minimum"], fs["maximum"] = 0, 65535
+			case "duration":
+				fs["pattern"] = durationPattern
+			}
+		}
+		properties[name] = fs
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// boundKeyword min/max 规则对应的关键字，与 Min/Max 对不同类型的含义一致
+func boundKeyword(t reflect.Type, rule string) string {
+	switch t.Kind() {
+	case reflect.String:
+		return rule + "Length"
+	case reflect.Slice, reflect.Array:
+		return rule + "Items"
+	}
+	if rule == "min" {
+		return "minimum"
+	}
+	return "maximum"
+}
+
+// tagDefault 将 default 标签转换为 schema 中的默认值
+func tagDefault(t reflect.Type, def string) any {
+	if t == durationType {
+		return def
+	}
+	v := reflect.New(t).Elem()
+	if err := setValue(v, def); err != nil {
+		return def
+	}
+	return v.Interface()
+}
+
+// addSectionSchema 将配置段的 schema 放到对应的配置键下
+func addSectionSchema(root map[string]any, key string, schema map[string]any) {
+	parts := strings.Split(strings.ToLower(key), ".")
+	current := root
+	for _, part := range parts[:len(parts)-1] {
+		properties, ok := current["properties"].(map[string]any)
+		if !ok {
+			properties = make(map[string]any)
+			current["properties"] = properties
+		}
+		next, ok := properties[part].(map[string]any)
+		if !ok {
+			next = map[string]any{"type": "object"}
+			properties[part] = next
+		}
+		current = next
+	}
+	properties, ok := current["properties"].(map[string]any)
+	if !ok {
+		properties = make(map[string]any)
+		current["properties"] = properties
+	}
+	properties[parts[len(parts)-1]] = schema
+}
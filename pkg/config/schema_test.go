@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// schemaProperty 按配置键获取 schema 中的属性
+func schemaProperty(t *testing.T, schema map[string]any, key string) map[string]any {
+	t.Helper()
+	current := schema
+	for _, part := range strings.Split(key, ".") {
+		properties, _ := current["properties"].(map[string]any)
+		next, ok := properties[part].(map[string]any)
+		if !ok {
+			t.Fatalf("schema 中缺少 %s", key)
+		}
+		current = next
+	}
+	return current
+}
+
+func TestJSONSchema(t *testing.T) {
+	type orderConfig struct {
+		Endpoint string        `mapstructure:"endpoint" validate:"required,url"`
+		Timeout  time.Duration `mapstructure:"timeout" default:"3s"`
+		Retries  int           `mapstructure:"retries" default:"2" validate:"min=0,max=5"`
+		Mode     string        `mapstructure:"mode" default:"fast" validate:"oneof=fast safe"`
+	}
+	sectionsMu.Lock()
+	saved := sections
+	sections = append(sections, &SectionValue[orderConfig]{key: "services.order"})
+	sectionsMu.Unlock()
+	t.Cleanup(func() {
+		sectionsMu.Lock()
+		sections = saved
+		sectionsMu.Unlock()
+	})
+
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema["$schema"] != schemaDraft {
+		t.Errorf("$schema = %v", schema["$schema"])
+	}
+
+	port := schemaProperty(t, schema, "server.http.port")
+	if port["type"] != "integer" || port["minimum"] != 0.0 || port["maximum"] != 65535.0 || port["default"] != 8080.0 {
+		t.Errorf("server.http.port = %v", port)
+	}
+	timeout := schemaProperty(t, schema, "server.http.read_timeout")
+	if timeout["pattern"] != nonNegativeDurationPattern || timeout["default"] != "30s" {
+		t.Errorf("server.http.read_timeout = %v", timeout)
+	}
+	if mode := schemaProperty(t, schema, "redis.mode"); len(mode["enum"].([]any)) != 4 {
+		t.Errorf("redis.mode = %v", mode)
+	}
+	if redis := schemaProperty(t, schema, "redis"); len(redis["allOf"].([]any)) != 2 {
+		t.Errorf("redis.allOf = %v", redis["allOf"])
+	}
+	if instances := schemaProperty(t, schema, "discovery.static.services"); instances["type"] != "object" {
+		t.Errorf("discovery.static.services = %v", instances)
+	}
+
+	order := schemaProperty(t, schema, "services.order")
+	if !reflect.DeepEqual(order["required"], []any{"endpoint"}) {
+		t.Errorf("required = %v", order["required"])
+	}
+	if p := schemaProperty(t, schema, "services.order.endpoint"); p["format"] != "uri" || p["minLength"] != 1.0 {
+		t.Errorf("endpoint = %v", p)
+	}
+	if p := schemaProperty(t, schema, "services.order.retries"); p["default"] != 2.0 || p["minimum"] != 0.0 || p["maximum"] != 5.0 {
+		t.Errorf("retries = %v", p)
+	}
+	if p := schemaProperty(t, schema, "services.order.timeout"); p["default"] != "3s" {
+		t.Errorf("timeout = %v", p)
+	}
+	if p := schemaProperty(t, schema, "services.order.mode"); !reflect.DeepEqual(p["enum"], []any{"fast", "safe"}) {
+		t.Errorf("mode = %v", p)
+	}
+}
+
+// TestSchemaRules_MatchValidator 确保 schemaRules 中的每条约束都与 ConfigValidator 一致：
+// 违反约束的值必须被校验器拒绝
+func TestSchemaRules_MatchValidator(t *testing.T) {
+	for key, rule := range schemaRules {
+		var invalid []any
+		if enum, ok := rule["enum"].([]string); ok {
+			invalid = append(invalid, "no-such-"+enum[0])
+		}
+		if min, ok := rule["minimum"].(int); ok {
+			invalid = append(invalid, min-1)
+		}
+		if max, ok := rule["maximum"].(int); ok {
+			invalid = append(invalid, max+1)
+		}
+		if _, ok := rule["minLength"]; ok {
+			invalid = append(invalid, "")
+		}
+		if pattern, ok := rule["pattern"].(string); ok {
+			if pattern == nonNegativeDurationPattern {
+				invalid = append(invalid, "ten seconds")
+			} else {
+				invalid = append(invalid, "http://example.com")
+			}
+		}
+		if len(invalid) == 0 {
+			t.Errorf("%s: 无法验证的约束 %v", key, rule)
+		}
+
+		for _, value := range invalid {
+			l := newLayeredConfig()
+			l.set(key, value, SourceDefault)
+			cfg, err := l.decode()
+			if err != nil {
+				t.Fatalf("%s=%v: %v", key, value, err)
+			}
+			found := false
+			for _, e := range ValidateConfig(cfg) {
+				if e.Field == key {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("%s=%v 违反 schema 约束，但校验器未报错", key, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretProvider 密钥提供者，解析配置中的 ${scheme:ref} 引用
+type SecretProvider interface {
+	// Resolve 返回引用对应的明文，ref 为引用中 scheme: 之后的部分
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretProviderFunc 函数形式的密钥提供者
+type SecretProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve 实现 SecretProvider
+func (f SecretProviderFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// secretResolveTimeout 单次解析全部引用的超时时间
+const secretResolveTimeout = 30 * time.Second
+
+// secretRefPattern 匹配 ${scheme:ref}
+var secretRefPattern = regexp.MustCompile(`\$\{([a-zA-Z][a-zA-Z0-9_-]*):([^}]*)\}`)
+
+var (
+	secretProviders = map[string]SecretProvider{
+		"file": FileSecretProvider{},
+		"env":  EnvSecretProvider{},
+		// 每次解析时读取环境变量，便于在加载配置前设置
+		"vault": SecretProviderFunc(func(ctx context.Context, ref string) (string, error) {
+			return NewVaultSecretProvider().Resolve(ctx, ref)
+		}),
+	}
+	secretMu sync.RWMutex
+)
+
+// localSecretSchemes 读取本机文件与环境变量的密钥提供者
+//
+// 远端配置层的值默认不能使用这些引用，避免配置中心的写权限扩大为读取本机任意文件或环境变量，
+// 见 WithRemoteSecretSchemes。
+var localSecretSchemes = []string{"file", "env"}
+
+// RegisterSecretProvider 注册密钥提供者，已存在同名 scheme 时替换
+//
+// 内置 file、env 与 vault（地址与令牌取自 VAULT_ADDR、VAULT_TOKEN）。
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	secretProviders[scheme] = provider
+}
+
+// GetSecretProvider 获取密钥提供者
+func GetSecretProvider(scheme string) (SecretProvider, bool) {
+	secretMu.RLock()
+	defer secretMu.RUnlock()
+	provider, ok := secretProviders[scheme]
+	return provider, ok
+}
+
+// FileSecretProvider 从文件读取密钥，如 ${file:/run/secrets/db}，忽略末尾换行
+type FileSecretProvider struct{}
+
+// Resolve 实现 SecretProvider
+func (FileSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// EnvSecretProvider 从环境变量读取密钥，如 ${env:DB_PASSWORD}
+type EnvSecretProvider struct{}
+
+// Resolve 实现 SecretProvider
+func (EnvSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("环境变量 %s 未设置", ref)
+	}
+	return value, nil
+}
+
+// ResolveSecrets 解析配置中所有字符串值里的密钥引用
+//
+// 引用可以是完整的值，也可以嵌入在字符串中（如 DSN）。同一次解析中相同的引用只请求一次。
+// 错误信息只包含配置键与引用，不包含密钥内容。
+func ResolveSecrets(cfg *Config) error {
+	return resolveSecrets(cfg, nil)
+}
+
+// resolveSecrets 解析密钥引用，allow 不为 nil 时只解析其允许的配置键与 scheme
+func resolveSecrets(cfg *Config, allow func(path, scheme string) bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+
+	r := &secretResolver{ctx: ctx, cache: make(map[string]string), allow: allow}
+	return walkStrings(reflect.ValueOf(cfg), "", r.resolve)
+}
+
+// secretResolver 单次解析过程
+type secretResolver struct {
+	ctx   context.Context
+	cache map[string]string
+	allow func(path, scheme string) bool
+}
+
+// resolve 替换字符串中的全部引用
+func (r *secretResolver) resolve(s, path string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var firstErr error
+	result := secretRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if firstErr != nil {
+			return ref
+		}
+		m := secretRefPattern.FindStringSubmatch(ref)
+		if r.allow != nil && !r.allow(path, m[1]) {
+			firstErr = fmt.Errorf("%s: 远端配置不允许使用 %s 引用", path, m[1])
+			return ref
+		}
+		if value, ok := r.cache[ref]; ok {
+			return value
+		}
+
+		provider, ok := GetSecretProvider(m[1])
+		if !ok {
+			firstErr = fmt.Errorf("%s: 未注册的密钥提供者 %s", path, m[1])
+			return ref
+		}
+		value, err := provider.Resolve(r.ctx, m[2])
+		if err != nil {
+			firstErr = fmt.Errorf("%s: 解析 %s 失败: %w", path, ref, err)
+			return ref
+		}
+		r.cache[ref] = value
+		return value
+	})
+	return result, firstErr
+}
+
+// walkStrings 递归替换结构体、map、切片中的字符串，path 为字符串对应的配置键
+func walkStrings(v reflect.Value, path string, fn func(s, path string) (string, error)) error {
+	join := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return walkStrings(v.Elem(), path, fn)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if elem := v.Elem(); elem.Kind() == reflect.String {
+			if !v.CanSet() {
+				return nil
+			}
+			resolved, err := fn(elem.String(), path)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(resolved))
+			return nil
+		}
+		// 接口中的值不可寻址，复制后处理再写回
+		cp := reflect.New(v.Elem().Type()).Elem()
+		cp.Set(v.Elem())
+		if err := walkStrings(cp, path, fn); err != nil {
+			return err
+		}
+		if v.CanSet() {
+			v.Set(cp)
+		}
+		return nil
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return nil
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			tag := strings.Split(field.Tag.Get("mapstructure"), ",")
+			fieldPath := path
+			if !containsString(tag[1:], "remain") && !containsString(tag[1:], "squash") {
+				fieldPath = join(fieldName(field))
+			}
+			if err := walkStrings(v.Field(i), fieldPath, fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			// map 的值不可寻址，复制后处理再写回
+			cp := reflect.New(v.Type().Elem()).Elem()
+			cp.Set(iter.Value())
+			if err := walkStrings(cp, join(fmt.Sprint(iter.Key().Interface())), fn); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), cp)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkStrings(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+		resolved, err := fn(v.String(), path)
+		if err != nil {
+			return err
+		}
+		v.SetString(resolved)
+	}
+	return nil
+}
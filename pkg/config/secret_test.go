@@ -0,0 +1,207 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFakeVault 创建模拟 Vault KV 接口的服务
+func newFakeVault(t *testing.T, token string, secrets map[string]map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		data, ok := secrets[strings.TrimPrefix(r.URL.Path, "/v1/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": data}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVaultSecretProvider(t *testing.T) {
+	srv := newFakeVault(t, "root", map[string]map[string]any{
+		"secret/data/db": {"password": "s3cret", "port": 3306},
+	})
+
+	p := NewVaultSecretProvider(WithVaultAddress(srv.URL), WithVaultToken("root"))
+	ctx := context.Background()
+
+	if got, err := p.Resolve(ctx, "secret/db#password"); err != nil || got != "s3cret" {
+		t.Errorf("Resolve() = %q, %v", got, err)
+	}
+	if got, err := p.Resolve(ctx, "secret/data/db#port"); err != nil || got != "3306" {
+		t.Errorf("Resolve() = %q, %v", got, err)
+	}
+	if _, err := p.Resolve(ctx, "secret/db#missing"); err == nil {
+		t.Error("不存在的键应返回错误")
+	}
+	if _, err := p.Resolve(ctx, "secret/db"); err == nil {
+		t.Error("缺少 #key 应返回错误")
+	}
+
+	denied := NewVaultSecretProvider(WithVaultAddress(srv.URL), WithVaultToken("bad"))
+	if _, err := denied.Resolve(ctx, "secret/db#password"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("无效令牌应返回 403, err = %v", err)
+	}
+}
+
+func TestVaultSecretProvider_KVv1(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/app" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"token": "t1"}})
+	}))
+	defer srv.Close()
+
+	p := NewVaultSecretProvider(WithVaultAddress(srv.URL), WithVaultKVVersion(1))
+	if got, err := p.Resolve(context.Background(), "kv/app#token"); err != nil || got != "t1" {
+		t.Errorf("Resolve() = %q, %v", got, err)
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "redis")
+	if err := os.WriteFile(file, []byte("redis-pass\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_CONSUL_TOKEN", "consul-token")
+
+	srv := newFakeVault(t, "root", map[string]map[string]any{
+		"secret/data/jwt": {"secret": "jwt-secret"},
+	})
+	RegisterSecretProvider("vault", NewVaultSecretProvider(WithVaultAddress(srv.URL), WithVaultToken("root")))
+	t.Cleanup(func() {
+		RegisterSecretProvider("vault", SecretProviderFunc(func(ctx context.Context, ref string) (string, error) {
+			return NewVaultSecretProvider().Resolve(ctx, ref)
+		}))
+	})
+
+	cfg := &Config{
+		Database: DatabaseConfig{Password: "pre-${env:TEST_CONSUL_TOKEN}-post"},
+		Redis:    RedisConfig{Password: "${file:" + file + "}"},
+		Consul:   ConsulConfig{Token: "${env:TEST_CONSUL_TOKEN}"},
+		Auth:     AuthConfig{Config: map[string]interface{}{"secret": "${vault:secret/jwt#secret}", "expire": 3600}},
+	}
+	if err := ResolveSecrets(cfg); err != nil {
+		t.Fatalf("ResolveSecrets() error = %v", err)
+	}
+
+	if cfg.Database.Password != "pre-consul-token-post" {
+		t.Errorf("Database.Password = %q", cfg.Database.Password)
+	}
+	if cfg.Redis.Password != "redis-pass" {
+		t.Errorf("Redis.Password = %q", cfg.Redis.Password)
+	}
+	if cfg.Consul.Token != "consul-token" {
+		t.Errorf("Consul.Token = %q", cfg.Consul.Token)
+	}
+	if cfg.Auth.Config["secret"] != "jwt-secret" || cfg.Auth.Config["expire"] != 3600 {
+		t.Errorf("Auth.Config = %v", cfg.Auth.Config)
+	}
+}
+
+func TestResolveSecrets_Errors(t *testing.T) {
+	cfg := &Config{Database: DatabaseConfig{Password: "${unknown:x}"}}
+	if err := ResolveSecrets(cfg); err == nil || !strings.Contains(err.Error(), "database.password") {
+		t.Errorf("未注册的 scheme 应返回包含配置键的错误, err = %v", err)
+	}
+
+	cfg = &Config{Redis: RedisConfig{Password: "${env:GOUPTER_TEST_UNSET_SECRET}"}}
+	if err := ResolveSecrets(cfg); err == nil {
+		t.Error("未设置的环境变量应返回错误")
+	}
+}
+
+func TestLoad_SecretRotation(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "db-password")
+	if err := os.WriteFile(secretFile, []byte("v1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	content := "database:\n  password: ${file:" + secretFile + "}\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(WithConfigFile("config"), WithConfigPaths(dir))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.Password != "v1" {
+		t.Fatalf("Database.Password = %q, want v1", cfg.Database.Password)
+	}
+
+	// 密钥轮换后 Reload 重新解析
+	if err := os.WriteFile(secretFile, []byte("v2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	change, err := Reload()
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if !change.Changed("database.password") || Get().Database.Password != "v2" {
+		t.Errorf("轮换未生效: keys = %v", change.Keys())
+	}
+}
+
+func TestConsulConfigCenter_ParseConfigSecrets(t *testing.T) {
+	t.Setenv("TEST_REDIS_PASSWORD", "from-env")
+	c := &ConfigCenter{format: "yaml"}
+
+	cfg := &Config{}
+	_, err := c.parseConfig([]byte("redis:\n  password: ${env:TEST_REDIS_PASSWORD}\n"), cfg)
+	if err == nil || !strings.Contains(err.Error(), "redis.password") {
+		t.Errorf("配置源内容不应引用本机环境变量, err = %v", err)
+	}
+}
+
+func TestLoad_RemoteSecretSchemes(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "db-password")
+	if err := os.WriteFile(secretFile, []byte("local-secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	content := "database:\n  password: ${file:" + secretFile + "}\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_REMOTE_SECRET", "env-secret")
+	remote := &remoteLayer{name: "consul:config/app", data: []byte("redis:\n  password: ${env:TEST_REMOTE_SECRET}\n")}
+
+	// 本地文件中的引用照常解析，远端配置层中的引用被拒绝
+	o := newOptions(WithConfigFile("config"), WithConfigPaths(dir))
+	if _, _, err := o.load(remote); err == nil || !strings.Contains(err.Error(), "redis.password") {
+		t.Errorf("远端 env 引用应被拒绝, err = %v", err)
+	}
+
+	// 环境变量覆盖远端值后来源为本地
+	t.Setenv("FLIT_REDIS_PASSWORD", "${env:TEST_REMOTE_SECRET}")
+	cfg, _, err := o.load(remote)
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if cfg.Database.Password != "local-secret" || cfg.Redis.Password != "env-secret" {
+		t.Errorf("Database.Password = %q, Redis.Password = %q", cfg.Database.Password, cfg.Redis.Password)
+	}
+	os.Unsetenv("FLIT_REDIS_PASSWORD")
+
+	o = newOptions(WithConfigFile("config"), WithConfigPaths(dir), WithRemoteSecretSchemes("env"))
+	if cfg, _, err := o.load(remote); err != nil || cfg.Redis.Password != "env-secret" {
+		t.Errorf("显式允许后应解析远端 env 引用, cfg = %v, err = %v", cfg, err)
+	}
+}
@@ -0,0 +1,388 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// sectionBinding 已注册、参与热更新的配置段
+type sectionBinding interface {
+	sectionKey() string
+	// check 校验新配置中的配置段，不修改当前值
+	check(cfg *Config) ValidationErrors
+	// reload 应用新配置中的配置段
+	reload(cfg *Config)
+	// sectionType 配置段的结构体类型，用于生成 JSON Schema
+	sectionType() reflect.Type
+}
+
+var (
+	sectionsMu sync.RWMutex
+	sections   []sectionBinding
+)
+
+// SectionValue 类型化的自定义配置段，随配置热更新
+type SectionValue[T any] struct {
+	key       string
+	value     atomic.Pointer[T]
+	mu        sync.Mutex
+	callbacks []func(old, new *T)
+}
+
+// Section 将自定义配置段解析为 T 并注册热更新
+//
+// key 为 Config 未声明的顶层配置键或其子键（如 "myservice"、"myservice.cache"）。
+// 字段按 mapstructure 标签映射，未配置的字段取 default 标签的值，随后按 validate 标签校验：
+//
+//	type MyServiceConfig struct {
+//		Endpoint string        `mapstructure:"endpoint" validate:"required,url"`
+//		Timeout  time.Duration `mapstructure:"timeout" default:"3s"`
+//		Mode     string        `mapstructure:"mode" default:"fast" validate:"oneof=fast safe"`
+//	}
+//
+//	section, err := config.Section[MyServiceConfig]("myservice")
+//	timeout := section.Get().Timeout
+//
+// 配置文件（Reload/WatchFile）或 Consul 配置中心更新时重新解析；新值校验失败时整份配置被拒绝。
+func Section[T any](key string) (*SectionValue[T], error) {
+	value := new(T)
+	if err := decodeSection(Get(), key, value); err != nil {
+		return nil, err
+	}
+
+	s := &SectionValue[T]{key: key}
+	s.value.Store(value)
+
+	sectionsMu.Lock()
+	sections = append(sections, s)
+	sectionsMu.Unlock()
+	return s, nil
+}
+
+// MustSection 同 Section，失败则 panic
+func MustSection[T any](key string) *SectionValue[T] {
+	s, err := Section[T](key)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Key 获取配置段的键
+func (s *SectionValue[T]) Key() string {
+	return s.key
+}
+
+// Get 获取当前值，返回的值不应被修改
+func (s *SectionValue[T]) Get() *T {
+	return s.value.Load()
+}
+
+// OnChange 注册配置段变更回调
+func (s *SectionValue[T]) OnChange(fn func(old, new *T)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callbacks = append(s.callbacks, fn)
+}
+
+func (s *SectionValue[T]) sectionKey() string {
+	return s.key
+}
+
+func (s *SectionValue[T]) sectionType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (s *SectionValue[T]) check(cfg *Config) ValidationErrors {
+	return sectionErrors(s.key, decodeSection(cfg, s.key, new(T)))
+}
+
+func (s *SectionValue[T]) reload(cfg *Config) {
+	value := new(T)
+	if err := decodeSection(cfg, s.key, value); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	old := s.value.Load()
+	if reflect.DeepEqual(old, value) {
+		s.mu.Unlock()
+		return
+	}
+	s.value.Store(value)
+	callbacks := make([]func(old, new *T), len(s.callbacks))
+	copy(callbacks, s.callbacks)
+	s.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(old, value)
+	}
+}
+
+// Bind 将自定义配置段解析到 target（结构体指针），规则同 Section
+//
+// Bind 仅解析一次，不随热更新改变 target；需要热更新时使用 Section。
+func Bind(key string, target any) error {
+	return decodeSection(Get(), key, target)
+}
+
+// SectionKeys 返回已注册热更新的配置段的键
+func SectionKeys() []string {
+	sectionsMu.RLock()
+	defer sectionsMu.RUnlock()
+	keys := make([]string, 0, len(sections))
+	for _, s := range sections {
+		keys = append(keys, s.sectionKey())
+	}
+	return keys
+}
+
+// checkSections 校验新配置中所有已注册的配置段
+func checkSections(cfg *Config) ValidationErrors {
+	sectionsMu.RLock()
+	defer sectionsMu.RUnlock()
+	var errs ValidationErrors
+	for _, s := range sections {
+		errs = append(errs, s.check(cfg)...)
+	}
+	return errs
+}
+
+// reloadSections 以新配置更新所有已注册的配置段
+func reloadSections(cfg *Config) {
+	sectionsMu.RLock()
+	list := make([]sectionBinding, len(sections))
+	copy(list, sections)
+	sectionsMu.RUnlock()
+
+	for _, s := range list {
+		s.reload(cfg)
+	}
+}
+
+// sectionErrors 将解析错误转换为校验错误
+func sectionErrors(key string, err error) ValidationErrors {
+	if err == nil {
+		return nil
+	}
+	if errs, ok := err.(ValidationErrors); ok {
+		return errs
+	}
+	return ValidationErrors{{Field: key, Message: err.Error()}}
+}
+
+// decodeSection 解析配置段：先填充 default 标签，再覆盖配置值，最后按 validate 标签校验
+func decodeSection(cfg *Config, key string, target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("配置段 %s 的目标必须是结构体指针", key)
+	}
+
+	if err := applyDefaults(rv.Elem(), key); err != nil {
+		return err
+	}
+
+	if raw := lookupSection(cfg, key); raw != nil {
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			Result:           target,
+			WeaklyTypedInput: true,
+			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				mapstructure.StringToTimeDurationHookFunc(),
+				mapstructure.StringToSliceHookFunc(","),
+			),
+		})
+		if err != nil {
+			return err
+		}
+		if err := decoder.Decode(raw); err != nil {
+			return fmt.Errorf("解析配置段 %s 失败: %w", key, err)
+		}
+	}
+
+	if errs := validateStruct(rv.Elem(), key); errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// lookupSection 在 Config.Extra 中按 . 分隔的键查找配置段
+func lookupSection(cfg *Config, key string) any {
+	if cfg == nil {
+		return nil
+	}
+	var current any = cfg.Extra
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		if current, ok = m[part]; !ok {
+			return nil
+		}
+	}
+	return current
+}
+
+// fieldName 获取字段对应的配置键
+func fieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// applyDefaults 为零值字段填充 default 标签的值，递归处理嵌套结构体
+func applyDefaults(v reflect.Value, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		fieldPath := path + "." + fieldName(field)
+
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if fv.IsZero() {
+				if err := setValue(fv, def); err != nil {
+					return fmt.Errorf("配置段字段 %s 的默认值无效: %w", fieldPath, err)
+				}
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+			if err := applyDefaults(fv, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue 将字符串解析为字段类型并赋值，切片以逗号分隔
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		parts := strings.Split(s, ",")
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setValue(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("不支持的类型 %s", v.Type())
+	}
+	return nil
+}
+
+// validateStruct 按 validate 标签校验结构体，递归处理嵌套结构体
+//
+// 支持的规则：required、min=N、max=N、oneof=a b c、email、url、ip、port、duration，以逗号分隔。
+func validateStruct(v reflect.Value, path string) ValidationErrors {
+	var errs ValidationErrors
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		fieldPath := path + "." + fieldName(field)
+
+		if tag := field.Tag.Get("validate"); tag != "" {
+			for _, rule := range strings.Split(tag, ",") {
+				fn, err := parseRule(strings.TrimSpace(rule))
+				if err != nil {
+					errs = append(errs, &ValidationError{Field: fieldPath, Message: err.Error()})
+					continue
+				}
+				if verr := fn(fv.Interface(), fieldPath); verr != nil {
+					errs = append(errs, verr)
+				}
+			}
+		}
+		if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+			errs = append(errs, validateStruct(fv, fieldPath)...)
+		}
+	}
+	return errs
+}
+
+// parseRule 将 validate 标签中的单条规则转换为 ValidationRule
+func parseRule(rule string) (ValidationRule, error) {
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		return Required, nil
+	case "min", "max":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("校验规则 %s 的参数无效", rule)
+		}
+		if name == "min" {
+			return Min(n), nil
+		}
+		return Max(n), nil
+	case "oneof":
+		return OneOf(strings.Fields(arg)...), nil
+	case "email":
+		return Email, nil
+	case "url":
+		return URL, nil
+	case "ip":
+		return IP, nil
+	case "port":
+		return Port, nil
+	case "duration":
+		return Duration, nil
+	default:
+		return nil, fmt.Errorf("未知的校验规则 %s", rule)
+	}
+}
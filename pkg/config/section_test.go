@@ -0,0 +1,210 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testSection struct {
+	Endpoint string        `mapstructure:"endpoint" validate:"required,url"`
+	Timeout  time.Duration `mapstructure:"timeout" default:"3s"`
+	Mode     string        `mapstructure:"mode" default:"fast" validate:"oneof=fast safe"`
+	Tags     []string      `mapstructure:"tags" default:"a,b"`
+	Retry    struct {
+		Max int `mapstructure:"max" default:"3" validate:"min=1,max=10"`
+	} `mapstructure:"retry"`
+}
+
+// loadSectionConfig 写入配置文件并加载，测试结束后清理已注册的配置段
+func loadSectionConfig(t *testing.T, content string) string {
+	t.Helper()
+	t.Cleanup(func() {
+		sectionsMu.Lock()
+		sections = nil
+		sectionsMu.Unlock()
+		reloadMu.Lock()
+		reloadCallbacks = nil
+		reloadMu.Unlock()
+	})
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(WithConfigFile("config"), WithConfigPaths(dir)); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return file
+}
+
+func TestSection_DefaultsAndValues(t *testing.T) {
+	loadSectionConfig(t, `
+app:
+  name: demo
+myservice:
+  endpoint: https://api.example.com
+  mode: safe
+`)
+
+	s, err := Section[testSection]("myservice")
+	if err != nil {
+		t.Fatalf("Section() error = %v", err)
+	}
+	got := s.Get()
+	if got.Endpoint != "https://api.example.com" || got.Mode != "safe" {
+		t.Errorf("配置值未解析: %+v", got)
+	}
+	if got.Timeout != 3*time.Second || got.Retry.Max != 3 || strings.Join(got.Tags, ",") != "a,b" {
+		t.Errorf("默认值未生效: %+v", got)
+	}
+	if keys := SectionKeys(); len(keys) != 1 || keys[0] != "myservice" {
+		t.Errorf("SectionKeys() = %v", keys)
+	}
+}
+
+func TestSection_Validate(t *testing.T) {
+	loadSectionConfig(t, `
+myservice:
+  mode: slow
+  retry:
+    max: 20
+`)
+
+	_, err := Section[testSection]("myservice")
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("error = %v, want ValidationErrors", err)
+	}
+	fields := make([]string, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	want := "myservice.endpoint,myservice.mode,myservice.retry.max"
+	if strings.Join(fields, ",") != want {
+		t.Errorf("fields = %v, want %s", fields, want)
+	}
+}
+
+func TestBind(t *testing.T) {
+	loadSectionConfig(t, `
+myservice:
+  endpoint: https://api.example.com
+  timeout: 5s
+`)
+
+	var cfg testSection
+	if err := Bind("myservice", &cfg); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %v, want 5s", cfg.Timeout)
+	}
+	if err := Bind("myservice", cfg); err == nil {
+		t.Error("非指针目标应返回错误")
+	}
+}
+
+func TestSection_ReloadFromFile(t *testing.T) {
+	file := loadSectionConfig(t, `
+myservice:
+  endpoint: https://a.example.com
+`)
+
+	s := MustSection[testSection]("myservice")
+	var changed *testSection
+	s.OnChange(func(old, new *testSection) { changed = new })
+
+	var reloaded *ConfigChange
+	OnReload(func(change *ConfigChange) { reloaded = change })
+
+	// 校验失败的配置被拒绝，保留原值
+	if err := os.WriteFile(file, []byte("myservice:\n  endpoint: not-a-url\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Reload(); err == nil {
+		t.Fatal("Reload() 应拒绝无效的配置段")
+	}
+	if s.Get().Endpoint != "https://a.example.com" || changed != nil {
+		t.Errorf("拒绝后不应更新: %+v", s.Get())
+	}
+
+	if err := os.WriteFile(file, []byte("myservice:\n  endpoint: https://b.example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if s.Get().Endpoint != "https://b.example.com" || changed == nil || changed.Endpoint != "https://b.example.com" {
+		t.Errorf("配置段未更新: %+v", s.Get())
+	}
+	if reloaded == nil || !reloaded.Changed("myservice") {
+		t.Errorf("OnReload 未收到配置段变更: %+v", reloaded)
+	}
+}
+
+func TestReload_CallbackReentry(t *testing.T) {
+	file := loadSectionConfig(t, `
+myservice:
+  endpoint: https://a.example.com
+`)
+
+	// 回调中注册回调并再次 Reload 不应死锁
+	var nested *ConfigChange
+	OnReload(func(change *ConfigChange) {
+		if nested != nil {
+			return
+		}
+		OnReload(func(*ConfigChange) {})
+		nested, _ = Reload()
+	})
+
+	if err := os.WriteFile(file, []byte("myservice:\n  endpoint: https://b.example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := Reload()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Reload() error = %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Reload() 在回调重入时死锁")
+	}
+	if nested == nil || len(nested.Changes) != 0 {
+		t.Errorf("回调中的 Reload() = %+v, want 无变更", nested)
+	}
+}
+
+func TestSection_ReloadFromConsul(t *testing.T) {
+	loadSectionConfig(t, `
+myservice:
+  endpoint: https://a.example.com
+`)
+
+	s := MustSection[testSection]("myservice")
+	source, _ := NewConsulSource("127.0.0.1:1", "config/test")
+	center := NewConsulConfigCenter(source)
+
+	if _, err := center.apply([]byte("myservice:\n  retry:\n    max: 0\n")); err == nil {
+		t.Fatal("apply() 应拒绝无效的配置段")
+	}
+
+	change, err := center.apply([]byte("myservice:\n  endpoint: https://c.example.com\n  mode: safe\n"))
+	if err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	if !change.Changed("myservice.mode") {
+		t.Errorf("changes = %v", change.Keys())
+	}
+	if got := s.Get(); got.Endpoint != "https://c.example.com" || got.Mode != "safe" {
+		t.Errorf("配置段未更新: %+v", got)
+	}
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidateFile 校验单个配置文件，规则与 ValidateAndLoad 相同
+//
+// 文件内容合并到内置默认值之上后解析与校验，不读取环境变量、其他环境的配置文件与 Consul，
+// 也不解析密钥引用，适合在部署前或 CI 中检查。类型错误与校验错误逐字段返回，并带有字段
+// 在文件中的行列号（字段未出现在文件中时取最近的上级配置键的位置）。
+// 文件无法读取或语法错误时返回 error。
+func ValidateFile(path string) (ValidationErrors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %s: %w", path, err)
+	}
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	settings, err := readSettings(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %s: %w", path, err)
+	}
+
+	dv := viper.New()
+	setDefaults(dv, nil)
+	l := newLayeredConfig()
+	l.merge(dv.AllSettings(), SourceDefault)
+	l.merge(settings, sourceFile+path)
+
+	// 解析失败的字段保持零值，其余字段照常校验
+	vp := viper.New()
+	if err := vp.MergeConfigMap(l.settings); err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	errs := decodeErrors(vp.Unmarshal(cfg))
+
+	failed := make(map[string]bool, len(errs))
+	for _, e := range errs {
+		failed[e.Field] = true
+	}
+	for _, e := range append(ValidateConfig(cfg), checkSections(cfg)...) {
+		if !failed[e.Field] {
+			errs = append(errs, e)
+		}
+	}
+
+	for _, e := range errs {
+		e.Line, e.Column = nodePosition(&root, e.Field)
+	}
+	// 按文件中的位置排序，无法定位的排在最后
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line == 0 || errs[j].Line == 0 {
+			return errs[j].Line == 0 && errs[i].Line != 0
+		}
+		return errs[i].Line < errs[j].Line
+	})
+	return errs, nil
+}
+
+// mapKeyPattern mapstructure 错误中 map 键的表示，如 services[user]
+var mapKeyPattern = regexp.MustCompile(`\[([^\]0-9][^\]]*)\]`)
+
+// decodeErrors 将 mapstructure 的解析错误拆分为逐字段的校验错误
+func decodeErrors(err error) ValidationErrors {
+	if err == nil {
+		return nil
+	}
+
+	var errs ValidationErrors
+	var walk func(error)
+	walk = func(err error) {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, inner := range joined.Unwrap() {
+				walk(inner)
+			}
+			return
+		}
+
+		de, ok := err.(*mapstructure.DecodeError)
+		if !ok {
+			if inner := errors.Unwrap(err); inner != nil {
+				walk(inner)
+				return
+			}
+			errs = append(errs, &ValidationError{Field: "config", Message: err.Error()})
+			return
+		}
+
+		msg := de.Unwrap().Error()
+		var pe *mapstructure.ParseError
+		var ue *mapstructure.UnconvertibleTypeError
+		switch {
+		case errors.As(de.Unwrap(), &pe):
+			msg = fmt.Sprintf("类型错误，应为 %s", pe.Expected.Type())
+		case errors.As(de.Unwrap(), &ue):
+			msg = fmt.Sprintf("类型错误，应为 %s", ue.Expected.Type())
+		}
+		// map 的键以 [key] 表示，统一为 . 分隔
+		field := mapKeyPattern.ReplaceAllString(de.Name(), ".$1")
+		errs = append(errs, &ValidationError{Field: field, Message: msg})
+	}
+	walk(err)
+	return errs
+}
+
+// nodePosition 查找配置键在 YAML 节点树中的位置，不存在时返回最近的上级配置键的位置
+func nodePosition(root *yaml.Node, field string) (line, column int) {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, part := range strings.Split(field, ".") {
+		name, index := part, -1
+		if i := strings.Index(part, "["); i > 0 && strings.HasSuffix(part, "]") {
+			name = part[:i]
+			index, _ = strconv.Atoi(part[i+1 : len(part)-1])
+		}
+
+		key, value := mappingValue(node, name)
+		if key == nil {
+			return line, column
+		}
+		line, column = key.Line, key.Column
+		node = value
+
+		if index >= 0 {
+			if node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+				return line, column
+			}
+			node = node.Content[index]
+			line, column = node.Line, node.Column
+		}
+	}
+	return line, column
+}
+
+// mappingValue 在映射节点中按键查找（不区分大小写，与 viper 一致）
+func mappingValue(node *yaml.Node, name string) (key, value *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, name) {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateFile(t *testing.T) {
+	path := writeFile(t, "config.yaml", `app:
+  name: demo
+  env: prod
+server:
+  http:
+    port: abc
+    read_timeout: 5s
+  grpc:
+    port: 70000
+redis:
+  mode: cluster
+discovery:
+  static:
+    services:
+      user:
+        - address: 10.0.0.1
+          port: x
+`)
+
+	errs, err := ValidateFile(path)
+	if err != nil {
+		t.Fatalf("ValidateFile() error = %v", err)
+	}
+
+	want := []struct {
+		field        string
+		line, column int
+	}{
+		{"app.env", 3, 3},
+		{"server.http.port", 6, 5},
+		{"server.grpc.port", 9, 5},
+		{"redis.addrs", 10, 1},
+		{"discovery.static.services.user[0].port", 17, 11},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("errs = %v", errs)
+	}
+	for i, w := range want {
+		e := errs[i]
+		if e.Field != w.field || e.Line != w.line || e.Column != w.column {
+			t.Errorf("errs[%d] = %s %d:%d, want %s %d:%d", i, e.Field, e.Line, e.Column, w.field, w.line, w.column)
+		}
+	}
+	if errs[1].Message != "类型错误，应为 int" {
+		t.Errorf("message = %s", errs[1].Message)
+	}
+}
+
+func TestValidateFile_Valid(t *testing.T) {
+	path := writeFile(t, "config.json", `{"app": {"name": "demo"}, "server": {"http": {"port": 8081}}}`)
+	errs, err := ValidateFile(path)
+	if err != nil || len(errs) != 0 {
+		t.Errorf("ValidateFile() = %v, %v", errs, err)
+	}
+
+	// 未出现在文件中的字段没有位置
+	path = writeFile(t, "config.yaml", "trace:\n  enabled: true\n  service_name: demo\nlog:\n  level: info\n")
+	errs, err = ValidateFile(path)
+	if err != nil || len(errs) != 1 || errs[0].Field != "trace.endpoint" || errs[0].Line != 1 {
+		t.Errorf("ValidateFile() = %v, %v", errs, err)
+	}
+}
+
+func TestValidateFile_SyntaxError(t *testing.T) {
+	path := writeFile(t, "config.yaml", "app:\n  name: [demo\n")
+	if _, err := ValidateFile(path); err == nil {
+		t.Error("语法错误应返回 error")
+	}
+	if _, err := ValidateFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("文件不存在应返回 error")
+	}
+}
@@ -14,6 +14,9 @@ type ValidationError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
 	Value   any    `json:"value,omitempty"`
+	// Line/Column 字段在配置文件中的位置，仅 ValidateFile 设置
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
 }
 
 func (e *ValidationError) Error() string {
@@ -94,6 +97,9 @@ func (v *ConfigValidator) ValidateConfig(cfg *Config) ValidationErrors {
 	// 校验 Consul 配置
 	v.validateConsul(&cfg.Consul)
 
+	// 校验服务发现配置
+	v.validateDiscovery(&cfg.Discovery)
+
 	// 校验 NATS 配置
 	v.validateNATS(&cfg.NATS)
 
@@ -334,6 +340,62 @@ func (v *ConfigValidator) validateConsul(cfg *ConsulConfig) {
 			})
 		}
 	}
+
+	switch cfg.Service.CheckMode {
+	case "", "pull", "ttl":
+	default:
+		v.errors = append(v.errors, &ValidationError{
+			Field:   "consul.service.check_mode",
+			Message: "检查模式必须是 pull 或 ttl",
+			Value:   cfg.Service.CheckMode,
+		})
+	}
+
+	if cfg.Service.TTL != "" {
+		if _, err := time.ParseDuration(cfg.Service.TTL); err != nil {
+			v.errors = append(v.errors, &ValidationError{
+				Field:   "consul.service.ttl",
+				Message: "无效的时间间隔格式",
+				Value:   cfg.Service.TTL,
+			})
+		}
+	}
+}
+
+// validateDiscovery 校验服务发现配置
+func (v *ConfigValidator) validateDiscovery(cfg *DiscoveryConfig) {
+	switch cfg.Type {
+	case "", "consul":
+	case "etcd":
+		if len(cfg.Etcd.Endpoints) == 0 {
+			v.errors = append(v.errors, &ValidationError{
+				Field:   "discovery.etcd.endpoints",
+				Message: "etcd 地址不能为空",
+			})
+		}
+	case "dns":
+		if cfg.DNS.Mode != "" && cfg.DNS.Mode != "srv" && cfg.DNS.Mode != "a" {
+			v.errors = append(v.errors, &ValidationError{
+				Field:   "discovery.dns.mode",
+				Message: "DNS 查询方式必须是 srv 或 a",
+				Value:   cfg.DNS.Mode,
+			})
+		}
+		if cfg.DNS.Mode == "a" && (cfg.DNS.Port <= 0 || cfg.DNS.Port > 65535) {
+			v.errors = append(v.errors, &ValidationError{
+				Field:   "discovery.dns.port",
+				Message: "A 记录查询需要指定 1-65535 范围内的端口",
+				Value:   cfg.DNS.Port,
+			})
+		}
+	case "static":
+	default:
+		v.errors = append(v.errors, &ValidationError{
+			Field:   "discovery.type",
+			Message: "服务发现类型必须是 consul、etcd、dns 或 static",
+			Value:   cfg.Type,
+		})
+	}
 }
 
 // validateNATS 校验NATS配置
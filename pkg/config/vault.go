@@ -0,0 +1,148 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// VaultSecretProvider 从 Vault（或兼容 KV 接口的存储）读取密钥
+//
+// 引用格式为 ${vault:<mount>/<path>#<key>}，如 ${vault:secret/db#password}。
+// KV v2 时请求 GET /v1/<mount>/data/<path>，取 data.data.<key>；KV v1 时请求 GET /v1/<mount>/<path>，取 data.<key>。
+type VaultSecretProvider struct {
+	address   string
+	token     string
+	namespace string
+	kvVersion int
+	client    *http.Client
+}
+
+// VaultOption Vault 密钥提供者选项
+type VaultOption func(*VaultSecretProvider)
+
+// WithVaultAddress 设置 Vault 地址，如 https://vault.example.com:8200
+func WithVaultAddress(addr string) VaultOption {
+	return func(p *VaultSecretProvider) {
+		p.address = strings.TrimRight(addr, "/")
+	}
+}
+
+// WithVaultToken 设置访问令牌
+func WithVaultToken(token string) VaultOption {
+	return func(p *VaultSecretProvider) {
+		p.token = token
+	}
+}
+
+// WithVaultNamespace 设置命名空间
+func WithVaultNamespace(namespace string) VaultOption {
+	return func(p *VaultSecretProvider) {
+		p.namespace = namespace
+	}
+}
+
+// WithVaultKVVersion 设置 KV 引擎版本（1 或 2，默认 2）
+func WithVaultKVVersion(version int) VaultOption {
+	return func(p *VaultSecretProvider) {
+		p.kvVersion = version
+	}
+}
+
+// WithVaultHTTPClient 设置 HTTP 客户端
+func WithVaultHTTPClient(client *http.Client) VaultOption {
+	return func(p *VaultSecretProvider) {
+		p.client = client
+	}
+}
+
+// NewVaultSecretProvider 创建 Vault 密钥提供者，默认读取 VAULT_ADDR、VAULT_TOKEN、VAULT_NAMESPACE
+func NewVaultSecretProvider(opts ...VaultOption) *VaultSecretProvider {
+	p := &VaultSecretProvider{
+		address:   strings.TrimRight(os.Getenv("VAULT_ADDR"), "/"),
+		token:     os.Getenv("VAULT_TOKEN"),
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+		kvVersion: 2,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Resolve 实现 SecretProvider
+func (p *VaultSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	if p.address == "" {
+		return "", fmt.Errorf("未设置 Vault 地址")
+	}
+
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("引用格式应为 <path>#<key>")
+	}
+
+	data, err := p.read(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("密钥 %s 不存在", key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// read 读取路径下的全部键值
+func (p *VaultSecretProvider) read(ctx context.Context, path string) (map[string]any, error) {
+	path = strings.Trim(path, "/")
+	if p.kvVersion == 2 {
+		mount, rest, _ := strings.Cut(path, "/")
+		if !strings.HasPrefix(rest, "data/") {
+			rest = "data/" + rest
+		}
+		path = mount + "/" + rest
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.address+"/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		req.Header.Set("X-Vault-Token", p.token)
+	}
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("读取 %s 失败: HTTP %d", path, resp.StatusCode)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if p.kvVersion == 2 {
+		data, _ := body.Data["data"].(map[string]any)
+		return data, nil
+	}
+	return body.Data, nil
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/goupter/goupter/pkg/config"
@@ -12,9 +13,10 @@ import (
 
 // SlowQueryLogger 慢查询日志记录器
 type SlowQueryLogger struct {
-	log       log.Logger
-	level     logger.LogLevel
-	threshold time.Duration
+	log   log.Logger
+	level logger.LogLevel
+	// 慢查询阈值（纳秒），LogMode 派生的实例共享同一阈值
+	threshold *atomic.Int64
 }
 
 // NewSlowQueryLogger 创建慢查询日志记录器
@@ -38,11 +40,26 @@ func NewSlowQueryLogger(l log.Logger, cfg *config.DatabaseConfig) *SlowQueryLogg
 		threshold = 200 * time.Millisecond
 	}
 
-	return &SlowQueryLogger{
+	sl := &SlowQueryLogger{
 		log:       l,
 		level:     logLevel,
-		threshold: threshold,
+		threshold: new(atomic.Int64),
 	}
+	sl.threshold.Store(int64(threshold))
+	return sl
+}
+
+// Threshold 获取慢查询阈值
+func (l *SlowQueryLogger) Threshold() time.Duration {
+	return time.Duration(l.threshold.Load())
+}
+
+// SetThreshold 更新慢查询阈值，可在运行时调用
+func (l *SlowQueryLogger) SetThreshold(threshold time.Duration) {
+	if threshold <= 0 {
+		threshold = 200 * time.Millisecond
+	}
+	l.threshold.Store(int64(threshold))
 }
 
 // LogMode 实现 gorm logger.Interface
@@ -96,7 +113,7 @@ func (l *SlowQueryLogger) Trace(ctx context.Context, begin time.Time, fc func()
 	case err != nil && l.level >= logger.Error:
 		fields = append(fields, log.Error(err))
 		l.log.Error("SQL error", fields...)
-	case elapsed >= l.threshold && l.level >= logger.Warn:
+	case elapsed >= l.Threshold() && l.level >= logger.Warn:
 		l.log.Warn("SQL slow query", fields...)
 	case l.level >= logger.Info:
 		l.log.Debug("SQL trace", fields...)
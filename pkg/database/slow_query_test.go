@@ -62,8 +62,8 @@ func TestNewSlowQueryLogger_DefaultThreshold(t *testing.T) {
 	}
 
 	sqLogger := NewSlowQueryLogger(mockLog, cfg)
-	if sqLogger.threshold != 200*time.Millisecond {
-		t.Errorf("Default threshold = %v, want 200ms", sqLogger.threshold)
+	if sqLogger.Threshold() != 200*time.Millisecond {
+		t.Errorf("Default threshold = %v, want 200ms", sqLogger.Threshold())
 	}
 }
 
@@ -78,6 +78,22 @@ func TestSlowQueryLogger_LogMode(t *testing.T) {
 	}
 }
 
+func TestSlowQueryLogger_SetThreshold(t *testing.T) {
+	sqLogger := NewSlowQueryLogger(&mockLogger{}, &config.DatabaseConfig{})
+	derived := sqLogger.LogMode(4).(*SlowQueryLogger)
+
+	// LogMode 派生的实例共享阈值
+	sqLogger.SetThreshold(time.Second)
+	if derived.Threshold() != time.Second {
+		t.Errorf("derived threshold = %v, want 1s", derived.Threshold())
+	}
+
+	sqLogger.SetThreshold(0)
+	if sqLogger.Threshold() != 200*time.Millisecond {
+		t.Errorf("threshold = %v, want 200ms", sqLogger.Threshold())
+	}
+}
+
 func TestSlowQueryLogger_LogMethods(t *testing.T) {
 	mockLog := &mockLogger{}
 	cfg := &config.DatabaseConfig{LogLevel: "info"}
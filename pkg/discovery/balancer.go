@@ -0,0 +1,397 @@
+package discovery
+
+import (
+	"context"
+	"hash/crc32"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DoneFunc 请求完成回调，err 为 nil 表示调用成功
+type DoneFunc func(err error)
+
+// Balancer 支持上下文与完成回调的负载均衡器
+//
+// 与 LoadBalancer 相比，Balancer 可以从 ctx 读取路由键（一致性哈希），
+// 并通过 DoneFunc 统计在途请求与延迟（最少请求、P2C）。
+type Balancer interface {
+	Pick(ctx context.Context, services []*ServiceInfo) (*ServiceInfo, DoneFunc)
+}
+
+// BalancerFactory 负载均衡器工厂，每个服务名持有独立的均衡器实例
+type BalancerFactory func() Balancer
+
+// 负载均衡策略名称
+const (
+	BalancerRoundRobin         = "round_robin"
+	BalancerWeightedRoundRobin = "weighted_round_robin"
+	BalancerRandom             = "random"
+	BalancerLeastRequest       = "least_request"
+	BalancerConsistentHash     = "consistent_hash"
+	BalancerP2C                = "p2c"
+)
+
+// NewBalancerFactory 根据策略名称创建均衡器工厂，未知名称返回 false
+func NewBalancerFactory(name string) (BalancerFactory, bool) {
+	switch name {
+	case "", BalancerRoundRobin:
+		return func() Balancer { return AdaptBalancer(NewRoundRobinBalancer()) }, true
+	case BalancerWeightedRoundRobin:
+		return func() Balancer { return NewWeightedRoundRobinBalancer() }, true
+	case BalancerRandom:
+		return func() Balancer { return NewRandomBalancer() }, true
+	case BalancerLeastRequest:
+		return func() Balancer { return NewLeastRequestBalancer() }, true
+	case BalancerConsistentHash:
+		return func() Balancer { return NewConsistentHashBalancer(0) }, true
+	case BalancerP2C:
+		return func() Balancer { return NewP2CBalancer() }, true
+	default:
+		return nil, false
+	}
+}
+
+func noopDone(error) {}
+
+// AdaptBalancer 将 LoadBalancer 适配为 Balancer
+func AdaptBalancer(lb LoadBalancer) Balancer {
+	if b, ok := lb.(Balancer); ok {
+		return b
+	}
+	return &balancerAdapter{lb: lb}
+}
+
+type balancerAdapter struct {
+	lb LoadBalancer
+}
+
+func (a *balancerAdapter) Pick(_ context.Context, services []*ServiceInfo) (*ServiceInfo, DoneFunc) {
+	return a.lb.Select(services), noopDone
+}
+
+// weightOf 返回实例权重，未设置时视为 1
+func weightOf(s *ServiceInfo) int {
+	if s.Weight <= 0 {
+		return 1
+	}
+	return s.Weight
+}
+
+// ==================== 加权轮询 ====================
+
+// WeightedRoundRobinBalancer 平滑加权轮询负载均衡器（Nginx 算法），使用 ServiceInfo.Weight
+type WeightedRoundRobinBalancer struct {
+	mu      sync.Mutex
+	current map[string]int
+}
+
+// NewWeightedRoundRobinBalancer 创建平滑加权轮询负载均衡器
+func NewWeightedRoundRobinBalancer() *WeightedRoundRobinBalancer {
+	return &WeightedRoundRobinBalancer{current: make(map[string]int)}
+}
+
+// Select 按权重选择实例
+func (b *WeightedRoundRobinBalancer) Select(services []*ServiceInfo) *ServiceInfo {
+	if len(services) == 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 实例列表变化后清理已下线实例的状态
+	if len(b.current) > len(services) {
+		alive := make(map[string]struct{}, len(services))
+		for _, s := range services {
+			alive[s.ID] = struct{}{}
+		}
+		for id := range b.current {
+			if _, ok := alive[id]; !ok {
+				delete(b.current, id)
+			}
+		}
+	}
+
+	var best *ServiceInfo
+	total := 0
+	for _, s := range services {
+		w := weightOf(s)
+		total += w
+		b.current[s.ID] += w
+		if best == nil || b.current[s.ID] > b.current[best.ID] {
+			best = s
+		}
+	}
+	b.current[best.ID] -= total
+	return best
+}
+
+// Pick 实现 Balancer
+func (b *WeightedRoundRobinBalancer) Pick(_ context.Context, services []*ServiceInfo) (*ServiceInfo, DoneFunc) {
+	return b.Select(services), noopDone
+}
+
+// ==================== 随机 ====================
+
+// RandomBalancer 加权随机负载均衡器
+type RandomBalancer struct{}
+
+// NewRandomBalancer 创建加权随机负载均衡器
+func NewRandomBalancer() *RandomBalancer {
+	return &RandomBalancer{}
+}
+
+// Select 按权重随机选择实例
+func (b *RandomBalancer) Select(services []*ServiceInfo) *ServiceInfo {
+	if len(services) == 0 {
+		return nil
+	}
+
+	total := 0
+	for _, s := range services {
+		total += weightOf(s)
+	}
+	n := rand.IntN(total)
+	for _, s := range services {
+		n -= weightOf(s)
+		if n < 0 {
+			return s
+		}
+	}
+	return services[len(services)-1]
+}
+
+// Pick 实现 Balancer
+func (b *RandomBalancer) Pick(_ context.Context, services []*ServiceInfo) (*ServiceInfo, DoneFunc) {
+	return b.Select(services), noopDone
+}
+
+// ==================== 最少请求 ====================
+
+// instanceLoad 实例负载统计
+type instanceLoad struct {
+	inflight int64
+	// latency 以纳秒记录的 EWMA 延迟
+	latency int64
+}
+
+// loadTracker 按实例 ID 记录在途请求与延迟
+type loadTracker struct {
+	mu    sync.Mutex
+	loads map[string]*instanceLoad
+}
+
+func (t *loadTracker) get(id string) *instanceLoad {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.loads == nil {
+		t.loads = make(map[string]*instanceLoad)
+	}
+	l, ok := t.loads[id]
+	if !ok {
+		l = &instanceLoad{}
+		t.loads[id] = l
+	}
+	return l
+}
+
+// begin 记录请求开始，返回完成回调
+func (t *loadTracker) begin(s *ServiceInfo) DoneFunc {
+	l := t.get(s.ID)
+	atomic.AddInt64(&l.inflight, 1)
+	start := time.Now()
+	var once sync.Once
+	return func(error) {
+		once.Do(func() {
+			atomic.AddInt64(&l.inflight, -1)
+			elapsed := int64(time.Since(start))
+			for {
+				old := atomic.LoadInt64(&l.latency)
+				next := elapsed
+				if old > 0 {
+					// 衰减系数 0.3
+					next = old + (elapsed-old)*3/10
+				}
+				if atomic.CompareAndSwapInt64(&l.latency, old, next) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// LeastRequestBalancer 最少在途请求负载均衡器
+type LeastRequestBalancer struct {
+	tracker loadTracker
+}
+
+// NewLeastRequestBalancer 创建最少请求负载均衡器
+func NewLeastRequestBalancer() *LeastRequestBalancer {
+	return &LeastRequestBalancer{}
+}
+
+// Pick 选择在途请求数 / 权重最小的实例
+func (b *LeastRequestBalancer) Pick(_ context.Context, services []*ServiceInfo) (*ServiceInfo, DoneFunc) {
+	if len(services) == 0 {
+		return nil, noopDone
+	}
+
+	// 随机起点，避免并列时总是选中第一个实例
+	offset := rand.IntN(len(services))
+	var best *ServiceInfo
+	bestScore := math.MaxFloat64
+	for i := range services {
+		s := services[(offset+i)%len(services)]
+		inflight := atomic.LoadInt64(&b.tracker.get(s.ID).inflight)
+		score := float64(inflight+1) / float64(weightOf(s))
+		if score < bestScore {
+			best, bestScore = s, score
+		}
+	}
+	return best, b.tracker.begin(best)
+}
+
+// ==================== 一致性哈希 ====================
+
+type hashKeyContextKey struct{}
+
+// WithHashKey 设置一致性哈希路由键
+func WithHashKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKeyContextKey{}, key)
+}
+
+// HashKeyFromContext 获取一致性哈希路由键
+func HashKeyFromContext(ctx context.Context) string {
+	if key, ok := ctx.Value(hashKeyContextKey{}).(string); ok {
+		return key
+	}
+	return ""
+}
+
+// ConsistentHashBalancer 一致性哈希负载均衡器，路由键通过 WithHashKey 传入
+type ConsistentHashBalancer struct {
+	replicas int
+	fallback *RandomBalancer
+
+	mu        sync.Mutex
+	signature string
+	hashes    []uint32
+	ring      map[uint32]*ServiceInfo
+}
+
+// NewConsistentHashBalancer 创建一致性哈希负载均衡器，replicas 为每单位权重的虚拟节点数
+func NewConsistentHashBalancer(replicas int) *ConsistentHashBalancer {
+	if replicas <= 0 {
+		replicas = 100
+	}
+	return &ConsistentHashBalancer{replicas: replicas, fallback: NewRandomBalancer()}
+}
+
+// Pick 根据 ctx 中的路由键选择实例，未设置路由键时随机选择
+func (b *ConsistentHashBalancer) Pick(ctx context.Context, services []*ServiceInfo) (*ServiceInfo, DoneFunc) {
+	key := HashKeyFromContext(ctx)
+	if key == "" || len(services) == 0 {
+		return b.fallback.Pick(ctx, services)
+	}
+	return b.SelectKey(services, key), noopDone
+}
+
+// SelectKey 根据路由键选择实例
+func (b *ConsistentHashBalancer) SelectKey(services []*ServiceInfo, key string) *ServiceInfo {
+	if len(services) == 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rebuild(services)
+	h := crc32.ChecksumIEEE([]byte(key))
+	idx := sort.Search(len(b.hashes), func(i int) bool { return b.hashes[i] >= h })
+	if idx == len(b.hashes) {
+		idx = 0
+	}
+	return b.ring[b.hashes[idx]]
+}
+
+// rebuild 实例集合变化时重建哈希环
+func (b *ConsistentHashBalancer) rebuild(services []*ServiceInfo) {
+	ids := make([]string, 0, len(services))
+	for _, s := range services {
+		ids = append(ids, s.ID+"@"+s.Addr()+"/"+strconv.Itoa(weightOf(s)))
+	}
+	sort.Strings(ids)
+	signature := strings.Join(ids, ",")
+	if signature == b.signature {
+		return
+	}
+
+	b.signature = signature
+	b.ring = make(map[uint32]*ServiceInfo)
+	b.hashes = b.hashes[:0]
+	for _, s := range services {
+		n := b.replicas * weightOf(s)
+		for i := 0; i < n; i++ {
+			h := crc32.ChecksumIEEE([]byte(s.ID + "#" + strconv.Itoa(i)))
+			if _, exists := b.ring[h]; exists {
+				continue
+			}
+			b.ring[h] = s
+			b.hashes = append(b.hashes, h)
+		}
+	}
+	sort.Slice(b.hashes, func(i, j int) bool { return b.hashes[i] < b.hashes[j] })
+}
+
+// ==================== P2C ====================
+
+// P2CBalancer Power of Two Choices 负载均衡器
+//
+// 随机选取两个实例，比较 (在途请求+1) × EWMA 延迟 / 权重，选择负载较低者。
+type P2CBalancer struct {
+	tracker loadTracker
+}
+
+// NewP2CBalancer 创建 P2C 负载均衡器
+func NewP2CBalancer() *P2CBalancer {
+	return &P2CBalancer{}
+}
+
+// Pick 实现 Balancer
+func (b *P2CBalancer) Pick(_ context.Context, services []*ServiceInfo) (*ServiceInfo, DoneFunc) {
+	switch len(services) {
+	case 0:
+		return nil, noopDone
+	case 1:
+		return services[0], b.tracker.begin(services[0])
+	}
+
+	i := rand.IntN(len(services))
+	j := rand.IntN(len(services) - 1)
+	if j >= i {
+		j++
+	}
+	a, c := services[i], services[j]
+	la, lc := b.tracker.get(a.ID), b.tracker.get(c.ID)
+	// 尚无延迟样本的实例按两者中较大的延迟估算，避免新实例被过度偏好或冷落
+	fallback := max(atomic.LoadInt64(&la.latency), atomic.LoadInt64(&lc.latency), 1)
+	if p2cScore(c, lc, fallback) < p2cScore(a, la, fallback) {
+		a = c
+	}
+	return a, b.tracker.begin(a)
+}
+
+func p2cScore(s *ServiceInfo, l *instanceLoad, fallback int64) float64 {
+	latency := atomic.LoadInt64(&l.latency)
+	if latency <= 0 {
+		latency = fallback
+	}
+	inflight := atomic.LoadInt64(&l.inflight)
+	return float64(inflight+1) * float64(latency) / float64(weightOf(s))
+}
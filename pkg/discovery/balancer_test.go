@@ -0,0 +1,185 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func testInstances() []*ServiceInfo {
+	return []*ServiceInfo{
+		{ID: "a", Address: "10.0.0.1", Port: 80, Weight: 5},
+		{ID: "b", Address: "10.0.0.2", Port: 80, Weight: 1},
+		{ID: "c", Address: "10.0.0.3", Port: 80, Weight: 1},
+	}
+}
+
+func TestWeightedRoundRobinBalancer(t *testing.T) {
+	b := NewWeightedRoundRobinBalancer()
+	services := testInstances()
+
+	counts := make(map[string]int)
+	for i := 0; i < 70; i++ {
+		counts[b.Select(services).ID]++
+	}
+
+	if counts["a"] != 50 || counts["b"] != 10 || counts["c"] != 10 {
+		t.Errorf("分布不符合权重: %v", counts)
+	}
+
+	// 平滑性：连续 7 次中 a 不应连续出现 5 次
+	seq := ""
+	for i := 0; i < 7; i++ {
+		seq += b.Select(services).ID
+	}
+	if seq == "aaaaabc" {
+		t.Errorf("加权轮询不平滑: %s", seq)
+	}
+}
+
+func TestRandomBalancer(t *testing.T) {
+	b := NewRandomBalancer()
+	if b.Select(nil) != nil {
+		t.Error("空列表应返回 nil")
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 7000; i++ {
+		counts[b.Select(testInstances()).ID]++
+	}
+	if counts["a"] < counts["b"]*3 {
+		t.Errorf("加权随机分布异常: %v", counts)
+	}
+}
+
+func TestLeastRequestBalancer(t *testing.T) {
+	b := NewLeastRequestBalancer()
+	services := []*ServiceInfo{{ID: "a"}, {ID: "b"}}
+
+	first, done1 := b.Pick(context.Background(), services)
+	second, done2 := b.Pick(context.Background(), services)
+	if first.ID == second.ID {
+		t.Errorf("第二次应选择空闲实例, got %s twice", first.ID)
+	}
+
+	done1(nil)
+	third, done3 := b.Pick(context.Background(), services)
+	if third.ID != first.ID {
+		t.Errorf("应选择已释放的实例 %s, got %s", first.ID, third.ID)
+	}
+	done2(nil)
+	done3(nil)
+}
+
+func TestConsistentHashBalancer(t *testing.T) {
+	b := NewConsistentHashBalancer(50)
+	services := testInstances()
+
+	ctx := WithHashKey(context.Background(), "user-42")
+	first, _ := b.Pick(ctx, services)
+	for i := 0; i < 10; i++ {
+		s, _ := b.Pick(ctx, services)
+		if s.ID != first.ID {
+			t.Fatalf("相同路由键应命中相同实例: %s != %s", s.ID, first.ID)
+		}
+	}
+
+	// 移除其他实例后，原实例上的键保持不变
+	var remaining []*ServiceInfo
+	for _, s := range services {
+		if s.ID == first.ID || s.ID == "a" {
+			remaining = append(remaining, s)
+		}
+	}
+	if s, _ := b.Pick(ctx, remaining); s.ID != first.ID {
+		t.Errorf("实例下线后路由键不应迁移: %s != %s", s.ID, first.ID)
+	}
+
+	if s, _ := b.Pick(context.Background(), services); s == nil {
+		t.Error("未设置路由键时应回退到随机选择")
+	}
+}
+
+func TestP2CBalancer(t *testing.T) {
+	b := NewP2CBalancer()
+	services := []*ServiceInfo{{ID: "a"}, {ID: "b"}}
+
+	// 让 a 持有大量在途请求
+	for i := 0; i < 10; i++ {
+		b.tracker.begin(services[0])
+	}
+
+	for i := 0; i < 20; i++ {
+		s, done := b.Pick(context.Background(), services)
+		if s.ID != "b" {
+			t.Fatalf("应选择负载较低的 b, got %s", s.ID)
+		}
+		done(nil)
+	}
+}
+
+func TestNewBalancerFactory(t *testing.T) {
+	for _, name := range []string{"", BalancerRoundRobin, BalancerWeightedRoundRobin, BalancerRandom,
+		BalancerLeastRequest, BalancerConsistentHash, BalancerP2C} {
+		factory, ok := NewBalancerFactory(name)
+		if !ok {
+			t.Errorf("策略 %q 应存在", name)
+			continue
+		}
+		if s, done := factory().Pick(context.Background(), testInstances()); s == nil || done == nil {
+			t.Errorf("策略 %q 未返回实例", name)
+		}
+	}
+
+	if _, ok := NewBalancerFactory("unknown"); ok {
+		t.Error("未知策略应返回 false")
+	}
+}
+
+func TestOutlierDetector(t *testing.T) {
+	now := time.Now()
+	d := NewOutlierDetector(&OutlierConfig{
+		ConsecutiveErrors:  3,
+		BaseEjectionTime:   10 * time.Second,
+		MaxEjectionTime:    time.Minute,
+		MaxEjectionPercent: 50,
+	})
+	d.now = func() time.Time { return now }
+
+	services := []*ServiceInfo{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	errFail := context.DeadlineExceeded
+
+	d.Report("a", errFail)
+	d.Report("a", errFail)
+	d.Report("a", nil)
+	d.Report("a", errFail)
+	if d.IsEjected("a") {
+		t.Error("成功调用应重置连续失败计数")
+	}
+
+	for i := 0; i < 3; i++ {
+		d.Report("a", errFail)
+	}
+	if !d.IsEjected("a") {
+		t.Fatal("连续失败后应摘除")
+	}
+	if got := d.Filter(services); len(got) != 3 {
+		t.Errorf("Filter() = %d instances, want 3", len(got))
+	}
+
+	// 超过 MaxEjectionPercent 时放回较早摘除的实例
+	now = now.Add(time.Second)
+	for _, id := range []string{"b", "c"} {
+		for i := 0; i < 3; i++ {
+			d.Report(id, errFail)
+		}
+	}
+	if got := d.Filter(services); len(got) != 2 {
+		t.Errorf("Filter() = %d instances, want 2", len(got))
+	}
+
+	now = now.Add(11 * time.Second)
+	if d.IsEjected("a") {
+		t.Error("摘除时间到期后应恢复")
+	}
+}
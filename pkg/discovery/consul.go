@@ -4,19 +4,38 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/goupter/goupter/pkg/config"
 	"github.com/goupter/goupter/pkg/log"
 	"github.com/hashicorp/consul/api"
 )
 
+// Watch 出错重试的默认退避时间
+const (
+	defaultWatchBackoff    = time.Second
+	defaultMaxWatchBackoff = 30 * time.Second
+)
+
 // ConsulDiscovery Consul服务发现
+//
+// 支持两种健康检查模式：默认由 Consul 主动探测 HTTP/gRPC 端点；
+// 配置 consul.service.check_mode=ttl 时改为 TTL 检查，由服务按 TTL/3 的周期主动上报心跳，
+// 适用于服务位于 NAT 之后等 Consul 无法访问的网络。
+// GetService 与 Watch 成功的结果会作为快照保存，Consul 不可用时 GetService 返回最近一次的快照。
 type ConsulDiscovery struct {
 	client   *api.Client
 	config   *config.ConsulConfig
 	logger   log.Logger
-	watchers map[string]context.CancelFunc
-	mu       sync.RWMutex
+	reporter HealthReporter
+	watches  watchGroup
+	// 心跳协程，按服务ID
+	heartbeats map[string]context.CancelFunc
+	// 最近一次成功获取的实例列表，按服务名
+	snapshots       map[string][]*ServiceInfo
+	watchBackoff    time.Duration
+	maxWatchBackoff time.Duration
+	mu              sync.RWMutex
 }
 
 // ConsulOption Consul选项
@@ -32,11 +51,27 @@ func WithConsulLogger(logger log.Logger) ConsulOption {
 	return func(d *ConsulDiscovery) { d.logger = logger }
 }
 
+// WithHealthReporter 设置 TTL 心跳上报的健康状态来源
+func WithHealthReporter(reporter HealthReporter) ConsulOption {
+	return func(d *ConsulDiscovery) { d.reporter = reporter }
+}
+
+// WithWatchBackoff 设置 Watch 出错后的重试退避时间（指数增长，最大 max）
+func WithWatchBackoff(initial, max time.Duration) ConsulOption {
+	return func(d *ConsulDiscovery) {
+		d.watchBackoff = initial
+		d.maxWatchBackoff = max
+	}
+}
+
 // NewConsulDiscovery 创建Consul服务发现
 func NewConsulDiscovery(opts ...ConsulOption) (*ConsulDiscovery, error) {
 	d := &ConsulDiscovery{
-		config:   &config.ConsulConfig{Host: "localhost", Port: 8500},
-		watchers: make(map[string]context.CancelFunc),
+		config:          &config.ConsulConfig{Host: "localhost", Port: 8500},
+		heartbeats:      make(map[string]context.CancelFunc),
+		snapshots:       make(map[string][]*ServiceInfo),
+		watchBackoff:    defaultWatchBackoff,
+		maxWatchBackoff: defaultMaxWatchBackoff,
 	}
 
 	for _, opt := range opts {
@@ -57,12 +92,43 @@ func NewConsulDiscovery(opts ...ConsulOption) (*ConsulDiscovery, error) {
 		return nil, fmt.Errorf("create consul client failed: %w", err)
 	}
 
+	if d.watchBackoff <= 0 {
+		d.watchBackoff = defaultWatchBackoff
+	}
+	if d.maxWatchBackoff < d.watchBackoff {
+		d.maxWatchBackoff = d.watchBackoff
+	}
+
 	d.client = client
 	return d, nil
 }
 
+// SetHealthReporter 设置 TTL 心跳上报的健康状态来源
+func (d *ConsulDiscovery) SetHealthReporter(reporter HealthReporter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reporter = reporter
+}
+
 // Register 注册服务
 func (d *ConsulDiscovery) Register(ctx context.Context, service *ServiceInfo) error {
+	registration := d.buildRegistration(service)
+	if err := d.client.Agent().ServiceRegister(registration); err != nil {
+		return fmt.Errorf("register service failed: %w", err)
+	}
+	d.startHeartbeat(registration)
+
+	if d.logger != nil {
+		d.logger.Info("service registered",
+			log.String("id", service.ID),
+			log.String("name", service.Name),
+		)
+	}
+	return nil
+}
+
+// buildRegistration 构建 Consul 注册信息
+func (d *ConsulDiscovery) buildRegistration(service *ServiceInfo) *api.AgentServiceRegistration {
 	registration := &api.AgentServiceRegistration{
 		ID:      service.ID,
 		Name:    service.Name,
@@ -71,36 +137,175 @@ func (d *ConsulDiscovery) Register(ctx context.Context, service *ServiceInfo) er
 		Tags:    service.Tags,
 		Meta:    service.Metadata,
 	}
+	if service.Weight > 0 {
+		registration.Weights = &api.AgentWeights{Passing: service.Weight, Warning: 1}
+	}
 
-	if d.config.Service.CheckInterval != "" {
-		check := &api.AgentServiceCheck{
-			Interval:                       d.config.Service.CheckInterval,
-			Timeout:                        d.config.Service.CheckTimeout,
-			DeregisterCriticalServiceAfter: d.config.Service.DeregisterCriticalServiceAfter,
+	checks := service.Checks
+	if d.config.Service.CheckMode == CheckTypeTTL {
+		// 心跳模式：以单个 TTL 检查替代 Consul 主动探测
+		checks = []*HealthCheck{{Type: CheckTypeTTL}}
+	}
+
+	// 显式声明的检查：每个协议一项
+	if len(checks) > 0 {
+		for i, hc := range checks {
+			check := d.newCheck(hc.Interval, hc.Timeout, hc.DeregisterCriticalServiceAfter)
+			switch hc.Type {
+			case CheckTypeTTL:
+				check.CheckID = ttlCheckID(service.ID, i)
+				check.TTL = d.checkTTL(hc.TTL)
+				check.Interval = ""
+				check.Timeout = ""
+			case CheckTypeGRPC:
+				check.GRPC = hc.Endpoint
+			default:
+				check.HTTP = hc.Endpoint
+			}
+			check.Name = fmt.Sprintf("%s %s check", service.Name, hc.Type)
+			registration.Checks = append(registration.Checks, check)
 		}
+		return registration
+	}
+
+	if d.config.Service.CheckInterval != "" {
+		check := d.newCheck("", "", "")
 		if grpcAddr, ok := service.Metadata["grpc_addr"]; ok {
 			check.GRPC = grpcAddr
 		} else {
-			check.HTTP = fmt.Sprintf("http://%s:%d/health", service.Address, service.Port)
+			check.HTTP = fmt.Sprintf("http://%s/health", service.Addr())
 		}
 		registration.Check = check
 	}
+	return registration
+}
 
-	if err := d.client.Agent().ServiceRegister(registration); err != nil {
-		return fmt.Errorf("register service failed: %w", err)
+// newCheck 创建检查项，未指定的参数使用 Consul 服务配置
+func (d *ConsulDiscovery) newCheck(interval, timeout, deregister string) *api.AgentServiceCheck {
+	if interval == "" {
+		interval = d.config.Service.CheckInterval
+	}
+	if interval == "" {
+		interval = "10s"
+	}
+	if timeout == "" {
+		timeout = d.config.Service.CheckTimeout
 	}
+	if deregister == "" {
+		deregister = d.config.Service.DeregisterCriticalServiceAfter
+	}
+	return &api.AgentServiceCheck{
+		Interval:                       interval,
+		Timeout:                        timeout,
+		DeregisterCriticalServiceAfter: deregister,
+	}
+}
 
-	if d.logger != nil {
-		d.logger.Info("service registered",
-			log.String("id", service.ID),
-			log.String("name", service.Name),
-		)
+// checkTTL 返回 TTL 检查的存活时间
+func (d *ConsulDiscovery) checkTTL(ttl string) string {
+	if ttl == "" {
+		ttl = d.config.Service.TTL
+	}
+	if ttl == "" {
+		ttl = "15s"
+	}
+	return ttl
+}
+
+func ttlCheckID(serviceID string, index int) string {
+	return fmt.Sprintf("service:%s:ttl:%d", serviceID, index)
+}
+
+// startHeartbeat 为注册信息中的 TTL 检查启动心跳协程
+func (d *ConsulDiscovery) startHeartbeat(registration *api.AgentServiceRegistration) {
+	var (
+		checkIDs []string
+		interval time.Duration
+	)
+	for _, check := range registration.Checks {
+		if check.TTL == "" {
+			continue
+		}
+		ttl, err := time.ParseDuration(check.TTL)
+		if err != nil || ttl <= 0 {
+			continue
+		}
+		checkIDs = append(checkIDs, check.CheckID)
+		if interval == 0 || ttl/3 < interval {
+			interval = ttl / 3
+		}
+	}
+
+	d.stopHeartbeat(registration.ID)
+	if len(checkIDs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.mu.Lock()
+	d.heartbeats[registration.ID] = cancel
+	d.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			d.heartbeat(ctx, registration, checkIDs, interval)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopHeartbeat 停止服务的心跳协程
+func (d *ConsulDiscovery) stopHeartbeat(serviceID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if cancel, ok := d.heartbeats[serviceID]; ok {
+		cancel()
+		delete(d.heartbeats, serviceID)
+	}
+}
+
+// heartbeat 上报一次心跳
+//
+// 上报失败（如 Consul Agent 重启后丢失注册信息）时重新注册服务，下个周期继续上报。
+func (d *ConsulDiscovery) heartbeat(ctx context.Context, registration *api.AgentServiceRegistration, checkIDs []string, timeout time.Duration) {
+	status, output := HealthStatusPassing, ""
+	d.mu.RLock()
+	reporter := d.reporter
+	d.mu.RUnlock()
+	if reporter != nil {
+		reportCtx, cancel := context.WithTimeout(ctx, timeout)
+		status, output = reporter(reportCtx)
+		cancel()
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	for _, checkID := range checkIDs {
+		err := d.client.Agent().UpdateTTLOpts(checkID, output, string(status), (&api.QueryOptions{}).WithContext(ctx))
+		if err == nil || ctx.Err() != nil {
+			continue
+		}
+		if d.logger != nil {
+			d.logger.Warn("update ttl check failed, re-registering",
+				log.String("id", registration.ID), log.Error(err))
+		}
+		if err := d.client.Agent().ServiceRegister(registration); err != nil && d.logger != nil {
+			d.logger.Error("re-register service failed", log.String("id", registration.ID), log.Error(err))
+		}
+		return
 	}
-	return nil
 }
 
 // Deregister 注销服务
 func (d *ConsulDiscovery) Deregister(ctx context.Context, serviceID string) error {
+	d.stopHeartbeat(serviceID)
 	if err := d.client.Agent().ServiceDeregister(serviceID); err != nil {
 		return fmt.Errorf("deregister service failed: %w", err)
 	}
@@ -111,68 +316,86 @@ func (d *ConsulDiscovery) Deregister(ctx context.Context, serviceID string) erro
 }
 
 // GetService 获取服务实例列表
+//
+// Consul 不可用时返回最近一次成功获取的快照（若存在）。
 func (d *ConsulDiscovery) GetService(ctx context.Context, name string) ([]*ServiceInfo, error) {
-	services, _, err := d.client.Health().Service(name, "", true, nil)
+	services, _, err := d.client.Health().Service(name, "", true, (&api.QueryOptions{}).WithContext(ctx))
 	if err != nil {
+		if snapshot, ok := d.snapshot(name); ok {
+			if d.logger != nil {
+				d.logger.Warn("get service failed, using last known instances",
+					log.String("service", name), log.Error(err))
+			}
+			return snapshot, nil
+		}
 		return nil, fmt.Errorf("get service failed: %w", err)
 	}
 
-	result := make([]*ServiceInfo, 0, len(services))
-	for _, s := range services {
-		result = append(result, &ServiceInfo{
-			ID:       s.Service.ID,
-			Name:     s.Service.Service,
-			Address:  s.Service.Address,
-			Port:     s.Service.Port,
-			Tags:     s.Service.Tags,
-			Metadata: s.Service.Meta,
-			Health:   getHealthStatus(s.Checks),
-		})
-	}
+	result := toServiceInfos(services)
+	d.saveSnapshot(name, result)
 	return result, nil
 }
 
+func (d *ConsulDiscovery) snapshot(name string) ([]*ServiceInfo, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	services, ok := d.snapshots[name]
+	if !ok {
+		return nil, false
+	}
+	return append([]*ServiceInfo(nil), services...), true
+}
+
+func (d *ConsulDiscovery) saveSnapshot(name string, services []*ServiceInfo) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.snapshots[name] = append([]*ServiceInfo(nil), services...)
+}
+
 // Watch 监听服务变化
 func (d *ConsulDiscovery) Watch(ctx context.Context, name string, callback func([]*ServiceInfo)) error {
-	d.mu.Lock()
-	if cancel, ok := d.watchers[name]; ok {
-		cancel()
-	}
-	watchCtx, cancel := context.WithCancel(ctx)
-	d.watchers[name] = cancel
-	d.mu.Unlock()
+	watchCtx := d.watches.start(ctx)
 
 	go func() {
 		var lastIndex uint64
+		backoff := d.watchBackoff
 		for {
 			select {
 			case <-watchCtx.Done():
 				return
 			default:
-				services, meta, err := d.client.Health().Service(name, "", true, &api.QueryOptions{
-					WaitIndex: lastIndex,
-				})
+				opts := (&api.QueryOptions{WaitIndex: lastIndex}).WithContext(watchCtx)
+				services, meta, err := d.client.Health().Service(name, "", true, opts)
 				if err != nil {
+					if watchCtx.Err() != nil {
+						return
+					}
 					if d.logger != nil {
-						d.logger.Error("watch service failed", log.String("service", name), log.Error(err))
+						d.logger.Error("watch service failed", log.String("service", name),
+							log.Duration("retry_after", backoff), log.Error(err))
+					}
+					select {
+					case <-watchCtx.Done():
+						return
+					case <-time.After(backoff):
+					}
+					backoff *= 2
+					if backoff > d.maxWatchBackoff {
+						backoff = d.maxWatchBackoff
 					}
 					continue
 				}
+				backoff = d.watchBackoff
 
+				// 索引回退（如 Consul 重建）时重新开始阻塞查询
+				if meta.LastIndex < lastIndex {
+					lastIndex = 0
+					continue
+				}
 				if meta.LastIndex > lastIndex {
 					lastIndex = meta.LastIndex
-					result := make([]*ServiceInfo, 0, len(services))
-					for _, s := range services {
-						result = append(result, &ServiceInfo{
-							ID:       s.Service.ID,
-							Name:     s.Service.Service,
-							Address:  s.Service.Address,
-							Port:     s.Service.Port,
-							Tags:     s.Service.Tags,
-							Metadata: s.Service.Meta,
-							Health:   getHealthStatus(s.Checks),
-						})
-					}
+					result := toServiceInfos(services)
+					d.saveSnapshot(name, result)
 					callback(result)
 				}
 			}
@@ -183,12 +406,14 @@ func (d *ConsulDiscovery) Watch(ctx context.Context, name string, callback func(
 
 // Close 关闭连接
 func (d *ConsulDiscovery) Close() error {
+	d.watches.stop()
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	for _, cancel := range d.watchers {
+	for _, cancel := range d.heartbeats {
 		cancel()
 	}
-	d.watchers = make(map[string]context.CancelFunc)
+	d.heartbeats = make(map[string]context.CancelFunc)
 	return nil
 }
 
@@ -197,6 +422,23 @@ func (d *ConsulDiscovery) Client() *api.Client {
 	return d.client
 }
 
+func toServiceInfos(entries []*api.ServiceEntry) []*ServiceInfo {
+	result := make([]*ServiceInfo, 0, len(entries))
+	for _, s := range entries {
+		result = append(result, &ServiceInfo{
+			ID:       s.Service.ID,
+			Name:     s.Service.Service,
+			Address:  s.Service.Address,
+			Port:     s.Service.Port,
+			Tags:     s.Service.Tags,
+			Metadata: s.Service.Meta,
+			Weight:   s.Service.Weights.Passing,
+			Health:   getHealthStatus(s.Checks),
+		})
+	}
+	return result
+}
+
 func getHealthStatus(checks api.HealthChecks) HealthStatus {
 	for _, check := range checks {
 		switch check.Status {
@@ -0,0 +1,234 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/config"
+	"github.com/hashicorp/consul/api"
+)
+
+func TestConsulDiscovery_BuildRegistration(t *testing.T) {
+	d := &ConsulDiscovery{config: &config.ConsulConfig{
+		Service: config.ConsulServiceConfig{CheckTimeout: "3s", DeregisterCriticalServiceAfter: "1m"},
+	}}
+
+	reg := d.buildRegistration(&ServiceInfo{
+		ID:       "user-1",
+		Name:     "user",
+		Address:  "10.0.0.5",
+		Port:     8080,
+		Weight:   3,
+		Metadata: map[string]string{"http_port": "8080", "grpc_port": "9090"},
+		Checks: []*HealthCheck{
+			{Type: CheckTypeHTTP, Endpoint: "http://10.0.0.5:8080/health"},
+			{Type: CheckTypeGRPC, Endpoint: "10.0.0.5:9090", Interval: "5s"},
+		},
+	})
+
+	if reg.Weights == nil || reg.Weights.Passing != 3 {
+		t.Errorf("Weights = %+v", reg.Weights)
+	}
+	if reg.Check != nil || len(reg.Checks) != 2 {
+		t.Fatalf("应为每个协议生成独立检查, Check=%v Checks=%d", reg.Check, len(reg.Checks))
+	}
+	if c := reg.Checks[0]; c.HTTP != "http://10.0.0.5:8080/health" || c.Interval != "10s" || c.Timeout != "3s" {
+		t.Errorf("http check = %+v", c)
+	}
+	if c := reg.Checks[1]; c.GRPC != "10.0.0.5:9090" || c.Interval != "5s" || c.DeregisterCriticalServiceAfter != "1m" {
+		t.Errorf("grpc check = %+v", c)
+	}
+}
+
+func TestConsulDiscovery_BuildRegistration_Legacy(t *testing.T) {
+	d := &ConsulDiscovery{config: &config.ConsulConfig{
+		Service: config.ConsulServiceConfig{CheckInterval: "15s"},
+	}}
+
+	reg := d.buildRegistration(&ServiceInfo{ID: "a", Name: "a", Address: "10.0.0.1", Port: 80})
+	if reg.Check == nil || reg.Check.HTTP != "http://10.0.0.1:80/health" || reg.Check.Interval != "15s" {
+		t.Errorf("Check = %+v", reg.Check)
+	}
+
+	d.config.Service.CheckInterval = ""
+	if reg := d.buildRegistration(&ServiceInfo{ID: "a", Name: "a"}); reg.Check != nil || len(reg.Checks) != 0 {
+		t.Error("未配置检查时不应注册检查")
+	}
+}
+
+// fakeConsul 模拟 Consul HTTP API 的部分接口
+type fakeConsul struct {
+	mu      sync.Mutex
+	down    bool
+	index   uint64
+	entries []*api.ServiceEntry
+	queries int
+	updates []string
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/v1/health/service/"):
+		f.queries++
+		if f.down {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+		_ = json.NewEncoder(w).Encode(f.entries)
+	case r.URL.Path == "/v1/agent/service/register",
+		strings.HasPrefix(r.URL.Path, "/v1/agent/service/deregister/"):
+	case strings.HasPrefix(r.URL.Path, "/v1/agent/check/update/"):
+		var body struct{ Status, Output string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.updates = append(f.updates, strings.TrimPrefix(r.URL.Path, "/v1/agent/check/update/")+" "+body.Status+" "+body.Output)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeConsul) set(fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn()
+}
+
+func newFakeConsulDiscovery(t *testing.T, f *fakeConsul, opts ...ConsulOption) *ConsulDiscovery {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	cfg := &config.ConsulConfig{Host: u.Hostname(), Port: port}
+	d, err := NewConsulDiscovery(append([]ConsulOption{WithConsulConfig(cfg)}, opts...)...)
+	if err != nil {
+		t.Fatalf("NewConsulDiscovery() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+	return d
+}
+
+func serviceEntry(id string) *api.ServiceEntry {
+	return &api.ServiceEntry{Service: &api.AgentService{ID: id, Service: "user", Address: "10.0.0.1", Port: 80}}
+}
+
+func TestConsulDiscovery_GetServiceLastKnownGood(t *testing.T) {
+	f := &fakeConsul{index: 1, entries: []*api.ServiceEntry{serviceEntry("a"), serviceEntry("b")}}
+	d := newFakeConsulDiscovery(t, f)
+
+	if _, err := d.GetService(context.Background(), "order"); err != nil {
+		t.Fatalf("GetService() error = %v", err)
+	}
+
+	f.set(func() { f.down = true })
+	services, err := d.GetService(context.Background(), "order")
+	if err != nil || len(services) != 2 {
+		t.Fatalf("Consul 不可用时应返回快照, got %d, %v", len(services), err)
+	}
+	if _, err := d.GetService(context.Background(), "unknown"); err == nil {
+		t.Error("无快照时应返回错误")
+	}
+}
+
+func TestConsulDiscovery_WatchBackoff(t *testing.T) {
+	f := &fakeConsul{down: true}
+	d := newFakeConsulDiscovery(t, f, WithWatchBackoff(20*time.Millisecond, 40*time.Millisecond))
+
+	got := make(chan []*ServiceInfo, 1)
+	if err := d.Watch(context.Background(), "user", func(s []*ServiceInfo) { got <- s }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	f.set(func() {
+		// 20 + 40 + 40 ... 退避下 150ms 内只应有少量请求
+		if f.queries > 6 {
+			t.Errorf("出错时应退避重试, queries = %d", f.queries)
+		}
+		f.down = false
+		f.index = 3
+		f.entries = []*api.ServiceEntry{serviceEntry("a")}
+	})
+
+	select {
+	case services := <-got:
+		if len(services) != 1 || services[0].ID != "a" {
+			t.Errorf("services = %v", services)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Consul 恢复后应收到实例列表")
+	}
+
+	// Watch 结果同样作为快照
+	f.set(func() { f.down = true })
+	if services, err := d.GetService(context.Background(), "user"); err != nil || len(services) != 1 {
+		t.Errorf("GetService() = %v, %v", services, err)
+	}
+}
+
+func TestConsulDiscovery_TTLHeartbeat(t *testing.T) {
+	f := &fakeConsul{}
+	d := newFakeConsulDiscovery(t, f)
+	d.config.Service.CheckMode = "ttl"
+	d.config.Service.TTL = "90ms"
+
+	var ready atomic.Bool
+	d.SetHealthReporter(func(context.Context) (HealthStatus, string) {
+		if ready.Load() {
+			return HealthStatusPassing, "ok"
+		}
+		return HealthStatusCritical, "db down"
+	})
+
+	service := &ServiceInfo{ID: "user-1", Name: "user", Address: "10.0.0.1", Port: 80,
+		Checks: []*HealthCheck{{Type: CheckTypeHTTP, Endpoint: "http://10.0.0.1/health"}}}
+	reg := d.buildRegistration(service)
+	if len(reg.Checks) != 1 || reg.Checks[0].TTL != "90ms" || reg.Checks[0].HTTP != "" || reg.Checks[0].Interval != "" {
+		t.Fatalf("ttl 模式应只注册 TTL 检查, got %+v", reg.Checks)
+	}
+
+	if err := d.Register(context.Background(), service); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	ready.Store(true)
+	time.Sleep(100 * time.Millisecond)
+
+	if err := d.Deregister(context.Background(), "user-1"); err != nil {
+		t.Fatalf("Deregister() error = %v", err)
+	}
+	f.mu.Lock()
+	updates := append([]string(nil), f.updates...)
+	f.mu.Unlock()
+
+	if len(updates) < 2 {
+		t.Fatalf("应周期上报心跳, updates = %v", updates)
+	}
+	if updates[0] != "service:user-1:ttl:0 critical db down" {
+		t.Errorf("first update = %q", updates[0])
+	}
+	if last := updates[len(updates)-1]; last != "service:user-1:ttl:0 passing ok" {
+		t.Errorf("last update = %q", last)
+	}
+
+	// 注销后停止心跳
+	time.Sleep(60 * time.Millisecond)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.updates) != len(updates) {
+		t.Error("注销后不应继续上报心跳")
+	}
+}
@@ -2,6 +2,9 @@ package discovery
 
 import (
 	"context"
+	"net"
+	"strconv"
+	"sync"
 	"sync/atomic"
 )
 
@@ -10,6 +13,7 @@ type Discovery interface {
 	Register(ctx context.Context, service *ServiceInfo) error
 	Deregister(ctx context.Context, serviceID string) error
 	GetService(ctx context.Context, name string) ([]*ServiceInfo, error)
+	// Watch 监听服务变化，同一服务可被多次监听，各订阅独立回调，ctx 取消或 Close 时停止
 	Watch(ctx context.Context, name string, callback func([]*ServiceInfo)) error
 	Close() error
 }
@@ -24,6 +28,29 @@ type ServiceInfo struct {
 	Metadata map[string]string `json:"metadata"`
 	Weight   int               `json:"weight"`
 	Health   HealthStatus      `json:"health"`
+	// Checks 注册时附带的健康检查，为空时由实现决定默认检查方式
+	Checks []*HealthCheck `json:"checks,omitempty"`
+}
+
+// Addr 返回 host:port 形式的实例地址
+func (s *ServiceInfo) Addr() string {
+	return net.JoinHostPort(s.Address, strconv.Itoa(s.Port))
+}
+
+// Endpoint 返回指定协议的访问地址
+//
+// 依次读取元数据 "<protocol>_addr"（完整地址）与 "<protocol>_port"（与 Address 组合），
+// 都不存在时返回 Addr()。
+func (s *ServiceInfo) Endpoint(protocol string) string {
+	if protocol != "" {
+		if addr := s.Metadata[protocol+"_addr"]; addr != "" {
+			return addr
+		}
+		if port := s.Metadata[protocol+"_port"]; port != "" {
+			return net.JoinHostPort(s.Address, port)
+		}
+	}
+	return s.Addr()
 }
 
 // HealthStatus 健康状态
@@ -35,13 +62,35 @@ const (
 	HealthStatusCritical HealthStatus = "critical"
 )
 
+// 健康检查类型
+const (
+	CheckTypeHTTP = "http"
+	CheckTypeGRPC = "grpc"
+	CheckTypeTTL  = "ttl"
+)
+
 // HealthCheck 健康检查配置
+//
+// Endpoint 对 http 类型为完整 URL，对 grpc 类型为 host:port[/service]，ttl 类型不使用；
+// ttl 类型由服务通过 HealthReporter 主动上报心跳，超过 TTL 未上报即视为不健康。
+// Interval、Timeout、TTL、DeregisterCriticalServiceAfter 为空时使用实现的默认值。
 type HealthCheck struct {
 	Type                           string `json:"type"`
-	Endpoint                       string `json:"endpoint"`
-	Interval                       string `json:"interval"`
-	Timeout                        string `json:"timeout"`
-	DeregisterCriticalServiceAfter string `json:"deregister"`
+	Endpoint                       string `json:"endpoint,omitempty"`
+	Interval                       string `json:"interval,omitempty"`
+	Timeout                        string `json:"timeout,omitempty"`
+	TTL                            string `json:"ttl,omitempty"`
+	DeregisterCriticalServiceAfter string `json:"deregister,omitempty"`
+}
+
+// HealthReporter 健康状态上报函数，返回状态与说明，用于 TTL 心跳
+type HealthReporter func(ctx context.Context) (HealthStatus, string)
+
+// HeartbeatDiscovery 支持 TTL 心跳的服务发现
+type HeartbeatDiscovery interface {
+	Discovery
+	// SetHealthReporter 设置心跳上报的健康状态来源，未设置时始终上报 passing
+	SetHealthReporter(reporter HealthReporter)
 }
 
 // LoadBalancer 负载均衡器
@@ -67,3 +116,43 @@ func (b *RoundRobinBalancer) Select(services []*ServiceInfo) *ServiceInfo {
 	idx := atomic.AddUint32(&b.index, 1) - 1
 	return services[idx%uint32(len(services))]
 }
+
+// watchGroup 按订阅管理的监听上下文
+type watchGroup struct {
+	mu      sync.Mutex
+	next    uint64
+	cancels map[uint64]context.CancelFunc
+}
+
+// start 为一次订阅派生监听上下文，ctx 取消或 stop 时结束，结束后自动移除
+func (g *watchGroup) start(ctx context.Context) context.Context {
+	watchCtx, cancel := context.WithCancel(ctx)
+
+	g.mu.Lock()
+	if g.cancels == nil {
+		g.cancels = make(map[uint64]context.CancelFunc)
+	}
+	id := g.next
+	g.next++
+	g.cancels[id] = cancel
+	g.mu.Unlock()
+
+	context.AfterFunc(watchCtx, func() {
+		g.mu.Lock()
+		delete(g.cancels, id)
+		g.mu.Unlock()
+	})
+	return watchCtx
+}
+
+// stop 停止全部订阅
+func (g *watchGroup) stop() {
+	g.mu.Lock()
+	cancels := g.cancels
+	g.cancels = nil
+	g.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
@@ -0,0 +1,178 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goupter/goupter/pkg/config"
+	"github.com/goupter/goupter/pkg/log"
+)
+
+// DNS 查询方式
+const (
+	DNSModeSRV = "srv"
+	DNSModeA   = "a"
+)
+
+// DNSResolver DNS 查询接口，*net.Resolver 实现了该接口
+type DNSResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// DNSDiscovery 基于 DNS 的只读服务发现
+//
+// srv 模式查询 _<port_name>._<protocol>.<service>.<domain> 的 SRV 记录（port_name 为空时直接查询
+// <service>.<domain>），SRV 权重作为实例权重；a 模式查询 A/AAAA 记录并使用配置的端口。
+// 服务注册由平台（如 Kubernetes）负责，Register/Deregister 为空操作。
+// Watch 按 refresh_interval 轮询，实例列表变化时回调；查询失败时保持上次结果。
+type DNSDiscovery struct {
+	config   *config.DNSDiscoveryConfig
+	resolver DNSResolver
+	logger   log.Logger
+	watches  watchGroup
+}
+
+// DNSOption DNS服务发现选项
+type DNSOption func(*DNSDiscovery)
+
+// WithDNSConfig 设置DNS服务发现配置
+func WithDNSConfig(cfg *config.DNSDiscoveryConfig) DNSOption {
+	return func(d *DNSDiscovery) { d.config = cfg }
+}
+
+// WithDNSResolver 设置DNS解析器
+func WithDNSResolver(resolver DNSResolver) DNSOption {
+	return func(d *DNSDiscovery) { d.resolver = resolver }
+}
+
+// WithDNSLogger 设置日志
+func WithDNSLogger(logger log.Logger) DNSOption {
+	return func(d *DNSDiscovery) { d.logger = logger }
+}
+
+// NewDNSDiscovery 创建DNS服务发现
+func NewDNSDiscovery(opts ...DNSOption) *DNSDiscovery {
+	d := &DNSDiscovery{
+		config:   &config.DNSDiscoveryConfig{},
+		resolver: net.DefaultResolver,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if d.config.Mode == "" {
+		d.config.Mode = DNSModeSRV
+	}
+	if d.config.Protocol == "" {
+		d.config.Protocol = "tcp"
+	}
+	if d.config.RefreshInterval <= 0 {
+		d.config.RefreshInterval = 10 * time.Second
+	}
+	return d
+}
+
+// Register DNS 服务发现为只读，注册由平台完成
+func (d *DNSDiscovery) Register(ctx context.Context, service *ServiceInfo) error {
+	return nil
+}
+
+// Deregister DNS 服务发现为只读，注销由平台完成
+func (d *DNSDiscovery) Deregister(ctx context.Context, serviceID string) error {
+	return nil
+}
+
+// host 返回服务的完整域名
+func (d *DNSDiscovery) host(name string) string {
+	if d.config.Domain == "" {
+		return name
+	}
+	return name + "." + strings.TrimPrefix(d.config.Domain, ".")
+}
+
+// GetService 获取服务实例列表
+func (d *DNSDiscovery) GetService(ctx context.Context, name string) ([]*ServiceInfo, error) {
+	host := d.host(name)
+
+	var result []*ServiceInfo
+	switch d.config.Mode {
+	case DNSModeA:
+		addrs, err := d.resolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("lookup host failed: %w", err)
+		}
+		for _, addr := range addrs {
+			result = append(result, d.newInstance(name, addr, d.config.Port, 0))
+		}
+	default:
+		_, records, err := d.resolver.LookupSRV(ctx, d.config.PortName, d.config.Protocol, host)
+		if err != nil {
+			return nil, fmt.Errorf("lookup srv failed: %w", err)
+		}
+		for _, srv := range records {
+			result = append(result, d.newInstance(name, strings.TrimSuffix(srv.Target, "."), int(srv.Port), int(srv.Weight)))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (d *DNSDiscovery) newInstance(name, address string, port, weight int) *ServiceInfo {
+	return &ServiceInfo{
+		ID:       net.JoinHostPort(address, strconv.Itoa(port)),
+		Name:     name,
+		Address:  address,
+		Port:     port,
+		Weight:   weight,
+		Metadata: map[string]string{},
+		Health:   HealthStatusPassing,
+	}
+}
+
+// Watch 轮询监听服务变化
+func (d *DNSDiscovery) Watch(ctx context.Context, name string, callback func([]*ServiceInfo)) error {
+	watchCtx := d.watches.start(ctx)
+
+	go func() {
+		ticker := time.NewTicker(d.config.RefreshInterval)
+		defer ticker.Stop()
+
+		var last []*ServiceInfo
+		first := true
+		for {
+			services, err := d.GetService(watchCtx, name)
+			switch {
+			case err != nil:
+				if watchCtx.Err() == nil && d.logger != nil {
+					d.logger.Warn("dns lookup failed", log.String("service", name), log.Error(err))
+				}
+			case first || !reflect.DeepEqual(services, last):
+				first = false
+				last = services
+				callback(services)
+			}
+
+			select {
+			case <-watchCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Close 停止所有监听
+func (d *DNSDiscovery) Close() error {
+	d.watches.stop()
+	return nil
+}
@@ -0,0 +1,124 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/config"
+)
+
+// fakeDNS 可修改记录的 DNS 解析器
+type fakeDNS struct {
+	mu    sync.Mutex
+	srv   map[string][]*net.SRV
+	hosts map[string][]string
+	err   error
+}
+
+func (f *fakeDNS) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return "", nil, f.err
+	}
+	key := "_" + service + "._" + proto + "." + name
+	if service == "" {
+		key = name
+	}
+	return key, f.srv[key], nil
+}
+
+func (f *fakeDNS) LookupHost(_ context.Context, host string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.hosts[host], nil
+}
+
+func (f *fakeDNS) set(fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn()
+}
+
+func TestDNSDiscovery_SRV(t *testing.T) {
+	r := &fakeDNS{srv: map[string][]*net.SRV{
+		"_grpc._tcp.user.default.svc.cluster.local": {
+			{Target: "user-1.user.default.svc.cluster.local.", Port: 9090, Weight: 10},
+			{Target: "user-0.user.default.svc.cluster.local.", Port: 9090, Weight: 20},
+		},
+	}}
+	d := NewDNSDiscovery(
+		WithDNSConfig(&config.DNSDiscoveryConfig{Domain: "default.svc.cluster.local", PortName: "grpc"}),
+		WithDNSResolver(r),
+	)
+
+	services, err := d.GetService(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("GetService() error = %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("len = %d, want 2", len(services))
+	}
+	s := services[0]
+	if s.Address != "user-0.user.default.svc.cluster.local" || s.Port != 9090 || s.Weight != 20 || s.Name != "user" {
+		t.Errorf("service = %+v", s)
+	}
+	if err := d.Register(context.Background(), s); err != nil {
+		t.Errorf("只读实现的 Register 应为空操作, got %v", err)
+	}
+}
+
+func TestDNSDiscovery_WatchA(t *testing.T) {
+	r := &fakeDNS{hosts: map[string][]string{"user": {"10.0.0.1"}}}
+	d := NewDNSDiscovery(
+		WithDNSConfig(&config.DNSDiscoveryConfig{Mode: DNSModeA, Port: 8080, RefreshInterval: 10 * time.Millisecond}),
+		WithDNSResolver(r),
+	)
+	defer d.Close()
+
+	updates := make(chan []*ServiceInfo, 10)
+	if err := d.Watch(context.Background(), "user", func(s []*ServiceInfo) { updates <- s }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	next := func() []*ServiceInfo {
+		select {
+		case s := <-updates:
+			return s
+		case <-time.After(time.Second):
+			t.Fatal("等待回调超时")
+			return nil
+		}
+	}
+
+	if s := next(); len(s) != 1 || s[0].ID != "10.0.0.1:8080" {
+		t.Fatalf("initial = %v", s)
+	}
+
+	// 查询失败不回调，保持上次结果
+	r.set(func() { r.err = errors.New("no such host") })
+	time.Sleep(40 * time.Millisecond)
+	if len(updates) != 0 {
+		t.Fatal("查询失败时不应回调")
+	}
+
+	r.set(func() {
+		r.err = nil
+		r.hosts["user"] = []string{"10.0.0.1", "10.0.0.2"}
+	})
+	if s := next(); len(s) != 2 {
+		t.Errorf("updated = %v", s)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if len(updates) != 0 {
+		t.Error("列表未变化时不应回调")
+	}
+}
@@ -0,0 +1,362 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/goupter/goupter/pkg/config"
+	"github.com/goupter/goupter/pkg/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdDiscovery etcd服务发现
+//
+// 实例以 JSON 形式写入 <prefix>/<service>/<id>，并绑定租约；注册后持续续约，
+// 进程异常退出时实例随租约过期自动摘除。续约中断（如 etcd 不可用）后以退避方式重新注册。
+// Watch 基于前缀监听，事件到达时回调完整的实例列表。
+type EtcdDiscovery struct {
+	client     *clientv3.Client
+	ownsClient bool
+	config     *config.EtcdConfig
+	logger     log.Logger
+	watches    watchGroup
+	// 注册中的实例，按服务ID
+	registrations map[string]*etcdRegistration
+	mu            sync.RWMutex
+}
+
+type etcdRegistration struct {
+	cancel  context.CancelFunc
+	leaseID clientv3.LeaseID
+}
+
+// EtcdOption etcd选项
+type EtcdOption func(*EtcdDiscovery)
+
+// WithEtcdConfig 设置etcd配置
+func WithEtcdConfig(cfg *config.EtcdConfig) EtcdOption {
+	return func(d *EtcdDiscovery) { d.config = cfg }
+}
+
+// WithEtcdLogger 设置日志
+func WithEtcdLogger(logger log.Logger) EtcdOption {
+	return func(d *EtcdDiscovery) { d.logger = logger }
+}
+
+// WithEtcdClient 使用已有的etcd客户端（Close 时不关闭该客户端）
+func WithEtcdClient(client *clientv3.Client) EtcdOption {
+	return func(d *EtcdDiscovery) { d.client = client }
+}
+
+// NewEtcdDiscovery 创建etcd服务发现
+func NewEtcdDiscovery(opts ...EtcdOption) (*EtcdDiscovery, error) {
+	d := &EtcdDiscovery{
+		config: &config.EtcdConfig{
+			Endpoints:   []string{"localhost:2379"},
+			DialTimeout: 5 * time.Second,
+		},
+		registrations: make(map[string]*etcdRegistration),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if d.config.Prefix == "" {
+		d.config.Prefix = "/goupter/services"
+	}
+	if d.config.TTL <= 0 {
+		d.config.TTL = 15 * time.Second
+	}
+
+	if d.client == nil {
+		client, err := clientv3.New(clientv3.Config{
+			Endpoints:   d.config.Endpoints,
+			Username:    d.config.Username,
+			Password:    d.config.Password,
+			DialTimeout: d.config.DialTimeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create etcd client failed: %w", err)
+		}
+		d.client = client
+		d.ownsClient = true
+	}
+
+	return d, nil
+}
+
+// serviceKey 返回服务前缀 <prefix>/<service>/
+func (d *EtcdDiscovery) serviceKey(name string) string {
+	return path.Join(d.config.Prefix, name) + "/"
+}
+
+// Register 注册服务
+func (d *EtcdDiscovery) Register(ctx context.Context, service *ServiceInfo) error {
+	value, err := json.Marshal(service)
+	if err != nil {
+		return fmt.Errorf("marshal service failed: %w", err)
+	}
+	key := d.serviceKey(service.Name) + service.ID
+
+	// 续约的生命周期独立于注册请求的 ctx，由 Deregister/Close 取消
+	keepCtx, cancel := context.WithCancel(context.Background())
+	leaseID, keepAlive, err := d.grant(ctx, keepCtx, key, string(value))
+	if err != nil {
+		cancel()
+		return fmt.Errorf("register service failed: %w", err)
+	}
+
+	d.mu.Lock()
+	if old, ok := d.registrations[service.ID]; ok {
+		old.cancel()
+	}
+	reg := &etcdRegistration{cancel: cancel, leaseID: leaseID}
+	d.registrations[service.ID] = reg
+	d.mu.Unlock()
+
+	go d.keepAlive(keepCtx, reg, key, string(value), keepAlive)
+
+	if d.logger != nil {
+		d.logger.Info("service registered",
+			log.String("id", service.ID),
+			log.String("name", service.Name),
+		)
+	}
+	return nil
+}
+
+// grant 创建租约、写入实例并开始续约
+func (d *EtcdDiscovery) grant(ctx, keepCtx context.Context, key, value string) (clientv3.LeaseID, <-chan *clientv3.LeaseKeepAliveResponse, error) {
+	lease, err := d.client.Grant(ctx, int64(d.config.TTL/time.Second))
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err := d.client.Put(ctx, key, value, clientv3.WithLease(lease.ID)); err != nil {
+		d.revoke(ctx, lease.ID)
+		return 0, nil, err
+	}
+	keepAlive, err := d.client.KeepAlive(keepCtx, lease.ID)
+	if err != nil {
+		d.revoke(ctx, lease.ID)
+		return 0, nil, err
+	}
+	return lease.ID, keepAlive, nil
+}
+
+// revoke 尽力撤销租约，不受调用方 ctx 取消的影响
+func (d *EtcdDiscovery) revoke(ctx context.Context, leaseID clientv3.LeaseID) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 3*time.Second)
+	defer cancel()
+	if _, err := d.client.Revoke(ctx, leaseID); err != nil && d.logger != nil {
+		d.logger.Warn("revoke etcd lease failed", log.Int64("lease", int64(leaseID)), log.Error(err))
+	}
+}
+
+// keepAlive 消费续约响应，续约通道关闭后以退避方式重新注册
+func (d *EtcdDiscovery) keepAlive(ctx context.Context, reg *etcdRegistration, key, value string, ch <-chan *clientv3.LeaseKeepAliveResponse) {
+	backoff := time.Second
+	for {
+		for range ch {
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if d.logger != nil {
+			d.logger.Warn("etcd lease lost, re-registering", log.String("key", key))
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			leaseID, next, err := d.grant(ctx, ctx, key, value)
+			if err == nil {
+				d.mu.Lock()
+				if ctx.Err() != nil {
+					// 重新注册期间已被注销，新租约不再归属任何注册
+					d.mu.Unlock()
+					d.revoke(ctx, leaseID)
+					return
+				}
+				reg.leaseID = leaseID
+				d.mu.Unlock()
+				ch = next
+				backoff = time.Second
+				break
+			}
+			if d.logger != nil {
+				d.logger.Error("re-register service failed", log.String("key", key), log.Error(err))
+			}
+			backoff = min(backoff*2, d.config.TTL)
+		}
+	}
+}
+
+// Deregister 注销服务
+func (d *EtcdDiscovery) Deregister(ctx context.Context, serviceID string) error {
+	d.mu.Lock()
+	reg, ok := d.registrations[serviceID]
+	delete(d.registrations, serviceID)
+	var leaseID clientv3.LeaseID
+	if ok {
+		// 持锁取消，保证续约协程不会在此之后更新租约
+		reg.cancel()
+		leaseID = reg.leaseID
+	}
+	d.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	// 撤销租约会同时删除绑定的键
+	if _, err := d.client.Revoke(ctx, leaseID); err != nil {
+		return fmt.Errorf("deregister service failed: %w", err)
+	}
+	if d.logger != nil {
+		d.logger.Info("service deregistered", log.String("id", serviceID))
+	}
+	return nil
+}
+
+// GetService 获取服务实例列表
+func (d *EtcdDiscovery) GetService(ctx context.Context, name string) ([]*ServiceInfo, error) {
+	resp, err := d.client.Get(ctx, d.serviceKey(name), clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("get service failed: %w", err)
+	}
+
+	instances := make(map[string]*ServiceInfo, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		d.applyPut(instances, kv.Key, kv.Value)
+	}
+	return sortedInstances(instances), nil
+}
+
+// Watch 监听服务变化
+func (d *EtcdDiscovery) Watch(ctx context.Context, name string, callback func([]*ServiceInfo)) error {
+	watchCtx := d.watches.start(ctx)
+
+	prefix := d.serviceKey(name)
+	go func() {
+		backoff := time.Second
+		for watchCtx.Err() == nil {
+			if err := d.watch(watchCtx, prefix, callback); err != nil && watchCtx.Err() == nil {
+				if d.logger != nil {
+					d.logger.Error("watch service failed", log.String("service", name),
+						log.Duration("retry_after", backoff), log.Error(err))
+				}
+				select {
+				case <-watchCtx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				backoff = min(backoff*2, 30*time.Second)
+				continue
+			}
+			backoff = time.Second
+		}
+	}()
+	return nil
+}
+
+// watch 全量读取后从下一个版本开始监听，监听中断（如版本被压缩）时返回
+func (d *EtcdDiscovery) watch(ctx context.Context, prefix string, callback func([]*ServiceInfo)) error {
+	resp, err := d.client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+
+	instances := make(map[string]*ServiceInfo, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		d.applyPut(instances, kv.Key, kv.Value)
+	}
+	callback(sortedInstances(instances))
+
+	watchCh := d.client.Watch(clientv3.WithRequireLeader(ctx), prefix,
+		clientv3.WithPrefix(), clientv3.WithRev(resp.Header.Revision+1))
+	for wresp := range watchCh {
+		if err := wresp.Err(); err != nil {
+			return err
+		}
+		for _, ev := range wresp.Events {
+			switch ev.Type {
+			case clientv3.EventTypePut:
+				d.applyPut(instances, ev.Kv.Key, ev.Kv.Value)
+			case clientv3.EventTypeDelete:
+				delete(instances, string(ev.Kv.Key))
+			}
+		}
+		callback(sortedInstances(instances))
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("watch channel closed")
+}
+
+func (d *EtcdDiscovery) applyPut(instances map[string]*ServiceInfo, key, value []byte) {
+	var service ServiceInfo
+	if err := json.Unmarshal(value, &service); err != nil {
+		if d.logger != nil {
+			d.logger.Warn("invalid service value", log.String("key", string(key)), log.Error(err))
+		}
+		return
+	}
+	if service.ID == "" {
+		service.ID = path.Base(string(key))
+	}
+	// 租约存活即视为健康
+	service.Health = HealthStatusPassing
+	instances[string(key)] = &service
+}
+
+// Close 关闭连接
+//
+// 注销所有由本实例注册的服务，停止监听；客户端由本实例创建时一并关闭。
+func (d *EtcdDiscovery) Close() error {
+	d.watches.stop()
+
+	d.mu.Lock()
+	leases := make([]clientv3.LeaseID, 0, len(d.registrations))
+	for _, reg := range d.registrations {
+		reg.cancel()
+		leases = append(leases, reg.leaseID)
+	}
+	d.registrations = make(map[string]*etcdRegistration)
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	for _, leaseID := range leases {
+		_, _ = d.client.Revoke(ctx, leaseID)
+	}
+
+	if d.ownsClient {
+		return d.client.Close()
+	}
+	return nil
+}
+
+// Client 获取etcd客户端
+func (d *EtcdDiscovery) Client() *clientv3.Client {
+	return d.client
+}
+
+// sortedInstances 按 ID 排序返回实例列表
+func sortedInstances(instances map[string]*ServiceInfo) []*ServiceInfo {
+	result := make([]*ServiceInfo, 0, len(instances))
+	for _, s := range instances {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
@@ -0,0 +1,332 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/config"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestEtcdDiscovery_Decode(t *testing.T) {
+	d := &EtcdDiscovery{config: &config.EtcdConfig{Prefix: "/goupter/services/"}}
+	if key := d.serviceKey("user"); key != "/goupter/services/user/" {
+		t.Errorf("serviceKey = %s", key)
+	}
+
+	value, _ := json.Marshal(&ServiceInfo{Name: "user", Address: "10.0.0.1", Port: 8080, Weight: 3})
+	instances := make(map[string]*ServiceInfo)
+	d.applyPut(instances, []byte("/goupter/services/user/b"), value)
+	d.applyPut(instances, []byte("/goupter/services/user/a"), value)
+	d.applyPut(instances, []byte("/goupter/services/user/bad"), []byte("{"))
+
+	services := sortedInstances(instances)
+	if len(services) != 2 {
+		t.Fatalf("无效值应被忽略, got %d", len(services))
+	}
+	if services[0].ID != "a" || services[1].ID != "b" {
+		t.Errorf("缺省 ID 应取键的最后一段并排序, got %s %s", services[0].ID, services[1].ID)
+	}
+	if services[0].Health != HealthStatusPassing || services[0].Weight != 3 {
+		t.Errorf("service = %+v", services[0])
+	}
+}
+
+// fakeEtcd 内存实现的 KV/Lease/Watcher，Put 绑定最近一次授予的租约
+type fakeEtcd struct {
+	clientv3.KV
+	clientv3.Lease
+	clientv3.Watcher
+
+	mu           sync.Mutex
+	rev          int64
+	lastLease    clientv3.LeaseID
+	kvs          map[string]fakeEtcdValue
+	keepAlives   map[clientv3.LeaseID]*fakeKeepAlive
+	revoked      []clientv3.LeaseID
+	putErr       error
+	keepAliveErr error
+	watchCh      chan clientv3.WatchResponse
+}
+
+type fakeEtcdValue struct {
+	value string
+	lease clientv3.LeaseID
+}
+
+type fakeKeepAlive struct {
+	ch   chan *clientv3.LeaseKeepAliveResponse
+	once sync.Once
+}
+
+func (k *fakeKeepAlive) close() { k.once.Do(func() { close(k.ch) }) }
+
+func newFakeEtcd() *fakeEtcd {
+	return &fakeEtcd{
+		kvs:        make(map[string]fakeEtcdValue),
+		keepAlives: make(map[clientv3.LeaseID]*fakeKeepAlive),
+		watchCh:    make(chan clientv3.WatchResponse, 4),
+	}
+}
+
+func (f *fakeEtcd) discovery(t *testing.T) *EtcdDiscovery {
+	t.Helper()
+	d, err := NewEtcdDiscovery(WithEtcdClient(&clientv3.Client{KV: f, Lease: f, Watcher: f}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func (f *fakeEtcd) Close() error { return nil }
+
+func (f *fakeEtcd) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastLease++
+	return &clientv3.LeaseGrantResponse{ID: f.lastLease, TTL: ttl}, nil
+}
+
+func (f *fakeEtcd) Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.revoked = append(f.revoked, id)
+	for key, v := range f.kvs {
+		if v.lease == id {
+			delete(f.kvs, key)
+		}
+	}
+	return &clientv3.LeaseRevokeResponse{}, nil
+}
+
+func (f *fakeEtcd) KeepAlive(ctx context.Context, id clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.keepAliveErr != nil {
+		return nil, f.keepAliveErr
+	}
+	ka := &fakeKeepAlive{ch: make(chan *clientv3.LeaseKeepAliveResponse)}
+	f.keepAlives[id] = ka
+	go func() {
+		<-ctx.Done()
+		ka.close()
+	}()
+	return ka.ch, nil
+}
+
+// expire 模拟租约过期：续约通道关闭，绑定的键被删除
+func (f *fakeEtcd) expire(id clientv3.LeaseID) {
+	f.mu.Lock()
+	ka := f.keepAlives[id]
+	for key, v := range f.kvs {
+		if v.lease == id {
+			delete(f.kvs, key)
+		}
+	}
+	f.mu.Unlock()
+	if ka != nil {
+		ka.close()
+	}
+}
+
+func (f *fakeEtcd) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.putErr != nil {
+		return nil, f.putErr
+	}
+	f.rev++
+	f.kvs[key] = fakeEtcdValue{value: val, lease: f.lastLease}
+	return &clientv3.PutResponse{}, nil
+}
+
+func (f *fakeEtcd) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: f.rev}}
+	for k, v := range f.kvs {
+		if strings.HasPrefix(k, key) {
+			resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v.value)})
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeEtcd) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	ch := make(chan clientv3.WatchResponse)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case resp := <-f.watchCh:
+				select {
+				case ch <- resp:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+func (f *fakeEtcd) value(key string) (fakeEtcdValue, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.kvs[key]
+	return v, ok
+}
+
+func (f *fakeEtcd) revokedLeases() []clientv3.LeaseID {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]clientv3.LeaseID(nil), f.revoked...)
+}
+
+func TestEtcdDiscovery_RegisterDeregister(t *testing.T) {
+	f := newFakeEtcd()
+	d := f.discovery(t)
+	defer d.Close()
+	ctx := context.Background()
+
+	if err := d.Register(ctx, &ServiceInfo{ID: "a", Name: "user", Address: "10.0.0.1", Port: 8080}); err != nil {
+		t.Fatal(err)
+	}
+	v, ok := f.value("/goupter/services/user/a")
+	if !ok || v.lease != 1 {
+		t.Fatalf("实例应绑定租约写入, got %+v %v", v, ok)
+	}
+	services, err := d.GetService(ctx, "user")
+	if err != nil || len(services) != 1 || services[0].Address != "10.0.0.1" {
+		t.Fatalf("GetService = %v, %v", services, err)
+	}
+
+	if err := d.Deregister(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.revokedLeases(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("注销应撤销租约, revoked = %v", got)
+	}
+	if services, _ := d.GetService(ctx, "user"); len(services) != 0 {
+		t.Errorf("注销后不应有实例, got %d", len(services))
+	}
+}
+
+func TestEtcdDiscovery_RegisterRevokesOnFailure(t *testing.T) {
+	for name, setup := range map[string]func(*fakeEtcd){
+		"put":       func(f *fakeEtcd) { f.putErr = errors.New("put failed") },
+		"keepalive": func(f *fakeEtcd) { f.keepAliveErr = errors.New("keepalive failed") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFakeEtcd()
+			setup(f)
+			d := f.discovery(t)
+			defer d.Close()
+
+			if err := d.Register(context.Background(), &ServiceInfo{ID: "a", Name: "user"}); err == nil {
+				t.Fatal("应返回错误")
+			}
+			if got := f.revokedLeases(); len(got) != 1 || got[0] != 1 {
+				t.Errorf("失败时应撤销已授予的租约, revoked = %v", got)
+			}
+		})
+	}
+}
+
+func TestEtcdDiscovery_ReregisterAfterLeaseLost(t *testing.T) {
+	f := newFakeEtcd()
+	d := f.discovery(t)
+	defer d.Close()
+	ctx := context.Background()
+	key := "/goupter/services/user/a"
+
+	if err := d.Register(ctx, &ServiceInfo{ID: "a", Name: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	f.expire(1)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if v, ok := f.value(key); ok && v.lease == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("续约中断后应以新租约重新注册")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err := d.Deregister(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.revokedLeases(); len(got) != 1 || got[0] != 2 {
+		t.Errorf("注销应撤销重新注册后的租约, revoked = %v", got)
+	}
+	if _, ok := f.value(key); ok {
+		t.Error("注销后键应被删除")
+	}
+}
+
+func TestEtcdDiscovery_Watch(t *testing.T) {
+	f := newFakeEtcd()
+	d := f.discovery(t)
+	defer d.Close()
+
+	value := func(s *ServiceInfo) []byte {
+		b, _ := json.Marshal(s)
+		return b
+	}
+	f.kvs["/goupter/services/user/a"] = fakeEtcdValue{value: string(value(&ServiceInfo{ID: "a", Name: "user"}))}
+	f.kvs["/goupter/services/order/x"] = fakeEtcdValue{value: string(value(&ServiceInfo{ID: "x", Name: "order"}))}
+
+	updates := make(chan []*ServiceInfo, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := d.Watch(ctx, "user", func(s []*ServiceInfo) { updates <- s }); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func() []string {
+		t.Helper()
+		select {
+		case s := <-updates:
+			ids := make([]string, len(s))
+			for i, svc := range s {
+				ids[i] = svc.ID
+			}
+			return ids
+		case <-time.After(2 * time.Second):
+			t.Fatal("未收到回调")
+			return nil
+		}
+	}
+	if got := ids(); !slices.Equal(got, []string{"a"}) {
+		t.Fatalf("初始列表应只含本服务前缀下的实例, got %v", got)
+	}
+
+	f.watchCh <- clientv3.WatchResponse{Events: []*clientv3.Event{{
+		Type: clientv3.EventTypePut,
+		Kv:   &mvccpb.KeyValue{Key: []byte("/goupter/services/user/b"), Value: value(&ServiceInfo{ID: "b", Name: "user"})},
+	}}}
+	if got := ids(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("PUT 后 got %v", got)
+	}
+
+	f.watchCh <- clientv3.WatchResponse{Events: []*clientv3.Event{{
+		Type: clientv3.EventTypeDelete,
+		Kv:   &mvccpb.KeyValue{Key: []byte("/goupter/services/user/a")},
+	}}}
+	if got := ids(); !slices.Equal(got, []string{"b"}) {
+		t.Fatalf("DELETE 后 got %v", got)
+	}
+}
@@ -0,0 +1,81 @@
+package grpcresolver
+
+import (
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+// WeightedRoundRobinName 加权轮询负载均衡策略名称
+const WeightedRoundRobinName = "goupter_weighted_round_robin"
+
+// weightKey 地址权重属性键
+type weightKey struct{}
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(
+		WeightedRoundRobinName,
+		&wrrPickerBuilder{},
+		base.Config{HealthCheck: true},
+	))
+}
+
+// WithWeightedRoundRobin 使用基于 ServiceInfo.Weight 的加权轮询负载均衡
+func WithWeightedRoundRobin() grpc.DialOption {
+	return grpc.WithDefaultServiceConfig(`{"loadBalancingConfig":[{"` + WeightedRoundRobinName + `":{}}]}`)
+}
+
+// AddressWeight 读取地址上的权重，未设置时为 1
+func AddressWeight(addr resolver.Address) int {
+	if w, ok := addr.BalancerAttributes.Value(weightKey{}).(int); ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// wrrPickerBuilder 加权轮询 Picker 构建器
+type wrrPickerBuilder struct{}
+
+func (*wrrPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	p := &wrrPicker{}
+	for sc, scInfo := range info.ReadySCs {
+		p.items = append(p.items, &wrrItem{subConn: sc, weight: AddressWeight(scInfo.Address)})
+	}
+	return p
+}
+
+type wrrItem struct {
+	subConn balancer.SubConn
+	weight  int
+	current int
+}
+
+// wrrPicker 平滑加权轮询 Picker
+type wrrPicker struct {
+	mu    sync.Mutex
+	items []*wrrItem
+}
+
+func (p *wrrPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var best *wrrItem
+	total := 0
+	for _, item := range p.items {
+		total += item.weight
+		item.current += item.weight
+		if best == nil || item.current > best.current {
+			best = item
+		}
+	}
+	best.current -= total
+	return balancer.PickResult{SubConn: best.subConn}, nil
+}
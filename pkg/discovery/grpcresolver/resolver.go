@@ -0,0 +1,223 @@
+// Package grpcresolver 将 discovery.Discovery 接入 gRPC 的名称解析与负载均衡
+//
+// 目标地址格式：
+//
+//	goupter:///user-service?tag=v1&tag=grpc&meta.zone=a&port=grpc
+//
+// tag 为实例必须包含的标签（可重复），meta.<key> 为实例元数据必须匹配的键值，
+// port 为协议名，用于读取元数据中的 <port>_addr / <port>_port，默认 grpc。
+package grpcresolver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/goupter/goupter/pkg/discovery"
+	"github.com/goupter/goupter/pkg/log"
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme 解析器 scheme
+const Scheme = "goupter"
+
+// DefaultPortName 默认读取的协议端口名
+const DefaultPortName = "grpc"
+
+// Target 生成服务名对应的 gRPC 目标地址
+func Target(service string) string {
+	return Scheme + ":///" + service
+}
+
+// Builder gRPC 解析器构建器
+type Builder struct {
+	resolver *discovery.Resolver
+	logger   log.Logger
+}
+
+// Option 构建器选项
+type Option func(*Builder)
+
+// WithLogger 设置日志
+func WithLogger(logger log.Logger) Option {
+	return func(b *Builder) { b.logger = logger }
+}
+
+// NewBuilder 基于服务解析器创建 gRPC 解析器构建器
+func NewBuilder(r *discovery.Resolver, opts ...Option) *Builder {
+	b := &Builder{resolver: r}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Register 基于服务发现创建构建器并注册到 gRPC 全局解析器表
+//
+// 也可以通过 grpc.WithResolvers(NewBuilder(...)) 按连接注册。
+func Register(d discovery.Discovery, opts ...Option) *Builder {
+	b := NewBuilder(discovery.NewResolver(d), opts...)
+	resolver.Register(b)
+	return b
+}
+
+// Scheme 实现 resolver.Builder
+func (b *Builder) Scheme() string {
+	return Scheme
+}
+
+// Build 实现 resolver.Builder
+func (b *Builder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	spec, err := parseTarget(target.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &grpcResolver{
+		builder: b,
+		spec:    spec,
+		cc:      cc,
+	}
+	r.subscribe()
+	return r, nil
+}
+
+// targetSpec 解析后的目标地址
+type targetSpec struct {
+	service  string
+	portName string
+	filters  []discovery.InstanceFilter
+}
+
+func parseTarget(u url.URL) (*targetSpec, error) {
+	service := strings.TrimPrefix(u.Path, "/")
+	if service == "" {
+		service = u.Opaque
+	}
+	if service == "" {
+		return nil, fmt.Errorf("grpcresolver: missing service name in target %q", u.String())
+	}
+
+	query := u.Query()
+	spec := &targetSpec{service: service, portName: DefaultPortName}
+	if port := query.Get("port"); port != "" {
+		spec.portName = port
+	}
+	if tags := query["tag"]; len(tags) > 0 {
+		spec.filters = append(spec.filters, discovery.MatchTags(tags...))
+	}
+
+	md := make(map[string]string)
+	for key, values := range query {
+		if name, ok := strings.CutPrefix(key, "meta."); ok && len(values) > 0 {
+			md[name] = values[0]
+		}
+	}
+	if len(md) > 0 {
+		spec.filters = append(spec.filters, discovery.MatchMetadata(md))
+	}
+
+	return spec, nil
+}
+
+// grpcResolver 单个目标地址的解析器
+type grpcResolver struct {
+	builder *Builder
+	spec    *targetSpec
+	cc      resolver.ClientConn
+
+	mu          sync.Mutex
+	unsubscribe func()
+	closed      bool
+}
+
+// subscribe 订阅服务实例变化，失败时报告给 ClientConn，等待 ResolveNow 重试
+func (r *grpcResolver) subscribe() {
+	r.mu.Lock()
+	if r.closed || r.unsubscribe != nil {
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Unlock()
+
+	cancel, err := r.builder.resolver.Subscribe(context.Background(), r.spec.service, r.update)
+	if err != nil {
+		if r.builder.logger != nil {
+			r.builder.logger.Warn("grpc resolve service failed",
+				log.String("service", r.spec.service),
+				log.Error(err),
+			)
+		}
+		r.cc.ReportError(err)
+		return
+	}
+
+	r.mu.Lock()
+	if r.closed || r.unsubscribe != nil {
+		r.mu.Unlock()
+		cancel()
+		return
+	}
+	r.unsubscribe = cancel
+	r.mu.Unlock()
+}
+
+// update 将实例列表转换为 gRPC 地址
+func (r *grpcResolver) update(services []*discovery.ServiceInfo) {
+	addrs := make([]resolver.Address, 0, len(services))
+	for _, s := range services {
+		if !r.match(s) {
+			continue
+		}
+		addrs = append(addrs, resolver.Address{
+			Addr:               s.Endpoint(r.spec.portName),
+			BalancerAttributes: attributes.New(weightKey{}, weightOf(s)),
+		})
+	}
+
+	if len(addrs) == 0 {
+		r.cc.ReportError(fmt.Errorf("%w: %s", discovery.ErrNoAvailableInstance, r.spec.service))
+		return
+	}
+	if err := r.cc.UpdateState(resolver.State{Addresses: addrs}); err != nil && r.builder.logger != nil {
+		r.builder.logger.Debug("grpc resolver update state failed",
+			log.String("service", r.spec.service),
+			log.Error(err),
+		)
+	}
+}
+
+func (r *grpcResolver) match(s *discovery.ServiceInfo) bool {
+	for _, f := range r.spec.filters {
+		if !f(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveNow 实现 resolver.Resolver，订阅失败时重试
+func (r *grpcResolver) ResolveNow(resolver.ResolveNowOptions) {
+	go r.subscribe()
+}
+
+// Close 实现 resolver.Resolver
+func (r *grpcResolver) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	if r.unsubscribe != nil {
+		r.unsubscribe()
+		r.unsubscribe = nil
+	}
+}
+
+func weightOf(s *discovery.ServiceInfo) int {
+	if s.Weight <= 0 {
+		return 1
+	}
+	return s.Weight
+}
@@ -0,0 +1,118 @@
+package grpcresolver
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/discovery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+// staticDiscovery 返回固定实例列表的服务发现
+type staticDiscovery struct {
+	services []*discovery.ServiceInfo
+}
+
+func (d *staticDiscovery) Register(context.Context, *discovery.ServiceInfo) error { return nil }
+func (d *staticDiscovery) Deregister(context.Context, string) error               { return nil }
+func (d *staticDiscovery) GetService(context.Context, string) ([]*discovery.ServiceInfo, error) {
+	return d.services, nil
+}
+func (d *staticDiscovery) Watch(context.Context, string, func([]*discovery.ServiceInfo)) error {
+	return nil
+}
+func (d *staticDiscovery) Close() error { return nil }
+
+func startHealthServer(t *testing.T) (string, int) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	s := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	return "127.0.0.1", lis.Addr().(*net.TCPAddr).Port
+}
+
+func TestParseTarget(t *testing.T) {
+	u, _ := url.Parse("goupter:///user-service?tag=v1&tag=grpc&meta.zone=a&port=rpc")
+	spec, err := parseTarget(*u)
+	if err != nil {
+		t.Fatalf("parseTarget() error = %v", err)
+	}
+	if spec.service != "user-service" || spec.portName != "rpc" || len(spec.filters) != 2 {
+		t.Errorf("解析结果不正确: %+v", spec)
+	}
+
+	u, _ = url.Parse("goupter:///")
+	if _, err := parseTarget(*u); err == nil {
+		t.Error("缺少服务名应返回错误")
+	}
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	hostA, portA := startHealthServer(t)
+	hostB, portB := startHealthServer(t)
+	hostC, portC := startHealthServer(t)
+
+	d := &staticDiscovery{services: []*discovery.ServiceInfo{
+		{ID: "a", Address: hostA, Port: 1, Weight: 3, Tags: []string{"grpc"},
+			Metadata: map[string]string{"grpc_port": strconv.Itoa(portA)}},
+		{ID: "b", Address: hostB, Port: 1, Weight: 1, Tags: []string{"grpc"},
+			Metadata: map[string]string{"grpc_port": strconv.Itoa(portB)}},
+		{ID: "c", Address: hostC, Port: portC, Tags: []string{"http"}},
+	}}
+
+	builder := NewBuilder(discovery.NewResolver(d))
+	conn, err := grpc.NewClient(Target("user")+"?tag=grpc",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithResolvers(builder),
+		WithWeightedRoundRobin(),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := grpc_health_v1.NewHealthClient(conn)
+	// 等待两个后端都就绪
+	seen := make(map[string]int)
+	for len(seen) < 2 {
+		var p peer.Peer
+		if _, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.Peer(&p), grpc.WaitForReady(true)); err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		seen[p.Addr.String()]++
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 40; i++ {
+		var p peer.Peer
+		if _, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.Peer(&p)); err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		counts[p.Addr.String()]++
+	}
+
+	addrA := net.JoinHostPort(hostA, strconv.Itoa(portA))
+	addrB := net.JoinHostPort(hostB, strconv.Itoa(portB))
+	if counts[addrA] != 30 || counts[addrB] != 10 {
+		t.Errorf("加权分布不正确: %v", counts)
+	}
+	if counts[net.JoinHostPort(hostC, strconv.Itoa(portC))] != 0 {
+		t.Error("不匹配标签的实例不应被调用")
+	}
+}
@@ -0,0 +1,155 @@
+package discovery
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// OutlierConfig 异常实例摘除配置
+type OutlierConfig struct {
+	// ConsecutiveErrors 连续失败多少次后摘除实例
+	ConsecutiveErrors int
+	// BaseEjectionTime 基础摘除时长，实际时长 = 基础时长 × 累计摘除次数
+	BaseEjectionTime time.Duration
+	// MaxEjectionTime 最大摘除时长
+	MaxEjectionTime time.Duration
+	// MaxEjectionPercent 最多摘除的实例百分比，避免全部实例被摘除
+	MaxEjectionPercent int
+}
+
+// DefaultOutlierConfig 默认异常实例摘除配置
+func DefaultOutlierConfig() *OutlierConfig {
+	return &OutlierConfig{
+		ConsecutiveErrors:  5,
+		BaseEjectionTime:   30 * time.Second,
+		MaxEjectionTime:    5 * time.Minute,
+		MaxEjectionPercent: 50,
+	}
+}
+
+// outlierStat 单个实例的失败统计
+type outlierStat struct {
+	consecutive int
+	ejections   int
+	ejectedAt   time.Time
+	ejectedTill time.Time
+}
+
+// OutlierDetector 基于连续失败次数的异常实例检测器
+type OutlierDetector struct {
+	config *OutlierConfig
+	stats  map[string]*outlierStat
+	mu     sync.Mutex
+	now    func() time.Time
+}
+
+// NewOutlierDetector 创建异常实例检测器
+func NewOutlierDetector(cfg *OutlierConfig) *OutlierDetector {
+	if cfg == nil {
+		cfg = DefaultOutlierConfig()
+	}
+	return &OutlierDetector{
+		config: cfg,
+		stats:  make(map[string]*outlierStat),
+		now:    time.Now,
+	}
+}
+
+// Report 上报一次调用结果
+func (d *OutlierDetector) Report(id string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stat, ok := d.stats[id]
+	if !ok {
+		stat = &outlierStat{}
+		d.stats[id] = stat
+	}
+
+	if err == nil {
+		stat.consecutive = 0
+		return
+	}
+
+	stat.consecutive++
+	now := d.now()
+	if stat.consecutive < d.config.ConsecutiveErrors || now.Before(stat.ejectedTill) {
+		return
+	}
+
+	stat.ejections++
+	stat.consecutive = 0
+	ejection := d.config.BaseEjectionTime * time.Duration(stat.ejections)
+	if d.config.MaxEjectionTime > 0 && ejection > d.config.MaxEjectionTime {
+		ejection = d.config.MaxEjectionTime
+	}
+	stat.ejectedAt = now
+	stat.ejectedTill = now.Add(ejection)
+}
+
+// IsEjected 实例当前是否处于摘除状态
+func (d *OutlierDetector) IsEjected(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stat, ok := d.stats[id]
+	return ok && d.now().Before(stat.ejectedTill)
+}
+
+// Filter 过滤掉被摘除的实例
+//
+// 摘除数量受 MaxEjectionPercent 限制，超出部分按摘除时间先后放回；
+// 若过滤后没有可用实例则返回原列表（恐慌模式）。
+func (d *OutlierDetector) Filter(services []*ServiceInfo) []*ServiceInfo {
+	if len(services) == 0 {
+		return services
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	alive := make(map[string]struct{}, len(services))
+	var ejected []*ServiceInfo
+	for _, s := range services {
+		alive[s.ID] = struct{}{}
+		if stat, ok := d.stats[s.ID]; ok && now.Before(stat.ejectedTill) {
+			ejected = append(ejected, s)
+		}
+	}
+
+	// 清理已下线实例的统计
+	for id := range d.stats {
+		if _, ok := alive[id]; !ok {
+			delete(d.stats, id)
+		}
+	}
+
+	if len(ejected) == 0 {
+		return services
+	}
+
+	maxEjected := len(services) * d.config.MaxEjectionPercent / 100
+	if len(ejected) > maxEjected {
+		// 保留最近被摘除的实例，较早摘除的放回
+		sort.Slice(ejected, func(i, j int) bool {
+			return d.stats[ejected[i].ID].ejectedAt.After(d.stats[ejected[j].ID].ejectedAt)
+		})
+		ejected = ejected[:maxEjected]
+	}
+
+	skip := make(map[string]struct{}, len(ejected))
+	for _, s := range ejected {
+		skip[s.ID] = struct{}{}
+	}
+	result := make([]*ServiceInfo, 0, len(services)-len(skip))
+	for _, s := range services {
+		if _, ok := skip[s.ID]; !ok {
+			result = append(result, s)
+		}
+	}
+	if len(result) == 0 {
+		return services
+	}
+	return result
+}
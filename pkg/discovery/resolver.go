@@ -0,0 +1,335 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/goupter/goupter/pkg/log"
+)
+
+// ErrNoAvailableInstance 没有可用的服务实例
+var ErrNoAvailableInstance = errors.New("discovery: no available instance")
+
+// InstanceFilter 实例过滤器，返回 true 表示保留
+type InstanceFilter func(*ServiceInfo) bool
+
+// HealthyFilter 过滤掉健康状态为 critical 的实例
+func HealthyFilter(s *ServiceInfo) bool {
+	return s.Health != HealthStatusCritical
+}
+
+// MatchTags 要求实例包含全部指定标签
+func MatchTags(tags ...string) InstanceFilter {
+	return func(s *ServiceInfo) bool {
+		for _, tag := range tags {
+			found := false
+			for _, t := range s.Tags {
+				if t == tag {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchMetadata 要求实例元数据包含全部指定键值
+func MatchMetadata(md map[string]string) InstanceFilter {
+	return func(s *ServiceInfo) bool {
+		for k, v := range md {
+			if s.Metadata[k] != v {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Resolver 客户端服务解析器
+//
+// 按服务名维护一份经过 Watch 持续更新、健康过滤后的实例列表，
+// 并在其上执行负载均衡与异常实例摘除。同一服务名只会发起一次 Watch，
+// 多个订阅者共享该列表。
+type Resolver struct {
+	discovery Discovery
+	logger    log.Logger
+	filters   []InstanceFilter
+	balancer  BalancerFactory
+	outlier   *OutlierConfig
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	entries map[string]*resolverEntry
+	pending map[string]*resolverCall
+	mu      sync.Mutex
+}
+
+// resolverEntry 单个服务名的解析状态
+type resolverEntry struct {
+	name      string
+	instances []*ServiceInfo
+	balancer  Balancer
+	outlier   *OutlierDetector
+	subs      map[int]func([]*ServiceInfo)
+	nextSubID int
+	mu        sync.RWMutex
+}
+
+// resolverCall 进行中的服务初始化，同一服务名的并发调用等待同一结果
+type resolverCall struct {
+	done  chan struct{}
+	entry *resolverEntry
+	err   error
+}
+
+// ResolverOption 解析器选项
+type ResolverOption func(*Resolver)
+
+// WithResolverLogger 设置日志
+func WithResolverLogger(logger log.Logger) ResolverOption {
+	return func(r *Resolver) { r.logger = logger }
+}
+
+// WithInstanceFilter 添加实例过滤器（在健康过滤之后执行）
+func WithInstanceFilter(filters ...InstanceFilter) ResolverOption {
+	return func(r *Resolver) { r.filters = append(r.filters, filters...) }
+}
+
+// WithBalancer 设置负载均衡器工厂
+func WithBalancer(factory BalancerFactory) ResolverOption {
+	return func(r *Resolver) { r.balancer = factory }
+}
+
+// WithOutlierDetection 启用异常实例摘除，每个服务名独立统计，cfg 为 nil 时使用默认配置
+func WithOutlierDetection(cfg *OutlierConfig) ResolverOption {
+	return func(r *Resolver) {
+		if cfg == nil {
+			cfg = DefaultOutlierConfig()
+		}
+		r.outlier = cfg
+	}
+}
+
+// NewResolver 创建服务解析器
+func NewResolver(d Discovery, opts ...ResolverOption) *Resolver {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Resolver{
+		discovery: d,
+		filters:   []InstanceFilter{HealthyFilter},
+		balancer: func() Balancer {
+			return AdaptBalancer(NewRoundRobinBalancer())
+		},
+		ctx:     ctx,
+		cancel:  cancel,
+		entries: make(map[string]*resolverEntry),
+		pending: make(map[string]*resolverCall),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Resolve 获取服务的可用实例列表，首次调用时拉取实例并开始监听
+func (r *Resolver) Resolve(ctx context.Context, name string) ([]*ServiceInfo, error) {
+	entry, err := r.entry(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return entry.snapshot(), nil
+}
+
+// Pick 为一次调用选择实例，调用结束后必须执行返回的 DoneFunc
+func (r *Resolver) Pick(ctx context.Context, name string) (*ServiceInfo, DoneFunc, error) {
+	entry, err := r.entry(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	instances := entry.snapshot()
+	if entry.outlier != nil {
+		instances = entry.outlier.Filter(instances)
+	}
+	if len(instances) == 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNoAvailableInstance, name)
+	}
+
+	instance, done := entry.balancer.Pick(ctx, instances)
+	if instance == nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNoAvailableInstance, name)
+	}
+
+	return instance, func(err error) {
+		done(err)
+		if entry.outlier != nil {
+			entry.outlier.Report(instance.ID, err)
+		}
+	}, nil
+}
+
+// Subscribe 订阅服务实例变化，立即以当前列表回调一次，返回取消订阅函数
+func (r *Resolver) Subscribe(ctx context.Context, name string, fn func([]*ServiceInfo)) (func(), error) {
+	entry, err := r.entry(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.mu.Lock()
+	id := entry.nextSubID
+	entry.nextSubID++
+	entry.subs[id] = fn
+	instances := entry.instances
+	entry.mu.Unlock()
+
+	fn(instances)
+
+	return func() {
+		entry.mu.Lock()
+		delete(entry.subs, id)
+		entry.mu.Unlock()
+	}, nil
+}
+
+// Report 上报服务实例的调用结果（供自行选择实例的调用方使用），服务尚未解析时忽略
+func (r *Resolver) Report(name, instanceID string, err error) {
+	r.mu.Lock()
+	entry, ok := r.entries[name]
+	r.mu.Unlock()
+	if ok && entry.outlier != nil {
+		entry.outlier.Report(instanceID, err)
+	}
+}
+
+// Close 停止所有监听
+func (r *Resolver) Close() error {
+	r.cancel()
+	r.mu.Lock()
+	r.entries = make(map[string]*resolverEntry)
+	r.mu.Unlock()
+	return nil
+}
+
+// entry 获取或初始化服务名对应的解析状态
+//
+// 拉取与 Watch 在锁外进行，不阻塞其他服务的解析；同一服务名的并发调用只初始化一次。
+func (r *Resolver) entry(ctx context.Context, name string) (*resolverEntry, error) {
+	r.mu.Lock()
+	if entry, ok := r.entries[name]; ok {
+		r.mu.Unlock()
+		return entry, nil
+	}
+	if r.ctx.Err() != nil {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("discovery: resolver closed")
+	}
+	if call, ok := r.pending[name]; ok {
+		r.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.entry, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &resolverCall{done: make(chan struct{})}
+	r.pending[name] = call
+	r.mu.Unlock()
+
+	call.entry, call.err = r.open(ctx, name)
+
+	r.mu.Lock()
+	delete(r.pending, name)
+	if call.err == nil {
+		if r.ctx.Err() != nil {
+			call.entry, call.err = nil, fmt.Errorf("discovery: resolver closed")
+		} else {
+			r.entries[name] = call.entry
+		}
+	}
+	r.mu.Unlock()
+	close(call.done)
+
+	return call.entry, call.err
+}
+
+// open 拉取服务实例并开始监听
+func (r *Resolver) open(ctx context.Context, name string) (*resolverEntry, error) {
+	services, err := r.discovery.GetService(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("resolve service %s failed: %w", name, err)
+	}
+
+	entry := &resolverEntry{
+		name:      name,
+		instances: r.filter(services),
+		balancer:  r.balancer(),
+		subs:      make(map[int]func([]*ServiceInfo)),
+	}
+	if r.outlier != nil {
+		entry.outlier = NewOutlierDetector(r.outlier)
+	}
+
+	if err := r.discovery.Watch(r.ctx, name, func(services []*ServiceInfo) {
+		r.update(entry, services)
+	}); err != nil {
+		return nil, fmt.Errorf("watch service %s failed: %w", name, err)
+	}
+
+	return entry, nil
+}
+
+// update 更新实例列表并通知订阅者
+func (r *Resolver) update(entry *resolverEntry, services []*ServiceInfo) {
+	instances := r.filter(services)
+
+	entry.mu.Lock()
+	entry.instances = instances
+	subs := make([]func([]*ServiceInfo), 0, len(entry.subs))
+	for _, fn := range entry.subs {
+		subs = append(subs, fn)
+	}
+	entry.mu.Unlock()
+
+	if r.logger != nil {
+		r.logger.Debug("service instances updated",
+			log.String("service", entry.name),
+			log.Int("instances", len(instances)),
+		)
+	}
+
+	for _, fn := range subs {
+		fn(instances)
+	}
+}
+
+// filter 执行实例过滤
+func (r *Resolver) filter(services []*ServiceInfo) []*ServiceInfo {
+	result := make([]*ServiceInfo, 0, len(services))
+	for _, s := range services {
+		keep := true
+		for _, f := range r.filters {
+			if !f(s) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func (e *resolverEntry) snapshot() []*ServiceInfo {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.instances
+}
@@ -0,0 +1,280 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeDiscovery 用于测试的内存服务发现
+type fakeDiscovery struct {
+	mu        sync.Mutex
+	services  map[string][]*ServiceInfo
+	callbacks map[string]func([]*ServiceInfo)
+	watches   int
+	gets      int
+	// onGet 在 GetService 返回前调用，用于模拟慢查询
+	onGet func(name string)
+}
+
+func newFakeDiscovery() *fakeDiscovery {
+	return &fakeDiscovery{
+		services:  make(map[string][]*ServiceInfo),
+		callbacks: make(map[string]func([]*ServiceInfo)),
+	}
+}
+
+func (f *fakeDiscovery) Register(ctx context.Context, s *ServiceInfo) error {
+	f.mu.Lock()
+	f.services[s.Name] = append(f.services[s.Name], s)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeDiscovery) Deregister(ctx context.Context, id string) error { return nil }
+
+func (f *fakeDiscovery) GetService(ctx context.Context, name string) ([]*ServiceInfo, error) {
+	if f.onGet != nil {
+		f.onGet(name)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gets++
+	if _, ok := f.services[name]; !ok {
+		return nil, errors.New("service not found")
+	}
+	return f.services[name], nil
+}
+
+func (f *fakeDiscovery) Watch(ctx context.Context, name string, cb func([]*ServiceInfo)) error {
+	f.mu.Lock()
+	f.callbacks[name] = cb
+	f.watches++
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeDiscovery) Close() error { return nil }
+
+func (f *fakeDiscovery) push(name string, services []*ServiceInfo) {
+	f.mu.Lock()
+	f.services[name] = services
+	cb := f.callbacks[name]
+	f.mu.Unlock()
+	if cb != nil {
+		cb(services)
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	d := newFakeDiscovery()
+	d.services["user"] = []*ServiceInfo{
+		{ID: "u1", Health: HealthStatusPassing},
+		{ID: "u2", Health: HealthStatusCritical},
+	}
+
+	r := NewResolver(d)
+	defer r.Close()
+
+	instances, err := r.Resolve(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if len(instances) != 1 || instances[0].ID != "u1" {
+		t.Errorf("应过滤掉 critical 实例, got %v", instances)
+	}
+
+	// 再次解析不应重复 Watch
+	_, _ = r.Resolve(context.Background(), "user")
+	if d.watches != 1 {
+		t.Errorf("watches = %d, want 1", d.watches)
+	}
+
+	d.push("user", []*ServiceInfo{{ID: "u3"}, {ID: "u4"}})
+	instances, _ = r.Resolve(context.Background(), "user")
+	if len(instances) != 2 {
+		t.Errorf("Watch 更新后实例数 = %d, want 2", len(instances))
+	}
+
+	if _, err := r.Resolve(context.Background(), "missing"); err == nil {
+		t.Error("不存在的服务应返回错误")
+	}
+}
+
+func TestResolver_Filters(t *testing.T) {
+	d := newFakeDiscovery()
+	d.services["order"] = []*ServiceInfo{
+		{ID: "o1", Tags: []string{"v1", "grpc"}, Metadata: map[string]string{"zone": "a"}},
+		{ID: "o2", Tags: []string{"v2", "grpc"}, Metadata: map[string]string{"zone": "a"}},
+		{ID: "o3", Tags: []string{"v1"}, Metadata: map[string]string{"zone": "b"}},
+	}
+
+	r := NewResolver(d, WithInstanceFilter(MatchTags("v1"), MatchMetadata(map[string]string{"zone": "a"})))
+	defer r.Close()
+
+	instances, _ := r.Resolve(context.Background(), "order")
+	if len(instances) != 1 || instances[0].ID != "o1" {
+		t.Errorf("过滤结果不正确: %v", instances)
+	}
+}
+
+func TestResolver_PickAndOutlier(t *testing.T) {
+	d := newFakeDiscovery()
+	d.services["pay"] = []*ServiceInfo{{ID: "p1"}, {ID: "p2"}}
+
+	r := NewResolver(d, WithOutlierDetection(&OutlierConfig{
+		ConsecutiveErrors:  1,
+		BaseEjectionTime:   1e9,
+		MaxEjectionPercent: 50,
+	}))
+	defer r.Close()
+
+	instance, done, err := r.Pick(context.Background(), "pay")
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	done(errors.New("connection refused"))
+
+	for i := 0; i < 5; i++ {
+		s, done, _ := r.Pick(context.Background(), "pay")
+		if s.ID == instance.ID {
+			t.Fatalf("被摘除的实例 %s 不应被选中", instance.ID)
+		}
+		done(nil)
+	}
+
+	d.push("pay", nil)
+	if _, _, err := r.Pick(context.Background(), "pay"); !errors.Is(err, ErrNoAvailableInstance) {
+		t.Errorf("无实例时应返回 ErrNoAvailableInstance, got %v", err)
+	}
+}
+
+func TestResolver_OutlierPerService(t *testing.T) {
+	d := newFakeDiscovery()
+	d.services["pay"] = []*ServiceInfo{{ID: "p1"}, {ID: "p2"}}
+	d.services["user"] = []*ServiceInfo{{ID: "u1"}, {ID: "u2"}}
+
+	r := NewResolver(d, WithOutlierDetection(&OutlierConfig{
+		ConsecutiveErrors:  2,
+		BaseEjectionTime:   1e9,
+		MaxEjectionPercent: 50,
+	}))
+	defer r.Close()
+
+	// 交替解析两个服务，pay 的失败统计不应被 user 的解析清除
+	ejected := ""
+	for i := 0; i < 4 && ejected == ""; i++ {
+		s, done, err := r.Pick(context.Background(), "pay")
+		if err != nil {
+			t.Fatalf("Pick(pay) error = %v", err)
+		}
+		if s.ID == "p1" {
+			done(errors.New("connection refused"))
+		} else {
+			done(nil)
+		}
+		if _, done, err := r.Pick(context.Background(), "user"); err == nil {
+			done(nil)
+		}
+		r.mu.Lock()
+		if r.entries["pay"].outlier.IsEjected("p1") {
+			ejected = "p1"
+		}
+		r.mu.Unlock()
+	}
+	if ejected != "p1" {
+		t.Fatal("p1 连续失败后应被摘除")
+	}
+
+	for i := 0; i < 4; i++ {
+		if s, done, _ := r.Pick(context.Background(), "pay"); s.ID == "p1" {
+			t.Fatal("被摘除的实例 p1 不应被选中")
+		} else {
+			done(nil)
+		}
+		if _, done, err := r.Pick(context.Background(), "user"); err == nil {
+			done(nil)
+		}
+	}
+
+	// Report 按服务名上报
+	r.Report("user", "u1", errors.New("timeout"))
+	r.Report("user", "u1", errors.New("timeout"))
+	r.mu.Lock()
+	userEjected := r.entries["user"].outlier.IsEjected("u1")
+	payEjected := r.entries["pay"].outlier.IsEjected("u1")
+	r.mu.Unlock()
+	if !userEjected || payEjected {
+		t.Errorf("user 的 u1 应被摘除且不影响 pay, user=%v pay=%v", userEjected, payEjected)
+	}
+}
+
+func TestResolver_EntryConcurrent(t *testing.T) {
+	d := newFakeDiscovery()
+	d.services["slow"] = []*ServiceInfo{{ID: "s1"}}
+	d.services["fast"] = []*ServiceInfo{{ID: "f1"}}
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	d.onGet = func(name string) {
+		if name == "slow" {
+			started <- struct{}{}
+			<-release
+		}
+	}
+
+	r := NewResolver(d)
+	defer r.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.Resolve(context.Background(), "slow"); err != nil {
+				t.Errorf("Resolve(slow) error = %v", err)
+			}
+		}()
+	}
+	<-started
+
+	// slow 的拉取未完成时，其他服务的解析不应被阻塞
+	if _, err := r.Resolve(context.Background(), "fast"); err != nil {
+		t.Fatalf("Resolve(fast) error = %v", err)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if d.gets != 2 || d.watches != 2 {
+		t.Errorf("gets = %d, watches = %d, want 2, 2", d.gets, d.watches)
+	}
+}
+
+func TestResolver_Subscribe(t *testing.T) {
+	d := newFakeDiscovery()
+	d.services["cart"] = []*ServiceInfo{{ID: "c1"}}
+
+	r := NewResolver(d)
+	defer r.Close()
+
+	var got [][]*ServiceInfo
+	cancel, err := r.Subscribe(context.Background(), "cart", func(s []*ServiceInfo) {
+		got = append(got, s)
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	d.push("cart", []*ServiceInfo{{ID: "c1"}, {ID: "c2"}})
+	cancel()
+	d.push("cart", []*ServiceInfo{{ID: "c3"}})
+
+	if len(got) != 2 {
+		t.Fatalf("回调次数 = %d, want 2", len(got))
+	}
+	if len(got[1]) != 2 {
+		t.Errorf("第二次回调实例数 = %d, want 2", len(got[1]))
+	}
+}
@@ -0,0 +1,294 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/goupter/goupter/pkg/config"
+	"github.com/goupter/goupter/pkg/log"
+	"gopkg.in/yaml.v3"
+)
+
+// staticReloadDelay 文件变更后的合并延迟，避免编辑器多次写入触发多次加载
+const staticReloadDelay = 100 * time.Millisecond
+
+// StaticDiscovery 静态服务发现，适用于本地开发等无注册中心的场景
+//
+// 实例来源于配置中的内联列表与 YAML 文件（格式同配置 discovery.static.services）：
+//
+//	services:
+//	  user-service:
+//	    - address: 127.0.0.1
+//	      port: 8080
+//
+// 文件所在目录通过 fsnotify 监听，文件被修改或替换（包括 Kubernetes ConfigMap 的符号链接切换）后
+// 重新加载并回调 Watch。Register 的实例仅保存在进程内存中。
+type StaticDiscovery struct {
+	file       string
+	inline     map[string][]config.StaticInstance
+	fromFile   map[string][]config.StaticInstance
+	registered map[string]*ServiceInfo
+	watchers   map[*staticWatcher]struct{}
+	fsWatcher  *fsnotify.Watcher
+	logger     log.Logger
+	mu         sync.RWMutex
+}
+
+type staticWatcher struct {
+	name     string
+	ctx      context.Context
+	callback func([]*ServiceInfo)
+	last     []*ServiceInfo
+}
+
+// StaticOption 静态服务发现选项
+type StaticOption func(*StaticDiscovery)
+
+// WithStaticConfig 设置静态服务发现配置
+func WithStaticConfig(cfg *config.StaticDiscoveryConfig) StaticOption {
+	return func(d *StaticDiscovery) {
+		d.file = cfg.File
+		d.inline = cfg.Services
+	}
+}
+
+// WithStaticFile 设置服务列表文件
+func WithStaticFile(file string) StaticOption {
+	return func(d *StaticDiscovery) { d.file = file }
+}
+
+// WithStaticLogger 设置日志
+func WithStaticLogger(logger log.Logger) StaticOption {
+	return func(d *StaticDiscovery) { d.logger = logger }
+}
+
+// NewStaticDiscovery 创建静态服务发现
+func NewStaticDiscovery(opts ...StaticOption) (*StaticDiscovery, error) {
+	d := &StaticDiscovery{
+		registered: make(map[string]*ServiceInfo),
+		watchers:   make(map[*staticWatcher]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if d.file == "" {
+		return d, nil
+	}
+
+	services, err := loadStaticFile(d.file)
+	if err != nil {
+		return nil, err
+	}
+	d.fromFile = services
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create file watcher failed: %w", err)
+	}
+	// 监听目录而非文件，文件被替换（rename/symlink 切换）后仍能收到事件
+	if err := fsWatcher.Add(filepath.Dir(d.file)); err != nil {
+		_ = fsWatcher.Close()
+		return nil, fmt.Errorf("watch file failed: %w", err)
+	}
+	d.fsWatcher = fsWatcher
+	go d.watchFile()
+
+	return d, nil
+}
+
+// loadStaticFile 读取 YAML 服务列表文件
+func loadStaticFile(file string) (map[string][]config.StaticInstance, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read static services failed: %w", err)
+	}
+
+	var doc struct {
+		Services map[string][]config.StaticInstance `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse static services failed: %w", err)
+	}
+	return doc.Services, nil
+}
+
+// watchFile 监听文件变化并重新加载
+func (d *StaticDiscovery) watchFile() {
+	var timer *time.Timer
+	for {
+		select {
+		case ev, ok := <-d.fsWatcher.Events:
+			if !ok {
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(staticReloadDelay, d.reload)
+		case err, ok := <-d.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			if d.logger != nil {
+				d.logger.Warn("watch static services failed", log.Error(err))
+			}
+		}
+	}
+}
+
+// reload 重新加载文件，加载失败时保留原有列表
+func (d *StaticDiscovery) reload() {
+	services, err := loadStaticFile(d.file)
+	if err != nil {
+		if d.logger != nil {
+			d.logger.Warn("reload static services failed", log.String("file", d.file), log.Error(err))
+		}
+		return
+	}
+
+	d.mu.Lock()
+	d.fromFile = services
+	d.mu.Unlock()
+
+	if d.logger != nil {
+		d.logger.Info("static services reloaded", log.String("file", d.file))
+	}
+	d.notify()
+}
+
+// Register 注册服务（仅进程内有效）
+func (d *StaticDiscovery) Register(ctx context.Context, service *ServiceInfo) error {
+	d.mu.Lock()
+	d.registered[service.ID] = service
+	d.mu.Unlock()
+	d.notify()
+	return nil
+}
+
+// Deregister 注销服务
+func (d *StaticDiscovery) Deregister(ctx context.Context, serviceID string) error {
+	d.mu.Lock()
+	delete(d.registered, serviceID)
+	d.mu.Unlock()
+	d.notify()
+	return nil
+}
+
+// GetService 获取服务实例列表
+func (d *StaticDiscovery) GetService(ctx context.Context, name string) ([]*ServiceInfo, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.services(name), nil
+}
+
+// services 合并内联、文件与进程内注册的实例，调用方需持有锁
+func (d *StaticDiscovery) services(name string) []*ServiceInfo {
+	result := make([]*ServiceInfo, 0)
+	for _, source := range []map[string][]config.StaticInstance{d.inline, d.fromFile} {
+		for _, inst := range source[name] {
+			result = append(result, staticInstance(name, inst))
+		}
+	}
+	for _, s := range d.registered {
+		if s.Name == name {
+			result = append(result, s)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+func staticInstance(name string, inst config.StaticInstance) *ServiceInfo {
+	id := inst.ID
+	if id == "" {
+		id = net.JoinHostPort(inst.Address, strconv.Itoa(inst.Port))
+	}
+	metadata := inst.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	return &ServiceInfo{
+		ID:       id,
+		Name:     name,
+		Address:  inst.Address,
+		Port:     inst.Port,
+		Weight:   inst.Weight,
+		Tags:     inst.Tags,
+		Metadata: metadata,
+		Health:   HealthStatusPassing,
+	}
+}
+
+// Watch 监听服务变化，立即回调当前列表，之后在列表变化时回调
+func (d *StaticDiscovery) Watch(ctx context.Context, name string, callback func([]*ServiceInfo)) error {
+	d.mu.Lock()
+	w := &staticWatcher{name: name, ctx: ctx, callback: callback, last: d.services(name)}
+	d.watchers[w] = struct{}{}
+	d.mu.Unlock()
+
+	callback(w.last)
+
+	go func() {
+		<-ctx.Done()
+		d.mu.Lock()
+		delete(d.watchers, w)
+		d.mu.Unlock()
+	}()
+	return nil
+}
+
+// notify 回调列表发生变化的监听者
+func (d *StaticDiscovery) notify() {
+	type pending struct {
+		callback func([]*ServiceInfo)
+		services []*ServiceInfo
+	}
+
+	d.mu.Lock()
+	var calls []pending
+	for w := range d.watchers {
+		if w.ctx.Err() != nil {
+			continue
+		}
+		services := d.services(w.name)
+		if reflect.DeepEqual(services, w.last) {
+			continue
+		}
+		w.last = services
+		calls = append(calls, pending{callback: w.callback, services: services})
+	}
+	d.mu.Unlock()
+
+	for _, c := range calls {
+		c.callback(c.services)
+	}
+}
+
+// Close 停止文件监听
+func (d *StaticDiscovery) Close() error {
+	d.mu.Lock()
+	d.watchers = make(map[*staticWatcher]struct{})
+	d.mu.Unlock()
+
+	if d.fsWatcher != nil {
+		return d.fsWatcher.Close()
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package discovery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/config"
+)
+
+func TestStaticDiscovery_InlineAndRegister(t *testing.T) {
+	d, err := NewStaticDiscovery(WithStaticConfig(&config.StaticDiscoveryConfig{
+		Services: map[string][]config.StaticInstance{
+			"user": {{Address: "127.0.0.1", Port: 8080, Weight: 5}},
+		},
+	}))
+	if err != nil {
+		t.Fatalf("NewStaticDiscovery() error = %v", err)
+	}
+	defer d.Close()
+
+	updates := make(chan []*ServiceInfo, 10)
+	_ = d.Watch(context.Background(), "user", func(s []*ServiceInfo) { updates <- s })
+	if s := <-updates; len(s) != 1 || s[0].ID != "127.0.0.1:8080" || s[0].Weight != 5 {
+		t.Fatalf("initial = %+v", s)
+	}
+
+	_ = d.Register(context.Background(), &ServiceInfo{ID: "user-local", Name: "user", Address: "127.0.0.1", Port: 9000})
+	if s := <-updates; len(s) != 2 {
+		t.Errorf("注册后应回调, got %d", len(s))
+	}
+
+	_ = d.Deregister(context.Background(), "user-local")
+	services, _ := d.GetService(context.Background(), "user")
+	if len(services) != 1 || len(<-updates) != 1 {
+		t.Errorf("注销后应只剩内联实例, got %d", len(services))
+	}
+}
+
+func TestStaticDiscovery_FileReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "services.yaml")
+	write := func(content string) {
+		t.Helper()
+		// 先写临时文件再重命名，模拟原子替换
+		tmp := file + ".tmp"
+		if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`
+services:
+  order:
+    - id: order-1
+      address: 10.0.0.1
+      port: 8080
+      metadata:
+        grpc_port: "9090"
+`)
+
+	d, err := NewStaticDiscovery(WithStaticFile(file))
+	if err != nil {
+		t.Fatalf("NewStaticDiscovery() error = %v", err)
+	}
+	defer d.Close()
+
+	updates := make(chan []*ServiceInfo, 10)
+	_ = d.Watch(context.Background(), "order", func(s []*ServiceInfo) { updates <- s })
+	if s := <-updates; len(s) != 1 || s[0].Endpoint("grpc") != "10.0.0.1:9090" {
+		t.Fatalf("initial = %+v", s)
+	}
+
+	write(`
+services:
+  order:
+    - id: order-1
+      address: 10.0.0.1
+      port: 8080
+    - id: order-2
+      address: 10.0.0.2
+      port: 8080
+`)
+	select {
+	case s := <-updates:
+		if len(s) != 2 || s[1].ID != "order-2" {
+			t.Errorf("reloaded = %+v", s)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("文件变更后应回调")
+	}
+
+	// 无效内容保留原有列表
+	write("services: [")
+	time.Sleep(3 * staticReloadDelay)
+	if services, _ := d.GetService(context.Background(), "order"); len(services) != 2 {
+		t.Errorf("加载失败应保留原有列表, got %d", len(services))
+	}
+
+	if _, err := NewStaticDiscovery(WithStaticFile(filepath.Join(dir, "missing.yaml"))); err == nil {
+		t.Error("文件不存在应返回错误")
+	}
+}
+
+func TestStaticDiscovery_SharedResolvers(t *testing.T) {
+	d, err := NewStaticDiscovery(WithStaticConfig(&config.StaticDiscoveryConfig{
+		Services: map[string][]config.StaticInstance{
+			"user": {{Address: "127.0.0.1", Port: 8080}},
+		},
+	}))
+	if err != nil {
+		t.Fatalf("NewStaticDiscovery() error = %v", err)
+	}
+	defer d.Close()
+
+	ctx := context.Background()
+	r1 := NewResolver(d)
+	defer r1.Close()
+	r2 := NewResolver(d)
+	defer r2.Close()
+	for _, r := range []*Resolver{r1, r2} {
+		if _, err := r.Resolve(ctx, "user"); err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+	}
+
+	_ = d.Register(ctx, &ServiceInfo{ID: "user-local", Name: "user", Address: "127.0.0.1", Port: 9000})
+
+	// 两个解析器共享同一 Discovery，均应收到更新
+	deadline := time.Now().Add(time.Second)
+	for i, r := range []*Resolver{r1, r2} {
+		for {
+			services, _ := r.Resolve(ctx, "user")
+			if len(services) == 2 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("resolver %d 未收到更新, got %d", i+1, len(services))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// 关闭其中一个不影响另一个的监听
+	r1.Close()
+	_ = d.Deregister(ctx, "user-local")
+	for {
+		services, _ := r2.Resolve(ctx, "user")
+		if len(services) == 1 {
+			break
+		}
+		if time.Now().After(deadline.Add(time.Second)) {
+			t.Fatalf("resolver 2 未收到注销更新, got %d", len(services))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,270 @@
+// Package feature 功能开关
+//
+// 开关以 JSON 存放在配置中心（Consul KV）指定前缀下，本地求值并随配置中心实时更新。
+// 支持三种类型：
+//
+//	bool        开/关
+//	percentage  按用户（无用户时按租户）稳定分桶的百分比放量
+//	variant     按权重分配的多变体，用于 A/B 实验
+//
+// 定向规则按用户 ID、角色或租户匹配，命中的第一条规则直接决定结果。
+package feature
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+)
+
+// FlagType 开关类型
+type FlagType string
+
+const (
+	// TypeBool 开/关
+	TypeBool FlagType = "bool"
+	// TypePercentage 百分比放量
+	TypePercentage FlagType = "percentage"
+	// TypeVariant 多变体
+	TypeVariant FlagType = "variant"
+)
+
+// 求值原因
+const (
+	ReasonNotFound = "not_found"
+	ReasonDisabled = "disabled"
+	ReasonTarget   = "target"
+	ReasonRollout  = "rollout"
+	ReasonDefault  = "default"
+)
+
+// 开关相关错误
+var (
+	ErrFlagNotFound = errors.New("feature flag not found")
+	ErrInvalidFlag  = errors.New("invalid feature flag")
+)
+
+// flagNamePattern 开关名称，同时作为 KV 键的最后一段
+var flagNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// bucketCount 分桶数量，百分比精确到 0.01
+const bucketCount = 10000
+
+// Flag 功能开关
+type Flag struct {
+	Name        string   `json:"name"`
+	Type        FlagType `json:"type"`
+	Description string   `json:"description,omitempty"`
+	// Enabled 总开关，关闭时对所有人返回关闭与默认变体
+	Enabled bool `json:"enabled"`
+	// Percentage 放量百分比（0-100），仅 percentage 类型
+	Percentage float64 `json:"percentage,omitempty"`
+	// Variants 变体及权重，仅 variant 类型
+	Variants []Variant `json:"variants,omitempty"`
+	// DefaultVariant 关闭或无法分桶时返回的变体
+	DefaultVariant string `json:"default_variant,omitempty"`
+	// Rules 定向规则，按顺序匹配
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+// Variant 变体
+type Variant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// Rule 定向规则，用户、角色、租户任一匹配即命中
+type Rule struct {
+	Users   []string `json:"users,omitempty"`
+	Roles   []string `json:"roles,omitempty"`
+	Tenants []string `json:"tenants,omitempty"`
+	// Enabled 命中后的开关结果
+	Enabled bool `json:"enabled"`
+	// Variant 命中后返回的变体，仅 variant 类型
+	Variant string `json:"variant,omitempty"`
+}
+
+// EvalContext 求值上下文
+type EvalContext struct {
+	UserID string
+	Roles  []string
+	Tenant string
+}
+
+// Evaluation 求值结果
+type Evaluation struct {
+	Flag    string `json:"flag"`
+	Enabled bool   `json:"enabled"`
+	Variant string `json:"variant,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// Validate 校验开关定义
+func (f *Flag) Validate() error {
+	if !flagNamePattern.MatchString(f.Name) {
+		return fmt.Errorf("%w: 名称 %q 不合法", ErrInvalidFlag, f.Name)
+	}
+
+	variants := make(map[string]bool, len(f.Variants))
+	switch f.Type {
+	case TypeBool:
+	case TypePercentage:
+		if f.Percentage < 0 || f.Percentage > 100 {
+			return fmt.Errorf("%w: %s: percentage 必须在 0-100 之间", ErrInvalidFlag, f.Name)
+		}
+	case TypeVariant:
+		if len(f.Variants) == 0 {
+			return fmt.Errorf("%w: %s: variant 类型至少需要一个变体", ErrInvalidFlag, f.Name)
+		}
+		total := 0
+		for _, v := range f.Variants {
+			if v.Name == "" || variants[v.Name] {
+				return fmt.Errorf("%w: %s: 变体名称为空或重复", ErrInvalidFlag, f.Name)
+			}
+			if v.Weight < 0 {
+				return fmt.Errorf("%w: %s: 变体 %s 权重不能为负", ErrInvalidFlag, f.Name, v.Name)
+			}
+			variants[v.Name] = true
+			total += v.Weight
+		}
+		if total == 0 {
+			return fmt.Errorf("%w: %s: 变体权重之和必须大于 0", ErrInvalidFlag, f.Name)
+		}
+		if f.DefaultVariant != "" && !variants[f.DefaultVariant] {
+			return fmt.Errorf("%w: %s: 默认变体 %s 不存在", ErrInvalidFlag, f.Name, f.DefaultVariant)
+		}
+	default:
+		return fmt.Errorf("%w: %s: 未知类型 %q", ErrInvalidFlag, f.Name, f.Type)
+	}
+
+	for i, r := range f.Rules {
+		if len(r.Users)+len(r.Roles)+len(r.Tenants) == 0 {
+			return fmt.Errorf("%w: %s: 规则 %d 没有匹配条件", ErrInvalidFlag, f.Name, i)
+		}
+		if r.Variant != "" && !variants[r.Variant] {
+			return fmt.Errorf("%w: %s: 规则 %d 的变体 %s 不存在", ErrInvalidFlag, f.Name, i, r.Variant)
+		}
+	}
+	return nil
+}
+
+// Evaluate 对求值上下文计算开关结果，ec 可为 nil
+func (f *Flag) Evaluate(ec *EvalContext) Evaluation {
+	if ec == nil {
+		ec = &EvalContext{}
+	}
+	result := Evaluation{Flag: f.Name, Variant: f.DefaultVariant}
+
+	if !f.Enabled {
+		result.Reason = ReasonDisabled
+		return result
+	}
+
+	for _, r := range f.Rules {
+		if r.matches(ec) {
+			result.Enabled = r.Enabled
+			if f.Type == TypeVariant && r.Enabled {
+				result.Variant = r.Variant
+				if result.Variant == "" {
+					result.Variant = f.pickVariant(ec)
+				}
+			}
+			result.Reason = ReasonTarget
+			return result
+		}
+	}
+
+	switch f.Type {
+	case TypeBool:
+		result.Enabled = true
+		result.Reason = ReasonDefault
+	case TypePercentage:
+		key := ec.bucketKey()
+		if key == "" {
+			result.Reason = ReasonDefault
+			return result
+		}
+		result.Enabled = float64(bucket(f.Name, key)) < f.Percentage*bucketCount/100
+		result.Reason = ReasonRollout
+	case TypeVariant:
+		// 开关已开启，无法分桶时命中默认变体
+		result.Enabled = true
+		if ec.bucketKey() == "" {
+			result.Reason = ReasonDefault
+			return result
+		}
+		result.Variant = f.pickVariant(ec)
+		result.Reason = ReasonRollout
+	}
+	return result
+}
+
+// pickVariant 按权重为上下文稳定分配变体，无法分桶时返回默认变体
+func (f *Flag) pickVariant(ec *EvalContext) string {
+	key := ec.bucketKey()
+	if key == "" {
+		return f.DefaultVariant
+	}
+
+	total := 0
+	for _, v := range f.Variants {
+		total += v.Weight
+	}
+	if total == 0 {
+		return f.DefaultVariant
+	}
+
+	n := bucket(f.Name, key) % total
+	for _, v := range f.Variants {
+		if n < v.Weight {
+			return v.Name
+		}
+		n -= v.Weight
+	}
+	return f.DefaultVariant
+}
+
+// matches 规则是否命中
+func (r *Rule) matches(ec *EvalContext) bool {
+	if ec.UserID != "" && contains(r.Users, ec.UserID) {
+		return true
+	}
+	if ec.Tenant != "" && contains(r.Tenants, ec.Tenant) {
+		return true
+	}
+	for _, role := range ec.Roles {
+		if contains(r.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// bucketKey 分桶依据，优先用户，其次租户
+func (ec *EvalContext) bucketKey() string {
+	if ec.UserID != "" {
+		return "user:" + ec.UserID
+	}
+	if ec.Tenant != "" {
+		return "tenant:" + ec.Tenant
+	}
+	return ""
+}
+
+// bucket 计算稳定分桶，同一开关同一用户结果不变，不同开关相互独立
+func bucket(flag, key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(flag))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % bucketCount)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package feature
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFlag_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		flag Flag
+		ok   bool
+	}{
+		{"bool", Flag{Name: "new-ui", Type: TypeBool}, true},
+		{"bad name", Flag{Name: "a/b", Type: TypeBool}, false},
+		{"unknown type", Flag{Name: "x", Type: "int"}, false},
+		{"percentage out of range", Flag{Name: "x", Type: TypePercentage, Percentage: 101}, false},
+		{"variant without variants", Flag{Name: "x", Type: TypeVariant}, false},
+		{"variant zero weight", Flag{Name: "x", Type: TypeVariant, Variants: []Variant{{Name: "a"}}}, false},
+		{"variant duplicate", Flag{Name: "x", Type: TypeVariant, Variants: []Variant{{Name: "a", Weight: 1}, {Name: "a", Weight: 1}}}, false},
+		{"unknown default variant", Flag{Name: "x", Type: TypeVariant, Variants: []Variant{{Name: "a", Weight: 1}}, DefaultVariant: "b"}, false},
+		{"empty rule", Flag{Name: "x", Type: TypeBool, Rules: []Rule{{Enabled: true}}}, false},
+		{"rule unknown variant", Flag{Name: "x", Type: TypeVariant, Variants: []Variant{{Name: "a", Weight: 1}}, Rules: []Rule{{Users: []string{"u"}, Variant: "b"}}}, false},
+		{"variant", Flag{Name: "x", Type: TypeVariant, Variants: []Variant{{Name: "a", Weight: 1}}, DefaultVariant: "a"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.flag.Validate()
+			if tt.ok && err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidFlag) {
+				t.Errorf("Validate() error = %v, want ErrInvalidFlag", err)
+			}
+		})
+	}
+}
+
+func TestFlag_EvaluateBool(t *testing.T) {
+	flag := &Flag{
+		Name:    "new-ui",
+		Type:    TypeBool,
+		Enabled: true,
+		Rules: []Rule{
+			{Users: []string{"blocked"}, Enabled: false},
+			{Roles: []string{"admin"}, Enabled: true},
+		},
+	}
+
+	if r := flag.Evaluate(nil); !r.Enabled || r.Reason != ReasonDefault {
+		t.Errorf("Evaluate(nil) = %+v", r)
+	}
+	if r := flag.Evaluate(&EvalContext{UserID: "blocked", Roles: []string{"admin"}}); r.Enabled || r.Reason != ReasonTarget {
+		t.Errorf("先匹配的规则应生效: %+v", r)
+	}
+
+	flag.Enabled = false
+	if r := flag.Evaluate(&EvalContext{Roles: []string{"admin"}}); r.Enabled || r.Reason != ReasonDisabled {
+		t.Errorf("总开关关闭时应忽略规则: %+v", r)
+	}
+}
+
+func TestFlag_EvaluatePercentage(t *testing.T) {
+	flag := &Flag{
+		Name:       "checkout-v2",
+		Type:       TypePercentage,
+		Enabled:    true,
+		Percentage: 30,
+		Rules:      []Rule{{Tenants: []string{"beta"}, Enabled: true}},
+	}
+
+	enabled := 0
+	for i := 0; i < 10000; i++ {
+		ec := &EvalContext{UserID: fmt.Sprintf("user-%d", i)}
+		r := flag.Evaluate(ec)
+		if r.Reason != ReasonRollout {
+			t.Fatalf("reason = %s", r.Reason)
+		}
+		// 同一用户结果稳定
+		if flag.Evaluate(ec).Enabled != r.Enabled {
+			t.Fatal("同一用户结果应稳定")
+		}
+		if r.Enabled {
+			enabled++
+		}
+	}
+	if enabled < 2700 || enabled > 3300 {
+		t.Errorf("enabled = %d, want ≈3000", enabled)
+	}
+
+	if r := flag.Evaluate(&EvalContext{UserID: "x", Tenant: "beta"}); !r.Enabled || r.Reason != ReasonTarget {
+		t.Errorf("租户规则应命中: %+v", r)
+	}
+	if r := flag.Evaluate(&EvalContext{}); r.Enabled || r.Reason != ReasonDefault {
+		t.Errorf("无法分桶时应关闭: %+v", r)
+	}
+
+	flag.Percentage = 100
+	if !flag.Evaluate(&EvalContext{Tenant: "t1"}).Enabled {
+		t.Error("100% 应全部开启")
+	}
+	flag.Percentage = 0
+	if flag.Evaluate(&EvalContext{UserID: "u1"}).Enabled {
+		t.Error("0% 应全部关闭")
+	}
+}
+
+func TestFlag_EvaluateVariant(t *testing.T) {
+	flag := &Flag{
+		Name:           "pricing",
+		Type:           TypeVariant,
+		Enabled:        true,
+		Variants:       []Variant{{Name: "control", Weight: 50}, {Name: "treatment", Weight: 50}},
+		DefaultVariant: "control",
+		Rules:          []Rule{{Users: []string{"qa"}, Enabled: true, Variant: "treatment"}},
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		counts[flag.Evaluate(&EvalContext{UserID: fmt.Sprintf("user-%d", i)}).Variant]++
+	}
+	if counts["control"] < 4500 || counts["treatment"] < 4500 {
+		t.Errorf("counts = %v", counts)
+	}
+
+	if r := flag.Evaluate(&EvalContext{UserID: "qa"}); r.Variant != "treatment" || r.Reason != ReasonTarget {
+		t.Errorf("规则应指定变体: %+v", r)
+	}
+	if r := flag.Evaluate(nil); r.Variant != "control" || !r.Enabled || r.Reason != ReasonDefault {
+		t.Errorf("无法分桶时应开启并返回默认变体: %+v", r)
+	}
+
+	flag.Enabled = false
+	if r := flag.Evaluate(&EvalContext{UserID: "qa"}); r.Variant != "control" || r.Enabled {
+		t.Errorf("关闭时应返回默认变体: %+v", r)
+	}
+}
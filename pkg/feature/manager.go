@@ -0,0 +1,352 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goupter/goupter/pkg/config"
+	"github.com/goupter/goupter/pkg/log"
+)
+
+// 默认值
+const (
+	DefaultPrefix          = "features/"
+	DefaultRefreshInterval = 30 * time.Second
+)
+
+// Store 开关存储，*config.ConsulConfigCenter 直接实现该接口
+//
+// GetKey 在键不存在时应返回包装了 config.ErrKeyNotFound 的错误。
+type Store interface {
+	GetKey(key string) ([]byte, error)
+	PutKey(key string, value []byte) error
+	DeleteKey(key string) error
+	List(prefix string) ([]string, error)
+}
+
+// PrefixWatcher 支持按前缀监听的存储，如 *config.ConsulConfigCenter
+//
+// 存储未实现该接口时按刷新间隔轮询。
+type PrefixWatcher interface {
+	WatchPrefix(ctx context.Context, prefix string, fn func(map[string][]byte)) error
+}
+
+// Manager 功能开关管理器
+type Manager struct {
+	store           Store
+	prefix          string
+	refreshInterval time.Duration
+	tenantKey       string
+	logger          log.Logger
+
+	mu        sync.RWMutex
+	flags     map[string]*Flag
+	callbacks []func(map[string]*Flag)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Option 管理器选项
+type Option func(*Manager)
+
+// WithPrefix 设置 KV 前缀，默认 features/
+func WithPrefix(prefix string) Option {
+	return func(m *Manager) {
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		m.prefix = prefix
+	}
+}
+
+// WithRefreshInterval 设置轮询间隔，存储不支持前缀监听时生效，默认 30s
+func WithRefreshInterval(d time.Duration) Option {
+	return func(m *Manager) {
+		m.refreshInterval = d
+	}
+}
+
+// WithTenantKey 设置 AuthInfo.Extra 中租户 ID 的键，默认 tenant_id
+func WithTenantKey(key string) Option {
+	return func(m *Manager) {
+		m.tenantKey = key
+	}
+}
+
+// WithLogger 设置日志
+func WithLogger(logger log.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// NewManager 创建功能开关管理器
+func NewManager(store Store, opts ...Option) *Manager {
+	m := &Manager{
+		store:           store,
+		prefix:          DefaultPrefix,
+		refreshInterval: DefaultRefreshInterval,
+		tenantKey:       DefaultTenantKey,
+		flags:           make(map[string]*Flag),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Load 从存储加载全部开关，读取失败时返回错误并保留当前开关
+func (m *Manager) Load() error {
+	keys, err := m.store.List(m.prefix)
+	if err != nil {
+		return fmt.Errorf("列出功能开关失败: %w", err)
+	}
+
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if !m.isFlagKey(key) {
+			continue
+		}
+		data, err := m.store.GetKey(key)
+		if errors.Is(err, config.ErrKeyNotFound) {
+			// 列出与读取之间被删除
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("读取功能开关 %s 失败: %w", key, err)
+		}
+		values[key] = data
+	}
+
+	m.replace(values)
+	return nil
+}
+
+// Watch 启动后台更新，存储支持前缀监听时实时更新，否则定时轮询
+func (m *Manager) Watch() error {
+	m.mu.Lock()
+	if m.cancel != nil {
+		m.mu.Unlock()
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	done := m.done
+	m.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		if w, ok := m.store.(PrefixWatcher); ok {
+			err := w.WatchPrefix(ctx, m.prefix, func(values map[string][]byte) {
+				for key := range values {
+					if !m.isFlagKey(key) {
+						delete(values, key)
+					}
+				}
+				m.replace(values)
+			})
+			if err != nil && ctx.Err() == nil && m.logger != nil {
+				m.logger.Error("watch feature flags failed", log.String("prefix", m.prefix), log.Error(err))
+			}
+			return
+		}
+		m.pollLoop(ctx)
+	}()
+	return nil
+}
+
+// pollLoop 定时重新加载
+func (m *Manager) pollLoop(ctx context.Context) {
+	ticker := time.NewTicker(m.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Load(); err != nil && m.logger != nil {
+				m.logger.Warn("refresh feature flags failed", log.String("prefix", m.prefix), log.Error(err))
+			}
+		}
+	}
+}
+
+// Stop 停止后台更新
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.cancel, m.done = nil, nil
+	m.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// replace 用存储中的内容替换本地开关，无法解析的开关保留旧值
+func (m *Manager) replace(values map[string][]byte) {
+	m.mu.Lock()
+	flags := make(map[string]*Flag, len(values))
+	for key, data := range values {
+		name := strings.TrimPrefix(key, m.prefix)
+		flag, err := decodeFlag(name, data)
+		if err != nil {
+			if m.logger != nil {
+				m.logger.Warn("invalid feature flag", log.String("key", key), log.Error(err))
+			}
+			if prev, ok := m.flags[name]; ok {
+				flags[name] = prev
+			}
+			continue
+		}
+		flags[name] = flag
+	}
+	m.swapLocked(flags)
+}
+
+// swapLocked 替换本地开关并通知订阅者，调用方需持有写锁，返回前释放
+func (m *Manager) swapLocked(flags map[string]*Flag) {
+	m.flags = flags
+	callbacks := make([]func(map[string]*Flag), len(m.callbacks))
+	copy(callbacks, m.callbacks)
+	m.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(flags)
+	}
+}
+
+// isFlagKey 是否为前缀下的开关键，忽略目录键与子目录
+func (m *Manager) isFlagKey(key string) bool {
+	name := strings.TrimPrefix(key, m.prefix)
+	return strings.HasPrefix(key, m.prefix) && name != "" && !strings.Contains(name, "/")
+}
+
+// decodeFlag 解析开关，名称以 KV 键为准
+func decodeFlag(name string, data []byte) (*Flag, error) {
+	flag := &Flag{}
+	if err := json.Unmarshal(data, flag); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+	flag.Name = name
+	if flag.Type == "" {
+		flag.Type = TypeBool
+	}
+	if err := flag.Validate(); err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+// Set 校验并写入开关，同时更新本地并通知订阅者
+func (m *Manager) Set(flag *Flag) error {
+	if flag.Type == "" {
+		flag.Type = TypeBool
+	}
+	if err := flag.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(flag)
+	if err != nil {
+		return fmt.Errorf("序列化功能开关失败: %w", err)
+	}
+	if err := m.store.PutKey(m.prefix+flag.Name, data); err != nil {
+		return fmt.Errorf("写入功能开关失败: %w", err)
+	}
+
+	cp := *flag
+	m.mu.Lock()
+	flags := make(map[string]*Flag, len(m.flags)+1)
+	for k, v := range m.flags {
+		flags[k] = v
+	}
+	flags[flag.Name] = &cp
+	m.swapLocked(flags)
+	return nil
+}
+
+// Delete 删除开关，同时更新本地并通知订阅者
+func (m *Manager) Delete(name string) error {
+	if err := m.store.DeleteKey(m.prefix + name); err != nil {
+		return fmt.Errorf("删除功能开关失败: %w", err)
+	}
+
+	m.mu.Lock()
+	flags := make(map[string]*Flag, len(m.flags))
+	for k, v := range m.flags {
+		if k != name {
+			flags[k] = v
+		}
+	}
+	m.swapLocked(flags)
+	return nil
+}
+
+// Get 获取开关定义
+func (m *Manager) Get(name string) (*Flag, error) {
+	m.mu.RLock()
+	flag, ok := m.flags[name]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFlagNotFound, name)
+	}
+	cp := *flag
+	return &cp, nil
+}
+
+// Flags 获取全部开关，按名称排序
+func (m *Manager) Flags() []*Flag {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	flags := make([]*Flag, 0, len(m.flags))
+	for _, flag := range m.flags {
+		cp := *flag
+		flags = append(flags, &cp)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// OnChange 注册开关更新回调，每次从存储重新加载或经 Set、Delete 修改后调用
+func (m *Manager) OnChange(fn func(flags map[string]*Flag)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.callbacks = append(m.callbacks, fn)
+}
+
+// Evaluate 按上下文中的求值上下文计算开关，开关不存在时返回关闭
+func (m *Manager) Evaluate(ctx context.Context, name string) Evaluation {
+	m.mu.RLock()
+	flag, ok := m.flags[name]
+	m.mu.RUnlock()
+	if !ok {
+		return Evaluation{Flag: name, Reason: ReasonNotFound}
+	}
+
+	ec, _ := EvalContextFrom(ctx)
+	return flag.Evaluate(ec)
+}
+
+// IsEnabled 开关是否开启
+func (m *Manager) IsEnabled(ctx context.Context, name string) bool {
+	return m.Evaluate(ctx, name).Enabled
+}
+
+// Variant 获取分配的变体
+func (m *Manager) Variant(ctx context.Context, name string) string {
+	return m.Evaluate(ctx, name).Variant
+}
@@ -0,0 +1,301 @@
+package feature
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/config"
+)
+
+// fakeConsul 最小化的 Consul KV HTTP 接口，支持阻塞查询
+type fakeConsul struct {
+	mu      sync.Mutex
+	kv      map[string][]byte
+	index   uint64
+	changed chan struct{}
+}
+
+func newFakeConsul(t *testing.T) (*fakeConsul, *config.ConsulConfigCenter) {
+	t.Helper()
+	f := &fakeConsul{kv: make(map[string][]byte), index: 1, changed: make(chan struct{})}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	source, err := config.NewConsulSource(strings.TrimPrefix(srv.URL, "http://"), "config/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, config.NewConsulConfigCenter(source)
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	query := r.URL.Query()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.update(func() { f.kv[key] = body })
+		_, _ = w.Write([]byte("true"))
+		return
+	case http.MethodDelete:
+		f.update(func() { delete(f.kv, key) })
+		_, _ = w.Write([]byte("true"))
+		return
+	}
+
+	// 阻塞查询：等待索引变化
+	if index, _ := strconv.ParseUint(query.Get("index"), 10, 64); index > 0 {
+		f.mu.Lock()
+		current, changed := f.index, f.changed
+		f.mu.Unlock()
+		if index >= current {
+			select {
+			case <-changed:
+			case <-r.Context().Done():
+				return
+			case <-time.After(2 * time.Second):
+			}
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+
+	var keys []string
+	for k := range f.kv {
+		if (query.Has("keys") || query.Has("recurse")) && strings.HasPrefix(k, key) || k == key {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	if query.Has("keys") {
+		_ = json.NewEncoder(w).Encode(keys)
+		return
+	}
+	if len(keys) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	pairs := make([]map[string]any, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, map[string]any{
+			"Key":         k,
+			"Value":       base64.StdEncoding.EncodeToString(f.kv[k]),
+			"ModifyIndex": f.index,
+		})
+	}
+	_ = json.NewEncoder(w).Encode(pairs)
+}
+
+func (f *fakeConsul) update(fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn()
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// mapStore 不支持前缀监听的内存存储
+type mapStore struct {
+	mu sync.Mutex
+	kv map[string][]byte
+	// getErr 非空时 GetKey 返回该错误，模拟存储不可用
+	getErr error
+}
+
+func (s *mapStore) GetKey(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	v, ok := s.kv[key]
+	if !ok {
+		return nil, config.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (s *mapStore) PutKey(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kv[key] = value
+	return nil
+}
+
+func (s *mapStore) DeleteKey(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.kv, key)
+	return nil
+}
+
+func (s *mapStore) List(prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for k := range s.kv {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("等待条件超时")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManager_ConsulStore(t *testing.T) {
+	fake, center := newFakeConsul(t)
+	// 目录键与子目录被忽略
+	fake.kv["features/"] = nil
+	fake.kv["features/archive/old"] = []byte(`{"enabled":true}`)
+
+	admin := NewManager(center)
+	if err := admin.Set(&Flag{Name: "new-ui", Enabled: true, Rules: []Rule{{Roles: []string{"beta"}, Enabled: true}}}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := admin.Set(&Flag{Name: "bad", Type: TypePercentage, Percentage: 200}); !errors.Is(err, ErrInvalidFlag) {
+		t.Errorf("Set() error = %v, want ErrInvalidFlag", err)
+	}
+	if _, ok := fake.kv["features/new-ui"]; !ok {
+		t.Fatal("开关应写入 features/new-ui")
+	}
+
+	m := NewManager(center, WithPrefix("features"))
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	flags := m.Flags()
+	if len(flags) != 1 || flags[0].Name != "new-ui" || flags[0].Type != TypeBool {
+		t.Fatalf("Flags() = %+v", flags)
+	}
+
+	changed := make(chan map[string]*Flag, 10)
+	m.OnChange(func(flags map[string]*Flag) { changed <- flags })
+	if err := m.Watch(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	ctx := context.Background()
+	if !m.IsEnabled(ctx, "new-ui") {
+		t.Error("new-ui 应开启")
+	}
+
+	// 实时更新
+	if err := admin.Set(&Flag{Name: "new-ui", Enabled: false}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return !m.IsEnabled(ctx, "new-ui") })
+	<-changed
+
+	// 无法解析的内容保留旧定义
+	if err := center.PutKey("features/new-ui", []byte("{")); err != nil {
+		t.Fatal(err)
+	}
+	if err := center.PutKey("features/rollout", []byte(`{"type":"percentage","enabled":true,"percentage":100}`)); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		_, err := m.Get("rollout")
+		return err == nil
+	})
+	if flag, err := m.Get("new-ui"); err != nil || flag.Enabled {
+		t.Errorf("Get(new-ui) = %+v, %v", flag, err)
+	}
+
+	if err := admin.Delete("rollout"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		_, err := m.Get("rollout")
+		return errors.Is(err, ErrFlagNotFound)
+	})
+	if r := m.Evaluate(ctx, "rollout"); r.Enabled || r.Reason != ReasonNotFound {
+		t.Errorf("Evaluate() = %+v", r)
+	}
+}
+
+func TestManager_Poll(t *testing.T) {
+	store := &mapStore{kv: map[string][]byte{
+		"features/dark-mode": []byte(`{"enabled":false}`),
+	}}
+	m := NewManager(store, WithRefreshInterval(20*time.Millisecond))
+	if err := m.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Watch(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	ctx := context.Background()
+	if m.IsEnabled(ctx, "dark-mode") {
+		t.Fatal("dark-mode 应关闭")
+	}
+	_ = store.PutKey("features/dark-mode", []byte(`{"enabled":true}`))
+	waitFor(t, func() bool { return m.IsEnabled(ctx, "dark-mode") })
+}
+
+func TestManager_LoadKeepsFlagsOnError(t *testing.T) {
+	store := &mapStore{kv: map[string][]byte{
+		"features/dark-mode": []byte(`{"enabled":true}`),
+	}}
+	m := NewManager(store)
+	if err := m.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 读取失败不能当作开关已删除
+	store.getErr = errors.New("store unavailable")
+	if err := m.Load(); err == nil {
+		t.Error("Load() should fail when GetKey fails")
+	}
+	if !m.IsEnabled(context.Background(), "dark-mode") {
+		t.Error("读取失败时应保留原有开关")
+	}
+}
+
+func TestManager_SetDeleteNotify(t *testing.T) {
+	m := NewManager(&mapStore{kv: make(map[string][]byte)})
+	var got []map[string]*Flag
+	m.OnChange(func(flags map[string]*Flag) { got = append(got, flags) })
+
+	if err := m.Set(&Flag{Name: "dark-mode", Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0]["dark-mode"] == nil || !got[0]["dark-mode"].Enabled {
+		t.Fatalf("Set 应通知订阅者, got %v", got)
+	}
+
+	if err := m.Delete("dark-mode"); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || len(got[1]) != 0 {
+		t.Fatalf("Delete 应通知订阅者, got %v", got)
+	}
+}
@@ -0,0 +1,116 @@
+package feature
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/server/interceptor"
+	"github.com/goupter/goupter/pkg/server/middleware"
+	"google.golang.org/grpc"
+)
+
+// DefaultTenantKey AuthInfo.Extra 中租户 ID 的默认键
+const DefaultTenantKey = "tenant_id"
+
+// 上下文键类型
+type (
+	evalContextKey struct{}
+	managerKey     struct{}
+)
+
+// NewEvalContext 从认证信息构造求值上下文，info 可为 nil
+func NewEvalContext(info *middleware.AuthInfo, tenantKey string) *EvalContext {
+	ec := &EvalContext{}
+	if info == nil {
+		return ec
+	}
+
+	ec.UserID = info.UserID
+	ec.Roles = info.Roles
+	if tenantKey == "" {
+		tenantKey = DefaultTenantKey
+	}
+	if v, ok := info.Extra[tenantKey]; ok && v != nil {
+		ec.Tenant = fmt.Sprint(v)
+	}
+	return ec
+}
+
+// WithEvalContext 将求值上下文放入上下文
+func WithEvalContext(ctx context.Context, ec *EvalContext) context.Context {
+	return context.WithValue(ctx, evalContextKey{}, ec)
+}
+
+// EvalContextFrom 从上下文获取求值上下文
+func EvalContextFrom(ctx context.Context) (*EvalContext, bool) {
+	ec, ok := ctx.Value(evalContextKey{}).(*EvalContext)
+	return ec, ok
+}
+
+// WithManager 将管理器放入上下文
+func WithManager(ctx context.Context, m *Manager) context.Context {
+	return context.WithValue(ctx, managerKey{}, m)
+}
+
+// FromContext 从上下文获取管理器
+func FromContext(ctx context.Context) (*Manager, bool) {
+	m, ok := ctx.Value(managerKey{}).(*Manager)
+	return m, ok
+}
+
+// IsEnabled 使用上下文中的管理器判断开关，未经过中间件时返回 false
+func IsEnabled(ctx context.Context, name string) bool {
+	if m, ok := FromContext(ctx); ok {
+		return m.IsEnabled(ctx, name)
+	}
+	return false
+}
+
+// GetVariant 使用上下文中的管理器获取变体，未经过中间件时返回空
+func GetVariant(ctx context.Context, name string) string {
+	if m, ok := FromContext(ctx); ok {
+		return m.Variant(ctx, name)
+	}
+	return ""
+}
+
+// withRequest 构造请求的求值上下文并放入上下文
+func (m *Manager) withRequest(ctx context.Context, info *middleware.AuthInfo) context.Context {
+	ec := NewEvalContext(info, m.tenantKey)
+	return WithManager(WithEvalContext(ctx, ec), m)
+}
+
+// Middleware Gin 中间件，从认证信息构造求值上下文，需放在认证中间件之后
+func Middleware(m *Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		info, _ := middleware.GetAuthInfo(c)
+		c.Request = c.Request.WithContext(m.withRequest(c.Request.Context(), info))
+		c.Next()
+	}
+}
+
+// UnaryInterceptor gRPC 一元拦截器，需放在认证拦截器之后
+func UnaryInterceptor(m *Manager) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(m.withRequest(ctx, grpcAuthInfo(ctx)), req)
+	}
+}
+
+// StreamInterceptor gRPC 流式拦截器，需放在认证拦截器之后
+func StreamInterceptor(m *Manager) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, interceptor.WrapServerStream(ss, m.withRequest(ss.Context(), grpcAuthInfo(ss.Context()))))
+	}
+}
+
+// grpcAuthInfo 获取 gRPC 请求的认证信息，仅有用户 ID 时构造最小认证信息
+func grpcAuthInfo(ctx context.Context) *middleware.AuthInfo {
+	if info, ok := middleware.GetAuthInfoFromContext(ctx); ok {
+		return info
+	}
+	if userID, ok := interceptor.GetUserID(ctx); ok {
+		return &middleware.AuthInfo{UserID: userID}
+	}
+	return nil
+}
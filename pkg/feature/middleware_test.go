@@ -0,0 +1,111 @@
+package feature
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/server/middleware"
+	"google.golang.org/grpc"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	m := NewManager(&mapStore{kv: make(map[string][]byte)})
+	flags := []*Flag{
+		{Name: "beta", Enabled: true, Type: TypePercentage, Rules: []Rule{{Tenants: []string{"acme"}, Enabled: true}}},
+		{Name: "exp", Enabled: true, Type: TypeVariant, Variants: []Variant{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}}, Rules: []Rule{{Users: []string{"42"}, Enabled: true, Variant: "b"}}},
+	}
+	for _, f := range flags {
+		if err := m.Set(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func TestNewEvalContext(t *testing.T) {
+	if ec := NewEvalContext(nil, ""); ec.UserID != "" || ec.Tenant != "" {
+		t.Errorf("ec = %+v", ec)
+	}
+
+	info := &middleware.AuthInfo{UserID: "1", Roles: []string{"admin"}, Extra: map[string]interface{}{"tenant_id": 7, "org": "acme"}}
+	if ec := NewEvalContext(info, ""); ec.UserID != "1" || ec.Tenant != "7" || len(ec.Roles) != 1 {
+		t.Errorf("ec = %+v", ec)
+	}
+	if ec := NewEvalContext(info, "org"); ec.Tenant != "acme" {
+		t.Errorf("tenant = %s", ec.Tenant)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := newTestManager(t)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		middleware.SetAuthInfo(c, &middleware.AuthInfo{UserID: "42", Extra: map[string]interface{}{"tenant_id": "acme"}})
+	})
+	r.Use(Middleware(m))
+	r.GET("/", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if !IsEnabled(ctx, "beta") || GetVariant(ctx, "exp") != "b" {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d", w.Code)
+	}
+
+	if IsEnabled(context.Background(), "beta") || GetVariant(context.Background(), "exp") != "" {
+		t.Error("未经过中间件时应返回关闭")
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestInterceptors(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.WithValue(context.Background(), middleware.AuthContextKey{}, &middleware.AuthInfo{
+		UserID: "42",
+		Extra:  map[string]interface{}{"tenant_id": "acme"},
+	})
+
+	_, err := UnaryInterceptor(m)(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		ec, ok := EvalContextFrom(ctx)
+		if !ok || ec.UserID != "42" || ec.Tenant != "acme" {
+			t.Errorf("ec = %+v", ec)
+		}
+		if !IsEnabled(ctx, "beta") {
+			t.Error("beta 应开启")
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = StreamInterceptor(m)(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		if GetVariant(ss.Context(), "exp") != "b" {
+			t.Error("exp 应为 b")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
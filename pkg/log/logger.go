@@ -144,13 +144,16 @@ type Writer interface {
 }
 
 // contextKey 上下文键类型
-type contextKey struct{}
+type contextKey struct{ name string }
 
 // traceIDKey 追踪ID的上下文键
-var traceIDKey = contextKey{}
+var traceIDKey = contextKey{name: "trace_id"}
 
 // requestIDKey 请求ID的上下文键
-var requestIDKey = contextKey{}
+var requestIDKey = contextKey{name: "request_id"}
+
+// tenantIDKey 租户ID的上下文键
+var tenantIDKey = contextKey{name: "tenant_id"}
 
 // WithTraceID 设置追踪ID到上下文
 func WithTraceID(ctx context.Context, traceID string) context.Context {
@@ -177,3 +180,16 @@ func RequestIDFromContext(ctx context.Context) string {
 	}
 	return ""
 }
+
+// WithTenantID 设置租户ID到上下文
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, tenantID)
+}
+
+// TenantIDFromContext 从上下文获取租户ID
+func TenantIDFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantIDKey).(string); ok {
+		return tenantID
+	}
+	return ""
+}
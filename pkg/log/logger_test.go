@@ -137,6 +137,17 @@ func TestRequestIDFromContext_Empty(t *testing.T) {
 	}
 }
 
+func TestWithTenantID(t *testing.T) {
+	ctx := WithTenantID(context.Background(), "acme")
+
+	if got := TenantIDFromContext(ctx); got != "acme" {
+		t.Errorf("TenantIDFromContext() = %s, want acme", got)
+	}
+	if got := TenantIDFromContext(context.Background()); got != "" {
+		t.Errorf("TenantIDFromContext() from empty context = %s, want empty", got)
+	}
+}
+
 func TestLoggerConfig(t *testing.T) {
 	cfg := &LoggerConfig{
 		Level:      InfoLevel,
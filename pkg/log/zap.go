@@ -175,6 +175,10 @@ func (l *zapLogger) WithContext(ctx context.Context) Logger {
 		fields = append(fields, String("request_id", requestID))
 	}
 
+	if tenantID := TenantIDFromContext(ctx); tenantID != "" {
+		fields = append(fields, String("tenant_id", tenantID))
+	}
+
 	if len(fields) == 0 {
 		return l
 	}
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/goupter/goupter/pkg/tenant"
 	"gorm.io/gorm"
 )
 
@@ -20,12 +21,25 @@ type BaseModel[T any] struct {
 	db              *gorm.DB
 	geometryColumns []string // cached geometry columns
 	selectClause    string   // cached select clause with ST_AsText
+
+	// 多租户，见 WithTenantColumn、WithDBRouter
+	tenant tenantScope
+	router *tenant.DBRouter
 }
 
 // NewBaseModel 创建泛型基础模型
-func NewBaseModel[T any](db *gorm.DB) *BaseModel[T] {
+func NewBaseModel[T any](db *gorm.DB, opts ...Option) *BaseModel[T] {
 	m := &BaseModel[T]{db: db}
 	m.initGeometryColumns()
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	m.router = o.router
+	if o.tenantColumn != "" {
+		m.tenant = newTenantScope[T](db, o.tenantColumn)
+	}
 	return m
 }
 
@@ -114,16 +128,26 @@ func (m *BaseModel[T]) applyGeometrySelect(db *gorm.DB) *gorm.DB {
 	return db
 }
 
-// Insert 插入数据
+// Insert 插入数据，启用租户列时自动填充租户
 func (m *BaseModel[T]) Insert(ctx context.Context, tx *gorm.DB, data *T) error {
-	return m.getDB(tx).WithContext(ctx).Create(data).Error
+	db, err := m.session(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if err := m.tenant.fill(ctx, data); err != nil {
+		return err
+	}
+	return db.Create(data).Error
 }
 
 // FindOne 根据条件查询单条
 func (m *BaseModel[T]) FindOne(ctx context.Context, condition map[string]any) (*T, error) {
 	var result T
-	db := m.applyGeometrySelect(m.db.WithContext(ctx))
-	err := db.Where(condition).First(&result).Error
+	db, err := m.scoped(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = m.applyGeometrySelect(db).Where(condition).First(&result).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, ErrNotFound
@@ -134,13 +158,40 @@ func (m *BaseModel[T]) FindOne(ctx context.Context, condition map[string]any) (*
 }
 
 // Update 更新数据
+//
+// 启用租户列时按主键与租户更新全部字段，不会像 Save 那样在记录不存在时插入。
 func (m *BaseModel[T]) Update(ctx context.Context, tx *gorm.DB, data *T) error {
-	return m.getDB(tx).WithContext(ctx).Save(data).Error
+	if !m.tenant.enabled() {
+		db, err := m.session(ctx, tx)
+		if err != nil {
+			return err
+		}
+		return db.Save(data).Error
+	}
+
+	if err := m.tenant.fill(ctx, data); err != nil {
+		return err
+	}
+	if !m.tenant.hasPrimaryKey(ctx, data) {
+		return ErrInvalidID
+	}
+	db, err := m.scoped(ctx, tx)
+	if err != nil {
+		return err
+	}
+	return db.Model(data).Select("*").Updates(data).Error
 }
 
 // UpdateColumns 更新指定列
 func (m *BaseModel[T]) UpdateColumns(ctx context.Context, tx *gorm.DB, condition map[string]any, columns map[string]any) error {
-	return m.getDB(tx).WithContext(ctx).Model(new(T)).Where(condition).Updates(columns).Error
+	if err := m.tenant.checkColumns(ctx, condition, columns); err != nil {
+		return err
+	}
+	db, err := m.scoped(ctx, tx)
+	if err != nil {
+		return err
+	}
+	return db.Model(new(T)).Where(condition).Updates(columns).Error
 }
 
 // FindAll 查询所有记录
@@ -152,7 +203,11 @@ func (m *BaseModel[T]) FindAll(ctx context.Context, orderBy string, query string
 // FindCount 查询记录数
 func (m *BaseModel[T]) FindCount(ctx context.Context, query string, args ...any) (int64, error) {
 	var count int64
-	db := m.db.WithContext(ctx).Model(new(T))
+	db, err := m.scoped(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	db = db.Model(new(T))
 	if query != "" {
 		db = db.Where(query, args...)
 	}
@@ -164,7 +219,11 @@ func (m *BaseModel[T]) FindPage(ctx context.Context, page, pageSize int, orderBy
 	var results []*T
 	var total int64
 
-	db := m.applyGeometrySelect(m.db.WithContext(ctx).Model(new(T)))
+	db, err := m.scoped(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	db = m.applyGeometrySelect(db.Model(new(T)))
 	if query != "" {
 		db = db.Where(query, args...)
 	}
@@ -183,38 +242,71 @@ func (m *BaseModel[T]) FindPage(ctx context.Context, page, pageSize int, orderBy
 	if orderBy != "" {
 		db = db.Order(orderBy)
 	}
-	err := db.Find(&results).Error
+	err = db.Find(&results).Error
 	return results, int64(len(results)), err
 }
 
 // Delete 删除数据
 func (m *BaseModel[T]) Delete(ctx context.Context, tx *gorm.DB, condition map[string]any) error {
-	return m.getDB(tx).WithContext(ctx).Where(condition).Delete(new(T)).Error
+	if m.tenant.enabled() && len(condition) == 0 {
+		// 与未启用租户时一致，拒绝只有租户条件的整租户删除
+		return gorm.ErrMissingWhereClause
+	}
+	db, err := m.scoped(ctx, tx)
+	if err != nil {
+		return err
+	}
+	return db.Where(condition).Delete(new(T)).Error
 }
 
 // Transaction 事务
 func (m *BaseModel[T]) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
-	return m.db.WithContext(ctx).Transaction(fn)
+	db, err := m.session(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return db.Transaction(fn)
 }
 
-// Exec 执行自定义SQL
+// Exec 执行自定义SQL，不附加租户条件
 func (m *BaseModel[T]) Exec(ctx context.Context, tx *gorm.DB, sql string, args ...any) error {
-	return m.getDB(tx).WithContext(ctx).Exec(sql, args...).Error
+	db, err := m.session(ctx, tx)
+	if err != nil {
+		return err
+	}
+	return db.Exec(sql, args...).Error
 }
 
-// Query 执行自定义查询
+// Query 执行自定义查询，不附加租户条件
 func (m *BaseModel[T]) Query(ctx context.Context, dest any, sql string, args ...any) error {
-	return m.db.WithContext(ctx).Raw(sql, args...).Scan(dest).Error
+	db, err := m.session(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return db.Raw(sql, args...).Scan(dest).Error
 }
 
-// DB 获取底层DB
+// DB 获取底层DB，使用 WithDBRouter 时为 nil
 func (m *BaseModel[T]) DB() *gorm.DB {
 	return m.db
 }
 
-func (m *BaseModel[T]) getDB(tx *gorm.DB) *gorm.DB {
+// session 绑定 ctx 的连接：优先使用事务，其次租户数据库路由
+func (m *BaseModel[T]) session(ctx context.Context, tx *gorm.DB) (*gorm.DB, error) {
 	if tx != nil {
-		return tx
+		return tx.WithContext(ctx), nil
 	}
-	return m.db
+	if m.router != nil {
+		return m.router.DB(ctx)
+	}
+	return m.db.WithContext(ctx), nil
+}
+
+// scoped 附加租户条件的连接
+func (m *BaseModel[T]) scoped(ctx context.Context, tx *gorm.DB) (*gorm.DB, error) {
+	db, err := m.session(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	return m.tenant.apply(ctx, db)
 }
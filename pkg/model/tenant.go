@@ -0,0 +1,141 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/goupter/goupter/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Option 模型选项
+type Option func(*options)
+
+type options struct {
+	tenantColumn string
+	router       *tenant.DBRouter
+}
+
+// WithTenantColumn 按上下文中的租户自动隔离数据
+//
+// Find*、FindCount、Update、UpdateColumns、Delete 附加 "<column> = 租户" 条件，Insert 与 Update
+// 填充租户列；上下文没有租户时返回 tenant.ErrMissingTenant。Exec、Query 执行的原生 SQL 不做处理。
+func WithTenantColumn(column string) Option {
+	return func(o *options) {
+		o.tenantColumn = column
+	}
+}
+
+// WithDBRouter 按上下文中的租户选择数据库连接（每个租户独立数据库），此时 NewBaseModel 的 db 可为 nil
+func WithDBRouter(r *tenant.DBRouter) Option {
+	return func(o *options) {
+		o.router = r
+	}
+}
+
+// tenantScope 租户列，column 为空表示未启用
+type tenantScope struct {
+	column  string
+	field   *schema.Field
+	primary *schema.Field
+	err     error
+}
+
+func newTenantScope[T any](db *gorm.DB, column string) tenantScope {
+	s := tenantScope{column: column}
+
+	var namer schema.Namer = schema.NamingStrategy{}
+	if db != nil && db.Config != nil && db.NamingStrategy != nil {
+		namer = db.NamingStrategy
+	}
+	sch, err := schema.Parse(new(T), &sync.Map{}, namer)
+	if err != nil {
+		s.err = err
+		return s
+	}
+	if s.field = sch.LookUpField(column); s.field == nil {
+		s.err = fmt.Errorf("model: %s 没有租户列 %s", sch.Name, column)
+	}
+	s.primary = sch.PrioritizedPrimaryField
+	return s
+}
+
+func (s *tenantScope) enabled() bool {
+	return s.column != ""
+}
+
+// apply 附加租户条件
+func (s *tenantScope) apply(ctx context.Context, db *gorm.DB) (*gorm.DB, error) {
+	if !s.enabled() {
+		return db, nil
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	id, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: s.field.DBName}, Value: id}), nil
+}
+
+// fill 填充记录的租户列，记录已属于其他租户时返回 tenant.ErrTenantMismatch
+func (s *tenantScope) fill(ctx context.Context, data any) error {
+	if !s.enabled() {
+		return nil
+	}
+	if s.err != nil {
+		return s.err
+	}
+	id, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(data)
+	if v, zero := s.field.ValueOf(ctx, rv); !zero {
+		if fmt.Sprint(v) != id {
+			return tenant.ErrTenantMismatch
+		}
+		return nil
+	}
+	return s.field.Set(ctx, rv, id)
+}
+
+// hasPrimaryKey 记录是否带有主键，避免无主键的更新作用于整个租户
+func (s *tenantScope) hasPrimaryKey(ctx context.Context, data any) bool {
+	if s.primary == nil {
+		return false
+	}
+	_, zero := s.primary.ValueOf(ctx, reflect.ValueOf(data))
+	return !zero
+}
+
+// checkColumns 拒绝把记录改到其他租户，以及没有条件的整租户更新
+func (s *tenantScope) checkColumns(ctx context.Context, condition, columns map[string]any) error {
+	if !s.enabled() {
+		return nil
+	}
+	if len(condition) == 0 {
+		return gorm.ErrMissingWhereClause
+	}
+	if s.err != nil {
+		return s.err
+	}
+	v, ok := columns[s.field.DBName]
+	if !ok {
+		return nil
+	}
+	id, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(v) != id {
+		return tenant.ErrTenantMismatch
+	}
+	return nil
+}
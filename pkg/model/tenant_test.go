@@ -0,0 +1,185 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/tenant"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type doc struct {
+	ID       int64  `gorm:"column:id;primaryKey"`
+	TenantID string `gorm:"column:tenant_id"`
+	Title    string `gorm:"column:title"`
+}
+
+func (doc) TableName() string { return "docs" }
+
+// sqlRecorder 记录 DryRun 模式下生成的 SQL
+type sqlRecorder struct {
+	logger.Interface
+	mu   sync.Mutex
+	sqls []string
+}
+
+func (r *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.mu.Lock()
+	r.sqls = append(r.sqls, sql)
+	r.mu.Unlock()
+}
+
+func (r *sqlRecorder) last() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.sqls) == 0 {
+		return ""
+	}
+	return r.sqls[len(r.sqls)-1]
+}
+
+// newDryRunDB 不连接数据库，只生成 SQL
+func newDryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	t.Helper()
+	rec := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/app",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: rec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, rec
+}
+
+func TestBaseModel_TenantScope(t *testing.T) {
+	db, rec := newDryRunDB(t)
+	m := NewBaseModel[doc](db, WithTenantColumn("tenant_id"))
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	const cond = "`docs`.`tenant_id` = 'acme'"
+
+	if _, err := m.FindAll(ctx, "id", "title = ?", "x"); err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if sql := rec.last(); !strings.Contains(sql, cond) || !strings.Contains(sql, "title = 'x'") {
+		t.Errorf("FindAll SQL = %s", sql)
+	}
+
+	if _, err := m.FindCount(ctx, ""); err != nil {
+		t.Fatalf("FindCount() error = %v", err)
+	}
+	if sql := rec.last(); !strings.Contains(sql, "count(*)") || !strings.Contains(sql, cond) {
+		t.Errorf("FindCount SQL = %s", sql)
+	}
+
+	if err := m.UpdateColumns(ctx, nil, map[string]any{"id": 1}, map[string]any{"title": "y"}); err != nil {
+		t.Fatalf("UpdateColumns() error = %v", err)
+	}
+	if sql := rec.last(); !strings.HasPrefix(sql, "UPDATE") || !strings.Contains(sql, cond) {
+		t.Errorf("UpdateColumns SQL = %s", sql)
+	}
+
+	if err := m.Delete(ctx, nil, map[string]any{"id": 1}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if sql := rec.last(); !strings.HasPrefix(sql, "DELETE") || !strings.Contains(sql, cond) {
+		t.Errorf("Delete SQL = %s", sql)
+	}
+
+	if err := m.Update(ctx, nil, &doc{ID: 1, Title: "z"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if sql := rec.last(); !strings.HasPrefix(sql, "UPDATE") || !strings.Contains(sql, cond) || strings.Contains(sql, "INSERT") {
+		t.Errorf("Update SQL = %s", sql)
+	}
+}
+
+func TestBaseModel_TenantFill(t *testing.T) {
+	db, rec := newDryRunDB(t)
+	m := NewBaseModel[doc](db, WithTenantColumn("tenant_id"))
+	ctx := tenant.WithTenant(context.Background(), "acme")
+
+	d := &doc{Title: "a"}
+	if err := m.Insert(ctx, nil, d); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+	if d.TenantID != "acme" || !strings.Contains(rec.last(), "'acme'") {
+		t.Errorf("TenantID = %q, SQL = %s", d.TenantID, rec.last())
+	}
+
+	if err := m.Insert(ctx, nil, &doc{TenantID: "globex"}); !errors.Is(err, tenant.ErrTenantMismatch) {
+		t.Errorf("Insert(other tenant) error = %v", err)
+	}
+	if err := m.Update(ctx, nil, &doc{ID: 1, TenantID: "globex"}); !errors.Is(err, tenant.ErrTenantMismatch) {
+		t.Errorf("Update(other tenant) error = %v", err)
+	}
+	if err := m.UpdateColumns(ctx, nil, map[string]any{"id": 1}, map[string]any{"tenant_id": "globex"}); !errors.Is(err, tenant.ErrTenantMismatch) {
+		t.Errorf("UpdateColumns(tenant_id) error = %v", err)
+	}
+}
+
+func TestBaseModel_TenantFailClosed(t *testing.T) {
+	db, rec := newDryRunDB(t)
+	m := NewBaseModel[doc](db, WithTenantColumn("tenant_id"))
+	ctx := context.Background()
+
+	if _, err := m.FindAll(ctx, "", ""); !errors.Is(err, tenant.ErrMissingTenant) {
+		t.Errorf("FindAll() error = %v", err)
+	}
+	if _, err := m.FindOne(ctx, map[string]any{"id": 1}); !errors.Is(err, tenant.ErrMissingTenant) {
+		t.Errorf("FindOne() error = %v", err)
+	}
+	if err := m.Insert(ctx, nil, &doc{}); !errors.Is(err, tenant.ErrMissingTenant) {
+		t.Errorf("Insert() error = %v", err)
+	}
+	if len(rec.sqls) != 0 {
+		t.Errorf("no SQL should be generated without tenant: %v", rec.sqls)
+	}
+
+	acme := tenant.WithTenant(ctx, "acme")
+	if err := m.Update(acme, nil, &doc{Title: "no id"}); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Update(no primary key) error = %v", err)
+	}
+	if err := m.Delete(acme, nil, nil); !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Errorf("Delete(no condition) error = %v", err)
+	}
+
+	bad := NewBaseModel[doc](db, WithTenantColumn("org_id"))
+	if _, err := bad.FindAll(acme, "", ""); err == nil {
+		t.Error("unknown tenant column should fail")
+	}
+}
+
+func TestBaseModel_DBRouter(t *testing.T) {
+	opened := map[string]int{}
+	recorders := map[string]*sqlRecorder{}
+	router := tenant.NewDBRouter(func(ctx context.Context, id string) (*gorm.DB, error) {
+		opened[id]++
+		db, rec := newDryRunDB(t)
+		recorders[id] = rec
+		return db, nil
+	})
+	m := NewBaseModel[doc](nil, WithDBRouter(router))
+
+	for _, id := range []string{"acme", "globex", "acme"} {
+		if _, err := m.FindAll(tenant.WithTenant(context.Background(), id), "", ""); err != nil {
+			t.Fatalf("FindAll(%s) error = %v", id, err)
+		}
+	}
+	if opened["acme"] != 1 || opened["globex"] != 1 {
+		t.Errorf("opened = %v", opened)
+	}
+	if len(recorders["acme"].sqls) != 2 || len(recorders["globex"].sqls) != 1 {
+		t.Error("queries should be routed to the tenant database")
+	}
+	if _, err := m.FindAll(context.Background(), "", ""); !errors.Is(err, tenant.ErrMissingTenant) {
+		t.Errorf("FindAll(no tenant) error = %v", err)
+	}
+}
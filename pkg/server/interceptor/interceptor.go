@@ -2,9 +2,12 @@ package interceptor
 
 import (
 	"context"
+	"errors"
 	"runtime/debug"
+	"strings"
 	"time"
 
+	"github.com/goupter/goupter/pkg/auth"
 	"github.com/goupter/goupter/pkg/log"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -210,6 +213,8 @@ func handlePanic(ctx context.Context, p interface{}, method string, cfg Recovery
 
 // AuthConfig 认证拦截器配置
 type AuthConfig struct {
+	// Authenticator 鉴权器，设置后优先于 TokenValidator，完整的 auth.Claims 写入上下文
+	Authenticator auth.Authenticator
 	// TokenValidator 验证Token并返回用户信息
 	TokenValidator func(ctx context.Context, token string) (userID string, err error)
 	// SkipMethods 跳过认证的方法
@@ -218,6 +223,8 @@ type AuthConfig struct {
 	AuthScheme string
 	// MetadataKey 元数据键（默认authorization）
 	MetadataKey string
+	// Logger 记录鉴权依赖故障，默认 log.Default()
+	Logger log.Logger
 }
 
 // DefaultAuthConfig 默认认证配置
@@ -225,6 +232,7 @@ func DefaultAuthConfig() AuthConfig {
 	return AuthConfig{
 		AuthScheme:  "Bearer",
 		MetadataKey: "authorization",
+		Logger:      log.Default(),
 		SkipMethods: []string{
 			"/grpc.health.v1.Health/Check",
 			"/grpc.health.v1.Health/Watch",
@@ -244,6 +252,9 @@ func UnaryAuth(cfg AuthConfig) grpc.UnaryServerInterceptor {
 	if cfg.AuthScheme == "" {
 		cfg.AuthScheme = "Bearer"
 	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
 
 	skipMethods := make(map[string]bool)
 	for _, m := range cfg.SkipMethods {
@@ -262,18 +273,10 @@ func UnaryAuth(cfg AuthConfig) grpc.UnaryServerInterceptor {
 			return nil, err
 		}
 
-		// 验证Token
-		if cfg.TokenValidator == nil {
-			return nil, status.Error(codes.Internal, "token validator not configured")
-		}
-
-		userID, err := cfg.TokenValidator(ctx, token)
+		ctx, err = authenticate(ctx, cfg, token)
 		if err != nil {
-			return nil, status.Error(codes.Unauthenticated, err.Error())
+			return nil, err
 		}
-
-		// 将用户ID添加到上下文
-		ctx = context.WithValue(ctx, userIDKey{}, userID)
 		return handler(ctx, req)
 	}
 }
@@ -286,6 +289,9 @@ func StreamAuth(cfg AuthConfig) grpc.StreamServerInterceptor {
 	if cfg.AuthScheme == "" {
 		cfg.AuthScheme = "Bearer"
 	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
 
 	skipMethods := make(map[string]bool)
 	for _, m := range cfg.SkipMethods {
@@ -306,24 +312,55 @@ func StreamAuth(cfg AuthConfig) grpc.StreamServerInterceptor {
 			return err
 		}
 
-		// 验证Token
-		if cfg.TokenValidator == nil {
-			return status.Error(codes.Internal, "token validator not configured")
+		ctx, err = authenticate(ctx, cfg, token)
+		if err != nil {
+			return err
 		}
 
+		// 使用包装的Stream传递认证信息
+		return handler(srv, WrapServerStream(ss, ctx))
+	}
+}
+
+// authenticate 验证令牌，将用户ID、auth.Claims 与原始令牌写入上下文
+func authenticate(ctx context.Context, cfg AuthConfig, token string) (context.Context, error) {
+	var claims *auth.Claims
+	switch {
+	case cfg.Authenticator != nil:
+		c, err := cfg.Authenticator.Authenticate(ctx, token)
+		if err != nil {
+			var authErr *auth.AuthError
+			if !errors.As(err, &authErr) {
+				// 鉴权依赖故障（如 JWKS、缓存不可用），详情只记录在服务端
+				method, _ := grpc.Method(ctx)
+				cfg.Logger.Error("authentication unavailable", log.String("method", method), log.Error(err))
+				return nil, status.Error(codes.Unavailable, "authentication service unavailable")
+			}
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		claims = c
+	case cfg.TokenValidator != nil:
 		userID, err := cfg.TokenValidator(ctx, token)
 		if err != nil {
-			return status.Error(codes.Unauthenticated, err.Error())
+			return nil, status.Error(codes.Unauthenticated, err.Error())
 		}
+		claims = &auth.Claims{UserID: userID}
+	default:
+		return nil, status.Error(codes.Internal, "token validator not configured")
+	}
 
-		// 使用包装的Stream传递认证信息
-		wrapped := &wrappedServerStream{
-			ServerStream: ss,
-			ctx:          context.WithValue(ctx, userIDKey{}, userID),
-		}
+	ctx = context.WithValue(ctx, userIDKey{}, claims.UserID)
+	ctx = auth.ContextWithClaims(ctx, claims)
+	return auth.ContextWithToken(ctx, token), nil
+}
 
-		return handler(srv, wrapped)
+// WrapServerStream 返回上下文替换为 ctx 的 ServerStream，供流式拦截器向后续处理器传递上下文
+func WrapServerStream(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	// 多个拦截器依次替换上下文时不逐层嵌套
+	if w, ok := ss.(*wrappedServerStream); ok {
+		ss = w.ServerStream
 	}
+	return &wrappedServerStream{ServerStream: ss, ctx: ctx}
 }
 
 // wrappedServerStream 包装的ServerStream
@@ -348,13 +385,13 @@ func extractToken(ctx context.Context, metadataKey, scheme string) (string, erro
 		return "", status.Error(codes.Unauthenticated, "missing authorization token")
 	}
 
-	auth := values[0]
+	header := values[0]
 	prefix := scheme + " "
-	if len(auth) < len(prefix) {
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
 		return "", status.Error(codes.Unauthenticated, "invalid authorization format")
 	}
 
-	return auth[len(prefix):], nil
+	return header[len(prefix):], nil
 }
 
 // GetUserID 从上下文获取用户ID
@@ -363,6 +400,125 @@ func GetUserID(ctx context.Context) (string, bool) {
 	return userID, ok
 }
 
+// === 授权拦截器 ===
+
+// UnaryRequireRoles 要求调用方具备全部角色，需放在 UnaryAuth 之后
+//
+// 拦截器作用于所有方法；只需保护部分服务时配合 UnaryForMethods 使用。
+func UnaryRequireRoles(roles ...string) grpc.UnaryServerInterceptor {
+	return unaryRequire(func(c *auth.Claims) error { return checkRoles(c, roles) })
+}
+
+// StreamRequireRoles 流式调用的 UnaryRequireRoles
+func StreamRequireRoles(roles ...string) grpc.StreamServerInterceptor {
+	return streamRequire(func(c *auth.Claims) error { return checkRoles(c, roles) })
+}
+
+// UnaryRequireScopes 要求调用方具备全部权限范围，需放在 UnaryAuth 之后
+func UnaryRequireScopes(scopes ...string) grpc.UnaryServerInterceptor {
+	return unaryRequire(func(c *auth.Claims) error { return checkScopes(c, scopes) })
+}
+
+// StreamRequireScopes 流式调用的 UnaryRequireScopes
+func StreamRequireScopes(scopes ...string) grpc.StreamServerInterceptor {
+	return streamRequire(func(c *auth.Claims) error { return checkScopes(c, scopes) })
+}
+
+// UnaryRequireMFA 要求多因素认证，需放在 UnaryAuth 之后；不满足时返回 Unauthenticated
+//
+// 通常配合 UnaryForMethods 只保护敏感方法。
+func UnaryRequireMFA(opts ...auth.MFAOption) grpc.UnaryServerInterceptor {
+	req := auth.NewMFARequirement(opts...)
+	return unaryRequire(func(c *auth.Claims) error { return checkMFA(c, req) })
+}
+
+// StreamRequireMFA 流式调用的 UnaryRequireMFA
+func StreamRequireMFA(opts ...auth.MFAOption) grpc.StreamServerInterceptor {
+	req := auth.NewMFARequirement(opts...)
+	return streamRequire(func(c *auth.Claims) error { return checkMFA(c, req) })
+}
+
+// UnaryForMethods 只对匹配的方法执行拦截器，methods 为完整方法名（/pkg.Service/Method）
+// 或以 / 结尾的服务前缀（/pkg.Service/）
+func UnaryForMethods(methods []string, interceptor grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !matchMethod(methods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+		return interceptor(ctx, req, info, handler)
+	}
+}
+
+// StreamForMethods 流式调用的 UnaryForMethods
+func StreamForMethods(methods []string, interceptor grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !matchMethod(methods, info.FullMethod) {
+			return handler(srv, ss)
+		}
+		return interceptor(srv, ss, info, handler)
+	}
+}
+
+func matchMethod(methods []string, fullMethod string) bool {
+	for _, m := range methods {
+		if m == fullMethod || (strings.HasSuffix(m, "/") && strings.HasPrefix(fullMethod, m)) {
+			return true
+		}
+	}
+	return false
+}
+
+func unaryRequire(check func(*auth.Claims) error) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := requireClaims(ctx, check); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamRequire(check func(*auth.Claims) error) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := requireClaims(ss.Context(), check); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func requireClaims(ctx context.Context, check func(*auth.Claims) error) error {
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+	return check(claims)
+}
+
+func checkRoles(claims *auth.Claims, roles []string) error {
+	for _, role := range roles {
+		if !claims.HasRole(role) {
+			return status.Error(codes.PermissionDenied, "insufficient permissions")
+		}
+	}
+	return nil
+}
+
+func checkScopes(claims *auth.Claims, scopes []string) error {
+	for _, scope := range scopes {
+		if !claims.HasScope(scope) {
+			return status.Error(codes.PermissionDenied, "insufficient scope")
+		}
+	}
+	return nil
+}
+
+func checkMFA(claims *auth.Claims, req *auth.MFARequirement) error {
+	if err := req.Check(claims); err != nil {
+		return status.Error(codes.Unauthenticated, auth.ErrInsufficientAuth.Message)
+	}
+	return nil
+}
+
 // === 超时拦截器 ===
 
 // UnaryTimeout 一元调用超时拦截器
@@ -458,12 +614,7 @@ func StreamRequestID(generator func() string) grpc.StreamServerInterceptor {
 			// 忽略错误
 		}
 
-		wrapped := &wrappedServerStream{
-			ServerStream: ss,
-			ctx:          ctx,
-		}
-
-		return handler(srv, wrapped)
+		return handler(srv, WrapServerStream(ss, ctx))
 	}
 }
 
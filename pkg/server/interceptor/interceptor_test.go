@@ -3,9 +3,11 @@ package interceptor
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/goupter/goupter/pkg/auth"
 	"github.com/goupter/goupter/pkg/log"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -21,6 +23,15 @@ func (h mockUnaryHandler) Handle(ctx context.Context, req interface{}, info *grp
 	return handler(ctx, req)
 }
 
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *mockServerStream) Context() context.Context {
+	return s.ctx
+}
+
 // === 日志拦截器测试 ===
 
 func TestUnaryLogging_Success(t *testing.T) {
@@ -289,6 +300,199 @@ func TestUnaryAuth_SkipMethods(t *testing.T) {
 	}
 }
 
+// stubAuthenticator 只实现 Authenticate 的测试鉴权器
+type stubAuthenticator struct {
+	auth.Authenticator
+	claims map[string]*auth.Claims
+}
+
+func (a *stubAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Claims, error) {
+	if c, ok := a.claims[token]; ok {
+		return c, nil
+	}
+	if token == "outage_token" {
+		return nil, errors.New("jwks: dial tcp 10.0.0.1:443: connection refused")
+	}
+	return nil, auth.ErrTokenInvalid
+}
+
+func newStubAuthenticator() *stubAuthenticator {
+	return &stubAuthenticator{claims: map[string]*auth.Claims{
+		"admin_token":  {UserID: "u1", Roles: []string{"admin"}, Scopes: []string{"orders:read", "orders:write"}},
+		"reader_token": {UserID: "u2", Roles: []string{"user"}, Scopes: []string{"orders:read"}},
+	}}
+}
+
+func bearerContext(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestUnaryAuth_Authenticator(t *testing.T) {
+	cfg := DefaultAuthConfig()
+	cfg.Authenticator = newStubAuthenticator()
+	interceptor := UnaryAuth(cfg)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		claims, ok := auth.ClaimsFromContext(ctx)
+		if !ok || claims.UserID != "u1" || !claims.HasRole("admin") || !claims.HasScope("orders:write") {
+			t.Errorf("claims = %+v", claims)
+		}
+		if userID, _ := GetUserID(ctx); userID != "u1" {
+			t.Errorf("userID不正确: %s", userID)
+		}
+		if auth.TokenFromContext(ctx) != "admin_token" {
+			t.Error("应该透传原始令牌")
+		}
+		return "ok", nil
+	}
+	if _, err := interceptor(bearerContext("admin_token"), "request", info, handler); err != nil {
+		t.Errorf("不应该返回错误: %v", err)
+	}
+
+	_, err := interceptor(bearerContext("bad_token"), "request", info, handler)
+	if st, _ := status.FromError(err); st.Code() != codes.Unauthenticated || st.Message() != auth.ErrTokenInvalid.Message {
+		t.Errorf("应该返回Unauthenticated，实际: %v", err)
+	}
+
+	// 鉴权依赖故障不应视为凭证无效，也不应暴露内部错误
+	_, err = interceptor(bearerContext("outage_token"), "request", info, handler)
+	if st, _ := status.FromError(err); st.Code() != codes.Unavailable || strings.Contains(st.Message(), "10.0.0.1") {
+		t.Errorf("应该返回不含内部细节的Unavailable，实际: %v", err)
+	}
+}
+
+func TestUnaryAuth_WrongScheme(t *testing.T) {
+	cfg := DefaultAuthConfig()
+	cfg.Authenticator = newStubAuthenticator()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic admin_token"))
+
+	_, err := UnaryAuth(cfg)(ctx, "request", &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil })
+	if st, _ := status.FromError(err); st.Code() != codes.Unauthenticated {
+		t.Errorf("应该返回Unauthenticated，实际: %v", err)
+	}
+}
+
+func TestStreamAuth_Authenticator(t *testing.T) {
+	cfg := DefaultAuthConfig()
+	cfg.Authenticator = newStubAuthenticator()
+	interceptor := StreamAuth(cfg)
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+
+	var got *auth.Claims
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		got, _ = auth.ClaimsFromContext(ss.Context())
+		return nil
+	}
+	if err := interceptor(nil, &mockServerStream{ctx: bearerContext("reader_token")}, info, handler); err != nil {
+		t.Fatalf("不应该返回错误: %v", err)
+	}
+	if got == nil || got.UserID != "u2" || !got.HasScope("orders:read") {
+		t.Errorf("claims = %+v", got)
+	}
+}
+
+func TestUnaryRequireRolesAndScopes(t *testing.T) {
+	cfg := DefaultAuthConfig()
+	cfg.Authenticator = newStubAuthenticator()
+	authn := UnaryAuth(cfg)
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.v1.OrderService/Delete"}
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	tests := []struct {
+		name        string
+		interceptor grpc.UnaryServerInterceptor
+		token       string
+		want        codes.Code
+	}{
+		{"role ok", UnaryRequireRoles("admin"), "admin_token", codes.OK},
+		{"role denied", UnaryRequireRoles("admin"), "reader_token", codes.PermissionDenied},
+		{"scope ok", UnaryRequireScopes("orders:read"), "reader_token", codes.OK},
+		{"scope denied", UnaryRequireScopes("orders:read", "orders:write"), "reader_token", codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := authn(bearerContext(tt.token), "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return tt.interceptor(ctx, req, info, ok)
+			})
+			if st, _ := status.FromError(err); st.Code() != tt.want {
+				t.Errorf("code = %v, want %v", st.Code(), tt.want)
+			}
+		})
+	}
+
+	// 未经过认证拦截器
+	_, err := UnaryRequireRoles("admin")(context.Background(), "request", info, ok)
+	if st, _ := status.FromError(err); st.Code() != codes.Unauthenticated {
+		t.Errorf("应该返回Unauthenticated，实际: %v", err)
+	}
+}
+
+func TestStreamRequireRoles(t *testing.T) {
+	ctx := auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: "u2", Roles: []string{"user"}})
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+	handler := func(srv interface{}, ss grpc.ServerStream) error { return nil }
+
+	err := StreamRequireRoles("admin")(nil, &mockServerStream{ctx: ctx}, info, handler)
+	if st, _ := status.FromError(err); st.Code() != codes.PermissionDenied {
+		t.Errorf("应该返回PermissionDenied，实际: %v", err)
+	}
+	if err := StreamRequireScopes()(nil, &mockServerStream{ctx: ctx}, info, handler); err != nil {
+		t.Errorf("不应该返回错误: %v", err)
+	}
+}
+
+func TestUnaryRequireMFA(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/admin.v1.AdminService/DeleteUser"}
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	interceptor := UnaryRequireMFA(auth.WithAMR(auth.AMRHardwareKey))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"no claims", context.Background(), codes.Unauthenticated},
+		{"single factor", auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: "u1", AMR: []string{auth.AMRPassword}}), codes.Unauthenticated},
+		{"wrong method", auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: "u1", AMR: []string{auth.AMRPassword, auth.AMROTP}}), codes.Unauthenticated},
+		{"hardware key", auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: "u1", AMR: []string{auth.AMRPassword, auth.AMRHardwareKey}}), codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interceptor(tt.ctx, "request", info, ok)
+			if st, _ := status.FromError(err); st.Code() != tt.want {
+				t.Errorf("code = %v, want %v", st.Code(), tt.want)
+			}
+		})
+	}
+
+	ctx := auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: "u1", AMR: []string{auth.AMRPassword}})
+	err := StreamRequireMFA()(nil, &mockServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"},
+		func(srv interface{}, ss grpc.ServerStream) error { return nil })
+	if st, _ := status.FromError(err); st.Code() != codes.Unauthenticated || st.Message() != auth.ErrInsufficientAuth.Message {
+		t.Errorf("应该返回Unauthenticated，实际: %v", err)
+	}
+}
+
+func TestUnaryForMethods(t *testing.T) {
+	guarded := UnaryForMethods([]string{"/admin.v1.AdminService/", "/orders.v1.OrderService/Delete"}, UnaryRequireRoles("admin"))
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	tests := map[string]codes.Code{
+		"/admin.v1.AdminService/ListUsers": codes.Unauthenticated,
+		"/orders.v1.OrderService/Delete":   codes.Unauthenticated,
+		"/orders.v1.OrderService/Get":      codes.OK,
+		"/grpc.health.v1.Health/Check":     codes.OK,
+	}
+	for method, want := range tests {
+		_, err := guarded(context.Background(), "request", &grpc.UnaryServerInfo{FullMethod: method}, ok)
+		if st, _ := status.FromError(err); st.Code() != want {
+			t.Errorf("%s code = %v, want %v", method, st.Code(), want)
+		}
+	}
+}
+
 // === 超时拦截器测试 ===
 
 func TestUnaryTimeout_Success(t *testing.T) {
@@ -526,3 +730,17 @@ func TestDefaultStreamInterceptors(t *testing.T) {
 		t.Errorf("应该有2个拦截器，实际: %d", len(interceptors))
 	}
 }
+
+func TestWrapServerStream(t *testing.T) {
+	type key struct{}
+	ss := &mockServerStream{ctx: context.Background()}
+
+	first := WrapServerStream(ss, context.WithValue(ss.Context(), key{}, "a"))
+	second := WrapServerStream(first, context.WithValue(first.Context(), key{}, "b"))
+	if second.Context().Value(key{}) != "b" {
+		t.Errorf("应该使用最后替换的上下文")
+	}
+	if w, ok := second.(*wrappedServerStream); !ok || w.ServerStream != ss {
+		t.Error("多次替换上下文不应逐层嵌套")
+	}
+}
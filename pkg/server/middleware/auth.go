@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/log"
 )
 
 // 认证相关错误
@@ -29,6 +32,10 @@ type AuthInfo struct {
 	Scopes    []string
 	Extra     map[string]interface{}
 	ExpiresAt time.Time
+	// AMR、ACR、AuthTime 认证方式、认证等级与认证时间，见 auth.Claims
+	AMR      []string
+	ACR      string
+	AuthTime time.Time
 }
 
 // HasRole 检查是否有指定角色
@@ -76,6 +83,11 @@ func (a *AuthInfo) IsExpired() bool {
 	return !a.ExpiresAt.IsZero() && time.Now().After(a.ExpiresAt)
 }
 
+// IsMFA 是否为多因素认证
+func (a *AuthInfo) IsMFA() bool {
+	return auth.IsMFA(a.AMR)
+}
+
 // GetAuthInfo 从Gin上下文获取认证信息
 func GetAuthInfo(c *gin.Context) (*AuthInfo, bool) {
 	if info, exists := c.Get("auth_info"); exists {
@@ -115,12 +127,130 @@ func (f TokenValidatorFunc) Validate(token string) (*AuthInfo, error) {
 	return f(token)
 }
 
+// ClaimsValidator 返回完整 auth.Claims 的令牌验证器
+//
+// BearerAuth 检测到验证器实现该接口时传入请求上下文，并将 auth.Claims 写入上下文。
+type ClaimsValidator interface {
+	TokenValidator
+	ValidateClaims(ctx context.Context, token string) (*auth.Claims, error)
+}
+
+// AuthenticatorValidator 将 auth.Authenticator 适配为令牌验证器，可直接用于 BearerAuth、APIKeyAuth
+func AuthenticatorValidator(a auth.Authenticator) ClaimsValidator {
+	return authenticatorValidator{a}
+}
+
+type authenticatorValidator struct {
+	authenticator auth.Authenticator
+}
+
+func (v authenticatorValidator) Validate(token string) (*AuthInfo, error) {
+	claims, err := v.authenticator.Authenticate(context.Background(), token)
+	if err != nil {
+		return nil, err
+	}
+	return AuthInfoFromClaims(claims), nil
+}
+
+func (v authenticatorValidator) ValidateClaims(ctx context.Context, token string) (*auth.Claims, error) {
+	return v.authenticator.Authenticate(ctx, token)
+}
+
+// AuthInfoFromClaims 由 auth.Claims 构造认证信息
+func AuthInfoFromClaims(claims *auth.Claims) *AuthInfo {
+	return &AuthInfo{
+		UserID:    claims.UserID,
+		Username:  claims.Username,
+		Roles:     claims.Roles,
+		Scopes:    claims.Scopes,
+		Extra:     claims.Extra,
+		ExpiresAt: claims.ExpiresAt,
+		AMR:       claims.AMR,
+		ACR:       claims.ACR,
+		AuthTime:  claims.AuthTime,
+	}
+}
+
+// claimsFromAuthInfo 由认证信息构造 auth.Claims，供只返回 AuthInfo 的验证器使用
+func claimsFromAuthInfo(info *AuthInfo) *auth.Claims {
+	return &auth.Claims{
+		UserID:    info.UserID,
+		Username:  info.Username,
+		Roles:     info.Roles,
+		Scopes:    info.Scopes,
+		Extra:     info.Extra,
+		ExpiresAt: info.ExpiresAt,
+		AMR:       info.AMR,
+		ACR:       info.ACR,
+		AuthTime:  info.AuthTime,
+	}
+}
+
+// TokenExtractor 从请求中提取令牌，请求未携带令牌时返回 ErrMissingToken
+type TokenExtractor func(c *gin.Context) (string, error)
+
+// DefaultAPIKeyHeader API Key 的默认请求头
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// BearerTokenExtractor 从 Authorization: Bearer <token> 提取令牌
+func BearerTokenExtractor() TokenExtractor {
+	return func(c *gin.Context) (string, error) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			return "", ErrMissingToken
+		}
+
+		const prefix = "Bearer "
+		if !strings.HasPrefix(header, prefix) {
+			return "", ErrInvalidToken
+		}
+
+		token := strings.TrimPrefix(header, prefix)
+		if token == "" {
+			return "", ErrInvalidToken
+		}
+		return token, nil
+	}
+}
+
+// HeaderTokenExtractor 从指定请求头提取令牌，如 X-API-Key
+func HeaderTokenExtractor(header string) TokenExtractor {
+	return func(c *gin.Context) (string, error) {
+		token := strings.TrimSpace(c.GetHeader(header))
+		if token == "" {
+			return "", ErrMissingToken
+		}
+		return token, nil
+	}
+}
+
+// ChainTokenExtractors 依次尝试多个提取器，返回第一个携带的令牌
+func ChainTokenExtractors(extractors ...TokenExtractor) TokenExtractor {
+	return func(c *gin.Context) (string, error) {
+		for _, extract := range extractors {
+			token, err := extract(c)
+			if err == ErrMissingToken {
+				continue
+			}
+			return token, err
+		}
+		return "", ErrMissingToken
+	}
+}
+
 // BearerAuthConfig Bearer认证配置
+//
+// Validator 实现 ClaimsValidator 时，非 *auth.AuthError 的错误视为鉴权依赖故障（如 JWKS、缓存不可用），
+// 返回 503 并只在服务端日志中记录详情。
 type BearerAuthConfig struct {
-	Validator    TokenValidator
+	Validator TokenValidator
+	// Extractor 令牌提取方式，默认 BearerTokenExtractor
+	Extractor    TokenExtractor
 	Realm        string
 	SkipPaths    []string
 	ErrorHandler func(*gin.Context, error)
+	// Logger 记录鉴权依赖故障，默认 log.Default()
+	Logger log.Logger
 }
 
 // BearerAuth Bearer Token认证中间件
@@ -131,12 +261,25 @@ func BearerAuth(validator TokenValidator) gin.HandlerFunc {
 	})
 }
 
+// APIKeyAuth API Key 认证中间件，从 X-API-Key 请求头或 Authorization: Bearer 提取
+func APIKeyAuth(validator TokenValidator) gin.HandlerFunc {
+	return BearerAuthWithConfig(BearerAuthConfig{
+		Validator: validator,
+		Extractor: ChainTokenExtractors(HeaderTokenExtractor(DefaultAPIKeyHeader), BearerTokenExtractor()),
+		Realm:     "Authorization Required",
+	})
+}
+
 // BearerAuthWithConfig 带配置的Bearer认证中间件
 func BearerAuthWithConfig(cfg BearerAuthConfig) gin.HandlerFunc {
 	skipPaths := make(map[string]bool)
 	for _, path := range cfg.SkipPaths {
 		skipPaths[path] = true
 	}
+	extract := cfg.Extractor
+	if extract == nil {
+		extract = BearerTokenExtractor()
+	}
 
 	return func(c *gin.Context) {
 		if skipPaths[c.Request.URL.Path] {
@@ -144,25 +287,30 @@ func BearerAuthWithConfig(cfg BearerAuthConfig) gin.HandlerFunc {
 			return
 		}
 
-		auth := c.GetHeader("Authorization")
-		if auth == "" {
-			handleAuthError(c, cfg, ErrMissingToken)
-			return
-		}
-
-		const prefix = "Bearer "
-		if !strings.HasPrefix(auth, prefix) {
-			handleAuthError(c, cfg, ErrInvalidToken)
+		token, err := extract(c)
+		if err != nil {
+			handleAuthError(c, cfg, err)
 			return
 		}
 
-		token := strings.TrimPrefix(auth, prefix)
-		if token == "" {
-			handleAuthError(c, cfg, ErrInvalidToken)
-			return
+		var (
+			claims   *auth.Claims
+			authInfo *AuthInfo
+		)
+		if cv, ok := cfg.Validator.(ClaimsValidator); ok {
+			claims, err = cv.ValidateClaims(c.Request.Context(), token)
+			if err == nil {
+				authInfo = AuthInfoFromClaims(claims)
+			} else if !isAuthError(err) {
+				handleAuthUnavailable(c, cfg, err)
+				return
+			}
+		} else {
+			authInfo, err = cfg.Validator.Validate(token)
+			if err == nil {
+				claims = claimsFromAuthInfo(authInfo)
+			}
 		}
-
-		authInfo, err := cfg.Validator.Validate(token)
 		if err != nil {
 			handleAuthError(c, cfg, err)
 			return
@@ -173,6 +321,8 @@ func BearerAuthWithConfig(cfg BearerAuthConfig) gin.HandlerFunc {
 			return
 		}
 
+		ctx := auth.ContextWithToken(c.Request.Context(), token)
+		c.Request = c.Request.WithContext(auth.ContextWithClaims(ctx, claims))
 		SetAuthInfo(c, authInfo)
 		c.Next()
 	}
@@ -196,6 +346,30 @@ func handleAuthError(c *gin.Context, cfg BearerAuthConfig, err error) {
 	})
 }
 
+// handleAuthUnavailable 鉴权依赖故障时返回 503，不向客户端暴露内部错误
+func handleAuthUnavailable(c *gin.Context, cfg BearerAuthConfig, err error) {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Error("authentication unavailable", log.String("path", c.Request.URL.Path), log.Error(err))
+
+	if cfg.ErrorHandler != nil {
+		cfg.ErrorHandler(c, err)
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+		"code":    http.StatusServiceUnavailable,
+		"message": "authentication service unavailable",
+	})
+}
+
+// isAuthError 是否为凭证本身的错误（无效、过期、撤销等）
+func isAuthError(err error) bool {
+	var authErr *auth.AuthError
+	return errors.As(err, &authErr)
+}
+
 // RequireRoles 要求指定角色（需要所有角色）
 func RequireRoles(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -269,3 +443,43 @@ func RequireScopes(scopes ...string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireMFA 要求多因素认证，用于敏感路由的 step-up，需放在认证中间件之后
+//
+// 不满足时按 RFC 9470 返回 401 与 WWW-Authenticate: Bearer error="insufficient_user_authentication"，
+// 附带要求的 acr_values 与 max_age，客户端据此引导用户完成二次验证后重新获取令牌。
+//
+//	admin.Use(middleware.BearerAuth(validator), middleware.RequireMFA(auth.WithMFAMaxAge(10*time.Minute)))
+func RequireMFA(opts ...auth.MFAOption) gin.HandlerFunc {
+	req := auth.NewMFARequirement(opts...)
+
+	challenge := `Bearer error="insufficient_user_authentication", error_description="` + auth.ErrInsufficientAuth.Message + `"`
+	if len(req.ACR) > 0 {
+		challenge += `, acr_values="` + strings.Join(req.ACR, " ") + `"`
+	}
+	if req.MaxAge > 0 {
+		challenge += ", max_age=" + strconv.FormatInt(int64(req.MaxAge/time.Second), 10)
+	}
+
+	return func(c *gin.Context) {
+		authInfo, ok := GetAuthInfo(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    http.StatusUnauthorized,
+				"message": "authentication required",
+			})
+			return
+		}
+
+		if err := req.Check(claimsFromAuthInfo(authInfo)); err != nil {
+			c.Header("WWW-Authenticate", challenge)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    http.StatusUnauthorized,
+				"message": auth.ErrInsufficientAuth.Message,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,12 +1,16 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/auth"
 )
 
 func TestAuthInfo_HasRole(t *testing.T) {
@@ -184,6 +188,135 @@ func TestBearerAuth_SkipPaths(t *testing.T) {
 	}
 }
 
+func TestAPIKeyAuth(t *testing.T) {
+	validator := TokenValidatorFunc(func(token string) (*AuthInfo, error) {
+		if token == "gk_valid" {
+			return &AuthInfo{UserID: "svc"}, nil
+		}
+		return nil, ErrInvalidToken
+	})
+
+	router := gin.New()
+	router.Use(APIKeyAuth(validator))
+	router.GET("/test", func(c *gin.Context) {
+		info, _ := GetAuthInfo(c)
+		c.String(http.StatusOK, info.UserID)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"X-API-Key", DefaultAPIKeyHeader, "gk_valid", http.StatusOK},
+		{"Bearer", "Authorization", "Bearer gk_valid", http.StatusOK},
+		{"invalid key", DefaultAPIKeyHeader, "gk_invalid", http.StatusUnauthorized},
+		{"missing", "", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/test", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("期望%d，实际: %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+// ctxAuthenticator 校验上下文透传的测试鉴权器
+type ctxAuthenticator struct {
+	auth.Authenticator
+}
+
+type ctxKey struct{}
+
+func (ctxAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Claims, error) {
+	if token == "outage" {
+		return nil, errors.New("jwks: dial tcp 10.0.0.1:443: connection refused")
+	}
+	if token != "valid" {
+		return nil, auth.ErrTokenInvalid
+	}
+	return &auth.Claims{
+		UserID:  "u1",
+		Roles:   []string{"admin"},
+		Scopes:  []string{"orders:read"},
+		TokenID: "jti-1",
+		Extra:   map[string]interface{}{"request_id": ctx.Value(ctxKey{})},
+	}, nil
+}
+
+func TestAuthenticatorValidator(t *testing.T) {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ctxKey{}, "req-1"))
+	})
+	router.Use(BearerAuth(AuthenticatorValidator(ctxAuthenticator{})), RequireRoles("admin"), RequireScopes("orders:read"))
+	router.GET("/test", func(c *gin.Context) {
+		claims, ok := auth.ClaimsFromContext(c.Request.Context())
+		if !ok || claims.TokenID != "jti-1" || claims.Extra["request_id"] != "req-1" {
+			t.Errorf("claims = %+v", claims)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for token, want := range map[string]int{"valid": http.StatusOK, "invalid": http.StatusUnauthorized, "outage": http.StatusServiceUnavailable} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("token %s: 期望%d，实际: %d", token, want, w.Code)
+		}
+		if strings.Contains(w.Body.String(), "10.0.0.1") {
+			t.Errorf("token %s: 响应不应暴露内部错误: %s", token, w.Body.String())
+		}
+	}
+}
+
+func TestBearerAuth_ClaimsFromTokenValidator(t *testing.T) {
+	validator := TokenValidatorFunc(func(token string) (*AuthInfo, error) {
+		return &AuthInfo{UserID: "u1", Roles: []string{"user"}}, nil
+	})
+	router := gin.New()
+	router.Use(BearerAuth(validator))
+	router.GET("/test", func(c *gin.Context) {
+		claims, ok := auth.ClaimsFromContext(c.Request.Context())
+		if !ok || claims.UserID != "u1" || !claims.HasRole("user") {
+			t.Errorf("claims = %+v", claims)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer any")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("期望200，实际: %d", w.Code)
+	}
+}
+
+func TestChainTokenExtractors_InvalidStops(t *testing.T) {
+	extract := ChainTokenExtractors(BearerTokenExtractor(), HeaderTokenExtractor(DefaultAPIKeyHeader))
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	c.Request.Header.Set("Authorization", "Basic abc")
+	c.Request.Header.Set(DefaultAPIKeyHeader, "gk_valid")
+
+	// 携带了格式错误的 Authorization 时不回退到其他提取器
+	if _, err := extract(c); err != ErrInvalidToken {
+		t.Errorf("期望ErrInvalidToken，实际: %v", err)
+	}
+}
+
 func TestRequireRoles(t *testing.T) {
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
@@ -270,3 +403,43 @@ func TestRequireScopes(t *testing.T) {
 		t.Errorf("/delete应该返回403，实际: %d", w.Code)
 	}
 }
+
+func TestRequireMFA(t *testing.T) {
+	tests := []struct {
+		name       string
+		info       *AuthInfo
+		want       int
+		wantHeader string
+	}{
+		{"no auth", nil, http.StatusUnauthorized, ""},
+		{"single factor", &AuthInfo{UserID: "u1", AMR: []string{auth.AMRPassword}}, http.StatusUnauthorized,
+			`Bearer error="insufficient_user_authentication", error_description="insufficient user authentication", max_age=600`},
+		{"stale", &AuthInfo{UserID: "u1", AMR: []string{auth.AMRPassword, auth.AMROTP}, AuthTime: time.Now().Add(-time.Hour)}, http.StatusUnauthorized,
+			`Bearer error="insufficient_user_authentication", error_description="insufficient user authentication", max_age=600`},
+		{"mfa", &AuthInfo{UserID: "u1", AMR: []string{auth.AMRPassword, auth.AMROTP}, AuthTime: time.Now()}, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.info != nil {
+					SetAuthInfo(c, tt.info)
+				}
+				c.Next()
+			})
+			router.GET("/admin", RequireMFA(auth.WithMFAMaxAge(10*time.Minute)), func(c *gin.Context) {
+				c.String(http.StatusOK, "OK")
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/admin", nil)
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("code = %d, want %d", w.Code, tt.want)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.wantHeader {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 )
@@ -118,6 +119,31 @@ func CORSWithConfig(cfg CORSConfig) gin.HandlerFunc {
 	}
 }
 
+// CORSHandler 可在运行时更新配置的跨域中间件
+type CORSHandler struct {
+	handler atomic.Pointer[gin.HandlerFunc]
+}
+
+// NewCORSHandler 创建可更新配置的跨域中间件
+func NewCORSHandler(cfg CORSConfig) *CORSHandler {
+	h := &CORSHandler{}
+	h.Update(cfg)
+	return h
+}
+
+// Update 更新配置，对之后的请求生效
+func (h *CORSHandler) Update(cfg CORSConfig) {
+	handler := CORSWithConfig(cfg)
+	h.handler.Store(&handler)
+}
+
+// Handler 返回中间件
+func (h *CORSHandler) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		(*h.handler.Load())(c)
+	}
+}
+
 // CORSAllowAll 允许所有跨域请求
 func CORSAllowAll() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSHandler_Update(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowOrigins = []string{"https://a.example.com"}
+	cors := NewCORSHandler(cfg)
+
+	router := gin.New()
+	router.Use(cors.Handler())
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "OK")
+	})
+
+	allowed := func(origin string) string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Origin", origin)
+		router.ServeHTTP(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := allowed("https://b.example.com"); got != "" {
+		t.Errorf("更新前不应允许 b, got %q", got)
+	}
+
+	cfg.AllowOrigins = []string{"https://b.example.com"}
+	cors.Update(cfg)
+
+	if got := allowed("https://b.example.com"); got != "https://b.example.com" {
+		t.Errorf("更新后应允许 b, got %q", got)
+	}
+	if got := allowed("https://a.example.com"); got != "" {
+		t.Errorf("更新后不应允许 a, got %q", got)
+	}
+}
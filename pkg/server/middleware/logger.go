@@ -102,6 +102,10 @@ func Logger(logger log.Logger) gin.HandlerFunc {
 			}
 		}
 
+		if tenantID := log.TenantIDFromContext(c.Request.Context()); tenantID != "" {
+			fields = append(fields, log.String("tenant_id", tenantID))
+		}
+
 		// 记录错误
 		if len(c.Errors) > 0 {
 			fields = append(fields, log.String("errors", c.Errors.String()))
@@ -227,6 +231,10 @@ func LoggerWithConfig(cfg LoggerConfig) gin.HandlerFunc {
 			}
 		}
 
+		if tenantID := log.TenantIDFromContext(c.Request.Context()); tenantID != "" {
+			fields = append(fields, log.String("tenant_id", tenantID))
+		}
+
 		// 记录错误
 		if len(c.Errors) > 0 {
 			fields = append(fields, log.String("errors", c.Errors.String()))
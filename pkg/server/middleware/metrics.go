@@ -7,8 +7,15 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/log"
 )
 
+// MaxTenantSeries 按租户统计的最大租户数，超出的租户计入 OtherTenant，避免指标基数失控
+const MaxTenantSeries = 1000
+
+// OtherTenant 超出 MaxTenantSeries 的租户的统计标签
+const OtherTenant = "_other"
+
 // RequestMetrics 请求指标
 type RequestMetrics struct {
 	mu               sync.RWMutex
@@ -22,8 +29,10 @@ type RequestMetrics struct {
 	StatusCodeCounts map[int]int64
 	MethodCounts     map[string]int64
 	LatencyBuckets   map[string]int64
-	StartTime        time.Time
-	LastTime         time.Time
+	// TenantCounts 按租户统计的请求数，请求上下文中有租户 ID 时记录
+	TenantCounts map[string]int64
+	StartTime    time.Time
+	LastTime     time.Time
 }
 
 // NewRequestMetrics 创建请求指标
@@ -32,6 +41,7 @@ func NewRequestMetrics() *RequestMetrics {
 		StatusCodeCounts: make(map[int]int64),
 		MethodCounts:     make(map[string]int64),
 		LatencyBuckets:   make(map[string]int64),
+		TenantCounts:     make(map[string]int64),
 		StartTime:        time.Now(),
 		MinLatency:       time.Duration(1<<63 - 1),
 	}
@@ -64,6 +74,17 @@ func (m *RequestMetrics) Record(statusCode int, method string, latency time.Dura
 	m.LastTime = time.Now()
 }
 
+// RecordTenant 记录租户的请求
+func (m *RequestMetrics) RecordTenant(tenant string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.TenantCounts[tenant]; !ok && len(m.TenantCounts) >= MaxTenantSeries {
+		tenant = OtherTenant
+	}
+	m.TenantCounts[tenant]++
+}
+
 // IncrInFlight 增加正在处理的请求数
 func (m *RequestMetrics) IncrInFlight() { atomic.AddInt64(&m.InFlightRequests, 1) }
 
@@ -85,6 +106,7 @@ func (m *RequestMetrics) GetSnapshot() MetricsSnapshot {
 		StatusCodeCounts: make(map[int]int64),
 		MethodCounts:     make(map[string]int64),
 		LatencyBuckets:   make(map[string]int64),
+		TenantCounts:     make(map[string]int64),
 	}
 
 	if m.TotalRequests > 0 {
@@ -102,6 +124,9 @@ func (m *RequestMetrics) GetSnapshot() MetricsSnapshot {
 	for k, v := range m.LatencyBuckets {
 		snapshot.LatencyBuckets[k] = v
 	}
+	for k, v := range m.TenantCounts {
+		snapshot.TenantCounts[k] = v
+	}
 
 	return snapshot
 }
@@ -120,6 +145,7 @@ func (m *RequestMetrics) Reset() {
 	m.MinLatency = time.Duration(1<<63 - 1)
 	m.MaxLatency = 0
 	m.LatencyBuckets = make(map[string]int64)
+	m.TenantCounts = make(map[string]int64)
 	m.StartTime = time.Now()
 }
 
@@ -137,6 +163,7 @@ type MetricsSnapshot struct {
 	StatusCodeCounts map[int]int64
 	MethodCounts     map[string]int64
 	LatencyBuckets   map[string]int64
+	TenantCounts     map[string]int64
 }
 
 // SuccessRate 成功率
@@ -214,6 +241,9 @@ func MetricsWithConfig(cfg MetricsConfig) gin.HandlerFunc {
 		c.Next()
 
 		cfg.Metrics.Record(c.Writer.Status(), c.Request.Method, time.Since(start))
+		if tenant := log.TenantIDFromContext(c.Request.Context()); tenant != "" {
+			cfg.Metrics.RecordTenant(tenant)
+		}
 	}
 }
 
@@ -238,6 +268,7 @@ func MetricsHandler(metrics *RequestMetrics) gin.HandlerFunc {
 			"status_codes":       snapshot.StatusCodeCounts,
 			"methods":            snapshot.MethodCounts,
 			"latency_buckets":    snapshot.LatencyBuckets,
+			"tenants":            snapshot.TenantCounts,
 		})
 	}
 }
@@ -266,6 +297,10 @@ func PrometheusHandler(metrics *RequestMetrics, namespace string) gin.HandlerFun
 			output += namespace + "_requests_by_status{code=\"" + strconv.Itoa(code) + "\"} " +
 				strconv.FormatInt(count, 10) + "\n"
 		}
+		for tenant, count := range snapshot.TenantCounts {
+			output += namespace + "_requests_by_tenant{tenant=\"" + tenant + "\"} " +
+				strconv.FormatInt(count, 10) + "\n"
+		}
 
 		c.Header("Content-Type", "text/plain; charset=utf-8")
 		c.String(200, output)
@@ -3,11 +3,13 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/log"
 )
 
 func TestRequestMetrics_Record(t *testing.T) {
@@ -204,6 +206,49 @@ func TestMetricsMiddleware(t *testing.T) {
 	}
 }
 
+func TestMetricsMiddleware_Tenant(t *testing.T) {
+	metrics := NewRequestMetrics()
+
+	router := gin.New()
+	router.Use(MetricsWithConfig(MetricsConfig{Metrics: metrics}))
+	router.Use(func(c *gin.Context) {
+		if tenant := c.GetHeader("X-Tenant-ID"); tenant != "" {
+			c.Request = c.Request.WithContext(log.WithTenantID(c.Request.Context(), tenant))
+		}
+	})
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "OK")
+	})
+
+	for _, tenant := range []string{"acme", "acme", "globex", ""} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		router.ServeHTTP(w, req)
+	}
+
+	snapshot := metrics.GetSnapshot()
+	if snapshot.TenantCounts["acme"] != 2 || snapshot.TenantCounts["globex"] != 1 || len(snapshot.TenantCounts) != 2 {
+		t.Errorf("租户统计不正确: %v", snapshot.TenantCounts)
+	}
+}
+
+func TestRequestMetrics_TenantCardinality(t *testing.T) {
+	metrics := NewRequestMetrics()
+	for i := 0; i < MaxTenantSeries+10; i++ {
+		metrics.RecordTenant(strconv.Itoa(i))
+	}
+	metrics.RecordTenant("0")
+
+	snapshot := metrics.GetSnapshot()
+	if len(snapshot.TenantCounts) != MaxTenantSeries+1 {
+		t.Errorf("租户数应该是%d，实际: %d", MaxTenantSeries+1, len(snapshot.TenantCounts))
+	}
+	if snapshot.TenantCounts[OtherTenant] != 10 || snapshot.TenantCounts["0"] != 2 {
+		t.Errorf("超出部分应计入 %s: %d", OtherTenant, snapshot.TenantCounts[OtherTenant])
+	}
+}
+
 func TestMetricsMiddleware_SkipPaths(t *testing.T) {
 	metrics := NewRequestMetrics()
 	cfg := MetricsConfig{
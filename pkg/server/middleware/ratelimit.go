@@ -66,6 +66,17 @@ func (tb *TokenBucket) AllowN(key string, n int) bool {
 	return false
 }
 
+// SetRate 更新令牌生成速率与桶容量
+func (tb *TokenBucket) SetRate(rate float64, capacity int) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.rate = rate
+	tb.capacity = capacity
+	if tb.tokens > float64(capacity) {
+		tb.tokens = float64(capacity)
+	}
+}
+
 // === 滑动窗口限流器 ===
 
 // SlidingWindowLimiter 滑动窗口限流器
@@ -196,6 +207,17 @@ func (l *IPRateLimiter) AllowN(key string, n int) bool {
 	return l.getLimiter(key).AllowN(key, n)
 }
 
+// SetRate 更新速率与桶容量，同时作用于已有的令牌桶
+func (l *IPRateLimiter) SetRate(rate float64, capacity int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.capacity = capacity
+	for _, limiter := range l.limiters {
+		limiter.SetRate(rate, capacity)
+	}
+}
+
 // cleanup 清理过期的限流器
 func (l *IPRateLimiter) cleanup() {
 	ticker := time.NewTicker(5 * time.Minute)
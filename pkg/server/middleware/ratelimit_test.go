@@ -129,6 +129,24 @@ func TestIPRateLimiter(t *testing.T) {
 	}
 }
 
+func TestIPRateLimiter_SetRate(t *testing.T) {
+	limiter := NewIPRateLimiter(10, 5)
+	limiter.Allow("192.168.1.1")
+
+	// 已有与新建的令牌桶都使用新的容量
+	limiter.SetRate(10, 2)
+	for _, ip := range []string{"192.168.1.1", "192.168.1.2"} {
+		for i := 0; i < 2; i++ {
+			if !limiter.Allow(ip) {
+				t.Errorf("%s 请求 %d 应该被允许", ip, i)
+			}
+		}
+		if limiter.Allow(ip) {
+			t.Errorf("%s 应该被限流", ip)
+		}
+	}
+}
+
 // === 限流中间件测试 ===
 
 func TestRateLimitMiddleware(t *testing.T) {
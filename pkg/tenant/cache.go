@@ -0,0 +1,204 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/goupter/goupter/pkg/cache"
+)
+
+// DefaultCachePrefix 租户缓存键前缀，完整的键为 tenant:<租户ID>:<key>
+const DefaultCachePrefix = "tenant:"
+
+// tenantCache 按上下文中的租户为键加前缀的缓存
+type tenantCache struct {
+	cache.Cache
+	prefix string
+}
+
+// CacheOption 租户缓存选项
+type CacheOption func(*tenantCache)
+
+// WithCachePrefix 设置键前缀，默认 tenant:
+func WithCachePrefix(prefix string) CacheOption {
+	return func(c *tenantCache) {
+		c.prefix = prefix
+	}
+}
+
+// NewCache 包装缓存，所有键按上下文中的租户隔离，上下文没有租户时返回 ErrMissingTenant
+//
+// 跨租户共享的数据应直接使用被包装的缓存。
+func NewCache(c cache.Cache, opts ...CacheOption) cache.Cache {
+	tc := &tenantCache{Cache: c, prefix: DefaultCachePrefix}
+	for _, opt := range opts {
+		opt(tc)
+	}
+	return tc
+}
+
+// keyPrefix 当前租户的键前缀
+func (c *tenantCache) keyPrefix(ctx context.Context) (string, error) {
+	id, err := Require(ctx)
+	if err != nil {
+		return "", err
+	}
+	return c.prefix + id + ":", nil
+}
+
+func (c *tenantCache) key(ctx context.Context, key string) (string, error) {
+	prefix, err := c.keyPrefix(ctx)
+	if err != nil {
+		return "", err
+	}
+	return prefix + key, nil
+}
+
+func (c *tenantCache) Get(ctx context.Context, key string, value interface{}) error {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	return c.Cache.Get(ctx, k, value)
+}
+
+func (c *tenantCache) GetRaw(ctx context.Context, key string) (string, error) {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return c.Cache.GetRaw(ctx, k)
+}
+
+func (c *tenantCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	return c.Cache.Set(ctx, k, value, ttl)
+}
+
+func (c *tenantCache) Delete(ctx context.Context, key string) error {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	return c.Cache.Delete(ctx, k)
+}
+
+func (c *tenantCache) Exists(ctx context.Context, key string) (bool, error) {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return c.Cache.Exists(ctx, k)
+}
+
+func (c *tenantCache) MGet(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	prefix, err := c.keyPrefix(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values, err := c.Cache.MGet(ctx, prefixKeys(prefix, keys))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		result[strings.TrimPrefix(k, prefix)] = v
+	}
+	return result, nil
+}
+
+func (c *tenantCache) MSet(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
+	prefix, err := c.keyPrefix(ctx)
+	if err != nil {
+		return err
+	}
+	prefixed := make(map[string]interface{}, len(items))
+	for k, v := range items {
+		prefixed[prefix+k] = v
+	}
+	return c.Cache.MSet(ctx, prefixed, ttl)
+}
+
+func (c *tenantCache) MDelete(ctx context.Context, keys []string) error {
+	prefix, err := c.keyPrefix(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Cache.MDelete(ctx, prefixKeys(prefix, keys))
+}
+
+func (c *tenantCache) Incr(ctx context.Context, key string) (int64, error) {
+	return c.IncrBy(ctx, key, 1)
+}
+
+func (c *tenantCache) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return c.Cache.IncrBy(ctx, k, value)
+}
+
+func (c *tenantCache) Decr(ctx context.Context, key string) (int64, error) {
+	return c.DecrBy(ctx, key, 1)
+}
+
+func (c *tenantCache) DecrBy(ctx context.Context, key string, value int64) (int64, error) {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return c.Cache.DecrBy(ctx, k, value)
+}
+
+func (c *tenantCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return c.Cache.SetNX(ctx, k, value, ttl)
+}
+
+func (c *tenantCache) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	return c.Cache.Expire(ctx, k, ttl)
+}
+
+func (c *tenantCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	k, err := c.key(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return c.Cache.TTL(ctx, k)
+}
+
+// Keys 只扫描当前租户的键，返回的键不含租户前缀
+func (c *tenantCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	prefix, err := c.keyPrefix(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := c.Cache.Keys(ctx, prefix+pattern)
+	if err != nil {
+		return nil, err
+	}
+	for i, k := range keys {
+		keys[i] = strings.TrimPrefix(k, prefix)
+	}
+	return keys, nil
+}
+
+func prefixKeys(prefix string, keys []string) []string {
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = prefix + k
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/goupter/goupter/pkg/cache"
+)
+
+func TestCache(t *testing.T) {
+	base := cache.NewMemoryCache()
+	c := NewCache(base)
+	acme := WithTenant(context.Background(), "acme")
+	globex := WithTenant(context.Background(), "globex")
+
+	if err := c.Set(acme, "k", "a", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(globex, "k", "g", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	var v string
+	if err := c.Get(acme, "k", &v); err != nil || v != "a" {
+		t.Errorf("acme k = %q, %v", v, err)
+	}
+	if raw, err := base.GetRaw(context.Background(), "tenant:globex:k"); err != nil || raw == "" {
+		t.Errorf("底层键应带租户前缀: %q, %v", raw, err)
+	}
+
+	if n, err := c.Incr(acme, "n"); err != nil || n != 1 {
+		t.Errorf("Incr = %d, %v", n, err)
+	}
+	if ok, _ := c.Exists(globex, "n"); ok {
+		t.Error("计数器不应跨租户可见")
+	}
+
+	if err := c.MSet(acme, map[string]interface{}{"x": 1, "y": 2}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	values, err := c.MGet(acme, []string{"x", "y"})
+	if err != nil || len(values) != 2 || values["x"] == nil {
+		t.Errorf("MGet = %v, %v", values, err)
+	}
+
+	keys, err := c.Keys(acme, "*")
+	sort.Strings(keys)
+	if err != nil || len(keys) != 4 || keys[0] != "k" {
+		t.Errorf("Keys = %v, %v", keys, err)
+	}
+
+	if err := c.Delete(acme, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(globex, "k", &v); err != nil || v != "g" {
+		t.Errorf("删除不应影响其他租户: %q, %v", v, err)
+	}
+}
+
+func TestCache_MissingTenant(t *testing.T) {
+	c := NewCache(cache.NewMemoryCache(), WithCachePrefix("t:"))
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "k", "v", time.Minute); !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("Set() error = %v", err)
+	}
+	if _, err := c.Keys(ctx, "*"); !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("Keys() error = %v", err)
+	}
+	if _, err := c.Incr(ctx, "n"); !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("Incr() error = %v", err)
+	}
+}
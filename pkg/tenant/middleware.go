@@ -0,0 +1,152 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/server/interceptor"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Config 租户解析配置
+type Config struct {
+	// Resolvers 解析器，全部求值；多个解析器都解析出租户时必须一致。
+	// 只约束同时存在的来源：令牌不含租户声明时，请求头或子域名仍可指定任意租户，需要绑定时使用 Authority
+	Resolvers []Resolver
+	// Authority 权威来源，通常为 FromClaim；设置后只要请求解析出租户，Authority 必须解析出相同的租户，
+	// 否则返回 ErrTenantUnbound，未设置其他来源时直接使用 Authority 的租户
+	Authority Resolver
+	// Optional 允许请求不携带租户，如公共页面；默认必须携带
+	Optional bool
+	// Validate 校验租户是否存在且可用，返回错误时拒绝请求
+	Validate func(ctx context.Context, id string) error
+}
+
+// resolve 解析并校验租户，Optional 且未携带租户时返回空字符串
+func (cfg *Config) resolve(ctx context.Context, req RequestInfo) (string, error) {
+	var id string
+	for _, r := range cfg.Resolvers {
+		v, err := r.Resolve(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		if v == "" {
+			continue
+		}
+		if !Valid(v) {
+			return "", ErrInvalidTenant
+		}
+		if id != "" && v != id {
+			return "", ErrTenantMismatch
+		}
+		id = v
+	}
+
+	if cfg.Authority != nil {
+		v, err := cfg.Authority.Resolve(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case v != "" && !Valid(v):
+			return "", ErrInvalidTenant
+		case v == "" && id != "":
+			return "", ErrTenantUnbound
+		case v != "" && id != "" && v != id:
+			return "", ErrTenantMismatch
+		}
+		id = v
+	}
+
+	if id == "" {
+		if cfg.Optional {
+			return "", nil
+		}
+		return "", ErrMissingTenant
+	}
+	if cfg.Validate != nil {
+		if err := cfg.Validate(ctx, id); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// Middleware Gin 中间件，解析租户并写入请求上下文；使用 FromClaim 时需放在认证中间件之后
+func Middleware(cfg Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := cfg.resolve(c.Request.Context(), RequestInfo{Host: c.Request.Host, Header: c.GetHeader})
+		if err != nil {
+			code, message := http.StatusForbidden, "tenant not allowed"
+			switch {
+			case errors.Is(err, ErrMissingTenant), errors.Is(err, ErrInvalidTenant):
+				code, message = http.StatusBadRequest, err.Error()
+			case errors.Is(err, ErrTenantMismatch), errors.Is(err, ErrTenantUnbound):
+				message = err.Error()
+			}
+			c.AbortWithStatusJSON(code, gin.H{
+				"code":    code,
+				"message": message,
+			})
+			return
+		}
+		if id != "" {
+			c.Request = c.Request.WithContext(WithTenant(c.Request.Context(), id))
+		}
+		c.Next()
+	}
+}
+
+// UnaryInterceptor gRPC 一元拦截器，从 metadata 与 :authority 解析租户
+func UnaryInterceptor(cfg Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := cfg.grpcContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor gRPC 流式拦截器
+func StreamInterceptor(cfg Config) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := cfg.grpcContext(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, interceptor.WrapServerStream(ss, ctx))
+	}
+}
+
+// grpcContext 解析租户并写入上下文，返回 gRPC 状态错误
+func (cfg *Config) grpcContext(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	req := RequestInfo{Header: func(name string) string {
+		if v := md.Get(name); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}}
+	req.Host = req.Header(":authority")
+
+	id, err := cfg.resolve(ctx, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrMissingTenant), errors.Is(err, ErrInvalidTenant):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, ErrTenantMismatch), errors.Is(err, ErrTenantUnbound):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.PermissionDenied, "tenant not allowed")
+	}
+	if id != "" {
+		ctx = WithTenant(ctx, id)
+	}
+	return ctx, nil
+}
@@ -0,0 +1,177 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goupter/goupter/pkg/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestRouter(cfg Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if id := c.GetHeader("X-Claim-Tenant"); id != "" {
+			claims := &auth.Claims{UserID: "1", Extra: map[string]interface{}{"tenant_id": id}}
+			c.Request = c.Request.WithContext(auth.ContextWithClaims(c.Request.Context(), claims))
+		}
+	})
+	r.Use(Middleware(cfg))
+	r.GET("/", func(c *gin.Context) {
+		id, _ := FromContext(c.Request.Context())
+		c.String(http.StatusOK, id)
+	})
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	r := newTestRouter(Config{
+		Resolvers: []Resolver{FromSubdomain("example.com"), FromHeader(""), FromClaim("")},
+		Validate: func(ctx context.Context, id string) error {
+			if id == "suspended" {
+				return errors.New("suspended")
+			}
+			return nil
+		},
+	})
+
+	tests := []struct {
+		name    string
+		host    string
+		headers map[string]string
+		code    int
+		tenant  string
+	}{
+		{"子域名", "acme.example.com", nil, http.StatusOK, "acme"},
+		{"请求头", "example.com", map[string]string{DefaultHeader: "acme"}, http.StatusOK, "acme"},
+		{"子域名与令牌一致", "acme.example.com", map[string]string{"X-Claim-Tenant": "acme"}, http.StatusOK, "acme"},
+		{"请求头与令牌不一致", "example.com", map[string]string{DefaultHeader: "globex", "X-Claim-Tenant": "acme"}, http.StatusForbidden, ""},
+		{"缺少租户", "example.com", nil, http.StatusBadRequest, ""},
+		{"非法租户", "example.com", map[string]string{DefaultHeader: "acme:*"}, http.StatusBadRequest, ""},
+		{"校验失败", "suspended.example.com", nil, http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code == http.StatusOK && w.Body.String() != tt.tenant {
+				t.Errorf("tenant = %q, want %q", w.Body.String(), tt.tenant)
+			}
+		})
+	}
+}
+
+func TestMiddleware_Authority(t *testing.T) {
+	r := newTestRouter(Config{
+		Resolvers: []Resolver{FromSubdomain("example.com"), FromHeader("")},
+		Authority: FromClaim(""),
+	})
+
+	tests := []struct {
+		name    string
+		host    string
+		headers map[string]string
+		code    int
+		tenant  string
+	}{
+		{"仅令牌", "example.com", map[string]string{"X-Claim-Tenant": "acme"}, http.StatusOK, "acme"},
+		{"请求头与令牌一致", "example.com", map[string]string{DefaultHeader: "acme", "X-Claim-Tenant": "acme"}, http.StatusOK, "acme"},
+		{"令牌缺少租户声明", "example.com", map[string]string{DefaultHeader: "globex"}, http.StatusForbidden, ""},
+		{"子域名无令牌声明", "globex.example.com", nil, http.StatusForbidden, ""},
+		{"子域名与令牌不一致", "globex.example.com", map[string]string{"X-Claim-Tenant": "acme"}, http.StatusForbidden, ""},
+		{"缺少租户", "example.com", nil, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code == http.StatusOK && w.Body.String() != tt.tenant {
+				t.Errorf("tenant = %q, want %q", w.Body.String(), tt.tenant)
+			}
+		})
+	}
+}
+
+func TestMiddleware_Optional(t *testing.T) {
+	r := newTestRouter(Config{Resolvers: []Resolver{FromHeader("")}, Optional: true})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "" {
+		t.Errorf("status = %d, body = %q", w.Code, w.Body.String())
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	interceptor := UnaryInterceptor(Config{Resolvers: []Resolver{FromSubdomain("example.com"), FromHeader("")}})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		id, _ := FromContext(ctx)
+		return id, nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant-id", "acme"))
+	if resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler); err != nil || resp != "acme" {
+		t.Errorf("metadata: resp = %v, err = %v", resp, err)
+	}
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(":authority", "acme.example.com:443"))
+	if resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler); err != nil || resp != "acme" {
+		t.Errorf("authority: resp = %v, err = %v", resp, err)
+	}
+
+	if _, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("missing tenant code = %v", status.Code(err))
+	}
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(":authority", "acme.example.com", "x-tenant-id", "globex"))
+	if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("mismatch code = %v", status.Code(err))
+	}
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	interceptor := StreamInterceptor(Config{Resolvers: []Resolver{FromHeader("")}})
+	ss := &testServerStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant-id", "acme"))}
+
+	var got string
+	err := interceptor(nil, ss, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		got, _ = FromContext(stream.Context())
+		return nil
+	})
+	if err != nil || got != "acme" {
+		t.Errorf("tenant = %q, err = %v", got, err)
+	}
+}
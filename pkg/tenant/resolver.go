@@ -0,0 +1,93 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/goupter/goupter/pkg/auth"
+)
+
+// 默认值
+const (
+	DefaultHeader   = "X-Tenant-ID"
+	DefaultClaimKey = "tenant_id"
+)
+
+// RequestInfo 解析租户所需的请求信息，HTTP 与 gRPC 请求均可构造
+type RequestInfo struct {
+	// Host 请求主机名，可带端口；gRPC 取 :authority
+	Host string
+	// Header 读取请求头，gRPC 读取 metadata
+	Header func(name string) string
+}
+
+// Resolver 租户解析器，请求未携带租户时返回空字符串
+type Resolver interface {
+	Resolve(ctx context.Context, req RequestInfo) (string, error)
+}
+
+// ResolverFunc 函数类型的解析器
+type ResolverFunc func(ctx context.Context, req RequestInfo) (string, error)
+
+func (f ResolverFunc) Resolve(ctx context.Context, req RequestInfo) (string, error) {
+	return f(ctx, req)
+}
+
+// FromSubdomain 从子域名解析，如 baseDomain 为 example.com 时 acme.example.com 解析为 acme
+//
+// 只识别一级子域名；exclude 中的子域名（如 www、api）不视为租户。
+func FromSubdomain(baseDomain string, exclude ...string) Resolver {
+	suffix := "." + strings.ToLower(strings.Trim(baseDomain, "."))
+	return ResolverFunc(func(ctx context.Context, req RequestInfo) (string, error) {
+		host := strings.ToLower(req.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		sub, ok := strings.CutSuffix(host, suffix)
+		if !ok || sub == "" || strings.Contains(sub, ".") {
+			return "", nil
+		}
+		for _, e := range exclude {
+			if sub == e {
+				return "", nil
+			}
+		}
+		return sub, nil
+	})
+}
+
+// FromHeader 从请求头解析，name 为空时使用 X-Tenant-ID
+func FromHeader(name string) Resolver {
+	if name == "" {
+		name = DefaultHeader
+	}
+	return ResolverFunc(func(ctx context.Context, req RequestInfo) (string, error) {
+		if req.Header == nil {
+			return "", nil
+		}
+		return strings.TrimSpace(req.Header(name)), nil
+	})
+}
+
+// FromClaim 从 auth.Claims.Extra 解析，key 为空时使用 tenant_id，需放在认证之后
+func FromClaim(key string) Resolver {
+	if key == "" {
+		key = DefaultClaimKey
+	}
+	return ResolverFunc(func(ctx context.Context, req RequestInfo) (string, error) {
+		claims, ok := auth.ClaimsFromContext(ctx)
+		if !ok {
+			return "", nil
+		}
+		v, ok := claims.Extra[key]
+		if !ok || v == nil {
+			return "", nil
+		}
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(v), nil
+	})
+}
@@ -0,0 +1,131 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// OpenFunc 为租户打开数据库连接
+type OpenFunc func(ctx context.Context, id string) (*gorm.DB, error)
+
+// DBRouter 每个租户独立数据库时的连接路由，连接按需打开并复用
+type DBRouter struct {
+	open OpenFunc
+
+	mu    sync.Mutex
+	conns map[string]*routerConn
+}
+
+// routerConn 租户连接，ready 关闭后 db、err 可读
+type routerConn struct {
+	ready chan struct{}
+	db    *gorm.DB
+	err   error
+}
+
+// NewDBRouter 创建连接路由
+//
+//	router := tenant.NewDBRouter(func(ctx context.Context, id string) (*gorm.DB, error) {
+//		cfg := cfg.Database
+//		cfg.Database = "app_" + id
+//		m, err := database.NewMySQL(database.WithConfig(&cfg))
+//		if err != nil {
+//			return nil, err
+//		}
+//		return m.DB(), nil
+//	})
+func NewDBRouter(open OpenFunc) *DBRouter {
+	return &DBRouter{open: open, conns: make(map[string]*routerConn)}
+}
+
+// DB 获取上下文中租户的连接，已绑定 ctx；上下文没有租户时返回 ErrMissingTenant
+func (r *DBRouter) DB(ctx context.Context) (*gorm.DB, error) {
+	id, err := Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db, err := r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return db.WithContext(ctx), nil
+}
+
+// Get 获取指定租户的连接，同一租户并发请求只打开一次，打开失败不缓存
+func (r *DBRouter) Get(ctx context.Context, id string) (*gorm.DB, error) {
+	if !Valid(id) {
+		return nil, ErrInvalidTenant
+	}
+
+	r.mu.Lock()
+	c, ok := r.conns[id]
+	if !ok {
+		c = &routerConn{ready: make(chan struct{})}
+		r.conns[id] = c
+	}
+	r.mu.Unlock()
+
+	if ok {
+		select {
+		case <-c.ready:
+			return c.db, c.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	c.db, c.err = r.open(ctx, id)
+	if c.err != nil {
+		c.err = fmt.Errorf("打开租户 %s 的数据库失败: %w", id, c.err)
+		r.mu.Lock()
+		delete(r.conns, id)
+		r.mu.Unlock()
+	}
+	close(c.ready)
+	return c.db, c.err
+}
+
+// Evict 关闭并移除租户的连接，如租户下线或数据库迁移后
+func (r *DBRouter) Evict(id string) error {
+	r.mu.Lock()
+	c, ok := r.conns[id]
+	delete(r.conns, id)
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	<-c.ready
+	return closeDB(c.db)
+}
+
+// Close 关闭全部连接
+func (r *DBRouter) Close() error {
+	r.mu.Lock()
+	conns := r.conns
+	r.conns = make(map[string]*routerConn)
+	r.mu.Unlock()
+
+	var errs []error
+	for _, c := range conns {
+		<-c.ready
+		if err := closeDB(c.db); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func closeDB(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,62 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestDBRouter(t *testing.T) {
+	var opened atomic.Int32
+	fail := true
+	var mu sync.Mutex
+	r := NewDBRouter(func(ctx context.Context, id string) (*gorm.DB, error) {
+		opened.Add(1)
+		mu.Lock()
+		defer mu.Unlock()
+		if id == "broken" && fail {
+			return nil, errors.New("connection refused")
+		}
+		return &gorm.DB{Config: &gorm.Config{}}, nil
+	})
+
+	var wg sync.WaitGroup
+	dbs := make([]*gorm.DB, 10)
+	for i := range dbs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dbs[i], _ = r.Get(context.Background(), "acme")
+		}(i)
+	}
+	wg.Wait()
+	if opened.Load() != 1 {
+		t.Errorf("opened = %d, want 1", opened.Load())
+	}
+	for _, db := range dbs {
+		if db == nil || db != dbs[0] {
+			t.Fatal("同一租户应复用连接")
+		}
+	}
+
+	if _, err := r.Get(context.Background(), "broken"); err == nil {
+		t.Error("打开失败应返回错误")
+	}
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	if _, err := r.Get(context.Background(), "broken"); err != nil {
+		t.Errorf("打开失败不应缓存: %v", err)
+	}
+
+	if _, err := r.Get(context.Background(), "../etc"); !errors.Is(err, ErrInvalidTenant) {
+		t.Errorf("invalid id error = %v", err)
+	}
+	if _, err := r.DB(context.Background()); !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("DB() error = %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+// Package tenant 多租户支持
+//
+// 请求进入时由 Middleware（gRPC 为 UnaryInterceptor/StreamInterceptor）按配置的解析器
+// 确定租户并写入上下文，下游组件从上下文读取：
+//
+//	model.NewBaseModel[T](db, model.WithTenantColumn("tenant_id"))  // 查询、更新、删除自动带租户条件
+//	tenant.NewCache(redisCache)                                     // 缓存键按租户加前缀
+//	tenant.NewDBRouter(open)                                        // 每个租户独立数据库（可选）
+//
+// 租户 ID 同时写入日志上下文（log.WithContext 输出 tenant_id 字段）与请求指标。
+// 上下文中没有租户时，上述组件一律返回 ErrMissingTenant，不会退化为访问全部租户的数据。
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/goupter/goupter/pkg/log"
+)
+
+// 租户相关错误
+var (
+	ErrMissingTenant  = errors.New("tenant required")
+	ErrInvalidTenant  = errors.New("invalid tenant id")
+	ErrTenantMismatch = errors.New("tenant mismatch")
+	ErrTenantUnbound  = errors.New("tenant not bound to credentials")
+)
+
+// idPattern 租户 ID 会出现在缓存键、数据库名与指标标签中，只允许安全字符
+var idPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,63}$`)
+
+// Valid 租户 ID 是否合法：字母或数字开头，由字母、数字、下划线、连字符组成，最长 64 个字符
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// WithTenant 将租户 ID 写入上下文
+func WithTenant(ctx context.Context, id string) context.Context {
+	return log.WithTenantID(ctx, id)
+}
+
+// FromContext 从上下文获取租户 ID
+func FromContext(ctx context.Context) (string, bool) {
+	id := log.TenantIDFromContext(ctx)
+	return id, id != ""
+}
+
+// Require 从上下文获取租户 ID，没有租户时返回 ErrMissingTenant
+func Require(ctx context.Context) (string, error) {
+	if id, ok := FromContext(ctx); ok {
+		return id, nil
+	}
+	return "", ErrMissingTenant
+}
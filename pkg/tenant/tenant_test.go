@@ -0,0 +1,93 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/goupter/goupter/pkg/auth"
+	"github.com/goupter/goupter/pkg/log"
+)
+
+func TestValid(t *testing.T) {
+	for id, want := range map[string]bool{
+		"acme":                   true,
+		"a_1-b":                  true,
+		"":                       false,
+		"-acme":                  false,
+		"acme:1":                 false,
+		"acme corp":              false,
+		string(make([]byte, 65)): false,
+	} {
+		if got := Valid(id); got != want {
+			t.Errorf("Valid(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, err := Require(context.Background()); !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("Require() error = %v", err)
+	}
+
+	ctx := WithTenant(context.Background(), "acme")
+	if id, err := Require(ctx); err != nil || id != "acme" {
+		t.Errorf("Require() = %s, %v", id, err)
+	}
+	if log.TenantIDFromContext(ctx) != "acme" {
+		t.Error("租户应写入日志上下文")
+	}
+}
+
+func TestFromSubdomain(t *testing.T) {
+	r := FromSubdomain("example.com", "www")
+	tests := map[string]string{
+		"acme.example.com":      "acme",
+		"ACME.example.com:8080": "acme",
+		"example.com":           "",
+		"www.example.com":       "",
+		"a.b.example.com":       "",
+		"acme.other.com":        "",
+	}
+	for host, want := range tests {
+		got, err := r.Resolve(context.Background(), RequestInfo{Host: host})
+		if err != nil || got != want {
+			t.Errorf("Resolve(%s) = %q, %v, want %q", host, got, err, want)
+		}
+	}
+}
+
+func TestFromHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set(DefaultHeader, " acme ")
+	h.Set("X-Org", "globex")
+
+	if got, _ := FromHeader("").Resolve(context.Background(), RequestInfo{Header: h.Get}); got != "acme" {
+		t.Errorf("default header = %q", got)
+	}
+	if got, _ := FromHeader("X-Org").Resolve(context.Background(), RequestInfo{Header: h.Get}); got != "globex" {
+		t.Errorf("custom header = %q", got)
+	}
+	if got, _ := FromHeader("").Resolve(context.Background(), RequestInfo{}); got != "" {
+		t.Errorf("nil header = %q", got)
+	}
+}
+
+func TestFromClaim(t *testing.T) {
+	r := FromClaim("")
+	if got, _ := r.Resolve(context.Background(), RequestInfo{}); got != "" {
+		t.Errorf("no claims = %q", got)
+	}
+
+	ctx := auth.ContextWithClaims(context.Background(), &auth.Claims{Extra: map[string]interface{}{"tenant_id": "acme", "org": 7}})
+	if got, _ := r.Resolve(ctx, RequestInfo{}); got != "acme" {
+		t.Errorf("tenant_id = %q", got)
+	}
+	if got, _ := FromClaim("org").Resolve(ctx, RequestInfo{}); got != "7" {
+		t.Errorf("org = %q", got)
+	}
+	if got, _ := FromClaim("missing").Resolve(ctx, RequestInfo{}); got != "" {
+		t.Errorf("missing = %q", got)
+	}
+}